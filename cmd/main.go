@@ -1,20 +1,31 @@
 package main
 
 import (
+	"context"
 	"gocleanarchitecture/config"
+	"gocleanarchitecture/entities"
 	"gocleanarchitecture/frameworks/auth"
 	"gocleanarchitecture/frameworks/db"
 	"gocleanarchitecture/frameworks/db/sqlite"
 	"gocleanarchitecture/frameworks/db/supabase"
+	"gocleanarchitecture/frameworks/events"
 	"gocleanarchitecture/frameworks/logger"
+	"gocleanarchitecture/frameworks/mail"
 	"gocleanarchitecture/frameworks/web"
+	"gocleanarchitecture/frameworks/web/middleware"
 	"gocleanarchitecture/frameworks/websocket"
 	"gocleanarchitecture/interfaces"
 	"gocleanarchitecture/usecases"
 
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rs/cors"
 
@@ -41,6 +52,22 @@ func main() {
 	var blogPostRepo interfaces.BlogPostRepository
 	var userRepo interfaces.UserRepository
 	var commentRepo interfaces.CommentRepository
+	var outboxRepo interfaces.OutboxRepository
+	var refreshTokenRepo interfaces.RefreshTokenRepository
+	var revocationRepo interfaces.TokenRevocationRepository
+	var sessionRepo interfaces.SessionRepository
+	var roleRepo interfaces.RoleRepository
+	var moderationRepo interfaces.ModerationRepository
+	var notificationRepo interfaces.NotificationRepository
+	var auditRepo interfaces.AuditRepository
+	var loginAttemptRepo interfaces.LoginAttemptRepository
+	var mfaRepo interfaces.MFARepository
+	var userTokenRepo interfaces.UserTokenRepository
+	var identityRepo interfaces.IdentityRepository
+	var apiTokenRepo interfaces.APITokenRepository
+	var oauthClientRepo interfaces.OAuthClientRepository
+	var oauthCodeRepo interfaces.OAuthCodeRepository
+	var transactor usecases.Transactor
 
 	switch strings.ToLower(cfg.DBType) {
 	case "supabase":
@@ -50,11 +77,41 @@ func main() {
 		blogPostRepo = supabase.NewSupabaseBlogPostRepository(cfg.SupabaseURL, cfg.SupabaseKey)
 		userRepo = supabase.NewSupabaseUserRepository(cfg.SupabaseURL, cfg.SupabaseKey)
 		commentRepo = supabase.NewSupabaseCommentRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		refreshTokenRepo = supabase.NewSupabaseRefreshTokenRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		revocationRepo = supabase.NewSupabaseTokenRevocationRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		sessionRepo = supabase.NewSupabaseSessionRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		roleRepo = supabase.NewSupabaseRoleRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		moderationRepo = supabase.NewSupabaseModerationRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		notificationRepo = supabase.NewSupabaseNotificationRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		auditRepo = supabase.NewSupabaseAuditRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		loginAttemptRepo = supabase.NewSupabaseLoginAttemptRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		mfaRepo = supabase.NewSupabaseMFARepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		userTokenRepo = supabase.NewSupabaseUserTokenRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		identityRepo = supabase.NewSupabaseIdentityRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		apiTokenRepo = supabase.NewSupabaseAPITokenRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		oauthClientRepo = supabase.NewSupabaseOAuthClientRepository(cfg.SupabaseURL, cfg.SupabaseKey)
+		oauthCodeRepo = supabase.NewSupabaseOAuthCodeRepository(cfg.SupabaseURL, cfg.SupabaseKey)
 		customLogger.Info("Using Supabase repository", logger.Field("url", cfg.SupabaseURL))
 	case "inmemory":
 		blogPostRepo = db.NewInMemoryBlogPostRepository()
 		userRepo = db.NewInMemoryUserRepository()
 		commentRepo = db.NewInMemoryCommentRepository()
+		outboxRepo = db.NewInMemoryOutboxRepository()
+		refreshTokenRepo = db.NewInMemoryRefreshTokenRepository()
+		revocationRepo = db.NewInMemoryTokenRevocationRepository()
+		sessionRepo = db.NewInMemorySessionRepository()
+		roleRepo = db.NewInMemoryRoleRepository()
+		moderationRepo = db.NewInMemoryModerationRepository()
+		notificationRepo = db.NewInMemoryNotificationRepository()
+		auditRepo = db.NewInMemoryAuditRepository()
+		loginAttemptRepo = db.NewInMemoryLoginAttemptRepository()
+		mfaRepo = db.NewInMemoryMFARepository()
+		userTokenRepo = db.NewInMemoryUserTokenRepository()
+		identityRepo = db.NewInMemoryIdentityRepository()
+		apiTokenRepo = db.NewInMemoryAPITokenRepository()
+		oauthClientRepo = db.NewInMemoryOAuthClientRepository()
+		oauthCodeRepo = db.NewInMemoryOAuthCodeRepository()
+		transactor = db.NewInMemoryTransactor(blogPostRepo, commentRepo, userRepo, outboxRepo)
 		customLogger.Info("Using in-memory repository")
 		customLogger.Warn("In-memory database: data will be lost on restart")
 	case "sqlite":
@@ -68,33 +125,144 @@ func main() {
 		blogPostRepo = sqlite.NewSQLiteBlogPostRepository(sqliteDB)
 		userRepo = sqlite.NewSQLiteUserRepository(sqliteDB)
 		commentRepo = sqlite.NewSQLiteCommentRepository(sqliteDB)
+		outboxRepo = sqlite.NewSQLiteOutboxRepository(sqliteDB)
+		refreshTokenRepo = sqlite.NewSQLiteRefreshTokenRepository(sqliteDB)
+		revocationRepo = sqlite.NewSQLiteTokenRevocationRepository(sqliteDB)
+		sessionRepo = sqlite.NewSQLiteSessionRepository(sqliteDB)
+		roleRepo = sqlite.NewSQLiteRoleRepository(sqliteDB)
+		moderationRepo = sqlite.NewSQLiteModerationRepository(sqliteDB)
+		notificationRepo = sqlite.NewSQLiteNotificationRepository(sqliteDB)
+		auditRepo = sqlite.NewSQLiteAuditRepository(sqliteDB)
+		loginAttemptRepo = sqlite.NewSQLiteLoginAttemptRepository(sqliteDB)
+		mfaRepo = sqlite.NewSQLiteMFARepository(sqliteDB)
+		userTokenRepo = sqlite.NewSQLiteUserTokenRepository(sqliteDB)
+		identityRepo = sqlite.NewSQLiteIdentityRepository(sqliteDB)
+		apiTokenRepo = sqlite.NewSQLiteAPITokenRepository(sqliteDB)
+		oauthClientRepo = sqlite.NewSQLiteOAuthClientRepository(sqliteDB)
+		oauthCodeRepo = sqlite.NewSQLiteOAuthCodeRepository(sqliteDB)
+		transactor = sqlite.NewSQLiteTransactor(sqliteDB)
 		customLogger.Info("Using SQLite repository", logger.Field("path", cfg.DBPath))
 	}
 
 	// Initialize JWT Manager
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.JWTTokenDuration)
+	jwtManager, err := newJWTManager(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT signing: %v", err)
+	}
 	tokenGenerator := auth.NewTokenGeneratorAdapter(jwtManager)
 
 	// Create adapters and use cases with proper dependency injection
 	useCaseLogger := logger.NewUseCaseLoggerAdapter(customLogger)
 
-	// Initialize WebSocket hub
-	wsHub := websocket.NewHub()
+	// Initialize WebSocket hub with a broker so multiple instances share broadcasts
+	var broker websocket.Broker
+	switch strings.ToLower(cfg.BrokerType) {
+	case "postgres":
+		if cfg.BrokerPostgresURL == "" {
+			log.Fatal("BROKER_POSTGRES_URL must be provided when using the postgres broker")
+		}
+		pgBroker, err := websocket.NewPostgresBroker(cfg.BrokerPostgresURL, cfg.BrokerChannel, useCaseLogger)
+		if err != nil {
+			log.Fatalf("Failed to initialize postgres broker: %v", err)
+		}
+		defer pgBroker.Close()
+		broker = pgBroker
+		customLogger.Info("Using postgres LISTEN/NOTIFY broker", logger.Field("channel", cfg.BrokerChannel))
+	default:
+		broker = websocket.NewInMemoryBroker()
+	}
+
+	wsHub := websocket.NewHubWithConfig(broker, websocket.Config{
+		AllowedOrigins:        cfg.AllowedOrigins,
+		MaxConnectionsPerUser: cfg.WSMaxConnsPerUser,
+		MaxConnectionsPerIP:   cfg.WSMaxConnsPerIP,
+		MessageRate:           cfg.WSMessageRate,
+		MessageBurst:          cfg.WSMessageBurst,
+		PresenceHeartbeat:     cfg.WSPresenceInterval,
+		Logger:                useCaseLogger,
+	})
 	go wsHub.Run() // Start hub in a goroutine
 	customLogger.Info("WebSocket hub started")
 
+	// Domain event bus - delivery mechanisms subscribe here instead of being called by controllers
+	eventBus := events.NewBus(customLogger)
+	eventBus.Subscribe("websocket", wsHub.HandleEvent)
+
+	// Staff actions on users' content are logged and their authors notified
+	moderationUseCase := usecases.NewModerationUseCase(moderationRepo, useCaseLogger)
+	notificationUseCase := usecases.NewNotificationUseCase(notificationRepo, useCaseLogger)
+	eventBus.Subscribe("moderation-log", moderationUseCase.HandleEvent, entities.EventContentModerated)
+	eventBus.Subscribe("notifications", notificationUseCase.HandleEvent, entities.EventContentModerated)
+
+	// Background workers stop when the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Outbox relay - events written in the same transaction as the entity change
+	// are delivered to the bus at least once. Supabase has no transactions, so it
+	// publishes straight to the bus instead.
+	if outboxRepo != nil {
+		relay := events.NewRelay(outboxRepo, eventBus, customLogger, events.DefaultRelayConfig())
+		go relay.Run(backgroundCtx)
+		customLogger.Info("Outbox relay started")
+	}
+
+	// Expired refresh tokens, revocation entries, sessions, login attempts, two-factor challenges, email tokens, API tokens and authorization codes are pruned periodically
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+				if err := usecases.PruneExpiredTokens(time.Now(), revocationRepo, refreshTokenRepo, sessionRepo, loginAttemptRepo, mfaRepo, userTokenRepo, apiTokenRepo, oauthCodeRepo); err != nil {
+					customLogger.Error("Failed to prune expired tokens", logger.Field("error", err.Error()))
+				}
+			}
+		}
+	}()
+
+	// Permission policy consulted by the use cases and the admin middleware
+	policy := usecases.NewPolicy(userRepo, roleRepo, mfaRepo, useCaseLogger)
+	for _, name := range cfg.UnverifiedRestrict {
+		restriction := entities.Restriction(name)
+		if err := entities.ValidateRestriction(restriction); err != nil {
+			log.Fatalf("Invalid UNVERIFIED_USER_RESTRICTIONS: %v", err)
+		}
+		policy.UnverifiedRestrictions = append(policy.UnverifiedRestrictions, restriction)
+	}
+
+	// Verification and password reset emails
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+
+	// Append-only record of security-relevant and administrative actions
+	auditLog := usecases.NewAuditLog(auditRepo, useCaseLogger)
+
+	// Failed logins lock the account and the client IP with exponential backoff
+	accountLockout := usecases.DefaultAccountLockoutPolicy()
+	accountLockout.MaxFailures = cfg.LoginMaxAttempts
+	accountLockout.BaseDelay = cfg.LoginLockoutBase
+	accountLockout.MaxDelay = cfg.LoginLockoutMax
+	ipLockout := usecases.DefaultIPLockoutPolicy()
+	ipLockout.MaxFailures = cfg.LoginIPMaxAttempts
+	ipLockout.BaseDelay = cfg.LoginLockoutBase
+	ipLockout.MaxDelay = cfg.LoginLockoutMax
+	loginLockout := usecases.NewLoginLockout(loginAttemptRepo, accountLockout, ipLockout, notificationUseCase, useCaseLogger)
+
 	// Blog post use case
-	blogPostUseCase := usecases.NewBlogPostUseCase(blogPostRepo, useCaseLogger)
+	blogPostUseCase := usecases.NewBlogPostUseCase(blogPostRepo, useCaseLogger, eventBus, transactor, policy, auditLog)
 	blogPostController := &interfaces.BlogPostController{
 		BlogPostUseCase: blogPostUseCase,
-		WebSocketHub:    wsHub,
 	}
 
 	// Comment use case
-	commentUseCase := usecases.NewCommentUseCase(commentRepo, blogPostRepo, userRepo, useCaseLogger)
+	commentUseCase := usecases.NewCommentUseCase(commentRepo, blogPostRepo, userRepo, useCaseLogger, eventBus, transactor, policy, auditLog)
 	commentController := &interfaces.CommentController{
 		CommentUseCase: commentUseCase,
-		WebSocketHub:   wsHub,
 	}
 
 	// WebSocket handler
@@ -104,31 +272,111 @@ func main() {
 	var authController *interfaces.AuthController
 	var adminController *interfaces.AdminController
 	var oauth2Controller *interfaces.OAuth2Controller
+	var apiTokenAuthenticator middleware.APITokenAuthenticator
+	var oauthClientChecker middleware.OAuthClientChecker
+	var oauthServerController *interfaces.OAuthServerController
 	if userRepo != nil {
-		authUseCase := usecases.NewAuthUseCase(userRepo, tokenGenerator, useCaseLogger)
+		argon2Params := entities.DefaultArgon2idParams()
+		argon2Params.Memory = cfg.Argon2Memory
+		argon2Params.Iterations = cfg.Argon2Iterations
+		argon2Params.Parallelism = cfg.Argon2Parallelism
+
+		passwordPolicy := entities.PasswordPolicy{
+			MinLength:          cfg.PasswordMinLength,
+			RequireLowercase:   cfg.PasswordLowercase,
+			RequireUppercase:   cfg.PasswordUppercase,
+			RequireDigit:       cfg.PasswordDigit,
+			RequireSymbol:      cfg.PasswordSymbol,
+			MinStrength:        cfg.PasswordStrength,
+			RejectPersonalInfo: cfg.PasswordNoPersonal,
+		}
+
+		// Breached password corpus (optional)
+		var breachedPasswords usecases.BreachedPasswords
+		if cfg.BreachedPasswords != "" {
+			breachedFile, err := auth.OpenBreachedPasswordFile(cfg.BreachedPasswords)
+			if err != nil {
+				log.Fatalf("Failed to open breached password file: %v", err)
+			}
+			defer breachedFile.Close()
+			breachedPasswords = breachedFile
+			customLogger.Info("Breached password check enabled")
+		}
+
+		authUseCase := usecases.NewAuthUseCaseWithConfig(userRepo, tokenGenerator, useCaseLogger, usecases.AuthConfig{
+			RefreshTokens:        refreshTokenRepo,
+			RefreshTokenDuration: cfg.RefreshTokenTTL,
+			Revocations:          revocationRepo,
+			Sessions:             sessionRepo,
+			Audit:                auditLog,
+			Lockout:              loginLockout,
+			MFA:                  mfaRepo,
+			MFAIssuer:            cfg.MFAIssuer,
+			UserTokens:           userTokenRepo,
+			Mailer:               mailer,
+			AppURL:               cfg.AppURL,
+			EmailVerificationTTL: cfg.EmailVerifyTTL,
+			PasswordResetTTL:     cfg.PasswordResetTTL,
+			Identities:           identityRepo,
+			APITokens:            apiTokenRepo,
+			PasswordHasher:       entities.NewArgon2idHasherWithLimit(argon2Params, cfg.Argon2Concurrent),
+			PasswordPolicy:       passwordPolicy,
+			BreachedPasswords:    breachedPasswords,
+		})
 		authController = &interfaces.AuthController{AuthUseCase: authUseCase}
+		if apiTokenRepo != nil {
+			apiTokenAuthenticator = authUseCase
+		}
 
 		// Admin use case
-		adminUseCase := usecases.NewAdminUseCase(userRepo, useCaseLogger)
-		adminController = interfaces.NewAdminController(adminUseCase)
+		tokenRevoker := usecases.NewUserTokenRevoker(revocationRepo, refreshTokenRepo, sessionRepo, useCaseLogger)
+		adminUseCase := usecases.NewAdminUseCase(userRepo, useCaseLogger, eventBus, transactor, tokenRevoker, policy, auditLog, loginLockout)
+		adminController = interfaces.NewAdminController(adminUseCase, adminUseCase, authUseCase)
 
-		// OAuth2 providers (optional - only if configured)
-		var googleProvider *auth.OAuth2Provider
-		var githubProvider *auth.OAuth2Provider
+		// Authorization server for third-party apps
+		oauthServer := usecases.NewOAuthServerUseCase(oauthClientRepo, oauthCodeRepo, userRepo, tokenGenerator, tokenGenerator, revocationRepo, auditLog, useCaseLogger)
+		oauthServerController = interfaces.NewOAuthServerController(oauthServer)
+		oauthClientChecker = oauthServer
 
+		// Sign-in providers (optional - only if configured)
+		providers := auth.NewProviderRegistry()
 		if cfg.GoogleClientID != "" && cfg.GoogleClientSecret != "" {
-			googleProvider = auth.NewGoogleOAuth2Provider(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL)
+			registerProvider(providers, "google", auth.NewGoogleOAuth2Provider(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL))
 			customLogger.Info("Google OAuth2 enabled")
 		}
-
 		if cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" {
-			githubProvider = auth.NewGitHubOAuth2Provider(cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GitHubRedirectURL)
+			registerProvider(providers, "github", auth.NewGitHubOAuth2Provider(cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GitHubRedirectURL))
 			customLogger.Info("GitHub OAuth2 enabled")
 		}
+		for _, oidc := range cfg.OIDCProviders {
+			provider, err := auth.NewOIDCProvider(auth.OIDCConfig{
+				Name:         oidc.Name,
+				IssuerURL:    oidc.IssuerURL,
+				ClientID:     oidc.ClientID,
+				ClientSecret: oidc.ClientSecret,
+				RedirectURL:  oidc.RedirectURL,
+				Scopes:       oidc.Scopes,
+				Claims: auth.OIDCClaims{
+					Email:         oidc.Claims["email"],
+					EmailVerified: oidc.Claims["email_verified"],
+					Name:          oidc.Claims["name"],
+					Picture:       oidc.Claims["picture"],
+				},
+				TrustEmail: oidc.TrustEmail,
+			})
+			if err != nil {
+				log.Fatalf("Invalid OIDC provider %s: %v", oidc.Name, err)
+			}
+			registerProvider(providers, oidc.Name, provider)
+			customLogger.Info("OpenID Connect provider enabled", logger.Field("provider", oidc.Name), logger.Field("issuer", oidc.IssuerURL))
+		}
 
 		// Create OAuth2 controller if at least one provider is configured
-		if googleProvider != nil || githubProvider != nil {
-			oauth2Controller = interfaces.NewOAuth2Controller(googleProvider, githubProvider, authUseCase)
+		if len(providers.Names()) > 0 {
+			if frontendURL, err := url.Parse(cfg.OAuthFrontendURL); err != nil || (cfg.OAuthFrontendURL != "" && !frontendURL.IsAbs()) {
+				log.Fatal("OAUTH_FRONTEND_REDIRECT_URL must be an absolute URL")
+			}
+			oauth2Controller = interfaces.NewOAuth2Controller(providers, authUseCase, cfg.OAuthFrontendURL)
 			customLogger.Info("OAuth2 social login enabled")
 		}
 
@@ -139,21 +387,30 @@ func main() {
 
 	// Create router with all controllers
 	routerConfig := &web.RouterConfig{
-		BlogPostController: blogPostController,
-		AuthController:     authController,
-		AdminController:    adminController,
-		CommentController:  commentController,
-		WebSocketHandler:   wsHandler,
-		OAuth2Controller:   oauth2Controller,
-		UserRepo:           userRepo,
-		JWTManager:         jwtManager,
-		Logger:             customLogger,
+		BlogPostController:     blogPostController,
+		AuthController:         authController,
+		AdminController:        adminController,
+		CommentController:      commentController,
+		WebSocketHandler:       wsHandler,
+		OAuth2Controller:       oauth2Controller,
+		OAuthServerController:  oauthServerController,
+		NotificationController: interfaces.NewNotificationController(notificationUseCase),
+		ModerationController:   interfaces.NewModerationController(moderationUseCase),
+		AuditController:        interfaces.NewAuditController(auditLog),
+		UserRepo:               userRepo,
+		JWTManager:             jwtManager,
+		Revocations:            revocationRepo,
+		APITokens:              apiTokenAuthenticator,
+		OAuthClients:           oauthClientChecker,
+		Permissions:            policy,
+		TrustProxy:             cfg.TrustProxy,
+		Logger:                 customLogger,
 	}
 	router := web.NewRouter(routerConfig)
 
-	// Add CORS middleware with WebSocket support
+	// Add CORS middleware with WebSocket support (same origins as the WebSocket check)
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000"},
+		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
@@ -163,6 +420,84 @@ func main() {
 
 	handler := corsHandler.Handler(router)
 
-	customLogger.Info("Starting server", logger.Field("port", cfg.ServerPort))
-	log.Fatal(http.ListenAndServe(cfg.ServerPort, handler))
+	server := &http.Server{
+		Addr:    cfg.ServerPort,
+		Handler: handler,
+	}
+
+	go func() {
+		customLogger.Info("Starting server", logger.Field("port", cfg.ServerPort))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Wait for an interrupt, then stop taking requests, close WebSocket
+	// clients with "going away" and stop the relay before the deferred
+	// database and broker cleanup runs
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	customLogger.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		customLogger.Error("HTTP server shutdown failed", logger.Field("error", err.Error()))
+	}
+	if err := wsHub.Shutdown(ctx); err != nil {
+		customLogger.Error("WebSocket hub shutdown failed", logger.Field("error", err.Error()))
+	}
+	stopBackground()
+
+	customLogger.Info("Server stopped")
+}
+
+// newJWTManager loads the configured PEM signing keys. Without keys, tokens
+// are signed with JWT_SECRET (HS256).
+func newJWTManager(cfg *config.Config) (*auth.JWTManager, error) {
+	keys := make([]*auth.SigningKey, 0, len(cfg.JWTKeys))
+	for _, keyConfig := range cfg.JWTKeys {
+		key, err := auth.LoadKeyPEM(keyConfig.ID, keyConfig.Path)
+		if err != nil {
+			return nil, err
+		}
+		key.ActivateAt = keyConfig.ActivateAt
+		key.RetireAt = keyConfig.RetireAt
+		keys = append(keys, key)
+	}
+
+	return auth.NewJWTManagerWithConfig(auth.JWTConfig{
+		Secret:        cfg.JWTSecret,
+		AcceptHS256:   cfg.JWTAcceptHS256 || len(keys) == 0,
+		TokenDuration: cfg.JWTTokenDuration,
+		Keys:          keys,
+	})
+}
+
+// newMailer builds the mailer selected by MAIL_DRIVER. The file and memory
+// drivers keep messages locally for development and tests.
+func newMailer(cfg *config.Config) (usecases.Mailer, error) {
+	switch strings.ToLower(cfg.MailDriver) {
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("SMTP_HOST must be provided when using the smtp mail driver")
+		}
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "memory":
+		return mail.NewInMemoryOutbox(), nil
+	case "file", "":
+		return mail.NewFileOutbox(cfg.MailOutboxDir, cfg.MailFrom)
+	default:
+		return nil, errors.New("unknown MAIL_DRIVER: " + cfg.MailDriver)
+	}
+}
+
+// registerProvider adds a sign-in provider; a clash of names is a
+// configuration error
+func registerProvider(providers *auth.ProviderRegistry, name string, provider auth.Provider) {
+	if err := providers.Register(name, provider); err != nil {
+		log.Fatalf("Failed to register sign-in provider: %v", err)
+	}
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -17,14 +19,58 @@ type Config struct {
 	SupabaseUser       string // Database username for Supabase
 	SupabasePass       string // Database password for Supabase
 	JWTSecret          string
-	JWTTokenDuration   time.Duration
+	JWTTokenDuration   time.Duration // Access token lifetime
+	JWTKeys            []JWTKeyConfig
+	JWTAcceptHS256     bool // Accept tokens signed with JWT_SECRET alongside the asymmetric keys
+	RefreshTokenTTL    time.Duration
 	GoogleClientID     string
 	GoogleClientSecret string
 	GoogleRedirectURL  string
 	GitHubClientID     string
 	GitHubClientSecret string
 	GitHubRedirectURL  string
+	OIDCProviders      []OIDCProviderConfig
+	OAuthFrontendURL   string // Where OAuth callbacks redirect with a one-time code; empty answers with JSON
 	BaseURL            string // Base URL for OAuth callbacks
+	BrokerType         string // "inmemory" or "postgres" - WebSocket fan-out across instances
+	BrokerPostgresURL  string // Postgres connection string used for LISTEN/NOTIFY
+	BrokerChannel      string
+	AllowedOrigins     []string // Shared by CORS and the WebSocket origin check
+	TrustProxy         bool     // Take the client IP from X-Forwarded-For
+	WSMaxConnsPerUser  int
+	WSMaxConnsPerIP    int
+	WSMessageRate      float64 // Inbound frames per second per connection
+	WSMessageBurst     int
+	WSPresenceInterval time.Duration // How often instances report who is present on their topics
+	ShutdownTimeout    time.Duration
+	LoginMaxAttempts   int // Failed logins per account before it is locked, 0 disables
+	LoginIPMaxAttempts int // Failed logins per client IP before it is locked, 0 disables
+	LoginLockoutBase   time.Duration
+	LoginLockoutMax    time.Duration
+	MFAIssuer          string // Issuer name shown in authenticator apps
+	AppURL             string // Frontend base URL for links in emails
+	MailDriver         string // "file", "smtp" or "memory"
+	MailOutboxDir      string // Directory the file driver writes messages to
+	MailFrom           string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	EmailVerifyTTL     time.Duration
+	PasswordResetTTL   time.Duration
+	UnverifiedRestrict []string // Actions unverified users may not take, e.g. "comment"
+	Argon2Memory       uint32   // KiB per password hash
+	Argon2Iterations   uint32
+	Argon2Parallelism  uint8
+	Argon2Concurrent   int // Password hashes computed at once; 0 means one per CPU
+	PasswordMinLength  int
+	PasswordLowercase  bool   // Require a lowercase letter
+	PasswordUppercase  bool   // Require an uppercase letter
+	PasswordDigit      bool   // Require a digit
+	PasswordSymbol     bool   // Require a symbol
+	PasswordStrength   int    // Minimum strength score from 0 to 4
+	PasswordNoPersonal bool   // Refuse passwords containing the username or email address
+	BreachedPasswords  string // SHA1:COUNT file of breached passwords; empty disables the check
 }
 
 func Load() (*Config, error) {
@@ -34,13 +80,73 @@ func Load() (*Config, error) {
 	viper.SetDefault("LOG_FILE", "server.log")
 	viper.SetDefault("DB_TYPE", "sqlite")
 	viper.SetDefault("JWT_SECRET", "your-secret-key-change-this-in-production")
-	viper.SetDefault("JWT_TOKEN_DURATION_HOURS", 24)
+	viper.SetDefault("JWT_ACCESS_TOKEN_MINUTES", 15)
+	viper.SetDefault("JWT_ACCEPT_HS256", true)
+	viper.SetDefault("REFRESH_TOKEN_DURATION_HOURS", 720)
 	viper.SetDefault("BASE_URL", "http://localhost:8080")
 	viper.SetDefault("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/google/callback")
 	viper.SetDefault("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/github/callback")
+	viper.SetDefault("BROKER_TYPE", "inmemory")
+	viper.SetDefault("BROKER_CHANNEL", "blog_events")
+	viper.SetDefault("ALLOWED_ORIGINS", "http://localhost:5173,http://localhost:3000")
+	viper.SetDefault("WS_MAX_CONNS_PER_USER", 5)
+	viper.SetDefault("WS_MAX_CONNS_PER_IP", 20)
+	viper.SetDefault("WS_MESSAGE_RATE", 5)
+	viper.SetDefault("WS_MESSAGE_BURST", 10)
+	viper.SetDefault("WS_PRESENCE_HEARTBEAT_SECONDS", 30)
+	viper.SetDefault("TRUST_PROXY", false)
+	viper.SetDefault("SHUTDOWN_TIMEOUT_SECONDS", 15)
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_IP_MAX_ATTEMPTS", 20)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE_SECONDS", 60)
+	viper.SetDefault("LOGIN_LOCKOUT_MAX_MINUTES", 60)
+	viper.SetDefault("MFA_ISSUER", "GoCleanArchitecture")
+	viper.SetDefault("APP_URL", "http://localhost:5173")
+	viper.SetDefault("MAIL_DRIVER", "file")
+	viper.SetDefault("MAIL_OUTBOX_DIR", "./mail_outbox")
+	viper.SetDefault("MAIL_FROM", "GoCleanArchitecture <no-reply@localhost>")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("EMAIL_VERIFICATION_TTL_HOURS", 24)
+	viper.SetDefault("PASSWORD_RESET_TTL_MINUTES", 60)
+	viper.SetDefault("UNVERIFIED_USER_RESTRICTIONS", "")
+	viper.SetDefault("PASSWORD_ARGON2_MEMORY_KIB", 19456)
+	viper.SetDefault("PASSWORD_ARGON2_ITERATIONS", 2)
+	viper.SetDefault("PASSWORD_ARGON2_PARALLELISM", 1)
+	viper.SetDefault("PASSWORD_ARGON2_MAX_CONCURRENT", 0)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWERCASE", false)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPERCASE", false)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", false)
+	viper.SetDefault("PASSWORD_REQUIRE_SYMBOL", false)
+	viper.SetDefault("PASSWORD_MIN_STRENGTH", 0) // as entities.DefaultPasswordPolicy
+	viper.SetDefault("PASSWORD_REJECT_PERSONAL_INFO", true)
 
 	viper.AutomaticEnv()
 
+	jwtKeys, err := parseJWTKeys(viper.GetString("JWT_SIGNING_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	oidcProviders, err := parseOIDCProviders(viper.GetString("OIDC_PROVIDERS"), viper.GetString("BASE_URL"))
+	if err != nil {
+		return nil, err
+	}
+	// Argon2 needs at least one pass, one lane and 8 KiB per lane
+	parallelism := viper.GetUint("PASSWORD_ARGON2_PARALLELISM")
+	if viper.GetUint("PASSWORD_ARGON2_ITERATIONS") < 1 || parallelism < 1 || parallelism > 255 ||
+		viper.GetUint("PASSWORD_ARGON2_MEMORY_KIB") < 8*parallelism {
+		return nil, fmt.Errorf("invalid PASSWORD_ARGON2 settings: need at least 1 iteration, 1-255 lanes and 8 KiB of memory per lane")
+	}
+	if strength := viper.GetInt("PASSWORD_MIN_STRENGTH"); strength < 0 || strength > 4 {
+		return nil, fmt.Errorf("invalid PASSWORD_MIN_STRENGTH %d: must be from 0 to 4", strength)
+	}
+	// An empty list would allow every origin for CORS but only the same
+	// origin for WebSockets, so it has to be spelled out
+	allowedOrigins := splitList(viper.GetString("ALLOWED_ORIGINS"))
+	if len(allowedOrigins) == 0 {
+		return nil, fmt.Errorf("ALLOWED_ORIGINS is empty: list the frontend's origins, or * to allow any")
+	}
+
 	return &Config{
 		ServerPort:         viper.GetString("SERVER_PORT"),
 		DBPath:             viper.GetString("DB_PATH"),
@@ -52,13 +158,156 @@ func Load() (*Config, error) {
 		SupabaseUser:       viper.GetString("SUPABASE_USER"),
 		SupabasePass:       viper.GetString("SUPABASE_PASS"),
 		JWTSecret:          viper.GetString("JWT_SECRET"),
-		JWTTokenDuration:   time.Duration(viper.GetInt("JWT_TOKEN_DURATION_HOURS")) * time.Hour,
+		JWTTokenDuration:   time.Duration(viper.GetInt("JWT_ACCESS_TOKEN_MINUTES")) * time.Minute,
+		JWTKeys:            jwtKeys,
+		JWTAcceptHS256:     viper.GetBool("JWT_ACCEPT_HS256"),
+		RefreshTokenTTL:    time.Duration(viper.GetInt("REFRESH_TOKEN_DURATION_HOURS")) * time.Hour,
 		GoogleClientID:     viper.GetString("GOOGLE_CLIENT_ID"),
 		GoogleClientSecret: viper.GetString("GOOGLE_CLIENT_SECRET"),
 		GoogleRedirectURL:  viper.GetString("GOOGLE_REDIRECT_URL"),
 		GitHubClientID:     viper.GetString("GITHUB_CLIENT_ID"),
 		GitHubClientSecret: viper.GetString("GITHUB_CLIENT_SECRET"),
 		GitHubRedirectURL:  viper.GetString("GITHUB_REDIRECT_URL"),
+		OIDCProviders:      oidcProviders,
+		OAuthFrontendURL:   viper.GetString("OAUTH_FRONTEND_REDIRECT_URL"),
 		BaseURL:            viper.GetString("BASE_URL"),
+		BrokerType:         viper.GetString("BROKER_TYPE"),
+		BrokerPostgresURL:  viper.GetString("BROKER_POSTGRES_URL"),
+		BrokerChannel:      viper.GetString("BROKER_CHANNEL"),
+		AllowedOrigins:     allowedOrigins,
+		TrustProxy:         viper.GetBool("TRUST_PROXY") || viper.GetBool("WS_TRUST_PROXY"), // the WebSocket-only setting it replaced
+		WSMaxConnsPerUser:  viper.GetInt("WS_MAX_CONNS_PER_USER"),
+		WSMaxConnsPerIP:    viper.GetInt("WS_MAX_CONNS_PER_IP"),
+		WSMessageRate:      viper.GetFloat64("WS_MESSAGE_RATE"),
+		WSMessageBurst:     viper.GetInt("WS_MESSAGE_BURST"),
+		WSPresenceInterval: time.Duration(viper.GetInt("WS_PRESENCE_HEARTBEAT_SECONDS")) * time.Second,
+		ShutdownTimeout:    time.Duration(viper.GetInt("SHUTDOWN_TIMEOUT_SECONDS")) * time.Second,
+		LoginMaxAttempts:   viper.GetInt("LOGIN_MAX_ATTEMPTS"),
+		LoginIPMaxAttempts: viper.GetInt("LOGIN_IP_MAX_ATTEMPTS"),
+		LoginLockoutBase:   time.Duration(viper.GetInt("LOGIN_LOCKOUT_BASE_SECONDS")) * time.Second,
+		LoginLockoutMax:    time.Duration(viper.GetInt("LOGIN_LOCKOUT_MAX_MINUTES")) * time.Minute,
+		MFAIssuer:          viper.GetString("MFA_ISSUER"),
+		AppURL:             viper.GetString("APP_URL"),
+		MailDriver:         viper.GetString("MAIL_DRIVER"),
+		MailOutboxDir:      viper.GetString("MAIL_OUTBOX_DIR"),
+		MailFrom:           viper.GetString("MAIL_FROM"),
+		SMTPHost:           viper.GetString("SMTP_HOST"),
+		SMTPPort:           viper.GetInt("SMTP_PORT"),
+		SMTPUsername:       viper.GetString("SMTP_USERNAME"),
+		SMTPPassword:       viper.GetString("SMTP_PASSWORD"),
+		EmailVerifyTTL:     time.Duration(viper.GetInt("EMAIL_VERIFICATION_TTL_HOURS")) * time.Hour,
+		PasswordResetTTL:   time.Duration(viper.GetInt("PASSWORD_RESET_TTL_MINUTES")) * time.Minute,
+		UnverifiedRestrict: splitList(viper.GetString("UNVERIFIED_USER_RESTRICTIONS")),
+		Argon2Memory:       viper.GetUint32("PASSWORD_ARGON2_MEMORY_KIB"),
+		Argon2Iterations:   viper.GetUint32("PASSWORD_ARGON2_ITERATIONS"),
+		Argon2Parallelism:  uint8(parallelism),
+		Argon2Concurrent:   viper.GetInt("PASSWORD_ARGON2_MAX_CONCURRENT"),
+		PasswordMinLength:  viper.GetInt("PASSWORD_MIN_LENGTH"),
+		PasswordLowercase:  viper.GetBool("PASSWORD_REQUIRE_LOWERCASE"),
+		PasswordUppercase:  viper.GetBool("PASSWORD_REQUIRE_UPPERCASE"),
+		PasswordDigit:      viper.GetBool("PASSWORD_REQUIRE_DIGIT"),
+		PasswordSymbol:     viper.GetBool("PASSWORD_REQUIRE_SYMBOL"),
+		PasswordStrength:   viper.GetInt("PASSWORD_MIN_STRENGTH"),
+		PasswordNoPersonal: viper.GetBool("PASSWORD_REJECT_PERSONAL_INFO"),
+		BreachedPasswords:  viper.GetString("PASSWORD_BREACHED_FILE"),
 	}, nil
 }
+
+// JWTKeyConfig describes one PEM signing key and its rotation schedule
+type JWTKeyConfig struct {
+	ID         string
+	Path       string
+	ActivateAt time.Time // zero means active immediately
+	RetireAt   time.Time // zero means never retired
+}
+
+// parseJWTKeys parses JWT_SIGNING_KEYS: comma-separated entries of the form
+// kid|path[|activate_at[|retire_at]] with RFC 3339 times
+func parseJWTKeys(value string) ([]JWTKeyConfig, error) {
+	var keys []JWTKeyConfig
+	for _, entry := range splitList(value) {
+		fields := strings.Split(entry, "|")
+		if len(fields) < 2 || len(fields) > 4 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("invalid JWT_SIGNING_KEYS entry %q", entry)
+		}
+
+		key := JWTKeyConfig{ID: strings.TrimSpace(fields[0]), Path: strings.TrimSpace(fields[1])}
+		times := []*time.Time{&key.ActivateAt, &key.RetireAt}
+		for i, field := range fields[2:] {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time in JWT_SIGNING_KEYS entry %q: %w", entry, err)
+			}
+			*times[i] = t
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// OIDCProviderConfig describes one OpenID Connect provider
+type OIDCProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Claims       map[string]string // "email", "email_verified", "name" or "picture" to the claim holding it
+	TrustEmail   bool
+}
+
+// parseOIDCProviders reads the providers named in OIDC_PROVIDERS from
+// OIDC_<NAME>_ISSUER_URL, _CLIENT_ID, _CLIENT_SECRET, _SCOPES, _REDIRECT_URL,
+// _CLAIMS and _TRUST_EMAIL, with dashes in the name written as underscores.
+// _CLAIMS maps user details to claims, e.g. "email=upn,name=preferred_username".
+func parseOIDCProviders(names, baseURL string) ([]OIDCProviderConfig, error) {
+	var providers []OIDCProviderConfig
+	for _, name := range splitList(names) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		viper.SetDefault(prefix+"SCOPES", "openid,email,profile")
+		viper.SetDefault(prefix+"REDIRECT_URL", strings.TrimRight(baseURL, "/")+"/auth/oidc/"+name+"/callback")
+
+		provider := OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    viper.GetString(prefix + "ISSUER_URL"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(strings.ReplaceAll(viper.GetString(prefix+"SCOPES"), ",", " ")),
+			Claims:       make(map[string]string),
+			TrustEmail:   viper.GetBool(prefix + "TRUST_EMAIL"),
+		}
+		if provider.IssuerURL == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER_URL and %sCLIENT_ID are required", prefix, prefix)
+		}
+		for _, entry := range splitList(viper.GetString(prefix + "CLAIMS")) {
+			field, claim, ok := strings.Cut(entry, "=")
+			field, claim = strings.TrimSpace(field), strings.TrimSpace(claim)
+			switch {
+			case !ok || claim == "":
+				return nil, fmt.Errorf("invalid %sCLAIMS entry %q", prefix, entry)
+			case field != "email" && field != "email_verified" && field != "name" && field != "picture":
+				return nil, fmt.Errorf("unknown field %q in %sCLAIMS", field, prefix)
+			}
+			provider.Claims[field] = claim
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// splitList parses a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
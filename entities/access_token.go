@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// AccessToken describes what a signed access token asserts about its holder.
+// ID and ExpiresAt are filled in by the token generator. Tokens issued to a
+// third-party app name it in ClientID and are limited to their Scopes.
+type AccessToken struct {
+	ID         string // unique token ID (jti), used for revocation
+	UserID     string
+	Username   string
+	Email      string
+	SessionID  string
+	Role       UserRole
+	Generation int     // user's token generation when issued; a role change bumps it
+	ClientID   string  // the third-party app the token was issued to, if any
+	Scopes     []Scope // what a third-party app may do
+	ExpiresAt  time.Time
+}
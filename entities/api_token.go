@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// APITokenPrefix starts every personal API token, so that they can be told
+// apart from JWTs and recognised by secret scanners
+const APITokenPrefix = "pat_"
+
+// Scope is an area of the API a personal API token or third-party app may be
+// used for
+type Scope string
+
+const (
+	ScopePostsWrite         Scope = "posts:write"         // create, edit and delete blog posts
+	ScopePostsModerate      Scope = "posts:moderate"      // with posts:write, edit and delete other authors' posts
+	ScopeCommentsWrite      Scope = "comments:write"      // write, edit and delete comments
+	ScopeCommentsModerate   Scope = "comments:moderate"   // with comments:write, delete other users' comments
+	ScopeNotificationsRead  Scope = "notifications:read"  // list notifications
+	ScopeNotificationsWrite Scope = "notifications:write" // mark notifications read
+	ScopeProfileRead        Scope = "profile:read"        // read the user's profile
+	ScopeProfileWrite       Scope = "profile:write"       // update the user's profile
+)
+
+var allScopes = []Scope{
+	ScopePostsWrite,
+	ScopePostsModerate,
+	ScopeCommentsWrite,
+	ScopeCommentsModerate,
+	ScopeNotificationsRead,
+	ScopeNotificationsWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+// AllScopes returns every known scope
+func AllScopes() []Scope {
+	return append([]Scope(nil), allScopes...)
+}
+
+// ValidateScope checks that a scope is known
+func ValidateScope(scope Scope) error {
+	for _, s := range allScopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown scope: %s", scope)
+}
+
+// ModerationScope returns the scope a token needs to use a permission on
+// other users' content, or "" for permissions tokens can't use that way
+func ModerationScope(permission Permission) Scope {
+	switch permission {
+	case PermissionPostEditAny, PermissionPostDeleteAny:
+		return ScopePostsModerate
+	case PermissionCommentModerate:
+		return ScopeCommentsModerate
+	}
+	return ""
+}
+
+// APIToken is a long-lived token a user creates for scripts and CI jobs. It
+// acts as the user, but only on the routes its scopes allow. Only its hash is
+// stored.
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []Scope    `json:"scopes"`
+	Prefix     string     `json:"prefix"` // the token's first characters, to recognise it by
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"` // nil never expires
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// NewAPIToken validates a token's name and scopes; duplicate scopes are dropped
+func NewAPIToken(id, userID, name string, scopes []Scope, prefix, tokenHash string, createdAt time.Time, expiresAt *time.Time) (*APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("token name is required")
+	}
+	if len(name) > 100 {
+		return nil, errors.New("token name must be at most 100 characters")
+	}
+	unique, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIToken{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Scopes:    unique,
+		Prefix:    prefix,
+		TokenHash: tokenHash,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token may be used for a scope
+func (t *APIToken) HasScope(scope Scope) bool {
+	return containsScope(t.Scopes, scope)
+}
+
+// normalizeScopes validates scopes, requiring at least one, and returns them
+// sorted without duplicates
+func normalizeScopes(scopes []Scope) ([]Scope, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	seen := make(map[Scope]bool)
+	var unique []Scope
+	for _, scope := range scopes {
+		if err := ValidateScope(scope); err != nil {
+			return nil, err
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique, nil
+}
+
+func containsScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited actions
+const (
+	AuditUserRegister         = "user.register"
+	AuditLogin                = "auth.login"
+	AuditLoginFailed          = "auth.login_failed"
+	AuditLogout               = "auth.logout"
+	AuditLogoutAll            = "auth.logout_all"
+	AuditPasswordChange       = "auth.password_change"
+	AuditPasswordResetRequest = "auth.password_reset_request"
+	AuditPasswordReset        = "auth.password_reset"
+	AuditEmailVerify          = "auth.email_verify"
+	AuditRefreshReuse         = "auth.refresh_token_reuse"
+	AuditAccountLocked        = "auth.account_locked"
+	AuditMFAEnable            = "auth.mfa_enable"
+	AuditMFADisable           = "auth.mfa_disable"
+	AuditMFAFailed            = "auth.mfa_failed"
+	AuditIdentityLink         = "auth.identity_link"
+	AuditIdentityUnlink       = "auth.identity_unlink"
+	AuditAPITokenCreate       = "auth.api_token_create"
+	AuditAPITokenRevoke       = "auth.api_token_revoke"
+	AuditOAuthConsent         = "oauth.consent"
+	AuditOAuthClientCreate    = "oauth.client_create"
+	AuditOAuthClientDelete    = "oauth.client_delete"
+	AuditUserRoleChange       = "user.role_change"
+	AuditUserDelete           = "user.delete"
+	AuditUserUnlock           = "user.unlock"
+	AuditRoleCreate           = "role.create"
+	AuditRoleUpdate           = "role.update"
+	AuditRoleDelete           = "role.delete"
+	AuditRoleMFARequire       = "role.mfa_requirement"
+	AuditPostCreate           = "post.create"
+	AuditPostUpdate           = "post.update"
+	AuditPostDelete           = "post.delete"
+	AuditCommentCreate        = "comment.create"
+	AuditCommentUpdate        = "comment.update"
+	AuditCommentDelete        = "comment.delete"
+)
+
+// Audit target types
+const (
+	AuditTargetUser        = "user"
+	AuditTargetRole        = "role"
+	AuditTargetPost        = "post"
+	AuditTargetComment     = "comment"
+	AuditTargetOAuthClient = "oauth_client"
+)
+
+// AuditEvent records who did what to which resource, from where. Events are
+// append-only: once written they are never changed or deleted.
+type AuditEvent struct {
+	ID         string
+	OccurredAt time.Time
+	ActorID    string // empty when nobody is signed in, e.g. a failed login
+	Action     string
+	TargetType string
+	TargetID   string
+	IP         string
+	UserAgent  string
+	RequestID  string
+	Before     string // JSON snapshot of the target before the action, if any
+	After      string // JSON snapshot of the target after the action, if any
+}
+
+// NewAuditEvent creates an event for an action on a target
+func NewAuditEvent(action, actorID, targetType, targetID string) *AuditEvent {
+	return &AuditEvent{
+		ID:         uuid.New().String(),
+		OccurredAt: time.Now(),
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+}
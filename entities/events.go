@@ -0,0 +1,202 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event names used to route domain events to subscribers
+const (
+	EventPostPublished   = "post.published"
+	EventPostUpdated     = "post.updated"
+	EventPostDeleted     = "post.deleted"
+	EventCommentCreated  = "comment.created"
+	EventCommentUpdated  = "comment.updated"
+	EventCommentDeleted  = "comment.deleted"
+	EventUserRoleChanged = "user.role_changed"
+	EventUserDeleted     = "user.deleted"
+
+	EventContentModerated = "content.moderated"
+)
+
+// DomainEvent is something that happened in the domain that other parts of
+// the system may want to react to
+type DomainEvent interface {
+	EventName() string
+	EventID() string
+	OccurredAt() time.Time
+}
+
+// EventMeta carries the identity and timestamp shared by every domain event
+type EventMeta struct {
+	ID   string    `json:"event_id"`
+	Time time.Time `json:"occurred_at"`
+}
+
+func newEventMeta() EventMeta {
+	return EventMeta{
+		ID:   uuid.New().String(),
+		Time: time.Now(),
+	}
+}
+
+// EventID returns the unique identifier of the event
+func (m EventMeta) EventID() string {
+	return m.ID
+}
+
+// OccurredAt returns when the event happened
+func (m EventMeta) OccurredAt() time.Time {
+	return m.Time
+}
+
+// PostPublished is raised when a new blog post is created
+type PostPublished struct {
+	EventMeta
+	Post *BlogPost `json:"post"`
+}
+
+func NewPostPublished(post *BlogPost) *PostPublished {
+	return &PostPublished{EventMeta: newEventMeta(), Post: post}
+}
+
+func (e *PostPublished) EventName() string { return EventPostPublished }
+
+// PostUpdated is raised when a blog post's title or content changes
+type PostUpdated struct {
+	EventMeta
+	Post    *BlogPost `json:"post"`
+	ActorID string    `json:"actor_id"`
+}
+
+func NewPostUpdated(post *BlogPost, actorID string) *PostUpdated {
+	return &PostUpdated{EventMeta: newEventMeta(), Post: post, ActorID: actorID}
+}
+
+func (e *PostUpdated) EventName() string { return EventPostUpdated }
+
+// PostDeleted is raised when a blog post is removed
+type PostDeleted struct {
+	EventMeta
+	PostID   string `json:"post_id"`
+	AuthorID string `json:"author_id"`
+	ActorID  string `json:"actor_id"`
+}
+
+func NewPostDeleted(post *BlogPost, actorID string) *PostDeleted {
+	return &PostDeleted{EventMeta: newEventMeta(), PostID: post.ID, AuthorID: post.AuthorID, ActorID: actorID}
+}
+
+func (e *PostDeleted) EventName() string { return EventPostDeleted }
+
+// CommentCreated is raised when a comment or reply is posted
+type CommentCreated struct {
+	EventMeta
+	Comment *Comment `json:"comment"`
+}
+
+func NewCommentCreated(comment *Comment) *CommentCreated {
+	return &CommentCreated{EventMeta: newEventMeta(), Comment: comment}
+}
+
+func (e *CommentCreated) EventName() string { return EventCommentCreated }
+
+// CommentUpdated is raised when a comment's content changes
+type CommentUpdated struct {
+	EventMeta
+	Comment *Comment `json:"comment"`
+}
+
+func NewCommentUpdated(comment *Comment) *CommentUpdated {
+	return &CommentUpdated{EventMeta: newEventMeta(), Comment: comment}
+}
+
+func (e *CommentUpdated) EventName() string { return EventCommentUpdated }
+
+// CommentDeleted is raised when a comment is removed
+type CommentDeleted struct {
+	EventMeta
+	CommentID  string `json:"comment_id"`
+	BlogPostID string `json:"blog_post_id"`
+	AuthorID   string `json:"author_id"`
+	ActorID    string `json:"actor_id"`
+}
+
+func NewCommentDeleted(comment *Comment, actorID string) *CommentDeleted {
+	return &CommentDeleted{
+		EventMeta:  newEventMeta(),
+		CommentID:  comment.ID,
+		BlogPostID: comment.BlogPostID,
+		AuthorID:   comment.AuthorID,
+		ActorID:    actorID,
+	}
+}
+
+func (e *CommentDeleted) EventName() string { return EventCommentDeleted }
+
+// UserRoleChanged is raised when an admin changes a user's role
+type UserRoleChanged struct {
+	EventMeta
+	UserID  string   `json:"user_id"`
+	OldRole UserRole `json:"old_role"`
+	NewRole UserRole `json:"new_role"`
+}
+
+func NewUserRoleChanged(userID string, oldRole, newRole UserRole) *UserRoleChanged {
+	return &UserRoleChanged{EventMeta: newEventMeta(), UserID: userID, OldRole: oldRole, NewRole: newRole}
+}
+
+func (e *UserRoleChanged) EventName() string { return EventUserRoleChanged }
+
+// UserDeleted is raised when a user account is removed
+type UserDeleted struct {
+	EventMeta
+	UserID string `json:"user_id"`
+}
+
+func NewUserDeleted(userID string) *UserDeleted {
+	return &UserDeleted{EventMeta: newEventMeta(), UserID: userID}
+}
+
+func (e *UserDeleted) EventName() string { return EventUserDeleted }
+
+// ContentModerated is raised when staff edit or delete content written by
+// someone else
+type ContentModerated struct {
+	EventMeta
+	TargetType string `json:"target_type"` // ModerationTargetPost or ModerationTargetComment
+	TargetID   string `json:"target_id"`
+	Title      string `json:"title"` // post title, so the author knows which content it was
+	AuthorID   string `json:"author_id"`
+	ActorID    string `json:"actor_id"`
+	Action     string `json:"action"` // ModerationActionEdit or ModerationActionDelete
+	Reason     string `json:"reason"`
+}
+
+func NewPostModerated(post *BlogPost, actorID, action, reason string) *ContentModerated {
+	return &ContentModerated{
+		EventMeta:  newEventMeta(),
+		TargetType: ModerationTargetPost,
+		TargetID:   post.ID,
+		Title:      post.Title,
+		AuthorID:   post.AuthorID,
+		ActorID:    actorID,
+		Action:     action,
+		Reason:     reason,
+	}
+}
+
+func NewCommentModerated(comment *Comment, actorID, reason string) *ContentModerated {
+	return &ContentModerated{
+		EventMeta:  newEventMeta(),
+		TargetType: ModerationTargetComment,
+		TargetID:   comment.ID,
+		AuthorID:   comment.AuthorID,
+		ActorID:    actorID,
+		Action:     ModerationActionDelete,
+		Reason:     reason,
+	}
+}
+
+func (e *ContentModerated) EventName() string { return EventContentModerated }
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// Identity links an account at an external sign-in provider, such as Google
+// or GitHub, to a user. The provider's stable account ID identifies it; the
+// email address can change at the provider and is kept for display only.
+type Identity struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Provider   string    `json:"provider"`
+	ProviderID string    `json:"provider_id"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
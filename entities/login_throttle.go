@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"time"
+)
+
+// LockoutPolicy sets how failed logins are throttled. The first MaxFailures-1
+// failures are free; the MaxFailures-th locks for BaseDelay and every further
+// failure doubles the lock, up to MaxDelay. Failures are forgotten Window
+// after the last one once no lock is active.
+type LockoutPolicy struct {
+	MaxFailures int // 0 disables the lockout
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Window      time.Duration
+}
+
+// LoginThrottle counts recent failed logins for one key: an account or a
+// client IP
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time // zero when not locked
+	ExpiresAt     time.Time // when the record may be forgotten
+}
+
+// NewLoginThrottle creates an empty record for a key
+func NewLoginThrottle(key string) *LoginThrottle {
+	return &LoginThrottle{Key: key}
+}
+
+// RetryAfter returns how long the key stays locked, or zero
+func (t *LoginThrottle) RetryAfter(now time.Time) time.Duration {
+	if now.Before(t.LockedUntil) {
+		return t.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// RecordFailure counts a failed login and returns the lock it starts, or
+// zero when the key may keep trying
+func (t *LoginThrottle) RecordFailure(now time.Time, policy LockoutPolicy) time.Duration {
+	if !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt) {
+		t.Failures = 0
+		t.LockedUntil = time.Time{}
+	}
+
+	t.Failures++
+	t.LastFailureAt = now
+	t.ExpiresAt = now.Add(policy.Window)
+
+	if policy.MaxFailures <= 0 || t.Failures < policy.MaxFailures {
+		return 0
+	}
+
+	delay := policy.BaseDelay
+	for i := policy.MaxFailures; i < t.Failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	t.LockedUntil = now.Add(delay)
+	if t.LockedUntil.After(t.ExpiresAt) {
+		t.ExpiresAt = t.LockedUntil
+	}
+	return delay
+}
+
+// LoginLockedError is returned while too many failed logins block an account
+// or client IP
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed login attempts, try again later"
+}
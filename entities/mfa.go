@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+)
+
+// MFAEnrollment is a user's TOTP second factor. It takes effect once the user
+// confirms it with a first code. Recovery codes are stored hashed and each
+// works once.
+type MFAEnrollment struct {
+	UserID             string
+	Secret             string // base32 TOTP secret
+	CreatedAt          time.Time
+	ConfirmedAt        *time.Time
+	LastUsedStep       int64 // time step of the last accepted code, so a code can't be replayed
+	RecoveryCodeHashes []string
+}
+
+// NewMFAEnrollment starts an unconfirmed enrolment with a fresh secret
+func NewMFAEnrollment(userID string) (*MFAEnrollment, error) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// IsConfirmed reports whether the second factor is in use
+func (e *MFAEnrollment) IsConfirmed() bool {
+	return e.ConfirmedAt != nil
+}
+
+// Confirm puts the second factor in use with the given recovery code hashes
+func (e *MFAEnrollment) Confirm(now time.Time, recoveryCodeHashes []string) {
+	e.ConfirmedAt = &now
+	e.RecoveryCodeHashes = recoveryCodeHashes
+}
+
+// VerifyCode accepts a TOTP code for the current time step or one step either
+// side, to allow for clock drift. A code is accepted once.
+func (e *MFAEnrollment) VerifyCode(code string, now time.Time) bool {
+	step, ok := e.MatchCode(code, now)
+	if ok {
+		e.LastUsedStep = step
+	}
+	return ok
+}
+
+// MatchCode returns the time step of a TOTP code that VerifyCode would
+// accept, without using it up
+func (e *MFAEnrollment) MatchCode(code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - 1; step <= current+1; step++ {
+		if step <= e.LastUsedStep {
+			continue
+		}
+		expected, err := totpCode(e.Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// HasRecoveryCode reports whether the recovery code with the given hash is
+// still unused
+func (e *MFAEnrollment) HasRecoveryCode(hash string) bool {
+	found := false
+	for _, h := range e.RecoveryCodeHashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
+
+// UseRecoveryCode consumes the recovery code with the given hash
+func (e *MFAEnrollment) UseRecoveryCode(hash string) bool {
+	for i, h := range e.RecoveryCodeHashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			e.RecoveryCodeHashes = append(e.RecoveryCodeHashes[:i:i], e.RecoveryCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MaxMFAChallengeAttempts is how many wrong codes a challenge accepts before
+// the user has to sign in again
+const MaxMFAChallengeAttempts = 5
+
+// MFAChallenge is issued when a password login succeeds for a user with a
+// second factor. Its opaque token, stored hashed, is exchanged for access
+// tokens together with a valid code.
+type MFAChallenge struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// IsExpired reports whether the challenge can no longer be used
+func (c *MFAChallenge) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
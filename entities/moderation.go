@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Targets and actions of staff moderation
+const (
+	ModerationTargetPost    = "post"
+	ModerationTargetComment = "comment"
+
+	ModerationActionEdit   = "edit"
+	ModerationActionDelete = "delete"
+)
+
+const maxModerationReasonLength = 500
+
+// ModerationAction records a staff member editing or deleting content written
+// by someone else, and why
+type ModerationAction struct {
+	ID         string // ID of the ContentModerated event, so redelivery doesn't duplicate it
+	TargetType string
+	TargetID   string
+	AuthorID   string
+	ActorID    string
+	Action     string
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// NewModerationAction builds the moderation log entry for an event
+func NewModerationAction(event *ContentModerated) *ModerationAction {
+	return &ModerationAction{
+		ID:         event.EventID(),
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		AuthorID:   event.AuthorID,
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		Reason:     event.Reason,
+		CreatedAt:  event.OccurredAt(),
+	}
+}
+
+// ValidateModerationReason checks the reason given for acting on someone
+// else's content
+func ValidateModerationReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("a reason is required when acting on another author's content")
+	}
+	if len(reason) > maxModerationReasonLength {
+		return "", errors.New("reason cannot exceed 500 characters")
+	}
+	return reason, nil
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	NotificationContentModerated = "content_moderated"
+	NotificationAccountLocked    = "account_locked"
+)
+
+// Notification is a message for a user about something that happened to
+// their account or content
+type Notification struct {
+	ID        string
+	UserID    string
+	Type      string
+	Message   string
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
+
+// NewNotification creates an unread notification
+func NewNotification(userID, notificationType, message string) *Notification {
+	return &Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      notificationType,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+}
+
+// MarkRead marks the notification as read
+func (n *Notification) MarkRead(at time.Time) {
+	if n.ReadAt == nil {
+		n.ReadAt = &at
+	}
+}
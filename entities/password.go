@@ -0,0 +1,167 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher turns passwords into encoded hashes and checks them.
+// NeedsRehash reports whether a hash was made with an outdated algorithm or
+// parameters, so it can be replaced the next time the password is known.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) bool
+	NeedsRehash(encodedHash string) bool
+}
+
+// Argon2idParams are the cost parameters of Argon2id (RFC 9106)
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams returns OWASP's recommended minimum: 19 MiB, two
+// passes, one lane
+func DefaultArgon2idParams() Argon2idParams {
+	return Argon2idParams{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2idHasher hashes passwords with Argon2id in the PHC string format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+//
+// It also verifies the bcrypt hashes of accounts created before Argon2id,
+// and reports them as needing a rehash.
+//
+// Each hash takes Params.Memory of memory, so only a few run at once; the
+// rest wait their turn instead of exhausting memory under a login flood.
+type Argon2idHasher struct {
+	Params Argon2idParams
+	slots  chan struct{}
+}
+
+// NewArgon2idHasher creates a hasher that runs one hash per CPU at a time
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return NewArgon2idHasherWithLimit(params, 0)
+}
+
+// NewArgon2idHasherWithLimit creates a hasher that runs at most maxConcurrent
+// hashes at a time, or one per CPU when maxConcurrent is 0
+func NewArgon2idHasherWithLimit(params Argon2idParams, maxConcurrent int) *Argon2idHasher {
+	if maxConcurrent <= 0 {
+		maxConcurrent = runtime.GOMAXPROCS(0)
+	}
+	return &Argon2idHasher{Params: params, slots: make(chan struct{}, maxConcurrent)}
+}
+
+// DefaultPasswordHasher returns an Argon2idHasher with the default parameters
+func DefaultPasswordHasher() PasswordHasher {
+	return NewArgon2idHasher(DefaultArgon2idParams())
+}
+
+// Hash returns the PHC-encoded Argon2id hash of password under a new salt
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := h.deriveKey(password, salt, h.Params)
+	return encodeArgon2id(h.Params, salt, key), nil
+}
+
+// Verify checks password against an Argon2id or bcrypt hash
+func (h *Argon2idHasher) Verify(password, encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password)) == nil
+	}
+
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return false
+	}
+	other := h.deriveKey(password, salt, params)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// deriveKey runs Argon2id once a slot is free
+func (h *Argon2idHasher) deriveKey(password string, salt []byte, params Argon2idParams) []byte {
+	if h.slots != nil {
+		h.slots <- struct{}{}
+		defer func() { <-h.slots }()
+	}
+	return argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+}
+
+// NeedsRehash reports whether encodedHash is a bcrypt hash, or an Argon2id
+// hash with parameters other than h's
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return true
+	}
+	params, _, _, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return false // not a hash Verify accepts, so there is never a password to rehash
+	}
+	return params != h.Params
+}
+
+// phcEncoding is the unpadded base64 the PHC string format uses
+var phcEncoding = base64.RawStdEncoding
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encodedHash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	fields := strings.Split(encodedHash, "$")
+	if len(fields) != 6 || fields[0] != "" || fields[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := phcEncoding.DecodeString(fields[4])
+	if err != nil {
+		return params, nil, nil, errors.New("invalid argon2id salt")
+	}
+	key, err := phcEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") || strings.HasPrefix(encodedHash, "$2b$") || strings.HasPrefix(encodedHash, "$2y$")
+}
@@ -0,0 +1,66 @@
+package entities
+
+import "strings"
+
+// commonPasswords are the most used passwords and password words, most common
+// first, from public breach statistics. A word's rank is how many guesses an
+// attacker needs to reach it.
+var commonPasswords = strings.Fields(`
+	123456 password 12345678 qwerty 123456789 12345 1234 111111 1234567 dragon
+	123123 baseball abc123 football monkey letmein 696969 shadow master 666666
+	qwertyuiop 123321 mustang 1234567890 michael 654321 superman 1qaz2wsx 7777777 121212
+	000000 qazwsx 123qwe killer trustno1 jordan jennifer zxcvbnm asdfgh hunter
+	buster soccer harley batman andrew tigger sunshine iloveyou 2000 charlie
+	robert thomas hockey ranger daniel starwars klaster 112233 george computer
+	michelle jessica pepper 1111 zxcvbn 555555 11111111 131313 freedom 777777
+	pass maggie 159753 aaaaaa ginger princess joshua cheese amanda summer
+	love ashley nicole chelsea biteme matthew access yankees 987654321 dallas
+	austin thunder taylor matrix welcome admin login secret hello test
+	guest changeme passw0rd qwerty123 password1 iloveu abcdef monkey1 liverpool letmein1
+	football1 princess1 666666 donald flower hottie loveme zaq1zaq1 password123 qwe123
+	mynoob 18atcskd2w 3rjs1la7qe google 1q2w3e4r 1q2w3e4r5t 1q2w3e 123abc q1w2e3r4
+	whatever dragon1 master1 shadow1 sunshine1 baseball1 superman1 batman1 trustno1 test123
+	admin123 root toor user default demo sample temp temp123 qwertyu
+	blink182 dolphin cookie orange purple yellow silver golden diamond angel
+	angels butterfly lovely babygirl family friends forever heaven jesus christ
+	blessed destiny hannah samantha lauren emily sophie olivia emma madison
+	james john david richard joseph william charles christopher anthony mark
+	steven paul kevin brian edward jason jeffrey ryan jacob gary
+	nicholas eric stephen jonathan larry justin scott brandon frank benjamin
+	gregory samuel raymond patrick alexander jack dennis jerry tyler aaron
+	mary patricia linda barbara elizabeth susan margaret dorothy lisa nancy
+	karen betty helen sandra donna carol ruth sharon laura sarah
+	kimberly deborah melissa stephanie rebecca anna virginia kathleen pamela martha
+	spring winter autumn january february march april may june july
+	august september october november december monday tuesday wednesday thursday friday
+	saturday sunday morning night yesterday today tomorrow weekend holiday christmas
+	secure security private personal internet online website server network system
+	blog blogger writer author reader post posts comment comments article
+	money banking bitcoin crypto wallet office company business work school
+	college student teacher doctor nurse police soldier pilot driver engineer
+	apple banana cherry lemon mango peach strawberry chocolate coffee pizza
+	tiger lion eagle falcon wolf bear shark panther cobra viper
+	phoenix spider rabbit horse kitten puppy doggy kitty bunny turtle
+	music guitar piano rocknroll metallica nirvana eminem beatles elvis madonna
+	soccer1 basketball tennis golf boxing racing hockey1 cricket rugby surfing
+	ferrari porsche mercedes corvette camaro harley1 yamaha honda toyota nissan
+	america canada london paris berlin texas california florida chicago boston
+	happy smile funny crazy magic lucky cool sweet sexy beautiful
+	hello123 welcome1 welcome123 admin1 letmein123 password12 password2 qwerty1 abc1234 iloveyou1
+	asdf asdfasdf asdfghjkl qazwsxedc 1qazxsw2 zaq12wsx 147258369 147258 159357 123654
+	mother father sister brother daughter grandma grandpa baby darling sweetheart
+	naruto pokemon starwars1 matrix1 gandalf frodo hogwarts harry potter batman123
+	minecraft fortnite roblox xbox playstation nintendo zelda mario gamer gaming
+`)
+
+var commonPasswordRanks = rankWords(commonPasswords)
+
+func rankWords(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, word := range words {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}
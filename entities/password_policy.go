@@ -0,0 +1,136 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password rules, as reported in violations
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRuleStrength     = "strength"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+)
+
+// PasswordPolicy is what a new password must meet on top of the 8 to
+// MaxPasswordLength characters every password needs
+type PasswordPolicy struct {
+	MinLength          int // in characters
+	RequireLowercase   bool
+	RequireUppercase   bool
+	RequireDigit       bool
+	RequireSymbol      bool
+	MinStrength        int  // EstimatePasswordStrength score from 0 to 4; 0 accepts any
+	RejectPersonalInfo bool // refuse passwords containing the username or email address
+}
+
+// DefaultPasswordPolicy returns the rules passwords always had, plus refusing
+// ones that contain the username or email address
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:          8,
+		RejectPersonalInfo: true,
+	}
+}
+
+// PasswordViolation is one rule a password broke
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a rejected password broke, so the
+// client can show them all at once
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Check returns the rules password breaks. personalInfo is the account's
+// username and email address, which the password must not contain.
+func (p PasswordPolicy) Check(password string, personalInfo ...string) []PasswordViolation {
+	var violations []PasswordViolation
+	add := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	minLength := max(p.MinLength, 8)
+	if utf8.RuneCountInString(password) < minLength {
+		add(PasswordRuleMinLength, fmt.Sprintf("password must be at least %d characters", minLength))
+	}
+	if len(password) > MaxPasswordLength {
+		add(PasswordRuleMaxLength, fmt.Sprintf("password cannot exceed %d characters", MaxPasswordLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		add(PasswordRuleLowercase, "password must contain a lowercase letter")
+	}
+	if p.RequireUppercase && !upper {
+		add(PasswordRuleUppercase, "password must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add(PasswordRuleDigit, "password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add(PasswordRuleSymbol, "password must contain a symbol")
+	}
+
+	if p.RejectPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		add(PasswordRulePersonalInfo, "password must not contain your username or email address")
+	}
+
+	if p.MinStrength > 0 {
+		strength := EstimatePasswordStrength(password, personalInfo...)
+		if strength.Score < p.MinStrength {
+			message := "password is too easy to guess"
+			if strength.Warning != "" {
+				message += ": " + strength.Warning
+			}
+			add(PasswordRuleStrength, message)
+		}
+	}
+	return violations
+}
+
+// containsPersonalInfo reports whether password contains any of the inputs,
+// or the part of an email address before the @, ignoring case. Inputs under
+// three characters are too likely to match by chance.
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, input := range personalInfo {
+		for _, word := range personalWords(input) {
+			if utf8.RuneCountInString(word) >= 3 && strings.Contains(password, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
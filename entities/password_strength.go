@@ -0,0 +1,557 @@
+package entities
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PasswordStrength estimates how many guesses an attacker who tries common
+// passwords, words and patterns first would need, the way Dropbox's zxcvbn
+// does. Score runs from 0 (too guessable) to 4 (very unguessable).
+type PasswordStrength struct {
+	Guesses float64
+	Score   int
+	Warning string // what makes the password guessable, if anything
+}
+
+// Only this many characters are analysed; anything longer is strong anyway
+// and the estimate's cost grows with the cube of the length
+const strengthInputLimit = 100
+
+const (
+	bruteforceCardinality = 10
+	minGuessesSingleChar  = 10
+	minGuessesMultiChar   = 50
+	minYearSpace          = 20
+)
+
+// Warnings, phrased to follow "password is too easy to guess: "
+const (
+	warningCommon   = "it is based on a common password or word"
+	warningPersonal = "it is based on your username or email address"
+	warningSequence = "it contains a sequence like abc or 123"
+	warningRepeat   = "it repeats characters or groups of characters"
+	warningKeyboard = "it contains a keyboard pattern like qwerty"
+	warningYear     = "it contains a year"
+	warningTooShort = "it is too short"
+)
+
+// Longer dictionary words than this aren't looked up
+const dictionaryMaxLen = 30
+
+// strengthMatch is a guessable pattern covering password[i..j]
+type strengthMatch struct {
+	i, j       int
+	guesses    float64
+	warning    string
+	bruteforce bool
+}
+
+// EstimatePasswordStrength scores password. userInputs, such as the
+// username and email address, count as the most common words of all.
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) > strengthInputLimit {
+		runes = runes[:strengthInputLimit]
+	}
+	if len(runes) == 0 {
+		return PasswordStrength{Guesses: 1, Score: 0, Warning: warningTooShort}
+	}
+
+	personal := make(map[string]int)
+	for _, input := range userInputs {
+		for _, word := range personalWords(input) {
+			if _, ok := personal[word]; !ok {
+				personal[word] = len(personal) + 1
+			}
+		}
+	}
+
+	estimator := &strengthEstimator{personal: personal, cache: make(map[string]float64)}
+	guesses, sequence := estimator.mostGuessable(runes)
+
+	strength := PasswordStrength{Guesses: guesses, Score: strengthScore(guesses)}
+	if strength.Score < 3 {
+		strength.Warning = strengthWarning(sequence, len(runes))
+	}
+	return strength
+}
+
+// personalWords splits an input like an email address into the parts an
+// attacker would try
+func personalWords(input string) []string {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return nil
+	}
+	words := []string{input}
+	if local, _, found := strings.Cut(input, "@"); found {
+		words = append(words, local)
+	}
+	return words
+}
+
+func strengthScore(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// strengthWarning names the longest pattern in the most guessable reading of
+// the password
+func strengthWarning(sequence []strengthMatch, length int) string {
+	var longest *strengthMatch
+	for k := range sequence {
+		m := &sequence[k]
+		if !m.bruteforce && (longest == nil || m.j-m.i > longest.j-longest.i) {
+			longest = m
+		}
+	}
+	if longest != nil {
+		return longest.warning
+	}
+	if length < 10 {
+		return warningTooShort
+	}
+	return ""
+}
+
+type strengthEstimator struct {
+	personal map[string]int
+	cache    map[string]float64 // guesses of repeated units
+}
+
+type strengthState struct {
+	match *strengthMatch
+	pi    float64 // product of the guesses of the matches so far
+	g     float64 // guesses of the whole sequence
+}
+
+// mostGuessable finds the sequence of non-overlapping matches covering
+// password that an attacker would guess soonest: l matches of guesses g1..gl
+// take l! * g1*...*gl guesses, plus 10000^(l-1) to favour fewer matches.
+// Gaps are filled by brute force.
+func (e *strengthEstimator) mostGuessable(password []rune) (float64, []strengthMatch) {
+	n := len(password)
+	matches := e.matches(password)
+	byEnd := make([][]*strengthMatch, n)
+	for k := range matches {
+		m := &matches[k]
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// optimal[k][l] is the best sequence of l matches covering password[0..k]
+	optimal := make([]map[int]strengthState, n)
+	for k := range optimal {
+		optimal[k] = make(map[int]strengthState)
+	}
+	update := func(m *strengthMatch, l int) {
+		guesses := m.guesses
+		minimum := 1.0
+		if m.j-m.i+1 < n {
+			minimum = minGuessesMultiChar
+			if m.i == m.j {
+				minimum = minGuessesSingleChar
+			}
+		}
+		guesses = math.Max(guesses, minimum)
+
+		pi := guesses
+		if l > 1 {
+			pi *= optimal[m.i-1][l-1].pi
+		}
+		g := factorial(l)*pi + math.Pow(10000, float64(l-1))
+		for other, state := range optimal[m.j] {
+			if other <= l && state.g <= g {
+				return
+			}
+		}
+		optimal[m.j][l] = strengthState{match: m, pi: pi, g: g}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i == 0 {
+				update(m, 1)
+				continue
+			}
+			for l := range optimal[m.i-1] {
+				update(m, l+1)
+			}
+		}
+
+		// Brute force the characters up to k, but never right after another
+		// brute-forced stretch
+		update(bruteforceMatch(0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := bruteforceMatch(i, k)
+			for l, state := range optimal[i-1] {
+				if !state.match.bruteforce {
+					update(m, l+1)
+				}
+			}
+		}
+	}
+
+	best, bestG := 0, math.Inf(1)
+	for l, state := range optimal[n-1] {
+		if state.g < bestG || (state.g == bestG && l < best) {
+			best, bestG = l, state.g
+		}
+	}
+
+	sequence := make([]strengthMatch, best)
+	for k, l := n-1, best; l > 0; l-- {
+		m := optimal[k][l].match
+		sequence[l-1] = *m
+		k = m.i - 1
+	}
+	return bestG, sequence
+}
+
+func bruteforceMatch(i, j int) *strengthMatch {
+	return &strengthMatch{i: i, j: j, guesses: math.Pow(bruteforceCardinality, float64(j-i+1)), bruteforce: true}
+}
+
+func (e *strengthEstimator) matches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	matches = append(matches, e.dictionaryMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, e.repeatMatches(password)...)
+	matches = append(matches, keyboardMatches(password)...)
+	matches = append(matches, yearMatches(password)...)
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].i != matches[b].i {
+			return matches[a].i < matches[b].i
+		}
+		return matches[a].j < matches[b].j
+	})
+	return matches
+}
+
+// dictionaryMatches finds common passwords, words and user inputs, also
+// when reversed or written with l33t substitutions like p@ssw0rd
+func (e *strengthEstimator) dictionaryMatches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	lower := make([]rune, len(password))
+	for k, r := range password {
+		lower[k] = unicode.ToLower(r)
+	}
+	for i := range lower {
+		for j := i + 2; j < len(lower) && j-i < dictionaryMaxLen; j++ {
+			token := password[i : j+1]
+			word := string(lower[i : j+1])
+			variations := uppercaseVariations(token)
+
+			if rank, warning, ok := e.lookup(word); ok {
+				matches = append(matches, strengthMatch{i: i, j: j, guesses: float64(rank) * variations, warning: warning})
+			}
+			if reversed := reverseString(word); reversed != word {
+				if rank, warning, ok := e.lookup(reversed); ok {
+					matches = append(matches, strengthMatch{i: i, j: j, guesses: float64(rank) * variations * 2, warning: warning})
+				}
+			}
+			if unleeted, substitutions := unleet(word); substitutions > 0 {
+				if rank, warning, ok := e.lookup(unleeted); ok {
+					matches = append(matches, strengthMatch{i: i, j: j, guesses: float64(rank) * variations * float64(1+substitutions), warning: warning})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func (e *strengthEstimator) lookup(word string) (int, string, bool) {
+	if rank, ok := e.personal[word]; ok {
+		return rank, warningPersonal, true
+	}
+	if rank, ok := commonPasswordRanks[word]; ok {
+		return rank, warningCommon, true
+	}
+	return 0, "", false
+}
+
+// uppercaseVariations is how many ways of capitalising token an attacker
+// tries before this one: none for lowercase, few for Capitalised or ALL CAPS
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+	for k := 1; k <= upper && k <= lower; k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+var leetTable = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+func unleet(word string) (string, int) {
+	substitutions := 0
+	unleeted := []rune(word)
+	for k, r := range unleeted {
+		if plain, ok := leetTable[r]; ok {
+			unleeted[k] = plain
+			substitutions++
+		}
+	}
+	return string(unleeted), substitutions
+}
+
+// sequenceMatches finds runs like abcd, 4321 or XYZ
+func sequenceMatches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+2 < len(password); {
+		delta := password[i+1] - password[i]
+		if delta != 1 && delta != -1 {
+			i++
+			continue
+		}
+		j := i + 1
+		for j+1 < len(password) && password[j+1]-password[j] == delta {
+			j++
+		}
+		if j-i >= 2 {
+			guesses := 26.0
+			switch first := password[i]; {
+			case strings.ContainsRune("aAzZ019", first):
+				guesses = 4
+			case unicode.IsDigit(first):
+				guesses = 10
+			}
+			if delta < 0 {
+				guesses *= 2
+			}
+			matches = append(matches, strengthMatch{i: i, j: j, guesses: guesses * float64(j-i+1), warning: warningSequence})
+		}
+		i = j
+	}
+	return matches
+}
+
+// repeatMatches finds a character or group repeated back to back, like aaaa
+// or abcabc. It costs as many guesses as the group, times the repeats.
+func (e *strengthEstimator) repeatMatches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := range password {
+		for size := 1; i+2*size <= len(password); size++ {
+			unit := password[i : i+size]
+			count := 1
+			for end := i + size; end+size <= len(password) && string(password[end:end+size]) == string(unit); end += size {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			guesses := e.unitGuesses(unit) * float64(count)
+			matches = append(matches, strengthMatch{i: i, j: i + size*count - 1, guesses: guesses, warning: warningRepeat})
+		}
+	}
+	return matches
+}
+
+func (e *strengthEstimator) unitGuesses(unit []rune) float64 {
+	key := string(unit)
+	if guesses, ok := e.cache[key]; ok {
+		return guesses
+	}
+	guesses, _ := e.mostGuessable(unit)
+	e.cache[key] = guesses
+	return guesses
+}
+
+// keyboardRows lay out a US QWERTY keyboard, unshifted and shifted. Each row
+// starts half a key further right than the one above.
+var keyboardRows = [][2]string{
+	{"`1234567890-=", "~!@#$%^&*()_+"},
+	{" qwertyuiop[]\\", " QWERTYUIOP{}|"},
+	{" asdfghjkl;'", " ASDFGHJKL:\""},
+	{" zxcvbnm,./", " ZXCVBNM<>?"},
+}
+
+type keyPosition struct {
+	x, y    int
+	shifted bool
+}
+
+var (
+	keyboardKeys          = make(map[rune]keyPosition)
+	keyboardAverageDegree float64
+)
+
+func init() {
+	for y, row := range keyboardRows {
+		for shifted, keys := range row {
+			for x, r := range keys {
+				if r != ' ' {
+					keyboardKeys[r] = keyPosition{x: x, y: y, shifted: shifted == 1}
+				}
+			}
+		}
+	}
+
+	degrees := 0
+	for _, from := range keyboardKeys {
+		for _, to := range keyboardKeys {
+			if !to.shifted && keyDirection(from, to) >= 0 {
+				degrees++
+			}
+		}
+	}
+	keyboardAverageDegree = float64(degrees) / float64(len(keyboardKeys))
+}
+
+// keyDirection returns which of the six neighbouring keys to is of from, or
+// -1 if they aren't neighbours
+func keyDirection(from, to keyPosition) int {
+	dx, dy := to.x-from.x, to.y-from.y
+	switch {
+	case dy == 0 && dx == -1:
+		return 0
+	case dy == 0 && dx == 1:
+		return 1
+	case dy == -1 && dx == 0:
+		return 2
+	case dy == -1 && dx == 1:
+		return 3
+	case dy == 1 && dx == -1:
+		return 4
+	case dy == 1 && dx == 0:
+		return 5
+	default:
+		return -1
+	}
+}
+
+// keyboardMatches finds runs of neighbouring keys, like qwerty or 1qaz
+func keyboardMatches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+2 < len(password); {
+		turns, shifted, direction := 0, 0, -1
+		if keyboardKeys[password[i]].shifted {
+			shifted++
+		}
+		j := i
+		for j+1 < len(password) {
+			from, ok := keyboardKeys[password[j]]
+			to, ok2 := keyboardKeys[password[j+1]]
+			if !ok || !ok2 {
+				break
+			}
+			next := keyDirection(from, to)
+			if next < 0 {
+				break
+			}
+			if next != direction {
+				turns++
+				direction = next
+			}
+			if to.shifted {
+				shifted++
+			}
+			j++
+		}
+		if j-i >= 2 {
+			matches = append(matches, strengthMatch{i: i, j: j, guesses: keyboardGuesses(j-i+1, turns, shifted), warning: warningKeyboard})
+			i = j
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func keyboardGuesses(length, turns, shifted int) float64 {
+	starts := float64(len(keyboardKeys))
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= turns && j <= i-1; j++ {
+			guesses += binomial(i-1, j-1) * starts * math.Pow(keyboardAverageDegree, float64(j))
+		}
+	}
+
+	if unshifted := length - shifted; shifted > 0 {
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for k := 1; k <= shifted && k <= unshifted; k++ {
+				variations += binomial(length, k)
+			}
+			guesses *= variations
+		}
+	}
+	return guesses
+}
+
+// yearMatches finds recent years, which people add to make a password
+// "stronger"
+func yearMatches(password []rune) []strengthMatch {
+	var matches []strengthMatch
+	reference := time.Now().Year()
+	for i := 0; i+4 <= len(password); i++ {
+		year, err := strconv.Atoi(string(password[i : i+4]))
+		if err != nil || year < 1900 || year > 2099 || !unicode.IsDigit(password[i]) {
+			continue
+		}
+		space := math.Max(math.Abs(float64(year-reference)), minYearSpace)
+		matches = append(matches, strengthMatch{i: i, j: i + 3, guesses: space, warning: warningYear})
+	}
+	return matches
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+// RefreshToken is an opaque, single-use credential exchanged for a new access
+// token. Only a hash of the token is stored. Tokens rotated from the same
+// login share a FamilyID, so replaying an already-used token can revoke the
+// whole chain.
+type RefreshToken struct {
+	ID         string
+	UserID     string
+	FamilyID   string
+	TokenHash  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	RotatedAt  *time.Time // set once the token has been exchanged
+	ReplacedBy string     // ID of the token issued in exchange
+	RevokedAt  *time.Time
+}
+
+// IsExpired reports whether the token can no longer be used at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsRotated reports whether the token was already exchanged
+func (t *RefreshToken) IsRotated() bool {
+	return t.RotatedAt != nil
+}
+
+// IsRevoked reports whether the token or its family was revoked
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
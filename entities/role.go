@@ -0,0 +1,164 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Permission is a single action a role may grant
+type Permission string
+
+const (
+	PermissionPostPublish     Permission = "post:publish"     // create blog posts
+	PermissionPostEditAny     Permission = "post:edit:any"    // edit posts by other authors
+	PermissionPostDeleteAny   Permission = "post:delete:any"  // delete posts by other authors
+	PermissionCommentModerate Permission = "comment:moderate" // delete comments by other users
+	PermissionUserManage      Permission = "user:manage"      // admin area: users, roles, sessions
+)
+
+var allPermissions = []Permission{
+	PermissionPostPublish,
+	PermissionPostEditAny,
+	PermissionPostDeleteAny,
+	PermissionCommentModerate,
+	PermissionUserManage,
+}
+
+// AllPermissions returns every known permission
+func AllPermissions() []Permission {
+	return append([]Permission(nil), allPermissions...)
+}
+
+// ValidatePermission checks that a permission is known
+func ValidatePermission(permission Permission) error {
+	for _, p := range allPermissions {
+		if p == permission {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown permission: %s", permission)
+}
+
+// Role groups the permissions granted to the users assigned to it. Built-in
+// roles are defined in code; custom roles are defined by admins and stored.
+type Role struct {
+	Name        UserRole
+	Description string
+	Permissions []Permission
+	BuiltIn     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+var builtInRoles = map[UserRole]*Role{
+	RoleUser: {
+		Name:        RoleUser,
+		BuiltIn:     true,
+		Description: "Writes posts and comments",
+		Permissions: []Permission{PermissionPostPublish},
+	},
+	RoleEditor: {
+		Name:        RoleEditor,
+		BuiltIn:     true,
+		Description: "Edits and removes any blog post",
+		Permissions: []Permission{PermissionPostPublish, PermissionPostEditAny, PermissionPostDeleteAny},
+	},
+	RoleModerator: {
+		Name:        RoleModerator,
+		BuiltIn:     true,
+		Description: "Moderates posts and comments",
+		Permissions: []Permission{PermissionPostPublish, PermissionPostEditAny, PermissionPostDeleteAny, PermissionCommentModerate},
+	},
+	RoleAdmin: {
+		Name:        RoleAdmin,
+		BuiltIn:     true,
+		Description: "Full access",
+		Permissions: allPermissions,
+	},
+}
+
+// BuiltInRole returns the built-in role with the given name, or nil
+func BuiltInRole(name UserRole) *Role {
+	role, ok := builtInRoles[name]
+	if !ok {
+		return nil
+	}
+	return role.copy()
+}
+
+// BuiltInRoles returns every built-in role
+func BuiltInRoles() []*Role {
+	roles := make([]*Role, 0, len(builtInRoles))
+	for _, name := range []UserRole{RoleUser, RoleEditor, RoleModerator, RoleAdmin} {
+		roles = append(roles, BuiltInRole(name))
+	}
+	return roles
+}
+
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,29}$`)
+
+// NewRole creates a custom role with validation
+func NewRole(name, description string, permissions []Permission) (*Role, error) {
+	if err := ValidateRole(name); err != nil {
+		return nil, err
+	}
+	if BuiltInRole(UserRole(name)) != nil {
+		return nil, errors.New("cannot redefine a built-in role")
+	}
+
+	now := time.Now()
+	role := &Role{
+		Name:      UserRole(name),
+		CreatedAt: now,
+	}
+	if err := role.Update(description, permissions); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// Update replaces the description and permissions of a custom role
+func (r *Role) Update(description string, permissions []Permission) error {
+	if r.BuiltIn {
+		return errors.New("built-in roles cannot be changed")
+	}
+	if len(description) > 200 {
+		return errors.New("description cannot exceed 200 characters")
+	}
+
+	seen := make(map[Permission]bool, len(permissions))
+	unique := make([]Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if err := ValidatePermission(permission); err != nil {
+			return err
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			unique = append(unique, permission)
+		}
+	}
+
+	r.Description = strings.TrimSpace(description)
+	r.Permissions = unique
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// HasPermission reports whether the role grants a permission
+func (r *Role) HasPermission(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Role) copy() *Role {
+	roleCopy := *r
+	roleCopy.Permissions = append([]Permission(nil), r.Permissions...)
+	return &roleCopy
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Session is one signed-in device or browser. It starts at login and is kept
+// alive by refreshing; the session ID doubles as the refresh token family ID.
+type Session struct {
+	ID                   string
+	UserID               string
+	UserAgent            string
+	IP                   string
+	CreatedAt            time.Time
+	LastSeenAt           time.Time
+	ExpiresAt            time.Time
+	AccessTokenID        string    // latest access token issued for the session
+	AccessTokenExpiresAt time.Time // so revoking the session can revoke that token too
+	RevokedAt            *time.Time
+}
+
+// IsActive reports whether the session can still be used at the given time
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
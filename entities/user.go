@@ -2,19 +2,22 @@ package entities
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // UserRole represents the role of a user in the system
 type UserRole string
 
+// Built-in roles; see role.go for their permissions. Admins may define
+// custom roles as well.
 const (
-	RoleUser  UserRole = "user"
-	RoleAdmin UserRole = "admin"
+	RoleUser      UserRole = "user"
+	RoleEditor    UserRole = "editor"
+	RoleModerator UserRole = "moderator"
+	RoleAdmin     UserRole = "admin"
 )
 
 type User struct {
@@ -28,20 +31,26 @@ type User struct {
 	Role         UserRole
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	// EmailVerifiedAt is when the user proved they own Email; nil until then
+	EmailVerifiedAt *time.Time
 }
 
+// MaxPasswordLength bounds the work a single login can cause. Argon2id takes
+// passwords of any length, unlike bcrypt's 72 bytes.
+const MaxPasswordLength = 256
+
 var (
 	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{3,30}$`)
 )
 
 // NewUser creates a new user with validation and password hashing
-func NewUser(username, email, password, fullName string) (*User, error) {
+func NewUser(username, email, password, fullName string, hasher PasswordHasher) (*User, error) {
 	if err := validateUserData(username, email, password); err != nil {
 		return nil, err
 	}
 
-	passwordHash, err := hashPassword(password)
+	passwordHash, err := hasher.Hash(password)
 	if err != nil {
 		return nil, errors.New("failed to hash password")
 	}
@@ -75,14 +84,31 @@ func (u *User) Update(fullName, bio, avatarURL string) error {
 }
 
 // VerifyPassword checks if the provided password matches the user's password
-func (u *User) VerifyPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
-	return err == nil
+func (u *User) VerifyPassword(hasher PasswordHasher, password string) bool {
+	return hasher.Verify(password, u.PasswordHash)
+}
+
+// RehashPassword replaces a hash made with outdated parameters, given the
+// password it was just verified against. It reports whether the hash
+// changed. The password isn't validated again: a password that met the rules
+// when it was set stays usable.
+func (u *User) RehashPassword(hasher PasswordHasher, password string) (bool, error) {
+	if !hasher.NeedsRehash(u.PasswordHash) {
+		return false, nil
+	}
+
+	passwordHash, err := hasher.Hash(password)
+	if err != nil {
+		return false, errors.New("failed to hash password")
+	}
+
+	u.PasswordHash = passwordHash
+	return true, nil
 }
 
 // ChangePassword updates the user's password
-func (u *User) ChangePassword(oldPassword, newPassword string) error {
-	if !u.VerifyPassword(oldPassword) {
+func (u *User) ChangePassword(hasher PasswordHasher, oldPassword, newPassword string) error {
+	if !u.VerifyPassword(hasher, oldPassword) {
 		return errors.New("old password is incorrect")
 	}
 
@@ -90,7 +116,7 @@ func (u *User) ChangePassword(oldPassword, newPassword string) error {
 		return err
 	}
 
-	passwordHash, err := hashPassword(newPassword)
+	passwordHash, err := hasher.Hash(newPassword)
 	if err != nil {
 		return errors.New("failed to hash new password")
 	}
@@ -100,18 +126,51 @@ func (u *User) ChangePassword(oldPassword, newPassword string) error {
 	return nil
 }
 
+// ResetPassword sets a new password without checking the old one. The caller
+// must have authenticated the user, with a link sent to the account's email
+// address or by verifying the old password itself.
+func (u *User) ResetPassword(hasher PasswordHasher, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := hasher.Hash(newPassword)
+	if err != nil {
+		return errors.New("failed to hash new password")
+	}
+
+	u.PasswordHash = passwordHash
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsEmailVerified reports whether the user proved they own their email
+// address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// MarkEmailVerified records that the user proved they own their email address
+func (u *User) MarkEmailVerified(now time.Time) {
+	if u.EmailVerifiedAt == nil {
+		u.EmailVerifiedAt = &now
+		u.UpdatedAt = now
+	}
+}
+
 // Sanitize removes sensitive information before returning to client
 func (u *User) Sanitize() *User {
 	return &User{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		FullName:  u.FullName,
-		Bio:       u.Bio,
-		AvatarURL: u.AvatarURL,
-		Role:      u.Role,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:              u.ID,
+		Username:        u.Username,
+		Email:           u.Email,
+		FullName:        u.FullName,
+		Bio:             u.Bio,
+		AvatarURL:       u.AvatarURL,
+		Role:            u.Role,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		EmailVerifiedAt: u.EmailVerifiedAt,
 		// PasswordHash is intentionally omitted
 	}
 }
@@ -126,21 +185,21 @@ func (u *User) IsUser() bool {
 	return u.Role == RoleUser
 }
 
-// SetRole sets the user's role (should only be called by admins)
+// SetRole sets the user's role (should only be called by admins). Whether a
+// custom role exists is checked by the caller.
 func (u *User) SetRole(role UserRole) error {
-	if role != RoleUser && role != RoleAdmin {
-		return errors.New("invalid role: must be 'user' or 'admin'")
+	if err := ValidateRole(string(role)); err != nil {
+		return err
 	}
 	u.Role = role
 	u.UpdatedAt = time.Now()
 	return nil
 }
 
-// ValidateRole checks if a role string is valid
+// ValidateRole checks if a role string is a well-formed role name
 func ValidateRole(role string) error {
-	r := UserRole(role)
-	if r != RoleUser && r != RoleAdmin {
-		return errors.New("invalid role: must be 'user' or 'admin'")
+	if !roleNameRegex.MatchString(role) {
+		return errors.New("invalid role: must be 2-30 lowercase letters, numbers, '_' or '-', starting with a letter")
 	}
 	return nil
 }
@@ -192,13 +251,8 @@ func validatePassword(password string) error {
 	if len(password) < 8 {
 		return errors.New("password must be at least 8 characters")
 	}
-	if len(password) > 72 {
-		return errors.New("password cannot exceed 72 characters")
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password cannot exceed %d characters", MaxPasswordLength)
 	}
 	return nil
 }
-
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-}
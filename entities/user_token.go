@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// What a UserToken may be used for
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeIdentityLink      = "identity_link"
+	TokenPurposeLoginCode         = "login_code"
+)
+
+// UserToken is a single-use token that ties a later request to a user: it is
+// mailed to them to prove they own their email address, carried through a
+// provider's sign-in to link that account, or handed to the frontend after a
+// provider's sign-in to exchange for tokens. Only its hash is stored.
+type UserToken struct {
+	ID        string
+	UserID    string
+	Purpose   string
+	Email     string // the user's address when the token was issued
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *UserToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// Restriction is something users may be barred from until they verify their
+// email address
+type Restriction string
+
+const (
+	RestrictionPost    Restriction = "post"    // publish blog posts
+	RestrictionComment Restriction = "comment" // write comments
+)
+
+// ValidateRestriction checks that a restriction is known
+func ValidateRestriction(restriction Restriction) error {
+	switch restriction {
+	case RestrictionPost, RestrictionComment:
+		return nil
+	}
+	return fmt.Errorf("unknown restriction: %s", restriction)
+}
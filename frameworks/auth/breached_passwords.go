@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxBreachedLine bounds a line of the corpus: a 40-digit hash, a colon and
+// a count
+const maxBreachedLine = 64
+
+// BreachedPasswordFile looks up breached passwords in a local copy of the
+// Pwned Passwords corpus, so no password hash ever leaves the server. The
+// file has one "SHA1:COUNT" line per password with the hashes in uppercase
+// hex, sorted, as downloaded with haveibeenpwned-downloader. It is searched
+// on disk, so its size doesn't matter.
+type BreachedPasswordFile struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreachedPasswordFile opens the corpus at path and checks its first line
+func OpenBreachedPasswordFile(path string) (*BreachedPasswordFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	f := &BreachedPasswordFile{file: file, size: info.Size()}
+	_, hash, err := f.lineAt(0)
+	if err != nil || len(hash) != 40 || !isHex(hash) {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a file of SHA1:COUNT lines", path)
+	}
+	return f, nil
+}
+
+// Range returns the last 35 hex digits and counts of every breached hash
+// starting with the five-digit prefix
+func (f *BreachedPasswordFile) Range(prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+	if len(prefix) != 5 || !isHex(prefix) {
+		return nil, errors.New("prefix must be 5 hex digits")
+	}
+
+	// Find the first line whose hash sorts at or after the prefix
+	low, high := int64(0), f.size
+	for low < high {
+		middle := low + (high-low)/2
+		_, hash, err := f.lineAt(middle)
+		if err != nil {
+			return nil, err
+		}
+		if hash == "" || hash >= prefix {
+			high = middle
+		} else {
+			low = middle + 1
+		}
+	}
+	start, _, err := f.lineAt(low)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(io.NewSectionReader(f.file, start, f.size-start))
+	for scanner.Scan() {
+		hash, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		hash = strings.ToUpper(hash)
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			n = 1
+		}
+		suffixes[hash[5:]] = n
+	}
+	return suffixes, scanner.Err()
+}
+
+func (f *BreachedPasswordFile) Close() error {
+	return f.file.Close()
+}
+
+// lineAt finds the first line starting at or after offset, returning where
+// it starts and its hash, or "" past the last line
+func (f *BreachedPasswordFile) lineAt(offset int64) (int64, string, error) {
+	from := offset
+	if offset > 0 {
+		from-- // a line starting right at offset follows the newline before it
+	}
+	buf := make([]byte, 2*maxBreachedLine)
+	n, err := f.file.ReadAt(buf, from)
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	chunk := buf[:n]
+
+	start := from
+	if offset > 0 {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			if n == len(buf) {
+				return 0, "", errors.New("breached password file has an overlong line")
+			}
+			return f.size, "", nil
+		}
+		chunk = chunk[i+1:]
+		start = from + int64(i) + 1
+	}
+
+	line, _, _ := bytes.Cut(chunk, []byte("\n"))
+	hash, _, _ := bytes.Cut(bytes.TrimSpace(line), []byte(":"))
+	return start, strings.ToUpper(string(hash)), nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,55 +2,187 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// JWTManager signs access tokens with the active asymmetric key, or with the
+// HS256 secret when no asymmetric key is configured. Tokens name their
+// signing key in the kid header, so keys can be rotated without invalidating
+// tokens that are still in flight.
 type JWTManager struct {
 	secretKey     string
+	acceptHS256   bool
 	tokenDuration time.Duration
+	keys          []*SigningKey
+}
+
+// JWTConfig configures a JWTManager
+type JWTConfig struct {
+	Secret        string        // HS256 secret
+	AcceptHS256   bool          // keep accepting HS256 tokens, e.g. while migrating to asymmetric keys
+	TokenDuration time.Duration // access token lifetime
+	Keys          []*SigningKey // asymmetric keys and their rotation schedule
 }
 
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Generation int    `json:"gen"` // user's token generation when issued
+	SessionID  string `json:"sid,omitempty"`
+	Role       string `json:"role,omitempty"` // only current while Generation is
+	// A token issued to a third-party app names it and what it may do
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"` // space-separated
 	jwt.RegisteredClaims
 }
 
+// Subject describes the user a token is issued for
+type Subject struct {
+	UserID     string
+	Username   string
+	Email      string
+	Generation int
+	SessionID  string
+	Role       string
+	ClientID   string
+	Scope      string
+}
+
+// NewJWTManager creates a manager that signs and verifies with an HS256 secret
 func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
-	return &JWTManager{
-		secretKey:     secretKey,
-		tokenDuration: tokenDuration,
+	manager, _ := NewJWTManagerWithConfig(JWTConfig{
+		Secret:        secretKey,
+		AcceptHS256:   true,
+		TokenDuration: tokenDuration,
+	})
+	return manager
+}
+
+// NewJWTManagerWithConfig creates a manager with asymmetric signing keys
+func NewJWTManagerWithConfig(config JWTConfig) (*JWTManager, error) {
+	seen := make(map[string]bool, len(config.Keys))
+	for _, key := range config.Keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		seen[key.ID] = true
 	}
+	if len(config.Keys) == 0 && config.Secret == "" {
+		return nil, errors.New("a JWT secret or signing key is required")
+	}
+
+	return &JWTManager{
+		secretKey:     config.Secret,
+		acceptHS256:   config.AcceptHS256,
+		tokenDuration: config.TokenDuration,
+		keys:          config.Keys,
+	}, nil
 }
 
-// GenerateToken creates a new JWT token for a user
-func (m *JWTManager) GenerateToken(userID, username, email string) (string, error) {
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
+// GenerateToken creates a new JWT token for a user. Each token gets a unique
+// ID (jti) so it can be revoked individually.
+func (m *JWTManager) GenerateToken(subject Subject) (string, error) {
+	token, _, err := m.IssueToken(subject)
+	return token, err
+}
+
+// IssueToken creates a new JWT token and also returns its claims, so callers
+// can record the token ID and expiry
+func (m *JWTManager) IssueToken(subject Subject) (string, *Claims, error) {
+	claims := &Claims{
+		UserID:     subject.UserID,
+		Username:   subject.Username,
+		Email:      subject.Email,
+		Generation: subject.Generation,
+		SessionID:  subject.SessionID,
+		Role:       subject.Role,
+		ClientID:   subject.ClientID,
+		Scope:      subject.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	var signed string
+	var err error
+	if key := m.signingKey(time.Now()); key != nil {
+		token := jwt.NewWithClaims(key.method(), claims)
+		token.Header["kid"] = key.ID
+		signed, err = token.SignedString(key.private)
+	} else if len(m.keys) == 0 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signed, err = token.SignedString([]byte(m.secretKey))
+	} else {
+		err = errors.New("no active signing key")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// signingKey returns the most recently activated key scheduled to sign at now
+func (m *JWTManager) signingKey(now time.Time) *SigningKey {
+	var active *SigningKey
+	for _, key := range m.keys {
+		if key.signsAt(now) && (active == nil || key.ActivateAt.After(active.ActivateAt)) {
+			active = key
+		}
+	}
+	return active
+}
+
+// verificationKey resolves the key a token must be verified with
+func (m *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if !m.acceptHS256 || m.secretKey == "" || token.Method != jwt.SigningMethodHS256 {
 			return nil, errors.New("unexpected signing method")
 		}
 		return []byte(m.secretKey), nil
-	})
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	for _, key := range m.keys {
+		if key.ID != kid {
+			continue
+		}
+		if key.Algorithm != token.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		if !key.verifiesAt(now, m.tokenDuration) {
+			return nil, errors.New("signing key has been retired")
+		}
+		return key.public, nil
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// JWKS returns the public keys that may have signed a currently valid token.
+// Scheduled keys are published before they activate so verifiers can cache
+// them ahead of the rotation.
+func (m *JWTManager) JWKS() JWKS {
+	now := time.Now()
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if key.verifiesAt(now, m.tokenDuration) {
+			jwks.Keys = append(jwks.Keys, key.jwk())
+		}
+	}
+	return jwks
+}
+
+// ValidateToken validates a JWT token and returns the claims
+func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -63,15 +195,3 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
-
-// RefreshToken generates a new token with extended expiration
-func (m *JWTManager) RefreshToken(tokenString string) (string, error) {
-	claims, err := m.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Generate new token with same user info
-	return m.GenerateToken(claims.UserID, claims.Username, claims.Email)
-}
-
@@ -3,87 +3,166 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
-	"golang.org/x/oauth2/google"
 )
 
+// ErrNoVerifiedEmail is returned for provider accounts without a verified
+// primary email address; they can't sign in
+var ErrNoVerifiedEmail = errors.New("the account has no verified primary email address")
+
 // OAuth2Provider represents an OAuth2 provider configuration
 type OAuth2Provider struct {
-	Config *oauth2.Config
-	Name   string
+	Config    *oauth2.Config
+	Name      string
+	Endpoints OAuth2Endpoints
+}
+
+// OAuth2Endpoints are the URLs of a provider. Tests point them at a local
+// stand-in for the provider.
+type OAuth2Endpoints struct {
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	EmailsURL   string // GitHub only: the user's addresses and whether they are verified
+}
+
+// GoogleEndpoints are Google's production URLs
+var GoogleEndpoints = OAuth2Endpoints{
+	AuthURL:     "https://accounts.google.com/o/oauth2/auth",
+	TokenURL:    "https://oauth2.googleapis.com/token",
+	UserInfoURL: "https://www.googleapis.com/oauth2/v2/userinfo",
+}
+
+// GitHubEndpoints are GitHub's production URLs
+var GitHubEndpoints = OAuth2Endpoints{
+	AuthURL:     "https://github.com/login/oauth/authorize",
+	TokenURL:    "https://github.com/login/oauth/access_token",
+	UserInfoURL: "https://api.github.com/user",
+	EmailsURL:   "https://api.github.com/user/emails",
 }
 
 // UserInfo represents the user information returned by OAuth2 providers
 type UserInfo struct {
-	Email      string
-	Name       string
-	AvatarURL  string
-	Provider   string
-	ProviderID string
+	Email         string
+	EmailVerified bool // the provider vouches that the user owns Email
+	Name          string
+	AvatarURL     string
+	Provider      string
+	ProviderID    string
 }
 
 // NewGoogleOAuth2Provider creates a new Google OAuth2 provider
 func NewGoogleOAuth2Provider(clientID, clientSecret, redirectURL string) *OAuth2Provider {
-	return &OAuth2Provider{
-		Name: "google",
-		Config: &oauth2.Config{
-			ClientID:     clientID,
-			ClientSecret: clientSecret,
-			RedirectURL:  redirectURL,
-			Scopes: []string{
-				"https://www.googleapis.com/auth/userinfo.email",
-				"https://www.googleapis.com/auth/userinfo.profile",
-			},
-			Endpoint: google.Endpoint,
-		},
-	}
+	return NewGoogleOAuth2ProviderWithEndpoints(clientID, clientSecret, redirectURL, GoogleEndpoints)
+}
+
+// NewGoogleOAuth2ProviderWithEndpoints creates a Google OAuth2 provider that
+// talks to the given URLs
+func NewGoogleOAuth2ProviderWithEndpoints(clientID, clientSecret, redirectURL string, endpoints OAuth2Endpoints) *OAuth2Provider {
+	return newOAuth2Provider("google", clientID, clientSecret, redirectURL, endpoints, []string{
+		"https://www.googleapis.com/auth/userinfo.email",
+		"https://www.googleapis.com/auth/userinfo.profile",
+	})
 }
 
 // NewGitHubOAuth2Provider creates a new GitHub OAuth2 provider
 func NewGitHubOAuth2Provider(clientID, clientSecret, redirectURL string) *OAuth2Provider {
+	return NewGitHubOAuth2ProviderWithEndpoints(clientID, clientSecret, redirectURL, GitHubEndpoints)
+}
+
+// NewGitHubOAuth2ProviderWithEndpoints creates a GitHub OAuth2 provider that
+// talks to the given URLs
+func NewGitHubOAuth2ProviderWithEndpoints(clientID, clientSecret, redirectURL string, endpoints OAuth2Endpoints) *OAuth2Provider {
+	return newOAuth2Provider("github", clientID, clientSecret, redirectURL, endpoints, []string{"user:email", "read:user"})
+}
+
+func newOAuth2Provider(name, clientID, clientSecret, redirectURL string, endpoints OAuth2Endpoints, scopes []string) *OAuth2Provider {
 	return &OAuth2Provider{
-		Name: "github",
+		Name:      name,
+		Endpoints: endpoints,
 		Config: &oauth2.Config{
 			ClientID:     clientID,
 			ClientSecret: clientSecret,
 			RedirectURL:  redirectURL,
-			Scopes:       []string{"user:email", "read:user"},
-			Endpoint:     github.Endpoint,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  endpoints.AuthURL,
+				TokenURL: endpoints.TokenURL,
+			},
 		},
 	}
 }
 
-// GetAuthURL returns the OAuth2 authorization URL
-func (p *OAuth2Provider) GetAuthURL(state string) string {
-	return p.Config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+// GetAuthURL returns the OAuth2 authorization URL, with the PKCE challenge
+// for verifier (RFC 7636, S256)
+func (p *OAuth2Provider) GetAuthURL(state, verifier string) string {
+	return p.Config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 }
 
-// ExchangeCode exchanges an authorization code for a token
-func (p *OAuth2Provider) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	return p.Config.Exchange(ctx, code)
+// ExchangeCode exchanges an authorization code for a token, proving with the
+// PKCE verifier that it was issued to the flow GetAuthURL started
+func (p *OAuth2Provider) ExchangeCode(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	return p.Config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+}
+
+// AuthURL returns the consent screen URL for a sign-in flow
+func (p *OAuth2Provider) AuthURL(ctx context.Context, flow *AuthFlow) (string, error) {
+	return p.GetAuthURL(flow.State, flow.CodeVerifier), nil
+}
+
+// Authenticate exchanges the callback's code and fetches the user's profile
+func (p *OAuth2Provider) Authenticate(ctx context.Context, code string, flow *AuthFlow) (*UserInfo, error) {
+	token, err := p.ExchangeCode(ctx, code, flow.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	return p.GetUserInfo(ctx, token)
 }
 
 // GetUserInfo retrieves user information from the OAuth2 provider
 func (p *OAuth2Provider) GetUserInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error) {
+	if p.Name != "google" && p.Name != "github" {
+		return nil, fmt.Errorf("unsupported provider: %s", p.Name)
+	}
 	client := p.Config.Client(ctx, token)
 
-	var url string
-	switch p.Name {
-	case "google":
-		url = "https://www.googleapis.com/oauth2/v2/userinfo"
-	case "github":
-		url = "https://api.github.com/user"
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", p.Name)
+	body, err := fetch(client, p.Endpoints.UserInfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	userInfo, err := p.parseUserInfo(body)
+	if err != nil {
+		return nil, err
 	}
 
+	// The public profile email is optional and says nothing about
+	// verification, so GitHub addresses come from the emails API
+	if p.Name == "github" {
+		body, err := fetch(client, p.Endpoints.EmailsURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get email addresses: %w", err)
+		}
+		email, err := primaryVerifiedEmail(body)
+		if err != nil {
+			return nil, err
+		}
+		userInfo.Email = email
+		userInfo.EmailVerified = true
+	}
+
+	return userInfo, nil
+}
+
+// fetch returns the body of a successful GET
+func fetch(client *http.Client, url string) ([]byte, error) {
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user info: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -91,8 +170,10 @@ func (p *OAuth2Provider) GetUserInfo(ctx context.Context, token *oauth2.Token) (
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-
-	return p.parseUserInfo(body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return body, nil
 }
 
 func (p *OAuth2Provider) parseUserInfo(data []byte) (*UserInfo, error) {
@@ -103,16 +184,18 @@ func (p *OAuth2Provider) parseUserInfo(data []byte) (*UserInfo, error) {
 	switch p.Name {
 	case "google":
 		var googleUser struct {
-			ID      string `json:"id"`
-			Email   string `json:"email"`
-			Name    string `json:"name"`
-			Picture string `json:"picture"`
+			ID            string `json:"id"`
+			Email         string `json:"email"`
+			VerifiedEmail bool   `json:"verified_email"`
+			Name          string `json:"name"`
+			Picture       string `json:"picture"`
 		}
 		if err := json.Unmarshal(data, &googleUser); err != nil {
 			return nil, err
 		}
 		userInfo.ProviderID = googleUser.ID
 		userInfo.Email = googleUser.Email
+		userInfo.EmailVerified = googleUser.VerifiedEmail
 		userInfo.Name = googleUser.Name
 		userInfo.AvatarURL = googleUser.Picture
 
@@ -121,28 +204,39 @@ func (p *OAuth2Provider) parseUserInfo(data []byte) (*UserInfo, error) {
 			ID        int    `json:"id"`
 			Login     string `json:"login"`
 			Name      string `json:"name"`
-			Email     string `json:"email"`
 			AvatarURL string `json:"avatar_url"`
 		}
 		if err := json.Unmarshal(data, &githubUser); err != nil {
 			return nil, err
 		}
 		userInfo.ProviderID = fmt.Sprintf("%d", githubUser.ID)
-		userInfo.Email = githubUser.Email
 		if githubUser.Name != "" {
 			userInfo.Name = githubUser.Name
 		} else {
 			userInfo.Name = githubUser.Login
 		}
 		userInfo.AvatarURL = githubUser.AvatarURL
-
-		// GitHub might not return email in the user endpoint, need to fetch separately
-		if userInfo.Email == "" {
-			// For simplicity, we'll leave this as-is
-			// In production, you'd fetch from https://api.github.com/user/emails
-			userInfo.Email = githubUser.Login + "@github.local" // Placeholder
-		}
 	}
 
 	return userInfo, nil
 }
+
+// primaryVerifiedEmail picks the primary address from a GitHub emails API
+// response, provided GitHub has verified it
+func primaryVerifiedEmail(data []byte) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := json.Unmarshal(data, &emails); err != nil {
+		return "", err
+	}
+
+	for _, email := range emails {
+		if email.Primary && email.Verified && email.Email != "" {
+			return email.Email, nil
+		}
+	}
+	return "", ErrNoVerifiedEmail
+}
@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// jwksRefreshInterval limits how often an unknown key ID refetches the
+// provider's keys
+const jwksRefreshInterval = time.Minute
+
+// idTokenAlgorithms are the asymmetric algorithms accepted for ID tokens.
+// HS256 tokens, signed with the client secret, are refused.
+var idTokenAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCConfig configures a generic OpenID Connect provider such as Keycloak,
+// Okta, GitLab or Azure AD
+type OIDCConfig struct {
+	Name         string
+	IssuerURL    string // discovery document at IssuerURL + "/.well-known/openid-configuration"
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // "openid" is always requested
+	Claims       OIDCClaims
+	// TrustEmail treats the email claim as verified when the provider
+	// doesn't send email_verified, e.g. for a company directory
+	TrustEmail bool
+	HTTPClient *http.Client // nil uses a client with a 10 second timeout
+}
+
+// OIDCClaims names the claims user details are read from
+type OIDCClaims struct {
+	Email         string
+	EmailVerified string
+	Name          string
+	Picture       string
+}
+
+// DefaultOIDCClaims returns the standard OpenID Connect claim names
+func DefaultOIDCClaims() OIDCClaims {
+	return OIDCClaims{
+		Email:         "email",
+		EmailVerified: "email_verified",
+		Name:          "name",
+		Picture:       "picture",
+	}
+}
+
+// OIDCProvider signs users in with an OpenID Connect provider. Endpoints and
+// keys are discovered from the issuer on first use. Sign-ins use PKCE, and the
+// ID token's signature, issuer, audience, expiry and nonce are verified.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDCProvider checks the configuration; the provider is contacted on
+// first use, so an unreachable provider doesn't stop the server starting
+func NewOIDCProvider(config OIDCConfig) (*OIDCProvider, error) {
+	if config.Name == "" || config.ClientID == "" {
+		return nil, errors.New("OIDC provider name and client ID are required")
+	}
+	issuer, err := url.Parse(config.IssuerURL)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return nil, fmt.Errorf("invalid issuer URL for OIDC provider %s", config.Name)
+	}
+	config.IssuerURL = strings.TrimRight(config.IssuerURL, "/")
+
+	scopes := []string{"openid"}
+	for _, scope := range config.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	config.Scopes = scopes
+
+	defaults := DefaultOIDCClaims()
+	if config.Claims.Email == "" {
+		config.Claims.Email = defaults.Email
+	}
+	if config.Claims.EmailVerified == "" {
+		config.Claims.EmailVerified = defaults.EmailVerified
+	}
+	if config.Claims.Name == "" {
+		config.Claims.Name = defaults.Name
+	}
+	if config.Claims.Picture == "" {
+		config.Claims.Picture = defaults.Picture
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{config: config, client: client}, nil
+}
+
+// AuthURL returns the provider's sign-in page, carrying the flow's nonce and
+// PKCE challenge
+func (p *OIDCProvider) AuthURL(ctx context.Context, flow *AuthFlow) (string, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2Config(discovery).AuthCodeURL(flow.State,
+		oauth2.SetAuthURLParam("nonce", flow.Nonce),
+		oauth2.S256ChallengeOption(flow.CodeVerifier)), nil
+}
+
+// Authenticate redeems the code with the flow's PKCE verifier and verifies
+// the ID token. Claims missing from the ID token are read from the userinfo
+// endpoint.
+func (p *OIDCProvider) Authenticate(ctx context.Context, code string, flow *AuthFlow) (*UserInfo, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	config := p.oauth2Config(discovery)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(flow.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, errors.New("the provider returned no ID token")
+	}
+	claims, err := p.verifyIDToken(discovery, rawIDToken, flow.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+
+	if claimString(claims, p.config.Claims.Email) == "" && discovery.UserInfoEndpoint != "" {
+		body, err := fetch(config.Client(ctx, token), discovery.UserInfoEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user info: %w", err)
+		}
+		var userInfo map[string]interface{}
+		if err := json.Unmarshal(body, &userInfo); err != nil {
+			return nil, fmt.Errorf("failed to parse user info: %w", err)
+		}
+		if userInfo["sub"] != subject {
+			return nil, errors.New("user info subject does not match the ID token")
+		}
+		for name, value := range userInfo {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+
+	info := &UserInfo{
+		Provider:   p.config.Name,
+		ProviderID: subject,
+		Email:      claimString(claims, p.config.Claims.Email),
+		Name:       claimString(claims, p.config.Claims.Name),
+		AvatarURL:  claimString(claims, p.config.Claims.Picture),
+	}
+	if verified, ok := claimBool(claims, p.config.Claims.EmailVerified); ok {
+		info.EmailVerified = verified
+	} else {
+		info.EmailVerified = p.config.TrustEmail
+	}
+	if info.Email == "" {
+		info.EmailVerified = false
+	}
+	return info, nil
+}
+
+func (p *OIDCProvider) oauth2Config(discovery *oidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}
+}
+
+// discover fetches and caches the provider's discovery document. Failures
+// aren't cached, so a provider that was down is retried on the next sign-in.
+func (p *OIDCProvider) discover() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	body, err := fetch(p.client, p.config.IssuerURL+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %s: %w", p.config.Name, err)
+	}
+	discovery := &oidcDiscovery{}
+	if err := json.Unmarshal(body, discovery); err != nil {
+		return nil, fmt.Errorf("invalid discovery document for OIDC provider %s: %w", p.config.Name, err)
+	}
+	// The issuer must be the one configured, or a compromised or mistyped
+	// discovery URL could vouch for tokens from anywhere
+	if strings.TrimRight(discovery.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("OIDC provider %s reports issuer %q", p.config.Name, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("incomplete discovery document for OIDC provider %s", p.config.Name)
+	}
+
+	p.discovery = discovery
+	return discovery, nil
+}
+
+// verifyIDToken checks the ID token's signature and claims, returning them
+func (p *OIDCProvider) verifyIDToken(discovery *oidcDiscovery, raw, nonce string) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(discovery, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The nonce proves the token was issued for this sign-in, not replayed
+	// from another one
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, errors.New("nonce mismatch")
+	}
+
+	// A token for several audiences must name this client as the party it
+	// was issued to
+	audience, _ := claims.GetAudience()
+	azp, hasAZP := claims["azp"].(string)
+	if (len(audience) > 1 || hasAZP) && azp != p.config.ClientID {
+		return nil, errors.New("token was issued to another client")
+	}
+	return claims, nil
+}
+
+// key returns the provider's public key with the given ID, refetching the
+// key set when the ID is unknown so that key rotation is picked up
+func (p *OIDCProvider) key(discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, errors.New("unknown signing key")
+	}
+
+	body, err := fetch(p.client, discovery.JWKSURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	var jwks JWKS
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped; tokens they signed fail
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// lookupKey finds a cached key. Tokens without a key ID are accepted only
+// when the provider publishes a single key.
+func (p *OIDCProvider) lookupKey(kid string) crypto.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimBool reads a boolean claim; some providers send "true" as a string
+func claimBool(claims jwt.MapClaims, name string) (bool, bool) {
+	switch value := claims[name].(type) {
+	case bool:
+		return value, true
+	case string:
+		return value == "true", true
+	}
+	return false, false
+}
@@ -1,5 +1,10 @@
 package auth
 
+import (
+	"gocleanarchitecture/entities"
+	"strings"
+)
+
 // TokenGeneratorAdapter adapts JWTManager to the TokenGenerator interface
 type TokenGeneratorAdapter struct {
 	jwtManager *JWTManager
@@ -11,8 +16,23 @@ func NewTokenGeneratorAdapter(jwtManager *JWTManager) *TokenGeneratorAdapter {
 	}
 }
 
-func (a *TokenGeneratorAdapter) GenerateToken(userID, username, email string) (string, error) {
-	return a.jwtManager.GenerateToken(userID, username, email)
+func (a *TokenGeneratorAdapter) GenerateToken(token *entities.AccessToken) (string, error) {
+	signed, claims, err := a.jwtManager.IssueToken(Subject{
+		UserID:     token.UserID,
+		Username:   token.Username,
+		Email:      token.Email,
+		Generation: token.Generation,
+		SessionID:  token.SessionID,
+		Role:       string(token.Role),
+		ClientID:   token.ClientID,
+		Scope:      entities.FormatScopes(token.Scopes),
+	})
+	if err != nil {
+		return "", err
+	}
+	token.ID = claims.ID
+	token.ExpiresAt = claims.ExpiresAt.Time
+	return signed, nil
 }
 
 func (a *TokenGeneratorAdapter) ValidateToken(token string) (userID string, username string, email string, err error) {
@@ -23,3 +43,27 @@ func (a *TokenGeneratorAdapter) ValidateToken(token string) (userID string, user
 	return claims.UserID, claims.Username, claims.Email, nil
 }
 
+// VerifyAccessToken validates a token and returns everything it asserts
+func (a *TokenGeneratorAdapter) VerifyAccessToken(token string) (*entities.AccessToken, error) {
+	claims, err := a.jwtManager.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := &entities.AccessToken{
+		ID:         claims.ID,
+		UserID:     claims.UserID,
+		Username:   claims.Username,
+		Email:      claims.Email,
+		SessionID:  claims.SessionID,
+		Role:       entities.UserRole(claims.Role),
+		Generation: claims.Generation,
+		ClientID:   claims.ClientID,
+	}
+	for _, scope := range strings.Fields(claims.Scope) {
+		accessToken.Scopes = append(accessToken.Scopes, entities.Scope(scope))
+	}
+	if claims.ExpiresAt != nil {
+		accessToken.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return accessToken, nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemoryAPITokenRepository struct {
+	tokens map[string]entities.APIToken // by ID
+	mu     sync.RWMutex
+}
+
+func NewInMemoryAPITokenRepository() interfaces.APITokenRepository {
+	return &InMemoryAPITokenRepository{
+		tokens: make(map[string]entities.APIToken),
+	}
+}
+
+func (r *InMemoryAPITokenRepository) Save(token *entities.APIToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *token
+	stored.Scopes = append([]entities.Scope(nil), token.Scopes...)
+	r.tokens[token.ID] = stored
+	return nil
+}
+
+func (r *InMemoryAPITokenRepository) FindByHash(tokenHash string) (*entities.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryAPITokenRepository) FindByUser(userID string) ([]*entities.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*entities.APIToken
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			token := token
+			tokens = append(tokens, &token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *InMemoryAPITokenRepository) Delete(userID, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID {
+		return false, nil
+	}
+	delete(r.tokens, id)
+	return true, nil
+}
+
+func (r *InMemoryAPITokenRepository) UpdateLastUsed(id string, lastUsedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.tokens[id]; ok {
+		token.LastUsedAt = &lastUsedAt
+		r.tokens[id] = token
+	}
+	return nil
+}
+
+func (r *InMemoryAPITokenRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.ExpiresAt != nil && token.ExpiresAt.Before(before) {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sync"
+)
+
+type InMemoryAuditRepository struct {
+	events []*entities.AuditEvent // in insertion order
+	mu     sync.RWMutex
+}
+
+func NewInMemoryAuditRepository() interfaces.AuditRepository {
+	return &InMemoryAuditRepository{}
+}
+
+func (r *InMemoryAuditRepository) Append(event *entities.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eventCopy := *event
+	r.events = append(r.events, &eventCopy)
+	return nil
+}
+
+func (r *InMemoryAuditRepository) Find(filter interfaces.AuditFilter) ([]*entities.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*entities.AuditEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		event := r.events[i]
+		if !matchesAuditFilter(event, filter) {
+			continue
+		}
+		eventCopy := *event
+		events = append(events, &eventCopy)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func matchesAuditFilter(event *entities.AuditEvent, filter interfaces.AuditFilter) bool {
+	switch {
+	case filter.ActorID != "" && event.ActorID != filter.ActorID,
+		filter.Action != "" && event.Action != filter.Action,
+		filter.TargetType != "" && event.TargetType != filter.TargetType,
+		filter.TargetID != "" && event.TargetID != filter.TargetID,
+		!filter.Since.IsZero() && event.OccurredAt.Before(filter.Since),
+		!filter.Until.IsZero() && !event.OccurredAt.Before(filter.Until):
+		return false
+	}
+	return true
+}
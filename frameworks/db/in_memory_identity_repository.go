@@ -0,0 +1,79 @@
+package db
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+)
+
+type InMemoryIdentityRepository struct {
+	identities map[string]entities.Identity // by ID
+	mu         sync.RWMutex
+}
+
+func NewInMemoryIdentityRepository() interfaces.IdentityRepository {
+	return &InMemoryIdentityRepository{
+		identities: make(map[string]entities.Identity),
+	}
+}
+
+func (r *InMemoryIdentityRepository) Save(identity *entities.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.identities {
+		if id == identity.ID {
+			continue
+		}
+		if existing.Provider == identity.Provider &&
+			(existing.ProviderID == identity.ProviderID || existing.UserID == identity.UserID) {
+			return errors.New("identity already exists")
+		}
+	}
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+func (r *InMemoryIdentityRepository) FindByProvider(provider, providerID string) (*entities.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.ProviderID == providerID {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryIdentityRepository) FindByUser(userID string) ([]*entities.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var identities []*entities.Identity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identity := identity
+			identities = append(identities, &identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].Provider < identities[j].Provider
+	})
+	return identities, nil
+}
+
+func (r *InMemoryIdentityRepository) Delete(userID, provider string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			delete(r.identities, id)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
+)
+
+type InMemoryLoginAttemptRepository struct {
+	throttles map[string]entities.LoginThrottle
+	mu        sync.RWMutex
+}
+
+func NewInMemoryLoginAttemptRepository() interfaces.LoginAttemptRepository {
+	return &InMemoryLoginAttemptRepository{
+		throttles: make(map[string]entities.LoginThrottle),
+	}
+}
+
+func (r *InMemoryLoginAttemptRepository) Find(key string) (*entities.LoginThrottle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	throttle, ok := r.throttles[key]
+	if !ok {
+		return nil, nil
+	}
+	return &throttle, nil
+}
+
+func (r *InMemoryLoginAttemptRepository) Save(throttle *entities.LoginThrottle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.throttles[throttle.Key] = *throttle
+	return nil
+}
+
+func (r *InMemoryLoginAttemptRepository) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttles, key)
+	return nil
+}
+
+func (r *InMemoryLoginAttemptRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, throttle := range r.throttles {
+		if throttle.ExpiresAt.Before(before) {
+			delete(r.throttles, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemoryMFARepository struct {
+	enrollments   map[string]*entities.MFAEnrollment
+	challenges    map[string]*entities.MFAChallenge
+	requiredRoles map[entities.UserRole]bool
+	mu            sync.RWMutex
+}
+
+func NewInMemoryMFARepository() interfaces.MFARepository {
+	return &InMemoryMFARepository{
+		enrollments:   make(map[string]*entities.MFAEnrollment),
+		challenges:    make(map[string]*entities.MFAChallenge),
+		requiredRoles: make(map[entities.UserRole]bool),
+	}
+}
+
+func (r *InMemoryMFARepository) SaveEnrollment(enrollment *entities.MFAEnrollment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.enrollments[enrollment.UserID] = copyMFAEnrollment(enrollment)
+	return nil
+}
+
+func (r *InMemoryMFARepository) FindEnrollment(userID string) (*entities.MFAEnrollment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok {
+		return nil, nil
+	}
+	return copyMFAEnrollment(enrollment), nil
+}
+
+func (r *InMemoryMFARepository) DeleteEnrollment(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.enrollments, userID)
+	return nil
+}
+
+func (r *InMemoryMFARepository) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok || enrollment.LastUsedStep >= step {
+		return false, nil
+	}
+	enrollment.LastUsedStep = step
+	return true, nil
+}
+
+func (r *InMemoryMFARepository) ConsumeRecoveryCode(userID, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok {
+		return false, nil
+	}
+	return enrollment.UseRecoveryCode(codeHash), nil
+}
+
+func (r *InMemoryMFARepository) SaveChallenge(challenge *entities.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challengeCopy := *challenge
+	r.challenges[challenge.ID] = &challengeCopy
+	return nil
+}
+
+func (r *InMemoryMFARepository) FindChallengeByHash(tokenHash string) (*entities.MFAChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.TokenHash == tokenHash {
+			challengeCopy := *challenge
+			return &challengeCopy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryMFARepository) DeleteChallenge(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.challenges, id)
+	return nil
+}
+
+func (r *InMemoryMFARepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, challenge := range r.challenges {
+		if challenge.ExpiresAt.Before(before) {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryMFARepository) SetRoleRequired(role entities.UserRole, required bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if required {
+		r.requiredRoles[role] = true
+	} else {
+		delete(r.requiredRoles, role)
+	}
+	return nil
+}
+
+func (r *InMemoryMFARepository) FindRequiredRoles() ([]entities.UserRole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]entities.UserRole, 0, len(r.requiredRoles))
+	for role := range r.requiredRoles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles, nil
+}
+
+func copyMFAEnrollment(enrollment *entities.MFAEnrollment) *entities.MFAEnrollment {
+	enrollmentCopy := *enrollment
+	enrollmentCopy.RecoveryCodeHashes = append([]string(nil), enrollment.RecoveryCodeHashes...)
+	if enrollment.ConfirmedAt != nil {
+		confirmedAt := *enrollment.ConfirmedAt
+		enrollmentCopy.ConfirmedAt = &confirmedAt
+	}
+	return &enrollmentCopy
+}
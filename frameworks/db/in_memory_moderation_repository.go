@@ -0,0 +1,56 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+)
+
+type InMemoryModerationRepository struct {
+	actions map[string]*entities.ModerationAction
+	mu      sync.RWMutex
+}
+
+func NewInMemoryModerationRepository() interfaces.ModerationRepository {
+	return &InMemoryModerationRepository{
+		actions: make(map[string]*entities.ModerationAction),
+	}
+}
+
+func (r *InMemoryModerationRepository) Save(action *entities.ModerationAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.actions[action.ID]; exists {
+		return nil
+	}
+	actionCopy := *action
+	r.actions[action.ID] = &actionCopy
+	return nil
+}
+
+func (r *InMemoryModerationRepository) Find(filter interfaces.ModerationFilter) ([]*entities.ModerationAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var actions []*entities.ModerationAction
+	for _, action := range r.actions {
+		if (filter.TargetType != "" && action.TargetType != filter.TargetType) ||
+			(filter.TargetID != "" && action.TargetID != filter.TargetID) ||
+			(filter.ActorID != "" && action.ActorID != filter.ActorID) ||
+			(filter.AuthorID != "" && action.AuthorID != filter.AuthorID) {
+			continue
+		}
+		actionCopy := *action
+		actions = append(actions, &actionCopy)
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].CreatedAt.After(actions[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(actions) > filter.Limit {
+		actions = actions[:filter.Limit]
+	}
+	return actions, nil
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+)
+
+type InMemoryNotificationRepository struct {
+	notifications map[string]*entities.Notification
+	mu            sync.RWMutex
+}
+
+func NewInMemoryNotificationRepository() interfaces.NotificationRepository {
+	return &InMemoryNotificationRepository{
+		notifications: make(map[string]*entities.Notification),
+	}
+}
+
+func (r *InMemoryNotificationRepository) Save(notification *entities.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Create a copy to avoid external modifications
+	notificationCopy := *notification
+	r.notifications[notification.ID] = &notificationCopy
+	return nil
+}
+
+func (r *InMemoryNotificationRepository) FindByID(id string) (*entities.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notification, ok := r.notifications[id]
+	if !ok {
+		return nil, nil
+	}
+	notificationCopy := *notification
+	return &notificationCopy, nil
+}
+
+func (r *InMemoryNotificationRepository) FindByUserID(userID string, unreadOnly bool) ([]*entities.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notifications []*entities.Notification
+	for _, notification := range r.notifications {
+		if notification.UserID != userID || (unreadOnly && notification.ReadAt != nil) {
+			continue
+		}
+		notificationCopy := *notification
+		notifications = append(notifications, &notificationCopy)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	return notifications, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+)
+
+type InMemoryOAuthClientRepository struct {
+	clients map[string]entities.OAuthClient
+	mu      sync.RWMutex
+}
+
+func NewInMemoryOAuthClientRepository() interfaces.OAuthClientRepository {
+	return &InMemoryOAuthClientRepository{
+		clients: make(map[string]entities.OAuthClient),
+	}
+}
+
+func (r *InMemoryOAuthClientRepository) Save(client *entities.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *client
+	stored.RedirectURIs = append([]string(nil), client.RedirectURIs...)
+	stored.Scopes = append([]entities.Scope(nil), client.Scopes...)
+	r.clients[client.ID] = stored
+	return nil
+}
+
+func (r *InMemoryOAuthClientRepository) FindByID(id string) (*entities.OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, nil
+	}
+	return &client, nil
+}
+
+func (r *InMemoryOAuthClientRepository) FindAll() ([]*entities.OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := make([]*entities.OAuthClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		client := client
+		clients = append(clients, &client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].CreatedAt.Before(clients[j].CreatedAt) })
+	return clients, nil
+}
+
+// Delete leaves the client's codes behind; they fail to exchange once the
+// client is gone and are pruned when they expire
+func (r *InMemoryOAuthClientRepository) Delete(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[id]; !ok {
+		return false, nil
+	}
+	delete(r.clients, id)
+	return true, nil
+}
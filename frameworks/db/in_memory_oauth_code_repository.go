@@ -0,0 +1,67 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
+)
+
+type InMemoryOAuthCodeRepository struct {
+	codes map[string]entities.OAuthAuthorizationCode // by hash
+	mu    sync.RWMutex
+}
+
+func NewInMemoryOAuthCodeRepository() interfaces.OAuthCodeRepository {
+	return &InMemoryOAuthCodeRepository{
+		codes: make(map[string]entities.OAuthAuthorizationCode),
+	}
+}
+
+func (r *InMemoryOAuthCodeRepository) Save(code *entities.OAuthAuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *code
+	stored.Scopes = append([]entities.Scope(nil), code.Scopes...)
+	r.codes[code.CodeHash] = stored
+	return nil
+}
+
+func (r *InMemoryOAuthCodeRepository) FindByHash(codeHash string) (*entities.OAuthAuthorizationCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return nil, nil
+	}
+	return &code, nil
+}
+
+func (r *InMemoryOAuthCodeRepository) Consume(codeHash, tokenID string, tokenExpiresAt, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok || code.UsedAt != nil {
+		return false, nil
+	}
+	code.UsedAt = &at
+	code.TokenID = tokenID
+	code.TokenExpiresAt = tokenExpiresAt
+	r.codes[codeHash] = code
+	return true, nil
+}
+
+func (r *InMemoryOAuthCodeRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, code := range r.codes {
+		if code.ExpiresAt.Before(before) && (code.UsedAt == nil || code.TokenExpiresAt.Before(before)) {
+			delete(r.codes, hash)
+		}
+	}
+	return nil
+}
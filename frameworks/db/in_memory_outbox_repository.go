@@ -0,0 +1,100 @@
+package db
+
+import (
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemoryOutboxRepository struct {
+	messages map[string]*interfaces.OutboxMessage
+	mu       sync.RWMutex
+}
+
+func NewInMemoryOutboxRepository() interfaces.OutboxRepository {
+	return &InMemoryOutboxRepository{
+		messages: make(map[string]*interfaces.OutboxMessage),
+	}
+}
+
+func (r *InMemoryOutboxRepository) Append(messages ...*interfaces.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range messages {
+		// Create a copy to avoid external modifications
+		msgCopy := *msg
+		r.messages[msg.ID] = &msgCopy
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepository) FetchPending(limit int, now time.Time) ([]*interfaces.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pending []*interfaces.OutboxMessage
+	for _, msg := range r.messages {
+		if msg.Status == interfaces.OutboxStatusPending && !msg.NextAttemptAt.After(now) {
+			// Return a copy to avoid external modifications
+			msgCopy := *msg
+			pending = append(pending, &msgCopy)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (r *InMemoryOutboxRepository) MarkDelivered(id string, deliveredAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg, ok := r.messages[id]; ok {
+		msg.Status = interfaces.OutboxStatusDelivered
+		msg.DeliveredAt = &deliveredAt
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepository) MarkFailed(id string, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg, ok := r.messages[id]; ok {
+		msg.Attempts++
+		msg.LastError = lastError
+		msg.NextAttemptAt = nextAttemptAt
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepository) MarkDead(id string, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg, ok := r.messages[id]; ok {
+		msg.Status = interfaces.OutboxStatusDead
+		msg.Attempts++
+		msg.LastError = lastError
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepository) DeleteDeliveredBefore(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, msg := range r.messages {
+		if msg.Status == interfaces.OutboxStatusDelivered && msg.DeliveredAt != nil && msg.DeliveredAt.Before(before) {
+			delete(r.messages, id)
+		}
+	}
+	return nil
+}
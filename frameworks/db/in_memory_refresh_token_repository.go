@@ -0,0 +1,92 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
+)
+
+type InMemoryRefreshTokenRepository struct {
+	tokens map[string]*entities.RefreshToken
+	mu     sync.RWMutex
+}
+
+func NewInMemoryRefreshTokenRepository() interfaces.RefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{
+		tokens: make(map[string]*entities.RefreshToken),
+	}
+}
+
+func (r *InMemoryRefreshTokenRepository) Save(token *entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Create a copy to avoid external modifications
+	tokenCopy := *token
+	r.tokens[token.ID] = &tokenCopy
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) FindByHash(tokenHash string) (*entities.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			// Return a copy to avoid external modifications
+			tokenCopy := *token
+			return &tokenCopy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) MarkRotated(id, replacedBy string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.RotatedAt != nil {
+		return false, nil
+	}
+	token.RotatedAt = &at
+	token.ReplacedBy = replacedBy
+	return true, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeAllForUser(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+)
+
+type InMemoryRoleRepository struct {
+	roles map[entities.UserRole]*entities.Role
+	mu    sync.RWMutex
+}
+
+func NewInMemoryRoleRepository() interfaces.RoleRepository {
+	return &InMemoryRoleRepository{
+		roles: make(map[entities.UserRole]*entities.Role),
+	}
+}
+
+func (r *InMemoryRoleRepository) Save(role *entities.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roles[role.Name] = copyRole(role)
+	return nil
+}
+
+func (r *InMemoryRoleRepository) FindByName(name entities.UserRole) (*entities.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, nil
+	}
+	return copyRole(role), nil
+}
+
+func (r *InMemoryRoleRepository) FindAll() ([]*entities.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]*entities.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+func (r *InMemoryRoleRepository) Delete(name entities.UserRole) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.roles, name)
+	return nil
+}
+
+// copyRole copies a role including its permission slice to avoid external
+// modifications
+func copyRole(role *entities.Role) *entities.Role {
+	roleCopy := *role
+	roleCopy.Permissions = append([]entities.Permission(nil), role.Permissions...)
+	return &roleCopy
+}
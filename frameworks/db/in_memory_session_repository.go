@@ -0,0 +1,85 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sort"
+	"sync"
+	"time"
+)
+
+type InMemorySessionRepository struct {
+	sessions map[string]*entities.Session
+	mu       sync.RWMutex
+}
+
+func NewInMemorySessionRepository() interfaces.SessionRepository {
+	return &InMemorySessionRepository{
+		sessions: make(map[string]*entities.Session),
+	}
+}
+
+func (r *InMemorySessionRepository) Save(session *entities.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Create a copy to avoid external modifications
+	sessionCopy := *session
+	r.sessions[session.ID] = &sessionCopy
+	return nil
+}
+
+func (r *InMemorySessionRepository) FindByID(id string) (*entities.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	// Return a copy to avoid external modifications
+	sessionCopy := *session
+	return &sessionCopy, nil
+}
+
+func (r *InMemorySessionRepository) FindByUserID(userID string) ([]*entities.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*entities.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			sessionCopy := *session
+			sessions = append(sessions, &sessionCopy)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *InMemorySessionRepository) RevokeAllForUser(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *InMemorySessionRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
+)
+
+type InMemoryTokenRevocationRepository struct {
+	revoked     map[string]time.Time // token ID -> expiry
+	generations map[string]int
+	mu          sync.RWMutex
+}
+
+func NewInMemoryTokenRevocationRepository() interfaces.TokenRevocationRepository {
+	return &InMemoryTokenRevocationRepository{
+		revoked:     make(map[string]time.Time),
+		generations: make(map[string]int),
+	}
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeToken(tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) IsRevoked(tokenID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, revoked := r.revoked[tokenID]
+	return revoked, nil
+}
+
+func (r *InMemoryTokenRevocationRepository) BumpUserGeneration(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generations[userID]++
+	return r.generations[userID], nil
+}
+
+func (r *InMemoryTokenRevocationRepository) GetUserGeneration(userID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.generations[userID], nil
+}
+
+func (r *InMemoryTokenRevocationRepository) DeleteExpired(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenID, expiresAt := range r.revoked {
+		if expiresAt.Before(now) {
+			delete(r.revoked, tokenID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"gocleanarchitecture/frameworks/events"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"sync"
+)
+
+// InMemoryTransactor is the in-memory equivalent of the SQLite outbox
+// transaction. Writes are serialized, and events are staged and only appended
+// to the outbox when fn succeeds. Entity writes themselves are not rolled
+// back, so use cases should publish events after their last write.
+type InMemoryTransactor struct {
+	blogPosts interfaces.BlogPostRepository
+	comments  interfaces.CommentRepository
+	users     interfaces.UserRepository
+	outbox    interfaces.OutboxRepository
+	mu        sync.Mutex
+}
+
+func NewInMemoryTransactor(blogPosts interfaces.BlogPostRepository, comments interfaces.CommentRepository, users interfaces.UserRepository, outbox interfaces.OutboxRepository) usecases.Transactor {
+	return &InMemoryTransactor{
+		blogPosts: blogPosts,
+		comments:  comments,
+		users:     users,
+		outbox:    outbox,
+	}
+}
+
+func (t *InMemoryTransactor) WithinTransaction(fn func(tx *usecases.Tx) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	staged := &stagedOutbox{}
+	tx := &usecases.Tx{
+		BlogPosts: t.blogPosts,
+		Comments:  t.comments,
+		Users:     t.users,
+		Events:    events.NewOutboxPublisher(staged),
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return t.outbox.Append(staged.messages...)
+}
+
+// stagedOutbox collects messages until the transaction commits
+type stagedOutbox struct {
+	messages []*interfaces.OutboxMessage
+}
+
+func (s *stagedOutbox) Append(messages ...*interfaces.OutboxMessage) error {
+	s.messages = append(s.messages, messages...)
+	return nil
+}
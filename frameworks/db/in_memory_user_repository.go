@@ -112,6 +112,16 @@ func (r *InMemoryUserRepository) GetAll() ([]*entities.User, error) {
 	return users, nil
 }
 
+func (r *InMemoryUserRepository) UpdatePasswordHash(id, oldHash, newHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, exists := r.users[id]; exists && user.PasswordHash == oldHash {
+		user.PasswordHash = newHash
+	}
+	return nil
+}
+
 func (r *InMemoryUserRepository) Delete(id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
+)
+
+type InMemoryUserTokenRepository struct {
+	tokens map[string]entities.UserToken // by hash
+	mu     sync.RWMutex
+}
+
+func NewInMemoryUserTokenRepository() interfaces.UserTokenRepository {
+	return &InMemoryUserTokenRepository{
+		tokens: make(map[string]entities.UserToken),
+	}
+}
+
+func (r *InMemoryUserTokenRepository) Save(token *entities.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *InMemoryUserTokenRepository) FindByHash(tokenHash string) (*entities.UserToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (r *InMemoryUserTokenRepository) Consume(tokenHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[tokenHash]; !ok {
+		return false, nil
+	}
+	delete(r.tokens, tokenHash)
+	return true, nil
+}
+
+func (r *InMemoryUserTokenRepository) DeleteByUser(userID, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryUserTokenRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
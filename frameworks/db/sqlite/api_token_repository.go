@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteAPITokenRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteAPITokenRepository(db *sql.DB) interfaces.APITokenRepository {
+	return &SQLiteAPITokenRepository{DB: db}
+}
+
+const apiTokenColumns = "id, user_id, name, scopes, prefix, token_hash, created_at, expires_at, last_used_at"
+
+func (r *SQLiteAPITokenRepository) Save(token *entities.APIToken) error {
+	scopes, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(`
+		INSERT OR REPLACE INTO api_tokens (`+apiTokenColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.ID, token.UserID, token.Name, string(scopes), token.Prefix, token.TokenHash,
+		token.CreatedAt.UTC(), nullTime(token.ExpiresAt), nullTime(token.LastUsedAt))
+	return err
+}
+
+func (r *SQLiteAPITokenRepository) FindByHash(tokenHash string) (*entities.APIToken, error) {
+	token, err := scanAPIToken(r.DB.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?", tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *SQLiteAPITokenRepository) FindByUser(userID string) ([]*entities.APIToken, error) {
+	rows, err := r.DB.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*entities.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *SQLiteAPITokenRepository) Delete(userID, id string) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *SQLiteAPITokenRepository) UpdateLastUsed(id string, lastUsedAt time.Time) error {
+	_, err := r.DB.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", lastUsedAt.UTC(), id)
+	return err
+}
+
+func (r *SQLiteAPITokenRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM api_tokens WHERE expires_at IS NOT NULL AND expires_at < ?", before.UTC())
+	return err
+}
+
+func scanAPIToken(row rowScanner) (*entities.APIToken, error) {
+	token := &entities.APIToken{}
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &scopes, &token.Prefix, &token.TokenHash,
+		&token.CreatedAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return token, nil
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+)
+
+type SQLiteAuditRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteAuditRepository(db *sql.DB) interfaces.AuditRepository {
+	return &SQLiteAuditRepository{DB: db}
+}
+
+const auditColumns = `id, occurred_at, actor_id, action, target_type, target_id, ip, user_agent,
+	request_id, before_snapshot, after_snapshot`
+
+func (r *SQLiteAuditRepository) Append(event *entities.AuditEvent) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO audit_events (`+auditColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.ID, event.OccurredAt.UTC(), event.ActorID, event.Action, event.TargetType, event.TargetID,
+		event.IP, event.UserAgent, event.RequestID, event.Before, event.After)
+	return err
+}
+
+func (r *SQLiteAuditRepository) Find(filter interfaces.AuditFilter) ([]*entities.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = ?", filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		add("occurred_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		add("occurred_at < ?", filter.Until.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// rowid breaks ties between events written within the same instant
+	query += " ORDER BY occurred_at DESC, rowid DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*entities.AuditEvent
+	for rows.Next() {
+		event := &entities.AuditEvent{}
+		err := rows.Scan(&event.ID, &event.OccurredAt, &event.ActorID, &event.Action, &event.TargetType,
+			&event.TargetID, &event.IP, &event.UserAgent, &event.RequestID, &event.Before, &event.After)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type SQLiteBlogPostRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewSQLiteBlogPostRepository(db *sql.DB) interfaces.BlogPostRepository {
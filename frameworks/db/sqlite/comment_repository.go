@@ -7,7 +7,7 @@ import (
 )
 
 type SQLiteCommentRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewSQLiteCommentRepository(db *sql.DB) interfaces.CommentRepository {
@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+type SQLiteIdentityRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteIdentityRepository(db *sql.DB) interfaces.IdentityRepository {
+	return &SQLiteIdentityRepository{DB: db}
+}
+
+func (r *SQLiteIdentityRepository) Save(identity *entities.Identity) error {
+	// Upsert by ID only: a clash on (provider, provider_id) or (user_id,
+	// provider) must fail rather than replace another user's link
+	_, err := r.DB.Exec(`
+		INSERT INTO identities (id, user_id, provider, provider_id, email, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET email = excluded.email, last_used_at = excluded.last_used_at
+	`, identity.ID, identity.UserID, identity.Provider, identity.ProviderID, identity.Email,
+		identity.CreatedAt.UTC(), identity.LastUsedAt.UTC())
+	return err
+}
+
+func (r *SQLiteIdentityRepository) FindByProvider(provider, providerID string) (*entities.Identity, error) {
+	identity := &entities.Identity{}
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, provider, provider_id, email, created_at, last_used_at
+		FROM identities WHERE provider = ? AND provider_id = ?
+	`, provider, providerID).Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.ProviderID,
+		&identity.Email, &identity.CreatedAt, &identity.LastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *SQLiteIdentityRepository) FindByUser(userID string) ([]*entities.Identity, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, user_id, provider, provider_id, email, created_at, last_used_at
+		FROM identities WHERE user_id = ? ORDER BY provider
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*entities.Identity
+	for rows.Next() {
+		identity := &entities.Identity{}
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.ProviderID,
+			&identity.Email, &identity.CreatedAt, &identity.LastUsedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (r *SQLiteIdentityRepository) Delete(userID, provider string) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM identities WHERE user_id = ? AND provider = ?", userID, provider)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteLoginAttemptRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteLoginAttemptRepository(db *sql.DB) interfaces.LoginAttemptRepository {
+	return &SQLiteLoginAttemptRepository{DB: db}
+}
+
+func (r *SQLiteLoginAttemptRepository) Find(key string) (*entities.LoginThrottle, error) {
+	throttle := &entities.LoginThrottle{}
+	var lockedUntil sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT key, failures, last_failure_at, locked_until, expires_at
+		FROM login_attempts WHERE key = ?
+	`, key).Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &lockedUntil, &throttle.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = lockedUntil.Time
+	}
+	return throttle, nil
+}
+
+func (r *SQLiteLoginAttemptRepository) Save(throttle *entities.LoginThrottle) error {
+	lockedUntil := sql.NullTime{Time: throttle.LockedUntil.UTC(), Valid: !throttle.LockedUntil.IsZero()}
+	_, err := r.DB.Exec(`
+		INSERT OR REPLACE INTO login_attempts (key, failures, last_failure_at, locked_until, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, throttle.Key, throttle.Failures, throttle.LastFailureAt.UTC(), lockedUntil, throttle.ExpiresAt.UTC())
+	return err
+}
+
+func (r *SQLiteLoginAttemptRepository) Delete(key string) error {
+	_, err := r.DB.Exec("DELETE FROM login_attempts WHERE key = ?", key)
+	return err
+}
+
+func (r *SQLiteLoginAttemptRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM login_attempts WHERE expires_at < ?", before.UTC())
+	return err
+}
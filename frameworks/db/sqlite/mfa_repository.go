@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteMFARepository struct {
+	DB DBTX
+}
+
+func NewSQLiteMFARepository(db *sql.DB) interfaces.MFARepository {
+	return &SQLiteMFARepository{DB: db}
+}
+
+func (r *SQLiteMFARepository) SaveEnrollment(enrollment *entities.MFAEnrollment) error {
+	codes, err := json.Marshal(enrollment.RecoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(`
+		INSERT OR REPLACE INTO mfa_enrollments (user_id, secret, created_at, confirmed_at, last_used_step, recovery_code_hashes)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, enrollment.UserID, enrollment.Secret, enrollment.CreatedAt.UTC(), nullTime(enrollment.ConfirmedAt),
+		enrollment.LastUsedStep, string(codes))
+	return err
+}
+
+func (r *SQLiteMFARepository) FindEnrollment(userID string) (*entities.MFAEnrollment, error) {
+	enrollment := &entities.MFAEnrollment{}
+	var confirmedAt sql.NullTime
+	var codes string
+	err := r.DB.QueryRow(`
+		SELECT user_id, secret, created_at, confirmed_at, last_used_step, recovery_code_hashes
+		FROM mfa_enrollments WHERE user_id = ?
+	`, userID).Scan(&enrollment.UserID, &enrollment.Secret, &enrollment.CreatedAt, &confirmedAt,
+		&enrollment.LastUsedStep, &codes)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		enrollment.ConfirmedAt = &confirmedAt.Time
+	}
+	if err := json.Unmarshal([]byte(codes), &enrollment.RecoveryCodeHashes); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (r *SQLiteMFARepository) DeleteEnrollment(userID string) error {
+	_, err := r.DB.Exec("DELETE FROM mfa_enrollments WHERE user_id = ?", userID)
+	return err
+}
+
+func (r *SQLiteMFARepository) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE mfa_enrollments SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *SQLiteMFARepository) ConsumeRecoveryCode(userID, codeHash string) (bool, error) {
+	// The codes are a JSON array; the statement drops the one used only if it
+	// is still there, in a single write
+	result, err := r.DB.Exec(`
+		UPDATE mfa_enrollments
+		SET recovery_code_hashes = (
+			SELECT json_group_array(value) FROM json_each(mfa_enrollments.recovery_code_hashes) WHERE value != ?
+		)
+		WHERE user_id = ? AND EXISTS (
+			SELECT 1 FROM json_each(mfa_enrollments.recovery_code_hashes) WHERE value = ?
+		)
+	`, codeHash, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *SQLiteMFARepository) SaveChallenge(challenge *entities.MFAChallenge) error {
+	_, err := r.DB.Exec(`
+		INSERT OR REPLACE INTO mfa_challenges (id, user_id, token_hash, created_at, expires_at, attempts)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, challenge.ID, challenge.UserID, challenge.TokenHash, challenge.CreatedAt.UTC(), challenge.ExpiresAt.UTC(), challenge.Attempts)
+	return err
+}
+
+func (r *SQLiteMFARepository) FindChallengeByHash(tokenHash string) (*entities.MFAChallenge, error) {
+	challenge := &entities.MFAChallenge{}
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, token_hash, created_at, expires_at, attempts
+		FROM mfa_challenges WHERE token_hash = ?
+	`, tokenHash).Scan(&challenge.ID, &challenge.UserID, &challenge.TokenHash, &challenge.CreatedAt,
+		&challenge.ExpiresAt, &challenge.Attempts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (r *SQLiteMFARepository) DeleteChallenge(id string) error {
+	_, err := r.DB.Exec("DELETE FROM mfa_challenges WHERE id = ?", id)
+	return err
+}
+
+func (r *SQLiteMFARepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM mfa_challenges WHERE expires_at < ?", before.UTC())
+	return err
+}
+
+func (r *SQLiteMFARepository) SetRoleRequired(role entities.UserRole, required bool) error {
+	query := "DELETE FROM mfa_required_roles WHERE role = ?"
+	if required {
+		query = "INSERT OR IGNORE INTO mfa_required_roles (role) VALUES (?)"
+	}
+	_, err := r.DB.Exec(query, role)
+	return err
+}
+
+func (r *SQLiteMFARepository) FindRequiredRoles() ([]entities.UserRole, error) {
+	rows, err := r.DB.Query("SELECT role FROM mfa_required_roles ORDER BY role")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []entities.UserRole
+	for rows.Next() {
+		var role entities.UserRole
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
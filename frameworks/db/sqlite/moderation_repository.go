@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+)
+
+type SQLiteModerationRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteModerationRepository(db *sql.DB) interfaces.ModerationRepository {
+	return &SQLiteModerationRepository{DB: db}
+}
+
+func (r *SQLiteModerationRepository) Save(action *entities.ModerationAction) error {
+	_, err := r.DB.Exec(`
+		INSERT OR IGNORE INTO moderation_actions (id, target_type, target_id, author_id, actor_id, action, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, action.ID, action.TargetType, action.TargetID, action.AuthorID, action.ActorID,
+		action.Action, action.Reason, action.CreatedAt.UTC())
+	return err
+}
+
+func (r *SQLiteModerationRepository) Find(filter interfaces.ModerationFilter) ([]*entities.ModerationAction, error) {
+	var conditions []string
+	var args []interface{}
+	for column, value := range map[string]string{
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+		"actor_id":    filter.ActorID,
+		"author_id":   filter.AuthorID,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+
+	query := "SELECT id, target_type, target_id, author_id, actor_id, action, reason, created_at FROM moderation_actions"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*entities.ModerationAction
+	for rows.Next() {
+		action := &entities.ModerationAction{}
+		err := rows.Scan(&action.ID, &action.TargetType, &action.TargetID, &action.AuthorID,
+			&action.ActorID, &action.Action, &action.Reason, &action.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
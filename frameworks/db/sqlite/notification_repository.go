@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+type SQLiteNotificationRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteNotificationRepository(db *sql.DB) interfaces.NotificationRepository {
+	return &SQLiteNotificationRepository{DB: db}
+}
+
+const notificationColumns = "id, user_id, type, message, created_at, read_at"
+
+func (r *SQLiteNotificationRepository) Save(notification *entities.Notification) error {
+	_, err := r.DB.Exec(`
+		INSERT OR REPLACE INTO notifications (`+notificationColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, notification.ID, notification.UserID, notification.Type, notification.Message,
+		notification.CreatedAt.UTC(), nullTime(notification.ReadAt))
+	return err
+}
+
+func (r *SQLiteNotificationRepository) FindByID(id string) (*entities.Notification, error) {
+	notification, err := scanNotification(r.DB.QueryRow("SELECT "+notificationColumns+" FROM notifications WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return notification, err
+}
+
+func (r *SQLiteNotificationRepository) FindByUserID(userID string, unreadOnly bool) ([]*entities.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE user_id = ?"
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	rows, err := r.DB.Query(query+" ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*entities.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+func scanNotification(row rowScanner) (*entities.Notification, error) {
+	notification := &entities.Notification{}
+	var readAt sql.NullTime
+	err := row.Scan(&notification.ID, &notification.UserID, &notification.Type,
+		&notification.Message, &notification.CreatedAt, &readAt)
+	if err != nil {
+		return nil, err
+	}
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	return notification, nil
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+type SQLiteOAuthClientRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteOAuthClientRepository(db *sql.DB) interfaces.OAuthClientRepository {
+	return &SQLiteOAuthClientRepository{DB: db}
+}
+
+const oauthClientColumns = "id, name, secret_hash, redirect_uris, scopes, created_by, created_at"
+
+func (r *SQLiteOAuthClientRepository) Save(client *entities.OAuthClient) error {
+	redirectURIs, err := json.Marshal(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+	scopes, err := json.Marshal(client.Scopes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(`
+		INSERT OR REPLACE INTO oauth_clients (`+oauthClientColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, client.ID, client.Name, client.SecretHash, string(redirectURIs), string(scopes), client.CreatedBy, client.CreatedAt.UTC())
+	return err
+}
+
+func (r *SQLiteOAuthClientRepository) FindByID(id string) (*entities.OAuthClient, error) {
+	client, err := scanOAuthClient(r.DB.QueryRow("SELECT "+oauthClientColumns+" FROM oauth_clients WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (r *SQLiteOAuthClientRepository) FindAll() ([]*entities.OAuthClient, error) {
+	rows, err := r.DB.Query("SELECT " + oauthClientColumns + " FROM oauth_clients ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*entities.OAuthClient{}
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+func (r *SQLiteOAuthClientRepository) Delete(id string) (bool, error) {
+	if _, err := r.DB.Exec("DELETE FROM oauth_authorization_codes WHERE client_id = ?", id); err != nil {
+		return false, err
+	}
+	result, err := r.DB.Exec("DELETE FROM oauth_clients WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func scanOAuthClient(row rowScanner) (*entities.OAuthClient, error) {
+	client := &entities.OAuthClient{}
+	var redirectURIs, scopes string
+	err := row.Scan(&client.ID, &client.Name, &client.SecretHash, &redirectURIs, &scopes, &client.CreatedBy, &client.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(redirectURIs), &client.RedirectURIs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &client.Scopes); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
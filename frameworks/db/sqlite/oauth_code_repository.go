@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteOAuthCodeRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteOAuthCodeRepository(db *sql.DB) interfaces.OAuthCodeRepository {
+	return &SQLiteOAuthCodeRepository{DB: db}
+}
+
+func (r *SQLiteOAuthCodeRepository) Save(code *entities.OAuthAuthorizationCode) error {
+	scopes, err := json.Marshal(code.Scopes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(`
+		INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, string(scopes), code.CodeChallenge,
+		code.CreatedAt.UTC(), code.ExpiresAt.UTC())
+	return err
+}
+
+func (r *SQLiteOAuthCodeRepository) FindByHash(codeHash string) (*entities.OAuthAuthorizationCode, error) {
+	code := &entities.OAuthAuthorizationCode{}
+	var scopes string
+	var usedAt, tokenExpiresAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, created_at, expires_at,
+			used_at, token_id, token_expires_at
+		FROM oauth_authorization_codes WHERE code_hash = ?
+	`, codeHash).Scan(&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &scopes, &code.CodeChallenge,
+		&code.CreatedAt, &code.ExpiresAt, &usedAt, &code.TokenID, &tokenExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &code.Scopes); err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+	code.TokenExpiresAt = tokenExpiresAt.Time
+	return code, nil
+}
+
+func (r *SQLiteOAuthCodeRepository) Consume(codeHash, tokenID string, tokenExpiresAt, at time.Time) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE oauth_authorization_codes SET used_at = ?, token_id = ?, token_expires_at = ?
+		WHERE code_hash = ? AND used_at IS NULL
+	`, at.UTC(), tokenID, tokenExpiresAt.UTC(), codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *SQLiteOAuthCodeRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec(`
+		DELETE FROM oauth_authorization_codes
+		WHERE expires_at < ? AND (used_at IS NULL OR token_expires_at < ?)
+	`, before.UTC(), before.UTC())
+	return err
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+// SQLiteOutboxRepository stores timestamps in UTC so that the text
+// comparisons SQLite performs on DATETIME columns order correctly
+type SQLiteOutboxRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteOutboxRepository(db *sql.DB) interfaces.OutboxRepository {
+	return &SQLiteOutboxRepository{DB: db}
+}
+
+func (r *SQLiteOutboxRepository) Append(messages ...*interfaces.OutboxMessage) error {
+	for _, msg := range messages {
+		_, err := r.DB.Exec(`
+			INSERT INTO outbox (id, event_name, payload, status, attempts, last_error, next_attempt_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, msg.ID, msg.EventName, string(msg.Payload), msg.Status, msg.Attempts, msg.LastError, msg.NextAttemptAt.UTC(), msg.CreatedAt.UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteOutboxRepository) FetchPending(limit int, now time.Time) ([]*interfaces.OutboxMessage, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, event_name, payload, status, attempts, last_error, next_attempt_at, created_at, delivered_at
+		FROM outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY created_at ASC
+		LIMIT ?
+	`, interfaces.OutboxStatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*interfaces.OutboxMessage
+	for rows.Next() {
+		msg := &interfaces.OutboxMessage{}
+		var payload string
+		var deliveredAt sql.NullTime
+		err := rows.Scan(
+			&msg.ID, &msg.EventName, &payload, &msg.Status, &msg.Attempts,
+			&msg.LastError, &msg.NextAttemptAt, &msg.CreatedAt, &deliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = []byte(payload)
+		if deliveredAt.Valid {
+			msg.DeliveredAt = &deliveredAt.Time
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *SQLiteOutboxRepository) MarkDelivered(id string, deliveredAt time.Time) error {
+	_, err := r.DB.Exec("UPDATE outbox SET status = ?, delivered_at = ? WHERE id = ?",
+		interfaces.OutboxStatusDelivered, deliveredAt.UTC(), id)
+	return err
+}
+
+func (r *SQLiteOutboxRepository) MarkFailed(id string, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.DB.Exec("UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?",
+		lastError, nextAttemptAt.UTC(), id)
+	return err
+}
+
+func (r *SQLiteOutboxRepository) MarkDead(id string, lastError string) error {
+	_, err := r.DB.Exec("UPDATE outbox SET status = ?, attempts = attempts + 1, last_error = ? WHERE id = ?",
+		interfaces.OutboxStatusDead, lastError, id)
+	return err
+}
+
+func (r *SQLiteOutboxRepository) DeleteDeliveredBefore(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM outbox WHERE status = ? AND delivered_at < ?",
+		interfaces.OutboxStatusDelivered, before.UTC())
+	return err
+}
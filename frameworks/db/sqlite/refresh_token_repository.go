@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+// SQLiteRefreshTokenRepository stores timestamps in UTC so that the text
+// comparisons SQLite performs on DATETIME columns order correctly
+type SQLiteRefreshTokenRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteRefreshTokenRepository(db *sql.DB) interfaces.RefreshTokenRepository {
+	return &SQLiteRefreshTokenRepository{DB: db}
+}
+
+func (r *SQLiteRefreshTokenRepository) Save(token *entities.RefreshToken) error {
+	_, err := r.DB.Exec(`
+		INSERT OR REPLACE INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at, rotated_at, replaced_by, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.CreatedAt.UTC(), token.ExpiresAt.UTC(),
+		nullTime(token.RotatedAt), token.ReplacedBy, nullTime(token.RevokedAt))
+	return err
+}
+
+func (r *SQLiteRefreshTokenRepository) FindByHash(tokenHash string) (*entities.RefreshToken, error) {
+	token := &entities.RefreshToken{}
+	var rotatedAt, revokedAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, family_id, token_hash, created_at, expires_at, rotated_at, replaced_by, revoked_at
+		FROM refresh_tokens WHERE token_hash = ?
+	`, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.CreatedAt,
+		&token.ExpiresAt, &rotatedAt, &token.ReplacedBy, &revokedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if rotatedAt.Valid {
+		token.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (r *SQLiteRefreshTokenRepository) MarkRotated(id, replacedBy string, at time.Time) (bool, error) {
+	result, err := r.DB.Exec("UPDATE refresh_tokens SET rotated_at = ?, replaced_by = ? WHERE id = ? AND rotated_at IS NULL",
+		at.UTC(), replacedBy, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *SQLiteRefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	_, err := r.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+		at.UTC(), familyID)
+	return err
+}
+
+func (r *SQLiteRefreshTokenRepository) RevokeAllForUser(userID string, at time.Time) error {
+	_, err := r.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		at.UTC(), userID)
+	return err
+}
+
+func (r *SQLiteRefreshTokenRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM refresh_tokens WHERE expires_at < ?", before.UTC())
+	return err
+}
+
+// nullTime converts an optional timestamp for storage
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
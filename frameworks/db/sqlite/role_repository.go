@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+type SQLiteRoleRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteRoleRepository(db *sql.DB) interfaces.RoleRepository {
+	return &SQLiteRoleRepository{DB: db}
+}
+
+func (r *SQLiteRoleRepository) Save(role *entities.Role) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(`
+		INSERT OR REPLACE INTO roles (name, description, permissions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, role.Name, role.Description, string(permissions), role.CreatedAt.UTC(), role.UpdatedAt.UTC())
+	return err
+}
+
+func (r *SQLiteRoleRepository) FindByName(name entities.UserRole) (*entities.Role, error) {
+	role, err := scanRole(r.DB.QueryRow("SELECT name, description, permissions, created_at, updated_at FROM roles WHERE name = ?", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return role, err
+}
+
+func (r *SQLiteRoleRepository) FindAll() ([]*entities.Role, error) {
+	rows, err := r.DB.Query("SELECT name, description, permissions, created_at, updated_at FROM roles ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*entities.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *SQLiteRoleRepository) Delete(name entities.UserRole) error {
+	_, err := r.DB.Exec("DELETE FROM roles WHERE name = ?", name)
+	return err
+}
+
+func scanRole(row rowScanner) (*entities.Role, error) {
+	role := &entities.Role{}
+	var permissions string
+	if err := row.Scan(&role.Name, &role.Description, &permissions, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(permissions), &role.Permissions); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
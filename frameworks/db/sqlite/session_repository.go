@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteSessionRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteSessionRepository(db *sql.DB) interfaces.SessionRepository {
+	return &SQLiteSessionRepository{DB: db}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at,
+	access_token_id, access_token_expires_at, revoked_at`
+
+func (r *SQLiteSessionRepository) Save(session *entities.Session) error {
+	_, err := r.DB.Exec(`
+		INSERT OR REPLACE INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, session.ID, session.UserID, session.UserAgent, session.IP, session.CreatedAt.UTC(),
+		session.LastSeenAt.UTC(), session.ExpiresAt.UTC(), session.AccessTokenID,
+		session.AccessTokenExpiresAt.UTC(), nullTime(session.RevokedAt))
+	return err
+}
+
+func (r *SQLiteSessionRepository) FindByID(id string) (*entities.Session, error) {
+	session, err := scanSession(r.DB.QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+func (r *SQLiteSessionRepository) FindByUserID(userID string) ([]*entities.Session, error) {
+	rows, err := r.DB.Query("SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? ORDER BY last_seen_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*entities.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *SQLiteSessionRepository) RevokeAllForUser(userID string, at time.Time) error {
+	_, err := r.DB.Exec("UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		at.UTC(), userID)
+	return err
+}
+
+func (r *SQLiteSessionRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM sessions WHERE expires_at < ?", before.UTC())
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row rowScanner) (*entities.Session, error) {
+	session := &entities.Session{}
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt,
+		&session.LastSeenAt, &session.ExpiresAt, &session.AccessTokenID,
+		&session.AccessTokenExpiresAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
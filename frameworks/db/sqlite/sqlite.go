@@ -2,10 +2,19 @@ package sqlite
 
 import (
 	"database/sql"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so the same repository code
+// can run standalone or inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func InitDB(filepath string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", filepath)
 	if err != nil {
@@ -41,14 +50,22 @@ func InitDB(filepath string) (*sql.DB, error) {
 		full_name TEXT NOT NULL,
 		bio TEXT DEFAULT '',
 		avatar_url TEXT DEFAULT '',
-		role TEXT DEFAULT 'user' CHECK(role IN ('user', 'admin')),
+		role TEXT DEFAULT 'user',
 		created_at DATETIME NOT NULL,
-		updated_at DATETIME NOT NULL
+		updated_at DATETIME NOT NULL,
+		email_verified_at DATETIME
 	)`)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = dropUserRoleCheck(db); err != nil {
+		return nil, err
+	}
+	if err = addUserEmailVerifiedAt(db); err != nil {
+		return nil, err
+	}
+
 	// Create comments table
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS comments (
@@ -75,5 +92,356 @@ func InitDB(filepath string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	// Create outbox table for reliable event delivery
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS outbox (
+		id TEXT PRIMARY KEY,
+		event_name TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'delivered', 'dead')),
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT DEFAULT '',
+		next_attempt_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL,
+		delivered_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(status, next_attempt_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create refresh_tokens table - only token hashes are stored
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		rotated_at DATETIME,
+		replaced_by TEXT DEFAULT '',
+		revoked_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create token revocation tables - revoked_tokens rows expire with the token
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS revoked_tokens (
+		token_id TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+	CREATE TABLE IF NOT EXISTS token_generations (
+		user_id TEXT PRIMARY KEY,
+		generation INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL
+	);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create sessions table - a session's ID is its refresh token family ID
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		user_agent TEXT DEFAULT '',
+		ip TEXT DEFAULT '',
+		created_at DATETIME NOT NULL,
+		last_seen_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		access_token_id TEXT DEFAULT '',
+		access_token_expires_at DATETIME NOT NULL,
+		revoked_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create roles table - only custom roles are stored, permissions as JSON
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS roles (
+		name TEXT PRIMARY KEY,
+		description TEXT DEFAULT '',
+		permissions TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create moderation_actions table - staff edits and deletions of others' content
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS moderation_actions (
+		id TEXT PRIMARY KEY,
+		target_type TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		actor_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_moderation_actions_target ON moderation_actions(target_type, target_id);
+	CREATE INDEX IF NOT EXISTS idx_moderation_actions_created_at ON moderation_actions(created_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create notifications table
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS notifications (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		type TEXT NOT NULL,
+		message TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		read_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create login_attempts table - failed login counters per account and IP
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS login_attempts (
+		key TEXT PRIMARY KEY,
+		failures INTEGER NOT NULL,
+		last_failure_at DATETIME NOT NULL,
+		locked_until DATETIME,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_login_attempts_expires_at ON login_attempts(expires_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create two-factor authentication tables
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS mfa_enrollments (
+		user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		confirmed_at DATETIME,
+		last_used_step INTEGER NOT NULL DEFAULT 0,
+		recovery_code_hashes TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE TABLE IF NOT EXISTS mfa_challenges (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_mfa_challenges_expires_at ON mfa_challenges(expires_at);
+	CREATE TABLE IF NOT EXISTS mfa_required_roles (
+		role TEXT PRIMARY KEY
+	);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create user_tokens table - hashed email verification and password reset tokens
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS user_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		purpose TEXT NOT NULL,
+		email TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id, purpose);
+	CREATE INDEX IF NOT EXISTS idx_user_tokens_expires_at ON user_tokens(expires_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create identities table - provider accounts linked to users
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS identities (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		provider TEXT NOT NULL,
+		provider_id TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		last_used_at DATETIME NOT NULL,
+		UNIQUE (provider, provider_id),
+		UNIQUE (user_id, provider)
+	);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create api_tokens table - hashed personal API tokens
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		scopes TEXT NOT NULL DEFAULT '[]',
+		prefix TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create oauth_clients and oauth_authorization_codes tables - third-party
+	// apps and the hashed codes issued to them
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS oauth_clients (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		secret_hash TEXT NOT NULL DEFAULT '',
+		redirect_uris TEXT NOT NULL DEFAULT '[]',
+		scopes TEXT NOT NULL DEFAULT '[]',
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+		code_hash TEXT PRIMARY KEY,
+		client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		redirect_uri TEXT NOT NULL,
+		scopes TEXT NOT NULL DEFAULT '[]',
+		code_challenge TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME,
+		token_id TEXT NOT NULL DEFAULT '',
+		token_expires_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create audit_events table - append-only, enforced by triggers
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		occurred_at DATETIME NOT NULL,
+		actor_id TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		target_type TEXT NOT NULL DEFAULT '',
+		target_id TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		before_snapshot TEXT NOT NULL DEFAULT '',
+		after_snapshot TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events(occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+	BEGIN
+		SELECT RAISE(ABORT, 'audit events are append-only');
+	END;
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+	BEGIN
+		SELECT RAISE(ABORT, 'audit events are append-only');
+	END;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
+
+// dropUserRoleCheck rebuilds a users table created when roles were limited to
+// 'user' and 'admin', so users can be assigned the newer and custom roles.
+// SQLite can't drop a constraint in place.
+func dropUserRoleCheck(db *sql.DB) error {
+	var schema string
+	err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&schema)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(schema, "CHECK(role IN") {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	CREATE TABLE users_new (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		full_name TEXT NOT NULL,
+		bio TEXT DEFAULT '',
+		avatar_url TEXT DEFAULT '',
+		role TEXT DEFAULT 'user',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	INSERT INTO users_new SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at FROM users;
+	DROP TABLE users;
+	ALTER TABLE users_new RENAME TO users;
+	`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addUserEmailVerifiedAt adds the email_verified_at column to a users table
+// created before email verification. Existing accounts were activated
+// without it, so they are treated as verified.
+func addUserEmailVerifiedAt(db *sql.DB) error {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'email_verified_at'").Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+	UPDATE users SET email_verified_at = created_at;
+	`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteTokenRevocationRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteTokenRevocationRepository(db *sql.DB) interfaces.TokenRevocationRepository {
+	return &SQLiteTokenRevocationRepository{DB: db}
+}
+
+func (r *SQLiteTokenRevocationRepository) RevokeToken(tokenID string, expiresAt time.Time) error {
+	_, err := r.DB.Exec("INSERT OR REPLACE INTO revoked_tokens (token_id, expires_at) VALUES (?, ?)",
+		tokenID, expiresAt.UTC())
+	return err
+}
+
+func (r *SQLiteTokenRevocationRepository) IsRevoked(tokenID string) (bool, error) {
+	var count int
+	err := r.DB.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE token_id = ?", tokenID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *SQLiteTokenRevocationRepository) BumpUserGeneration(userID string) (int, error) {
+	_, err := r.DB.Exec(`
+		INSERT INTO token_generations (user_id, generation, updated_at) VALUES (?, 1, ?)
+		ON CONFLICT(user_id) DO UPDATE SET generation = generation + 1, updated_at = excluded.updated_at
+	`, userID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return r.GetUserGeneration(userID)
+}
+
+func (r *SQLiteTokenRevocationRepository) GetUserGeneration(userID string) (int, error) {
+	var generation int
+	err := r.DB.QueryRow("SELECT generation FROM token_generations WHERE user_id = ?", userID).Scan(&generation)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return generation, nil
+}
+
+func (r *SQLiteTokenRevocationRepository) DeleteExpired(now time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", now.UTC())
+	return err
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/frameworks/events"
+	"gocleanarchitecture/usecases"
+)
+
+// SQLiteTransactor runs use case writes in a database transaction. Events
+// published inside the transaction are written to the outbox table, so they
+// are committed atomically with the entity change.
+type SQLiteTransactor struct {
+	DB *sql.DB
+}
+
+func NewSQLiteTransactor(db *sql.DB) usecases.Transactor {
+	return &SQLiteTransactor{DB: db}
+}
+
+func (t *SQLiteTransactor) WithinTransaction(fn func(tx *usecases.Tx) error) error {
+	sqlTx, err := t.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	tx := &usecases.Tx{
+		BlogPosts: &SQLiteBlogPostRepository{DB: sqlTx},
+		Comments:  &SQLiteCommentRepository{DB: sqlTx},
+		Users:     &SQLiteUserRepository{DB: sqlTx},
+		Events:    events.NewOutboxPublisher(&SQLiteOutboxRepository{DB: sqlTx}),
+	}
+
+	if err := fn(tx); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	return sqlTx.Commit()
+}
@@ -9,7 +9,7 @@ import (
 )
 
 type SQLiteUserRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewSQLiteUserRepository(db *sql.DB) interfaces.UserRepository {
@@ -18,64 +18,32 @@ func NewSQLiteUserRepository(db *sql.DB) interfaces.UserRepository {
 
 func (r *SQLiteUserRepository) Save(user *entities.User) error {
 	_, err := r.DB.Exec(`
-		INSERT OR REPLACE INTO users (id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, user.ID, user.Username, user.Email, user.PasswordHash, user.FullName, user.Bio, user.AvatarURL, user.Role, user.CreatedAt, user.UpdatedAt)
+		INSERT OR REPLACE INTO users (id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at, email_verified_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, user.ID, user.Username, user.Email, user.PasswordHash, user.FullName, user.Bio, user.AvatarURL, user.Role, user.CreatedAt, user.UpdatedAt,
+		nullTime(user.EmailVerifiedAt))
 	return err
 }
 
 func (r *SQLiteUserRepository) FindByID(id string) (*entities.User, error) {
-	user := &entities.User{}
-	err := r.DB.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at
+	return scanUser(r.DB.QueryRow(`
+		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at, email_verified_at
 		FROM users WHERE id = ?
-	`, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.Bio, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return user, nil
+	`, id))
 }
 
 func (r *SQLiteUserRepository) FindByEmail(email string) (*entities.User, error) {
-	user := &entities.User{}
-	err := r.DB.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at
+	return scanUser(r.DB.QueryRow(`
+		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at, email_verified_at
 		FROM users WHERE email = ?
-	`, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.Bio, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return user, nil
+	`, email))
 }
 
 func (r *SQLiteUserRepository) FindByUsername(username string) (*entities.User, error) {
-	user := &entities.User{}
-	err := r.DB.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at
+	return scanUser(r.DB.QueryRow(`
+		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at, email_verified_at
 		FROM users WHERE username = ?
-	`, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FullName, &user.Bio, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return user, nil
+	`, username))
 }
 
 func (r *SQLiteUserRepository) ExistsByEmail(email string) (bool, error) {
@@ -98,7 +66,7 @@ func (r *SQLiteUserRepository) ExistsByUsername(username string) (bool, error) {
 
 func (r *SQLiteUserRepository) GetAll() ([]*entities.User, error) {
 	rows, err := r.DB.Query(`
-		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at
+		SELECT id, username, email, password_hash, full_name, bio, avatar_url, role, created_at, updated_at, email_verified_at
 		FROM users
 		ORDER BY created_at DESC
 	`)
@@ -109,11 +77,7 @@ func (r *SQLiteUserRepository) GetAll() ([]*entities.User, error) {
 
 	var users []*entities.User
 	for rows.Next() {
-		user := &entities.User{}
-		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-			&user.FullName, &user.Bio, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -123,7 +87,36 @@ func (r *SQLiteUserRepository) GetAll() ([]*entities.User, error) {
 	return users, rows.Err()
 }
 
+func (r *SQLiteUserRepository) UpdatePasswordHash(id, oldHash, newHash string) error {
+	_, err := r.DB.Exec("UPDATE users SET password_hash = ? WHERE id = ? AND password_hash = ?", newHash, id, oldHash)
+	return err
+}
+
 func (r *SQLiteUserRepository) Delete(id string) error {
 	_, err := r.DB.Exec("DELETE FROM users WHERE id = ?", id)
 	return err
 }
+
+// scanUser reads a user selected with the columns used throughout this file,
+// returning nil when no row matched
+func scanUser(row interface {
+	Scan(dest ...interface{}) error
+}) (*entities.User, error) {
+	user := &entities.User{}
+	var emailVerifiedAt sql.NullTime
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		&user.FullName, &user.Bio, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+		&emailVerifiedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	return user, nil
+}
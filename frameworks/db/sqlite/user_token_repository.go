@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+type SQLiteUserTokenRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteUserTokenRepository(db *sql.DB) interfaces.UserTokenRepository {
+	return &SQLiteUserTokenRepository{DB: db}
+}
+
+func (r *SQLiteUserTokenRepository) Save(token *entities.UserToken) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_tokens (id, user_id, purpose, email, token_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, token.ID, token.UserID, token.Purpose, token.Email, token.TokenHash, token.CreatedAt.UTC(), token.ExpiresAt.UTC())
+	return err
+}
+
+func (r *SQLiteUserTokenRepository) FindByHash(tokenHash string) (*entities.UserToken, error) {
+	token := &entities.UserToken{}
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, purpose, email, token_hash, created_at, expires_at
+		FROM user_tokens WHERE token_hash = ?
+	`, tokenHash).Scan(&token.ID, &token.UserID, &token.Purpose, &token.Email, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *SQLiteUserTokenRepository) Consume(tokenHash string) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM user_tokens WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *SQLiteUserTokenRepository) DeleteByUser(userID, purpose string) error {
+	_, err := r.DB.Exec("DELETE FROM user_tokens WHERE user_id = ? AND purpose = ?", userID, purpose)
+	return err
+}
+
+func (r *SQLiteUserTokenRepository) DeleteExpired(before time.Time) error {
+	_, err := r.DB.Exec("DELETE FROM user_tokens WHERE expires_at < ?", before.UTC())
+	return err
+}
@@ -0,0 +1,75 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseAPITokenRepository struct {
+	rest restClient
+}
+
+type supabaseAPIToken struct {
+	ID         string           `json:"id"`
+	UserID     string           `json:"user_id"`
+	Name       string           `json:"name"`
+	Scopes     []entities.Scope `json:"scopes"`
+	Prefix     string           `json:"prefix"`
+	TokenHash  string           `json:"token_hash"`
+	CreatedAt  time.Time        `json:"created_at"`
+	ExpiresAt  *time.Time       `json:"expires_at"`
+	LastUsedAt *time.Time       `json:"last_used_at"`
+}
+
+func NewSupabaseAPITokenRepository(url, apiKey string) interfaces.APITokenRepository {
+	return &SupabaseAPITokenRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseAPITokenRepository) Save(token *entities.APIToken) error {
+	return r.rest.do("POST", "api_tokens", supabaseAPIToken(*token), "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseAPITokenRepository) FindByHash(tokenHash string) (*entities.APIToken, error) {
+	var rows []supabaseAPIToken
+	if err := r.rest.do("GET", "api_tokens?token_hash=eq."+url.QueryEscape(tokenHash), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	token := entities.APIToken(rows[0])
+	return &token, nil
+}
+
+func (r *SupabaseAPITokenRepository) FindByUser(userID string) ([]*entities.APIToken, error) {
+	var rows []supabaseAPIToken
+	if err := r.rest.do("GET", "api_tokens?user_id=eq."+url.QueryEscape(userID)+"&order=created_at.desc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	tokens := make([]*entities.APIToken, 0, len(rows))
+	for _, row := range rows {
+		token := entities.APIToken(row)
+		tokens = append(tokens, &token)
+	}
+	return tokens, nil
+}
+
+func (r *SupabaseAPITokenRepository) Delete(userID, id string) (bool, error) {
+	var rows []supabaseAPIToken
+	path := "api_tokens?id=eq." + url.QueryEscape(id) + "&user_id=eq." + url.QueryEscape(userID)
+	if err := r.rest.do("DELETE", path, nil, "return=representation", &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+func (r *SupabaseAPITokenRepository) UpdateLastUsed(id string, lastUsedAt time.Time) error {
+	return r.rest.do("PATCH", "api_tokens?id=eq."+url.QueryEscape(id),
+		map[string]interface{}{"last_used_at": lastUsedAt.UTC()}, "", nil)
+}
+
+func (r *SupabaseAPITokenRepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "api_tokens?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
@@ -0,0 +1,73 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SupabaseAuditRepository struct {
+	rest restClient
+}
+
+type supabaseAuditEvent struct {
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	ActorID    string    `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id"`
+	Before     string    `json:"before_snapshot"`
+	After      string    `json:"after_snapshot"`
+}
+
+func NewSupabaseAuditRepository(url, apiKey string) interfaces.AuditRepository {
+	return &SupabaseAuditRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseAuditRepository) Append(event *entities.AuditEvent) error {
+	row := supabaseAuditEvent(*event)
+	return r.rest.do("POST", "audit_events", row, "", nil)
+}
+
+func (r *SupabaseAuditRepository) Find(filter interfaces.AuditFilter) ([]*entities.AuditEvent, error) {
+	query := url.Values{}
+	if filter.ActorID != "" {
+		query.Set("actor_id", "eq."+filter.ActorID)
+	}
+	if filter.Action != "" {
+		query.Set("action", "eq."+filter.Action)
+	}
+	if filter.TargetType != "" {
+		query.Set("target_type", "eq."+filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query.Set("target_id", "eq."+filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		query.Add("occurred_at", "gte."+timestamp(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		query.Add("occurred_at", "lt."+timestamp(filter.Until))
+	}
+	query.Set("order", "occurred_at.desc")
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var rows []supabaseAuditEvent
+	if err := r.rest.do("GET", "audit_events?"+query.Encode(), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	events := make([]*entities.AuditEvent, len(rows))
+	for i := range rows {
+		event := entities.AuditEvent(rows[i])
+		events[i] = &event
+	}
+	return events, nil
+}
@@ -0,0 +1,67 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseIdentityRepository struct {
+	rest restClient
+}
+
+type supabaseIdentity struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Provider   string    `json:"provider"`
+	ProviderID string    `json:"provider_id"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func NewSupabaseIdentityRepository(url, apiKey string) interfaces.IdentityRepository {
+	return &SupabaseIdentityRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseIdentityRepository) Save(identity *entities.Identity) error {
+	// Merges on the primary key; the unique constraints still reject a
+	// provider account linked to another user
+	return r.rest.do("POST", "identities", supabaseIdentity(*identity), "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseIdentityRepository) FindByProvider(provider, providerID string) (*entities.Identity, error) {
+	var rows []supabaseIdentity
+	path := "identities?provider=eq." + url.QueryEscape(provider) + "&provider_id=eq." + url.QueryEscape(providerID)
+	if err := r.rest.do("GET", path, nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	identity := entities.Identity(rows[0])
+	return &identity, nil
+}
+
+func (r *SupabaseIdentityRepository) FindByUser(userID string) ([]*entities.Identity, error) {
+	var rows []supabaseIdentity
+	if err := r.rest.do("GET", "identities?user_id=eq."+url.QueryEscape(userID)+"&order=provider.asc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	identities := make([]*entities.Identity, 0, len(rows))
+	for _, row := range rows {
+		identity := entities.Identity(row)
+		identities = append(identities, &identity)
+	}
+	return identities, nil
+}
+
+func (r *SupabaseIdentityRepository) Delete(userID, provider string) (bool, error) {
+	var rows []supabaseIdentity
+	path := "identities?user_id=eq." + url.QueryEscape(userID) + "&provider=eq." + url.QueryEscape(provider)
+	if err := r.rest.do("DELETE", path, nil, "return=representation", &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
@@ -0,0 +1,66 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseLoginAttemptRepository struct {
+	rest restClient
+}
+
+type supabaseLoginAttempt struct {
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+}
+
+func NewSupabaseLoginAttemptRepository(url, apiKey string) interfaces.LoginAttemptRepository {
+	return &SupabaseLoginAttemptRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseLoginAttemptRepository) Find(key string) (*entities.LoginThrottle, error) {
+	var rows []supabaseLoginAttempt
+	if err := r.rest.do("GET", "login_attempts?key=eq."+url.QueryEscape(key), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	throttle := &entities.LoginThrottle{
+		Key:           row.Key,
+		Failures:      row.Failures,
+		LastFailureAt: row.LastFailureAt,
+		ExpiresAt:     row.ExpiresAt,
+	}
+	if row.LockedUntil != nil {
+		throttle.LockedUntil = *row.LockedUntil
+	}
+	return throttle, nil
+}
+
+func (r *SupabaseLoginAttemptRepository) Save(throttle *entities.LoginThrottle) error {
+	row := supabaseLoginAttempt{
+		Key:           throttle.Key,
+		Failures:      throttle.Failures,
+		LastFailureAt: throttle.LastFailureAt,
+		ExpiresAt:     throttle.ExpiresAt,
+	}
+	if !throttle.LockedUntil.IsZero() {
+		row.LockedUntil = &throttle.LockedUntil
+	}
+	return r.rest.do("POST", "login_attempts", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseLoginAttemptRepository) Delete(key string) error {
+	return r.rest.do("DELETE", "login_attempts?key=eq."+url.QueryEscape(key), nil, "", nil)
+}
+
+func (r *SupabaseLoginAttemptRepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "login_attempts?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
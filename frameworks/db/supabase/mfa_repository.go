@@ -0,0 +1,158 @@
+package supabase
+
+import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SupabaseMFARepository struct {
+	rest restClient
+}
+
+type supabaseMFAEnrollment struct {
+	UserID             string     `json:"user_id"`
+	Secret             string     `json:"secret"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ConfirmedAt        *time.Time `json:"confirmed_at"`
+	LastUsedStep       int64      `json:"last_used_step"`
+	RecoveryCodeHashes []string   `json:"recovery_code_hashes"`
+}
+
+type supabaseMFAChallenge struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts"`
+}
+
+type supabaseMFARequiredRole struct {
+	Role entities.UserRole `json:"role"`
+}
+
+func NewSupabaseMFARepository(url, apiKey string) interfaces.MFARepository {
+	return &SupabaseMFARepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseMFARepository) SaveEnrollment(enrollment *entities.MFAEnrollment) error {
+	row := supabaseMFAEnrollment(*enrollment)
+	if row.RecoveryCodeHashes == nil {
+		row.RecoveryCodeHashes = []string{}
+	}
+	return r.rest.do("POST", "mfa_enrollments", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseMFARepository) FindEnrollment(userID string) (*entities.MFAEnrollment, error) {
+	var rows []supabaseMFAEnrollment
+	if err := r.rest.do("GET", "mfa_enrollments?user_id=eq."+url.QueryEscape(userID), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	enrollment := entities.MFAEnrollment(rows[0])
+	return &enrollment, nil
+}
+
+func (r *SupabaseMFARepository) DeleteEnrollment(userID string) error {
+	return r.rest.do("DELETE", "mfa_enrollments?user_id=eq."+url.QueryEscape(userID), nil, "", nil)
+}
+
+func (r *SupabaseMFARepository) ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	// The last_used_step filter makes the update conditional; the returned
+	// rows tell whether this call won
+	var rows []supabaseMFAEnrollment
+	err := r.rest.do("PATCH", "mfa_enrollments?user_id=eq."+url.QueryEscape(userID)+"&last_used_step=lt."+strconv.FormatInt(step, 10),
+		map[string]int64{"last_used_step": step}, "return=representation", &rows)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) == 1, nil
+}
+
+// recoveryCodeAttempts bounds how often ConsumeRecoveryCode reads the codes
+// again after another request changed them
+const recoveryCodeAttempts = 3
+
+func (r *SupabaseMFARepository) ConsumeRecoveryCode(userID, codeHash string) (bool, error) {
+	// PostgREST can't remove an element from the stored array, so the
+	// remaining codes are written only while the stored ones are still those
+	// read. Losing that race to another code means reading them again.
+	for attempt := 0; attempt < recoveryCodeAttempts; attempt++ {
+		enrollment, err := r.FindEnrollment(userID)
+		if err != nil || enrollment == nil {
+			return false, err
+		}
+		current, err := json.Marshal(enrollment.RecoveryCodeHashes)
+		if err != nil {
+			return false, err
+		}
+		if !enrollment.UseRecoveryCode(codeHash) {
+			return false, nil
+		}
+		remaining := enrollment.RecoveryCodeHashes
+		if remaining == nil {
+			remaining = []string{}
+		}
+
+		var rows []supabaseMFAEnrollment
+		err = r.rest.do("PATCH", "mfa_enrollments?user_id=eq."+url.QueryEscape(userID)+"&recovery_code_hashes=eq."+url.QueryEscape(string(current)),
+			map[string][]string{"recovery_code_hashes": remaining}, "return=representation", &rows)
+		if err != nil {
+			return false, err
+		}
+		if len(rows) == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *SupabaseMFARepository) SaveChallenge(challenge *entities.MFAChallenge) error {
+	row := supabaseMFAChallenge(*challenge)
+	return r.rest.do("POST", "mfa_challenges", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseMFARepository) FindChallengeByHash(tokenHash string) (*entities.MFAChallenge, error) {
+	var rows []supabaseMFAChallenge
+	if err := r.rest.do("GET", "mfa_challenges?token_hash=eq."+url.QueryEscape(tokenHash), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	challenge := entities.MFAChallenge(rows[0])
+	return &challenge, nil
+}
+
+func (r *SupabaseMFARepository) DeleteChallenge(id string) error {
+	return r.rest.do("DELETE", "mfa_challenges?id=eq."+url.QueryEscape(id), nil, "", nil)
+}
+
+func (r *SupabaseMFARepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "mfa_challenges?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
+
+func (r *SupabaseMFARepository) SetRoleRequired(role entities.UserRole, required bool) error {
+	if !required {
+		return r.rest.do("DELETE", "mfa_required_roles?role=eq."+url.QueryEscape(string(role)), nil, "", nil)
+	}
+	return r.rest.do("POST", "mfa_required_roles", supabaseMFARequiredRole{Role: role}, "resolution=ignore-duplicates", nil)
+}
+
+func (r *SupabaseMFARepository) FindRequiredRoles() ([]entities.UserRole, error) {
+	var rows []supabaseMFARequiredRole
+	if err := r.rest.do("GET", "mfa_required_roles?order=role.asc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	roles := make([]entities.UserRole, len(rows))
+	for i, row := range rows {
+		roles[i] = row.Role
+	}
+	return roles, nil
+}
@@ -0,0 +1,62 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SupabaseModerationRepository struct {
+	rest restClient
+}
+
+type supabaseModerationAction struct {
+	ID         string    `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	AuthorID   string    `json:"author_id"`
+	ActorID    string    `json:"actor_id"`
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewSupabaseModerationRepository(url, apiKey string) interfaces.ModerationRepository {
+	return &SupabaseModerationRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseModerationRepository) Save(action *entities.ModerationAction) error {
+	row := supabaseModerationAction(*action)
+	return r.rest.do("POST", "moderation_actions", row, "resolution=ignore-duplicates", nil)
+}
+
+func (r *SupabaseModerationRepository) Find(filter interfaces.ModerationFilter) ([]*entities.ModerationAction, error) {
+	query := url.Values{}
+	for column, value := range map[string]string{
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+		"actor_id":    filter.ActorID,
+		"author_id":   filter.AuthorID,
+	} {
+		if value != "" {
+			query.Set(column, "eq."+value)
+		}
+	}
+	query.Set("order", "created_at.desc")
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var rows []supabaseModerationAction
+	if err := r.rest.do("GET", "moderation_actions?"+query.Encode(), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	actions := make([]*entities.ModerationAction, len(rows))
+	for i := range rows {
+		action := entities.ModerationAction(rows[i])
+		actions[i] = &action
+	}
+	return actions, nil
+}
@@ -0,0 +1,60 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseNotificationRepository struct {
+	rest restClient
+}
+
+type supabaseNotification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func NewSupabaseNotificationRepository(url, apiKey string) interfaces.NotificationRepository {
+	return &SupabaseNotificationRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseNotificationRepository) Save(notification *entities.Notification) error {
+	row := supabaseNotification(*notification)
+	return r.rest.do("POST", "notifications", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseNotificationRepository) FindByID(id string) (*entities.Notification, error) {
+	var rows []supabaseNotification
+	if err := r.rest.do("GET", "notifications?id=eq."+url.QueryEscape(id), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	notification := entities.Notification(rows[0])
+	return &notification, nil
+}
+
+func (r *SupabaseNotificationRepository) FindByUserID(userID string, unreadOnly bool) ([]*entities.Notification, error) {
+	path := "notifications?user_id=eq." + url.QueryEscape(userID) + "&order=created_at.desc"
+	if unreadOnly {
+		path += "&read_at=is.null"
+	}
+
+	var rows []supabaseNotification
+	if err := r.rest.do("GET", path, nil, "", &rows); err != nil {
+		return nil, err
+	}
+	notifications := make([]*entities.Notification, len(rows))
+	for i := range rows {
+		notification := entities.Notification(rows[i])
+		notifications[i] = &notification
+	}
+	return notifications, nil
+}
@@ -0,0 +1,64 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseOAuthClientRepository struct {
+	rest restClient
+}
+
+type supabaseOAuthClient struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	SecretHash   string           `json:"secret_hash"`
+	RedirectURIs []string         `json:"redirect_uris"`
+	Scopes       []entities.Scope `json:"scopes"`
+	CreatedBy    string           `json:"created_by"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+func NewSupabaseOAuthClientRepository(url, apiKey string) interfaces.OAuthClientRepository {
+	return &SupabaseOAuthClientRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseOAuthClientRepository) Save(client *entities.OAuthClient) error {
+	return r.rest.do("POST", "oauth_clients", supabaseOAuthClient(*client), "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseOAuthClientRepository) FindByID(id string) (*entities.OAuthClient, error) {
+	var rows []supabaseOAuthClient
+	if err := r.rest.do("GET", "oauth_clients?id=eq."+url.QueryEscape(id), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	client := entities.OAuthClient(rows[0])
+	return &client, nil
+}
+
+func (r *SupabaseOAuthClientRepository) FindAll() ([]*entities.OAuthClient, error) {
+	var rows []supabaseOAuthClient
+	if err := r.rest.do("GET", "oauth_clients?order=created_at.asc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	clients := make([]*entities.OAuthClient, 0, len(rows))
+	for _, row := range rows {
+		client := entities.OAuthClient(row)
+		clients = append(clients, &client)
+	}
+	return clients, nil
+}
+
+// Delete relies on the foreign key to remove the client's codes
+func (r *SupabaseOAuthClientRepository) Delete(id string) (bool, error) {
+	var rows []supabaseOAuthClient
+	if err := r.rest.do("DELETE", "oauth_clients?id=eq."+url.QueryEscape(id), nil, "return=representation", &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
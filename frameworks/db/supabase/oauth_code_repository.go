@@ -0,0 +1,66 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseOAuthCodeRepository struct {
+	rest restClient
+}
+
+type supabaseOAuthCode struct {
+	CodeHash      string           `json:"code_hash"`
+	ClientID      string           `json:"client_id"`
+	UserID        string           `json:"user_id"`
+	RedirectURI   string           `json:"redirect_uri"`
+	Scopes        []entities.Scope `json:"scopes"`
+	CodeChallenge string           `json:"code_challenge"`
+	CreatedAt     time.Time        `json:"created_at"`
+	ExpiresAt     time.Time        `json:"expires_at"`
+
+	UsedAt         *time.Time `json:"used_at"`
+	TokenID        string     `json:"token_id"`
+	TokenExpiresAt time.Time  `json:"token_expires_at"`
+}
+
+func NewSupabaseOAuthCodeRepository(url, apiKey string) interfaces.OAuthCodeRepository {
+	return &SupabaseOAuthCodeRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseOAuthCodeRepository) Save(code *entities.OAuthAuthorizationCode) error {
+	return r.rest.do("POST", "oauth_authorization_codes", supabaseOAuthCode(*code), "", nil)
+}
+
+func (r *SupabaseOAuthCodeRepository) FindByHash(codeHash string) (*entities.OAuthAuthorizationCode, error) {
+	var rows []supabaseOAuthCode
+	if err := r.rest.do("GET", "oauth_authorization_codes?code_hash=eq."+url.QueryEscape(codeHash), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	code := entities.OAuthAuthorizationCode(rows[0])
+	return &code, nil
+}
+
+func (r *SupabaseOAuthCodeRepository) Consume(codeHash, tokenID string, tokenExpiresAt, at time.Time) (bool, error) {
+	// The used_at filter makes the update conditional; the returned rows tell
+	// whether this call won
+	var rows []supabaseOAuthCode
+	err := r.rest.do("PATCH", "oauth_authorization_codes?code_hash=eq."+url.QueryEscape(codeHash)+"&used_at=is.null",
+		map[string]interface{}{"used_at": at.UTC(), "token_id": tokenID, "token_expires_at": tokenExpiresAt.UTC()},
+		"return=representation", &rows)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) == 1, nil
+}
+
+func (r *SupabaseOAuthCodeRepository) DeleteExpired(before time.Time) error {
+	cutoff := timestamp(before)
+	return r.rest.do("DELETE", "oauth_authorization_codes?expires_at=lt."+url.QueryEscape(cutoff)+
+		"&or="+url.QueryEscape("(used_at.is.null,token_expires_at.lt."+cutoff+")"), nil, "", nil)
+}
@@ -0,0 +1,71 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseRefreshTokenRepository struct {
+	rest restClient
+}
+
+type supabaseRefreshToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	FamilyID   string     `json:"family_id"`
+	TokenHash  string     `json:"token_hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	ReplacedBy string     `json:"replaced_by"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func NewSupabaseRefreshTokenRepository(url, apiKey string) interfaces.RefreshTokenRepository {
+	return &SupabaseRefreshTokenRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseRefreshTokenRepository) Save(token *entities.RefreshToken) error {
+	row := supabaseRefreshToken(*token)
+	return r.rest.do("POST", "refresh_tokens", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseRefreshTokenRepository) FindByHash(tokenHash string) (*entities.RefreshToken, error) {
+	var rows []supabaseRefreshToken
+	if err := r.rest.do("GET", "refresh_tokens?token_hash=eq."+url.QueryEscape(tokenHash), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	token := entities.RefreshToken(rows[0])
+	return &token, nil
+}
+
+func (r *SupabaseRefreshTokenRepository) MarkRotated(id, replacedBy string, at time.Time) (bool, error) {
+	// The rotated_at filter makes the update conditional; the returned rows
+	// tell whether this call won
+	var rows []supabaseRefreshToken
+	err := r.rest.do("PATCH", "refresh_tokens?id=eq."+url.QueryEscape(id)+"&rotated_at=is.null",
+		map[string]interface{}{"rotated_at": at.UTC(), "replaced_by": replacedBy}, "return=representation", &rows)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) == 1, nil
+}
+
+func (r *SupabaseRefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	return r.rest.do("PATCH", "refresh_tokens?family_id=eq."+url.QueryEscape(familyID)+"&revoked_at=is.null",
+		map[string]interface{}{"revoked_at": at.UTC()}, "", nil)
+}
+
+func (r *SupabaseRefreshTokenRepository) RevokeAllForUser(userID string, at time.Time) error {
+	return r.rest.do("PATCH", "refresh_tokens?user_id=eq."+url.QueryEscape(userID)+"&revoked_at=is.null",
+		map[string]interface{}{"revoked_at": at.UTC()}, "", nil)
+}
+
+func (r *SupabaseRefreshTokenRepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "refresh_tokens?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
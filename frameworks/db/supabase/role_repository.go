@@ -0,0 +1,72 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseRoleRepository struct {
+	rest restClient
+}
+
+type supabaseRole struct {
+	Name        entities.UserRole     `json:"name"`
+	Description string                `json:"description"`
+	Permissions []entities.Permission `json:"permissions"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+func NewSupabaseRoleRepository(url, apiKey string) interfaces.RoleRepository {
+	return &SupabaseRoleRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseRoleRepository) Save(role *entities.Role) error {
+	row := supabaseRole{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+	return r.rest.do("POST", "roles", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseRoleRepository) FindByName(name entities.UserRole) (*entities.Role, error) {
+	var rows []supabaseRole
+	if err := r.rest.do("GET", "roles?name=eq."+url.QueryEscape(string(name)), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0].toEntity(), nil
+}
+
+func (r *SupabaseRoleRepository) FindAll() ([]*entities.Role, error) {
+	var rows []supabaseRole
+	if err := r.rest.do("GET", "roles?order=name.asc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	roles := make([]*entities.Role, len(rows))
+	for i := range rows {
+		roles[i] = rows[i].toEntity()
+	}
+	return roles, nil
+}
+
+func (r *SupabaseRoleRepository) Delete(name entities.UserRole) error {
+	return r.rest.do("DELETE", "roles?name=eq."+url.QueryEscape(string(name)), nil, "", nil)
+}
+
+func (row *supabaseRole) toEntity() *entities.Role {
+	return &entities.Role{
+		Name:        row.Name,
+		Description: row.Description,
+		Permissions: row.Permissions,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseSessionRepository struct {
+	rest restClient
+}
+
+type supabaseSession struct {
+	ID                   string     `json:"id"`
+	UserID               string     `json:"user_id"`
+	UserAgent            string     `json:"user_agent"`
+	IP                   string     `json:"ip"`
+	CreatedAt            time.Time  `json:"created_at"`
+	LastSeenAt           time.Time  `json:"last_seen_at"`
+	ExpiresAt            time.Time  `json:"expires_at"`
+	AccessTokenID        string     `json:"access_token_id"`
+	AccessTokenExpiresAt time.Time  `json:"access_token_expires_at"`
+	RevokedAt            *time.Time `json:"revoked_at"`
+}
+
+func NewSupabaseSessionRepository(url, apiKey string) interfaces.SessionRepository {
+	return &SupabaseSessionRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseSessionRepository) Save(session *entities.Session) error {
+	row := supabaseSession(*session)
+	return r.rest.do("POST", "sessions", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseSessionRepository) FindByID(id string) (*entities.Session, error) {
+	var rows []supabaseSession
+	if err := r.rest.do("GET", "sessions?id=eq."+url.QueryEscape(id), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	session := entities.Session(rows[0])
+	return &session, nil
+}
+
+func (r *SupabaseSessionRepository) FindByUserID(userID string) ([]*entities.Session, error) {
+	var rows []supabaseSession
+	if err := r.rest.do("GET", "sessions?user_id=eq."+url.QueryEscape(userID)+"&order=last_seen_at.desc", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	sessions := make([]*entities.Session, len(rows))
+	for i := range rows {
+		session := entities.Session(rows[i])
+		sessions[i] = &session
+	}
+	return sessions, nil
+}
+
+func (r *SupabaseSessionRepository) RevokeAllForUser(userID string, at time.Time) error {
+	return r.rest.do("PATCH", "sessions?user_id=eq."+url.QueryEscape(userID)+"&revoked_at=is.null",
+		map[string]interface{}{"revoked_at": at.UTC()}, "", nil)
+}
+
+func (r *SupabaseSessionRepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "sessions?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
@@ -0,0 +1,65 @@
+package supabase
+
+import (
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseTokenRevocationRepository struct {
+	rest restClient
+}
+
+type supabaseRevokedToken struct {
+	TokenID   string    `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type supabaseTokenGeneration struct {
+	UserID     string    `json:"user_id"`
+	Generation int       `json:"generation"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewSupabaseTokenRevocationRepository(url, apiKey string) interfaces.TokenRevocationRepository {
+	return &SupabaseTokenRevocationRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseTokenRevocationRepository) RevokeToken(tokenID string, expiresAt time.Time) error {
+	row := supabaseRevokedToken{TokenID: tokenID, ExpiresAt: expiresAt.UTC()}
+	return r.rest.do("POST", "revoked_tokens", row, "resolution=merge-duplicates", nil)
+}
+
+func (r *SupabaseTokenRevocationRepository) IsRevoked(tokenID string) (bool, error) {
+	var rows []supabaseRevokedToken
+	if err := r.rest.do("GET", "revoked_tokens?select=token_id&token_id=eq."+url.QueryEscape(tokenID), nil, "", &rows); err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
+
+// BumpUserGeneration uses the bump_token_generation function from
+// supabase_auth_schema.sql so concurrent bumps can't be lost
+func (r *SupabaseTokenRevocationRepository) BumpUserGeneration(userID string) (int, error) {
+	var generation int
+	err := r.rest.do("POST", "rpc/bump_token_generation", map[string]string{"p_user_id": userID}, "", &generation)
+	if err != nil {
+		return 0, err
+	}
+	return generation, nil
+}
+
+func (r *SupabaseTokenRevocationRepository) GetUserGeneration(userID string) (int, error) {
+	var rows []supabaseTokenGeneration
+	if err := r.rest.do("GET", "token_generations?user_id=eq."+url.QueryEscape(userID), nil, "", &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Generation, nil
+}
+
+func (r *SupabaseTokenRevocationRepository) DeleteExpired(now time.Time) error {
+	return r.rest.do("DELETE", "revoked_tokens?expires_at=lt."+url.QueryEscape(timestamp(now)), nil, "", nil)
+}
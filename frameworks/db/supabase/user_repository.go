@@ -8,6 +8,7 @@ import (
 	"gocleanarchitecture/interfaces"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -18,16 +19,17 @@ type SupabaseUserRepository struct {
 }
 
 type supabaseUser struct {
-	ID           string            `json:"id"`
-	Username     string            `json:"username"`
-	Email        string            `json:"email"`
-	PasswordHash string            `json:"password_hash"`
-	FullName     string            `json:"full_name"`
-	Bio          string            `json:"bio"`
-	AvatarURL    string            `json:"avatar_url"`
-	Role         entities.UserRole `json:"role"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID              string            `json:"id"`
+	Username        string            `json:"username"`
+	Email           string            `json:"email"`
+	PasswordHash    string            `json:"password_hash"`
+	FullName        string            `json:"full_name"`
+	Bio             string            `json:"bio"`
+	AvatarURL       string            `json:"avatar_url"`
+	Role            entities.UserRole `json:"role"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	EmailVerifiedAt *time.Time        `json:"email_verified_at"`
 }
 
 func NewSupabaseUserRepository(url, apiKey string) interfaces.UserRepository {
@@ -40,16 +42,17 @@ func NewSupabaseUserRepository(url, apiKey string) interfaces.UserRepository {
 
 func (r *SupabaseUserRepository) Save(user *entities.User) error {
 	supabaseUser := supabaseUser{
-		ID:           user.ID,
-		Username:     user.Username,
-		Email:        user.Email,
-		PasswordHash: user.PasswordHash,
-		FullName:     user.FullName,
-		Bio:          user.Bio,
-		AvatarURL:    user.AvatarURL,
-		Role:         user.Role,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		PasswordHash:    user.PasswordHash,
+		FullName:        user.FullName,
+		Bio:             user.Bio,
+		AvatarURL:       user.AvatarURL,
+		Role:            user.Role,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
 	}
 
 	jsonData, err := json.Marshal(supabaseUser)
@@ -221,6 +224,34 @@ func (r *SupabaseUserRepository) GetAll() ([]*entities.User, error) {
 	return users, nil
 }
 
+func (r *SupabaseUserRepository) UpdatePasswordHash(id, oldHash, newHash string) error {
+	jsonData, err := json.Marshal(map[string]string{"password_hash": newHash})
+	if err != nil {
+		return fmt.Errorf("failed to marshal password hash: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/rest/v1/users?id=eq.%s&password_hash=eq.%s", r.URL, url.QueryEscape(id), url.QueryEscape(oldHash))
+	req, err := http.NewRequest("PATCH", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	r.setHeaders(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 func (r *SupabaseUserRepository) Delete(id string) error {
 	url := fmt.Sprintf("%s/rest/v1/users?id=eq.%s", r.URL, id)
 	req, err := http.NewRequest("DELETE", url, nil)
@@ -252,15 +283,16 @@ func (r *SupabaseUserRepository) setHeaders(req *http.Request) {
 
 func (r *SupabaseUserRepository) toEntity(su *supabaseUser) *entities.User {
 	return &entities.User{
-		ID:           su.ID,
-		Username:     su.Username,
-		Email:        su.Email,
-		PasswordHash: su.PasswordHash,
-		FullName:     su.FullName,
-		Bio:          su.Bio,
-		AvatarURL:    su.AvatarURL,
-		Role:         su.Role,
-		CreatedAt:    su.CreatedAt,
-		UpdatedAt:    su.UpdatedAt,
+		ID:              su.ID,
+		Username:        su.Username,
+		Email:           su.Email,
+		PasswordHash:    su.PasswordHash,
+		FullName:        su.FullName,
+		Bio:             su.Bio,
+		AvatarURL:       su.AvatarURL,
+		Role:            su.Role,
+		CreatedAt:       su.CreatedAt,
+		UpdatedAt:       su.UpdatedAt,
+		EmailVerifiedAt: su.EmailVerifiedAt,
 	}
 }
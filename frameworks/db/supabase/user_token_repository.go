@@ -0,0 +1,60 @@
+package supabase
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+)
+
+type SupabaseUserTokenRepository struct {
+	rest restClient
+}
+
+type supabaseUserToken struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"token_hash"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewSupabaseUserTokenRepository(url, apiKey string) interfaces.UserTokenRepository {
+	return &SupabaseUserTokenRepository{rest: newRESTClient(url, apiKey)}
+}
+
+func (r *SupabaseUserTokenRepository) Save(token *entities.UserToken) error {
+	return r.rest.do("POST", "user_tokens", supabaseUserToken(*token), "", nil)
+}
+
+func (r *SupabaseUserTokenRepository) FindByHash(tokenHash string) (*entities.UserToken, error) {
+	var rows []supabaseUserToken
+	if err := r.rest.do("GET", "user_tokens?token_hash=eq."+url.QueryEscape(tokenHash), nil, "", &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	token := entities.UserToken(rows[0])
+	return &token, nil
+}
+
+func (r *SupabaseUserTokenRepository) Consume(tokenHash string) (bool, error) {
+	// The returned rows tell whether this call deleted the token
+	var rows []supabaseUserToken
+	err := r.rest.do("DELETE", "user_tokens?token_hash=eq."+url.QueryEscape(tokenHash), nil, "return=representation", &rows)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) == 1, nil
+}
+
+func (r *SupabaseUserTokenRepository) DeleteByUser(userID, purpose string) error {
+	return r.rest.do("DELETE", "user_tokens?user_id=eq."+url.QueryEscape(userID)+"&purpose=eq."+url.QueryEscape(purpose), nil, "", nil)
+}
+
+func (r *SupabaseUserTokenRepository) DeleteExpired(before time.Time) error {
+	return r.rest.do("DELETE", "user_tokens?expires_at=lt."+url.QueryEscape(timestamp(before)), nil, "", nil)
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/logger"
+)
+
+// Handler reacts to a published domain event
+type Handler func(event entities.DomainEvent) error
+
+type subscription struct {
+	name    string
+	handler Handler
+	async   bool
+	events  map[string]bool // empty means every event
+}
+
+func (s *subscription) matches(eventName string) bool {
+	return len(s.events) == 0 || s.events[eventName]
+}
+
+// Bus is an in-process event bus. Synchronous handlers run on the publishing
+// goroutine; asynchronous handlers run on their own goroutine. A panicking
+// handler is recovered and logged so it can't affect the publisher or other
+// subscribers.
+type Bus struct {
+	subscriptions []*subscription
+	logger        logger.Logger
+	wg            sync.WaitGroup
+	mu            sync.RWMutex
+}
+
+// NewBus creates a new event bus
+func NewBus(log logger.Logger) *Bus {
+	return &Bus{logger: log}
+}
+
+// Subscribe registers a synchronous handler. With no event names the handler
+// receives every event.
+func (b *Bus) Subscribe(name string, handler Handler, eventNames ...string) {
+	b.add(name, handler, false, eventNames)
+}
+
+// SubscribeAsync registers a handler that runs in its own goroutine, so slow
+// subscribers (webhooks, search indexing) don't delay the publisher
+func (b *Bus) SubscribeAsync(name string, handler Handler, eventNames ...string) {
+	b.add(name, handler, true, eventNames)
+}
+
+func (b *Bus) add(name string, handler Handler, async bool, eventNames []string) {
+	sub := &subscription{
+		name:    name,
+		handler: handler,
+		async:   async,
+		events:  make(map[string]bool, len(eventNames)),
+	}
+	for _, eventName := range eventNames {
+		sub.events[eventName] = true
+	}
+
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, sub)
+	b.mu.Unlock()
+}
+
+// Publish delivers events to all matching subscribers. Errors returned by
+// synchronous handlers are joined and returned; asynchronous handler errors
+// are only logged.
+func (b *Bus) Publish(events ...entities.DomainEvent) error {
+	b.mu.RLock()
+	subs := make([]*subscription, len(b.subscriptions))
+	copy(subs, b.subscriptions)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, event := range events {
+		for _, sub := range subs {
+			if !sub.matches(event.EventName()) {
+				continue
+			}
+
+			if sub.async {
+				b.wg.Add(1)
+				go func(sub *subscription, event entities.DomainEvent) {
+					defer b.wg.Done()
+					if err := b.dispatch(sub, event); err != nil {
+						b.logger.Error("Async event handler failed",
+							logger.Field("subscriber", sub.name),
+							logger.Field("event", event.EventName()),
+							logger.Field("error", err.Error()),
+						)
+					}
+				}(sub, event)
+				continue
+			}
+
+			if err := b.dispatch(sub, event); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Wait blocks until all in-flight asynchronous handlers have returned
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
+
+// dispatch invokes a single handler, converting a panic into an error
+func (b *Bus) dispatch(sub *subscription, event entities.DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Event handler panicked",
+				logger.Field("subscriber", sub.name),
+				logger.Field("event", event.EventName()),
+				logger.Field("error", r),
+				logger.Field("stack", string(debug.Stack())),
+			)
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return sub.handler(event)
+}
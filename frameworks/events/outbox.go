@@ -0,0 +1,224 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/logger"
+	"gocleanarchitecture/interfaces"
+)
+
+// eventTypes maps event names to constructors used when decoding outbox rows
+var eventTypes = map[string]func() entities.DomainEvent{
+	entities.EventPostPublished:    func() entities.DomainEvent { return &entities.PostPublished{} },
+	entities.EventPostUpdated:      func() entities.DomainEvent { return &entities.PostUpdated{} },
+	entities.EventPostDeleted:      func() entities.DomainEvent { return &entities.PostDeleted{} },
+	entities.EventCommentCreated:   func() entities.DomainEvent { return &entities.CommentCreated{} },
+	entities.EventCommentUpdated:   func() entities.DomainEvent { return &entities.CommentUpdated{} },
+	entities.EventCommentDeleted:   func() entities.DomainEvent { return &entities.CommentDeleted{} },
+	entities.EventUserRoleChanged:  func() entities.DomainEvent { return &entities.UserRoleChanged{} },
+	entities.EventUserDeleted:      func() entities.DomainEvent { return &entities.UserDeleted{} },
+	entities.EventContentModerated: func() entities.DomainEvent { return &entities.ContentModerated{} },
+}
+
+// Encode serializes a domain event into an outbox message
+func Encode(event entities.DomainEvent) (*interfaces.OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", event.EventName(), err)
+	}
+
+	now := time.Now()
+	return &interfaces.OutboxMessage{
+		ID:            event.EventID(),
+		EventName:     event.EventName(),
+		Payload:       payload,
+		Status:        interfaces.OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Decode rebuilds the typed domain event stored in an outbox message
+func Decode(msg *interfaces.OutboxMessage) (entities.DomainEvent, error) {
+	newEvent, ok := eventTypes[msg.EventName]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", msg.EventName)
+	}
+
+	event := newEvent()
+	if err := json.Unmarshal(msg.Payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", msg.EventName, err)
+	}
+	return event, nil
+}
+
+// OutboxPublisher writes events to the outbox instead of delivering them.
+// Bound to a transactional outbox writer, the events are committed
+// atomically with the entity change that raised them.
+type OutboxPublisher struct {
+	outbox interfaces.OutboxWriter
+}
+
+func NewOutboxPublisher(outbox interfaces.OutboxWriter) *OutboxPublisher {
+	return &OutboxPublisher{outbox: outbox}
+}
+
+func (p *OutboxPublisher) Publish(events ...entities.DomainEvent) error {
+	messages := make([]*interfaces.OutboxMessage, 0, len(events))
+	for _, event := range events {
+		msg, err := Encode(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, msg)
+	}
+	return p.outbox.Append(messages...)
+}
+
+// Dispatcher delivers decoded events to subscribers
+type Dispatcher interface {
+	Publish(events ...entities.DomainEvent) error
+}
+
+// RelayConfig controls how the outbox relay polls and retries
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration // how long delivered rows are kept
+}
+
+// DefaultRelayConfig returns sensible defaults for the outbox relay
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+		Retention:    24 * time.Hour,
+	}
+}
+
+// Relay moves events from the outbox to subscribers. A message is only
+// marked delivered after every synchronous subscriber accepted it, so delivery
+// is at-least-once; subscribers should use the event ID to drop duplicates.
+type Relay struct {
+	outbox     interfaces.OutboxRepository
+	dispatcher Dispatcher
+	logger     logger.Logger
+	config     RelayConfig
+}
+
+func NewRelay(outbox interfaces.OutboxRepository, dispatcher Dispatcher, log logger.Logger, config RelayConfig) *Relay {
+	return &Relay{
+		outbox:     outbox,
+		dispatcher: dispatcher,
+		logger:     log,
+		config:     config,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+	for {
+		r.ProcessBatch()
+
+		if r.config.Retention > 0 && time.Since(lastPrune) > r.config.Retention/24 {
+			if err := r.outbox.DeleteDeliveredBefore(time.Now().Add(-r.config.Retention)); err != nil {
+				r.logger.Error("Failed to prune outbox", logger.Field("error", err.Error()))
+			}
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch delivers one batch of due messages and returns how many were
+// delivered successfully
+func (r *Relay) ProcessBatch() int {
+	messages, err := r.outbox.FetchPending(r.config.BatchSize, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to fetch outbox messages", logger.Field("error", err.Error()))
+		return 0
+	}
+
+	delivered := 0
+	for _, msg := range messages {
+		if err := r.deliver(msg); err != nil {
+			r.fail(msg, err)
+			continue
+		}
+		if err := r.outbox.MarkDelivered(msg.ID, time.Now()); err != nil {
+			r.logger.Error("Failed to mark outbox message delivered",
+				logger.Field("id", msg.ID),
+				logger.Field("error", err.Error()),
+			)
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+func (r *Relay) deliver(msg *interfaces.OutboxMessage) error {
+	event, err := Decode(msg)
+	if err != nil {
+		return err
+	}
+	return r.dispatcher.Publish(event)
+}
+
+func (r *Relay) fail(msg *interfaces.OutboxMessage, deliveryErr error) {
+	attempts := msg.Attempts + 1
+	if attempts >= r.config.MaxAttempts {
+		r.logger.Error("Outbox message moved to dead letter",
+			logger.Field("id", msg.ID),
+			logger.Field("event", msg.EventName),
+			logger.Field("attempts", attempts),
+			logger.Field("error", deliveryErr.Error()),
+		)
+		if err := r.outbox.MarkDead(msg.ID, deliveryErr.Error()); err != nil {
+			r.logger.Error("Failed to mark outbox message dead", logger.Field("id", msg.ID), logger.Field("error", err.Error()))
+		}
+		return
+	}
+
+	r.logger.Warn("Outbox delivery failed, will retry",
+		logger.Field("id", msg.ID),
+		logger.Field("event", msg.EventName),
+		logger.Field("attempts", attempts),
+		logger.Field("error", deliveryErr.Error()),
+	)
+	next := time.Now().Add(r.backoff(attempts))
+	if err := r.outbox.MarkFailed(msg.ID, deliveryErr.Error(), next); err != nil {
+		r.logger.Error("Failed to record outbox failure", logger.Field("id", msg.ID), logger.Field("error", err.Error()))
+	}
+}
+
+// backoff doubles the wait after each failed attempt, up to MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+	return d
+}
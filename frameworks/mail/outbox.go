@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gocleanarchitecture/usecases"
+
+	"github.com/google/uuid"
+)
+
+// InMemoryOutbox keeps sent messages in memory instead of delivering them,
+// for tests
+type InMemoryOutbox struct {
+	messages []usecases.EmailMessage
+	mu       sync.RWMutex
+}
+
+func NewInMemoryOutbox() *InMemoryOutbox {
+	return &InMemoryOutbox{}
+}
+
+func (o *InMemoryOutbox) Send(message usecases.EmailMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (o *InMemoryOutbox) Messages() []usecases.EmailMessage {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return append([]usecases.EmailMessage(nil), o.messages...)
+}
+
+// FileOutbox writes each message to a .eml file in a directory instead of
+// delivering it, for development. Most mail clients open .eml files.
+type FileOutbox struct {
+	Dir  string
+	From string
+}
+
+func NewFileOutbox(dir, from string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	return &FileOutbox{Dir: dir, From: from}, nil
+}
+
+func (o *FileOutbox) Send(message usecases.EmailMessage) error {
+	now := time.Now()
+	data, err := formatMessage(o.From, message, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405Z"), uuid.New().String()[:8])
+	if err := os.WriteFile(filepath.Join(o.Dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
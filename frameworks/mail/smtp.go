@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"gocleanarchitecture/usecases"
+)
+
+// SMTPMailer sends email through an SMTP server. STARTTLS is used when the
+// server offers it; net/smtp refuses to send credentials over an unencrypted
+// connection to anything but localhost.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string // empty to send without authentication
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(message usecases.EmailMessage) error {
+	data, err := formatMessage(m.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	if err := smtp.SendMail(addr, auth, addressOf(m.From), []string{message.To}, data); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -8,8 +8,20 @@ import (
 	"net/http"
 )
 
-// AdminMiddlewareFunc creates a middleware that ensures the user has admin role
-func AdminMiddlewareFunc(userRepo interfaces.UserRepository) func(http.Handler) http.Handler {
+// PermissionChecker resolves the permissions granted by a role
+type PermissionChecker interface {
+	RoleHasPermission(role entities.UserRole, permission entities.Permission) (bool, error)
+	// EffectiveRole returns the role a user acts with, which is the user role
+	// while their own role requires a second factor they haven't set up
+	EffectiveRole(user *entities.User) (entities.UserRole, error)
+}
+
+// AdminMiddlewareFunc creates a middleware that ensures the user's role grants
+// the user:manage permission. The role claim set by AuthMiddleware is trusted
+// when present, since tokens carry the effective role; otherwise (no
+// revocation store, or a token issued before roles were added to tokens) the
+// user is looked up.
+func AdminMiddlewareFunc(userRepo interfaces.UserRepository, permissions PermissionChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// User ID should already be in context from AuthMiddleware
@@ -22,8 +34,36 @@ func AdminMiddlewareFunc(userRepo interfaces.UserRepository) func(http.Handler)
 				return
 			}
 
-			// Fetch user from repository to check role
-			user, err := userRepo.FindByID(userID)
+			role, ok := r.Context().Value("userRole").(entities.UserRole)
+			if !ok {
+				// Fetch user from repository to check role
+				user, err := userRepo.FindByID(userID)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "Failed to verify user permissions",
+					})
+					return
+				}
+
+				if user == nil {
+					w.WriteHeader(http.StatusUnauthorized)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "User not found",
+					})
+					return
+				}
+				role, err = permissions.EffectiveRole(user)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "Failed to verify user permissions",
+					})
+					return
+				}
+			}
+
+			allowed, err := permissions.RoleHasPermission(role, entities.PermissionUserManage)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(map[string]string{
@@ -31,17 +71,7 @@ func AdminMiddlewareFunc(userRepo interfaces.UserRepository) func(http.Handler)
 				})
 				return
 			}
-
-			if user == nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(map[string]string{
-					"error": "User not found",
-				})
-				return
-			}
-
-			// Check if user has admin role
-			if user.Role != entities.RoleAdmin {
+			if !allowed {
 				w.WriteHeader(http.StatusForbidden)
 				json.NewEncoder(w).Encode(map[string]string{
 					"error": "Admin privileges required",
@@ -50,7 +80,7 @@ func AdminMiddlewareFunc(userRepo interfaces.UserRepository) func(http.Handler)
 			}
 
 			// Add user role to context for controllers to use
-			ctx := context.WithValue(r.Context(), "userRole", user.Role)
+			ctx := context.WithValue(r.Context(), "userRole", role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
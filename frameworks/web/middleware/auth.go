@@ -2,23 +2,137 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"gocleanarchitecture/entities"
 	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/interfaces"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 )
 
+// APITokenAuthenticator resolves a personal API token to the token and the
+// user it acts for
+type APITokenAuthenticator interface {
+	AuthenticateAPIToken(value string) (*entities.APIToken, *entities.User, error)
+}
+
+// OAuthClientChecker reports whether a third-party app is still registered
+type OAuthClientChecker interface {
+	ClientExists(id string) (bool, error)
+}
+
 type AuthMiddleware struct {
-	jwtManager *auth.JWTManager
+	jwtManager   *auth.JWTManager
+	revocations  interfaces.TokenRevocationRepository
+	apiTokens    APITokenAuthenticator
+	oauthClients OAuthClientChecker
+}
+
+// AuthConfig holds the middleware's optional checks
+type AuthConfig struct {
+	Revocations  interfaces.TokenRevocationRepository // nil: tokens are valid until they expire
+	APITokens    APITokenAuthenticator                // nil rejects personal API tokens
+	OAuthClients OAuthClientChecker                   // nil: a deleted app's tokens work until they expire
+}
+
+// NewAuthMiddleware creates the middleware; revocations may be nil, in which
+// case tokens are valid until they expire
+func NewAuthMiddleware(jwtManager *auth.JWTManager, revocations interfaces.TokenRevocationRepository) *AuthMiddleware {
+	return &AuthMiddleware{
+		jwtManager:  jwtManager,
+		revocations: revocations,
+	}
+}
+
+// NewAuthMiddlewareWithAPITokens creates a middleware that also accepts
+// personal API tokens, on routes wrapped with RequireScope
+func NewAuthMiddlewareWithAPITokens(jwtManager *auth.JWTManager, revocations interfaces.TokenRevocationRepository, apiTokens APITokenAuthenticator) *AuthMiddleware {
+	return NewAuthMiddlewareWithConfig(jwtManager, AuthConfig{Revocations: revocations, APITokens: apiTokens})
 }
 
-func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
+// NewAuthMiddlewareWithConfig creates a middleware with the given checks
+func NewAuthMiddlewareWithConfig(jwtManager *auth.JWTManager, config AuthConfig) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtManager: jwtManager,
+		jwtManager:   jwtManager,
+		revocations:  config.Revocations,
+		apiTokens:    config.APITokens,
+		oauthClients: config.OAuthClients,
 	}
 }
 
+var errTokenRevoked = errors.New("token has been revoked")
+
+// checkClient rejects tokens issued to a third-party app that has since been
+// deleted
+func (a *AuthMiddleware) checkClient(claims *auth.Claims) error {
+	if a.oauthClients == nil || claims.ClientID == "" {
+		return nil
+	}
+	exists, err := a.oauthClients.ClientExists(claims.ClientID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errTokenRevoked
+	}
+	return nil
+}
+
+// checkRevocation rejects tokens revoked individually (logout) or issued
+// before the user's token generation was bumped (logout-all, password or
+// role change)
+func (a *AuthMiddleware) checkRevocation(claims *auth.Claims) error {
+	if a.revocations == nil {
+		return nil
+	}
+
+	if claims.ID != "" {
+		revoked, err := a.revocations.IsRevoked(claims.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errTokenRevoked
+		}
+	}
+
+	generation, err := a.revocations.GetUserGeneration(claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.Generation < generation {
+		return errTokenRevoked
+	}
+	return nil
+}
+
+// withClaims adds the token's user info to the request context. The role claim
+// is only passed on when the token's generation was checked: every role change
+// bumps the generation, so a token that passed the check carries the user's
+// current role.
+func (a *AuthMiddleware) withClaims(r *http.Request, claims *auth.Claims) *http.Request {
+	ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+	ctx = context.WithValue(ctx, "username", claims.Username)
+	ctx = context.WithValue(ctx, "email", claims.Email)
+	ctx = context.WithValue(ctx, "tokenID", claims.ID)
+	ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+	if claims.ExpiresAt != nil {
+		ctx = context.WithValue(ctx, "tokenExpiresAt", claims.ExpiresAt.Time)
+	}
+	if claims.ClientID != "" {
+		ctx = context.WithValue(ctx, "oauthClientID", claims.ClientID)
+		if scopes, err := entities.ParseScopes(claims.Scope); err == nil {
+			ctx = context.WithValue(ctx, "tokenScopes", scopes)
+		}
+	}
+	if a.revocations != nil && claims.Role != "" {
+		ctx = context.WithValue(ctx, "userRole", entities.UserRole(claims.Role))
+	}
+	return r.WithContext(ctx)
+}
+
 // Authenticate middleware validates JWT tokens and adds user info to context
 func (a *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -38,6 +152,11 @@ func (a *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 
 		token := parts[1]
 
+		if a.apiTokens != nil && strings.HasPrefix(token, entities.APITokenPrefix) {
+			a.authenticateAPIToken(w, r, token, next)
+			return
+		}
+
 		// Validate token
 		claims, err := a.jwtManager.ValidateToken(token)
 		if err != nil {
@@ -45,21 +164,161 @@ func (a *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add user info to request context
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "username", claims.Username)
-		ctx = context.WithValue(ctx, "email", claims.Email)
+		// Reject revoked tokens, and those of deleted third-party apps
+		err = a.checkRevocation(claims)
+		if err == nil {
+			err = a.checkClient(claims)
+		}
+		if err != nil {
+			if errors.Is(err, errTokenRevoked) {
+				http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "Failed to verify token", http.StatusInternalServerError)
+			return
+		}
+
+		// Tokens issued to third-party apps are limited to their scopes
+		if claims.ClientID != "" && !checkScope(w, r, strings.Fields(claims.Scope)) {
+			return
+		}
 
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
+		// Call next handler with user info in the request context
+		next.ServeHTTP(w, a.withClaims(r, claims))
 	})
 }
 
+// authenticateAPIToken admits a personal API token to routes that require a
+// scope it has
+func (a *AuthMiddleware) authenticateAPIToken(w http.ResponseWriter, r *http.Request, value string, next http.Handler) {
+	token, user, err := a.apiTokens.AuthenticateAPIToken(value)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			http.Error(w, "Failed to verify token", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return
+	}
+
+	scopes := make([]string, len(token.Scopes))
+	for i, scope := range token.Scopes {
+		scopes[i] = string(scope)
+	}
+	if !checkScope(w, r, scopes) {
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), "userID", user.ID)
+	ctx = context.WithValue(ctx, "username", user.Username)
+	ctx = context.WithValue(ctx, "email", user.Email)
+	ctx = context.WithValue(ctx, "apiTokenID", token.ID)
+	ctx = context.WithValue(ctx, "tokenScopes", token.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// checkScope admits a scoped token (a personal API token or a third-party
+// app's token) to a route that requires one of its scopes, answering 403
+// otherwise. Routes without a scope are for signed-in users only, so that
+// such tokens can't manage sessions, tokens or the account.
+func checkScope(w http.ResponseWriter, r *http.Request, scopes []string) bool {
+	required := requiredScope(r)
+	if required == "" {
+		http.Error(w, "This token can't be used for this endpoint", http.StatusForbidden)
+		return false
+	}
+	for _, scope := range scopes {
+		if scope == string(required) {
+			return true
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(required)+`"`)
+	http.Error(w, "Token lacks the "+string(required)+" scope", http.StatusForbidden)
+	return false
+}
+
+// scopedHandler is a route handler that scoped tokens may call when they have
+// its scope
+type scopedHandler struct {
+	scope   entities.Scope
+	handler http.HandlerFunc
+}
+
+func (h scopedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler(w, r)
+}
+
+// RequireScope marks a route as open to personal API tokens and third-party
+// apps with the given scope. Authenticate checks the scope of the matched route; signed-in users
+// aren't affected.
+func RequireScope(scope entities.Scope, handler http.HandlerFunc) http.Handler {
+	return scopedHandler{scope: scope, handler: handler}
+}
+
+// requiredScope returns the scope of the matched route, or "" when it isn't
+// open to scoped tokens
+func requiredScope(r *http.Request) entities.Scope {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	if handler, ok := route.GetHandler().(scopedHandler); ok {
+		return handler.scope
+	}
+	return ""
+}
+
+// AuthMiddlewareFuncWithConfig returns a mux middleware with the given checks
+func AuthMiddlewareFuncWithConfig(jwtManager *auth.JWTManager, config AuthConfig) mux.MiddlewareFunc {
+	middleware := NewAuthMiddlewareWithConfig(jwtManager, config)
+	return func(next http.Handler) http.Handler {
+		return middleware.Authenticate(next)
+	}
+}
+
 // Optional middleware function that returns a mux middleware
-func AuthMiddlewareFunc(jwtManager *auth.JWTManager) mux.MiddlewareFunc {
-	middleware := NewAuthMiddleware(jwtManager)
+func AuthMiddlewareFunc(jwtManager *auth.JWTManager, revocations interfaces.TokenRevocationRepository) mux.MiddlewareFunc {
+	middleware := NewAuthMiddleware(jwtManager, revocations)
+	return func(next http.Handler) http.Handler {
+		return middleware.Authenticate(next)
+	}
+}
+
+// AuthMiddlewareFuncWithAPITokens returns a mux middleware that also accepts
+// personal API tokens
+func AuthMiddlewareFuncWithAPITokens(jwtManager *auth.JWTManager, revocations interfaces.TokenRevocationRepository, apiTokens APITokenAuthenticator) mux.MiddlewareFunc {
+	middleware := NewAuthMiddlewareWithAPITokens(jwtManager, revocations, apiTokens)
 	return func(next http.Handler) http.Handler {
 		return middleware.Authenticate(next)
 	}
 }
 
+// AuthenticateOptional adds user info to the context when a valid token is
+// supplied and otherwise continues anonymously. Browsers can't set headers on
+// WebSocket handshakes, so the token may also be passed as ?token=.
+func (a *AuthMiddleware) AuthenticateOptional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if parts := strings.Split(r.Header.Get("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+
+		// Third-party apps' tokens aren't scoped for this, so they connect
+		// anonymously
+		if token != "" {
+			if claims, err := a.jwtManager.ValidateToken(token); err == nil && claims.ClientID == "" && a.checkRevocation(claims) == nil {
+				r = a.withClaims(r, claims)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// OptionalAuthMiddlewareFunc returns a mux middleware that authenticates when possible
+func OptionalAuthMiddlewareFunc(jwtManager *auth.JWTManager, revocations interfaces.TokenRevocationRepository) mux.MiddlewareFunc {
+	middleware := NewAuthMiddleware(jwtManager, revocations)
+	return func(next http.Handler) http.Handler {
+		return middleware.AuthenticateOptional(next)
+	}
+}
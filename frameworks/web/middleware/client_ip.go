@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ClientIPMiddleware stores the client's address in the context as
+// "clientIP", for the login lockout, sessions, the audit log, request logs
+// and the WebSocket connection cap. Behind a reverse proxy every connection
+// comes from the proxy, so with trustProxy set the address is the last one in
+// X-Forwarded-For, which the proxy appended; the ones before it are whatever
+// the client sent. Only set it when a proxy in front of the server always
+// sets the header.
+func ClientIPMiddleware(trustProxy bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "clientIP", clientIP(r, trustProxy))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1])); ip != nil {
+			return ip.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
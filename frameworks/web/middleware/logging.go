@@ -25,7 +25,8 @@ func LoggingMiddleware(log logger.Logger) mux.MiddlewareFunc {
 				logger.LogField{Key: "path", Value: r.URL.Path},
 				logger.LogField{Key: "status", Value: rw.status},
 				logger.LogField{Key: "duration", Value: time.Since(start).String()},
-				logger.LogField{Key: "ip", Value: r.RemoteAddr},
+				logger.LogField{Key: "ip", Value: r.Context().Value("clientIP")},
+				logger.LogField{Key: "request_id", Value: r.Context().Value("requestID")},
 			)
 		})
 	}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware tags every request with an ID, keeping a valid
+// X-Request-ID set by a proxy or the client. The ID is echoed in the response
+// and stored in the context as "requestID" for logs and the audit log.
+func RequestIDMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-ID")
+			if !validRequestID(requestID) {
+				requestID = uuid.New().String()
+			}
+
+			w.Header().Set("X-Request-ID", requestID)
+			ctx := context.WithValue(r.Context(), "requestID", requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII characters, so a
+// client can't inject line breaks into logs or CSV exports
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
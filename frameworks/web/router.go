@@ -1,6 +1,8 @@
 package web
 
 import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
 	"gocleanarchitecture/frameworks/auth"
 	"gocleanarchitecture/frameworks/logger"
 	"gocleanarchitecture/frameworks/web/middleware"
@@ -11,43 +13,84 @@ import (
 )
 
 type RouterConfig struct {
-	BlogPostController *interfaces.BlogPostController
-	AuthController     *interfaces.AuthController
-	AdminController    *interfaces.AdminController
-	CommentController  *interfaces.CommentController
-	WebSocketHandler   *interfaces.WebSocketHandler
-	OAuth2Controller   *interfaces.OAuth2Controller
-	UserRepo           interfaces.UserRepository
-	JWTManager         *auth.JWTManager
-	Logger             logger.Logger
+	BlogPostController     *interfaces.BlogPostController
+	AuthController         *interfaces.AuthController
+	AdminController        *interfaces.AdminController
+	CommentController      *interfaces.CommentController
+	WebSocketHandler       *interfaces.WebSocketHandler
+	OAuth2Controller       *interfaces.OAuth2Controller
+	OAuthServerController  *interfaces.OAuthServerController // nil disables the authorization server
+	NotificationController *interfaces.NotificationController
+	ModerationController   *interfaces.ModerationController
+	AuditController        *interfaces.AuditController
+	UserRepo               interfaces.UserRepository
+	JWTManager             *auth.JWTManager
+	Revocations            interfaces.TokenRevocationRepository
+	APITokens              middleware.APITokenAuthenticator // nil rejects personal API tokens
+	OAuthClients           middleware.OAuthClientChecker    // nil lets a deleted app's tokens work until they expire
+	Permissions            middleware.PermissionChecker
+	TrustProxy             bool // Take the client IP from X-Forwarded-For
+	Logger                 logger.Logger
 }
 
 func NewRouter(config *RouterConfig) *mux.Router {
 	router := mux.NewRouter()
 
 	// Add global middleware
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.ClientIPMiddleware(config.TrustProxy))
 	router.Use(middleware.LoggingMiddleware(config.Logger))
 	router.Use(middleware.RecoveryMiddleware(config.Logger))
 
+	// Accepts access tokens, and personal API tokens on routes wrapped with
+	// RequireScope
+	authenticate := middleware.AuthMiddlewareFuncWithConfig(config.JWTManager, middleware.AuthConfig{
+		Revocations:  config.Revocations,
+		APITokens:    config.APITokens,
+		OAuthClients: config.OAuthClients,
+	})
+
 	// Auth routes (public - no authentication required)
 	authRouter := router.PathPrefix("/auth").Subrouter()
 	authRouter.HandleFunc("/register", config.AuthController.Register).Methods("POST")
 	authRouter.HandleFunc("/login", config.AuthController.Login).Methods("POST")
+	authRouter.HandleFunc("/refresh", config.AuthController.Refresh).Methods("POST")
+	authRouter.HandleFunc("/mfa/verify", config.AuthController.VerifyMFA).Methods("POST")
+	authRouter.HandleFunc("/verify-email", config.AuthController.VerifyEmail).Methods("POST")
+	authRouter.HandleFunc("/password-reset/request", config.AuthController.RequestPasswordReset).Methods("POST")
+	authRouter.HandleFunc("/password-reset", config.AuthController.ResetPassword).Methods("POST")
 	authRouter.HandleFunc("/users/{username}", config.AuthController.GetUserByUsername).Methods("GET")
 
 	// Protected auth routes (requires authentication)
 	protectedAuthRouter := router.PathPrefix("/auth").Subrouter()
-	protectedAuthRouter.Use(middleware.AuthMiddlewareFunc(config.JWTManager))
-	protectedAuthRouter.HandleFunc("/profile", config.AuthController.GetProfile).Methods("GET")
-	protectedAuthRouter.HandleFunc("/profile", config.AuthController.UpdateProfile).Methods("PUT")
+	protectedAuthRouter.Use(authenticate)
+	protectedAuthRouter.Handle("/profile", middleware.RequireScope(entities.ScopeProfileRead, config.AuthController.GetProfile)).Methods("GET")
+	protectedAuthRouter.Handle("/profile", middleware.RequireScope(entities.ScopeProfileWrite, config.AuthController.UpdateProfile)).Methods("PUT")
 	protectedAuthRouter.HandleFunc("/change-password", config.AuthController.ChangePassword).Methods("POST")
-
-	// OAuth2 routes (public - no authentication required)
+	protectedAuthRouter.HandleFunc("/logout", config.AuthController.Logout).Methods("POST")
+	protectedAuthRouter.HandleFunc("/logout-all", config.AuthController.LogoutAll).Methods("POST")
+	protectedAuthRouter.HandleFunc("/sessions", config.AuthController.ListSessions).Methods("GET")
+	protectedAuthRouter.HandleFunc("/sessions/{id}", config.AuthController.RevokeSession).Methods("DELETE")
+	protectedAuthRouter.HandleFunc("/mfa", config.AuthController.GetMFAStatus).Methods("GET")
+	protectedAuthRouter.HandleFunc("/mfa/enroll", config.AuthController.EnrollMFA).Methods("POST")
+	protectedAuthRouter.HandleFunc("/mfa/confirm", config.AuthController.ConfirmMFA).Methods("POST")
+	protectedAuthRouter.HandleFunc("/mfa/disable", config.AuthController.DisableMFA).Methods("POST")
+	protectedAuthRouter.HandleFunc("/verify-email/resend", config.AuthController.ResendVerificationEmail).Methods("POST")
+	protectedAuthRouter.HandleFunc("/identities", config.AuthController.ListIdentities).Methods("GET")
+	protectedAuthRouter.HandleFunc("/identities/{provider}", config.AuthController.UnlinkIdentity).Methods("DELETE")
+	protectedAuthRouter.HandleFunc("/tokens", config.AuthController.ListAPITokens).Methods("GET")
+	protectedAuthRouter.HandleFunc("/tokens", config.AuthController.CreateAPIToken).Methods("POST")
+	protectedAuthRouter.HandleFunc("/tokens/{id}", config.AuthController.RevokeAPIToken).Methods("DELETE")
+
+	// OAuth2 and OpenID Connect routes (public - no authentication required)
 	if config.OAuth2Controller != nil {
-		authRouter.HandleFunc("/google", config.OAuth2Controller.InitiateGoogleLogin).Methods("GET")
-		authRouter.HandleFunc("/google/callback", config.OAuth2Controller.GoogleCallback).Methods("GET")
-		authRouter.HandleFunc("/github", config.OAuth2Controller.InitiateGitHubLogin).Methods("GET")
-		authRouter.HandleFunc("/github/callback", config.OAuth2Controller.GitHubCallback).Methods("GET")
+		authRouter.HandleFunc("/providers", config.OAuth2Controller.ListProviders).Methods("GET")
+		authRouter.HandleFunc("/oauth/exchange", config.OAuth2Controller.ExchangeCode).Methods("POST")
+		authRouter.HandleFunc("/{provider:google|github}", config.OAuth2Controller.InitiateLogin).Methods("GET")
+		authRouter.HandleFunc("/{provider:google|github}/callback", config.OAuth2Controller.Callback).Methods("GET")
+		authRouter.HandleFunc("/oidc/{provider}", config.OAuth2Controller.InitiateLogin).Methods("GET")
+		authRouter.HandleFunc("/oidc/{provider}/callback", config.OAuth2Controller.Callback).Methods("GET")
+		protectedAuthRouter.HandleFunc("/identities/{provider}", config.OAuth2Controller.BeginIdentityLink).Methods("POST")
 	}
 
 	// Blog post routes (public for reading, protected for writing)
@@ -56,19 +99,31 @@ func NewRouter(config *RouterConfig) *mux.Router {
 
 	// Protected blog post routes
 	protectedBlogRouter := router.PathPrefix("/blogposts").Subrouter()
-	protectedBlogRouter.Use(middleware.AuthMiddlewareFunc(config.JWTManager))
-	protectedBlogRouter.HandleFunc("", config.BlogPostController.CreateBlogPost).Methods("POST")
-	protectedBlogRouter.HandleFunc("/{id}", config.BlogPostController.UpdateBlogPost).Methods("PUT")
-	protectedBlogRouter.HandleFunc("/{id}", config.BlogPostController.DeleteBlogPost).Methods("DELETE")
+	protectedBlogRouter.Use(authenticate)
+	protectedBlogRouter.Handle("", middleware.RequireScope(entities.ScopePostsWrite, config.BlogPostController.CreateBlogPost)).Methods("POST")
+	protectedBlogRouter.Handle("/{id}", middleware.RequireScope(entities.ScopePostsWrite, config.BlogPostController.UpdateBlogPost)).Methods("PUT")
+	protectedBlogRouter.Handle("/{id}", middleware.RequireScope(entities.ScopePostsWrite, config.BlogPostController.DeleteBlogPost)).Methods("DELETE")
 
 	// Admin routes (requires authentication + admin role)
 	adminRouter := router.PathPrefix("/admin").Subrouter()
-	adminRouter.Use(middleware.AuthMiddlewareFunc(config.JWTManager))
-	adminRouter.Use(middleware.AdminMiddlewareFunc(config.UserRepo))
+	adminRouter.Use(authenticate)
+	adminRouter.Use(middleware.AdminMiddlewareFunc(config.UserRepo, config.Permissions))
 	adminRouter.HandleFunc("/users", config.AdminController.GetAllUsers).Methods("GET")
 	adminRouter.HandleFunc("/users/{id}", config.AdminController.GetUserDetails).Methods("GET")
 	adminRouter.HandleFunc("/users/{id}/role", config.AdminController.UpdateUserRole).Methods("PUT")
 	adminRouter.HandleFunc("/users/{id}", config.AdminController.DeleteUser).Methods("DELETE")
+	adminRouter.HandleFunc("/users/{id}/unlock", config.AdminController.UnlockUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/sessions", config.AdminController.GetUserSessions).Methods("GET")
+	adminRouter.HandleFunc("/users/{id}/sessions/{sessionId}", config.AdminController.RevokeUserSession).Methods("DELETE")
+	adminRouter.HandleFunc("/roles", config.AdminController.ListRoles).Methods("GET")
+	adminRouter.HandleFunc("/roles", config.AdminController.CreateRole).Methods("POST")
+	adminRouter.HandleFunc("/roles/{name}", config.AdminController.UpdateRole).Methods("PUT")
+	adminRouter.HandleFunc("/roles/{name}", config.AdminController.DeleteRole).Methods("DELETE")
+	adminRouter.HandleFunc("/mfa/required-roles", config.AdminController.ListMFARequiredRoles).Methods("GET")
+	adminRouter.HandleFunc("/mfa/required-roles/{name}", config.AdminController.SetRoleMFARequired).Methods("PUT")
+	adminRouter.HandleFunc("/moderation", config.ModerationController.ListActions).Methods("GET")
+	adminRouter.HandleFunc("/audit", config.AuditController.ListEvents).Methods("GET")
+	adminRouter.HandleFunc("/ws/stats", config.WebSocketHandler.GetHubStats).Methods("GET")
 
 	// Comment routes (public for reading, protected for writing)
 	router.HandleFunc("/blogposts/{blogPostId}/comments", config.CommentController.GetCommentsByBlogPost).Methods("GET")
@@ -76,13 +131,44 @@ func NewRouter(config *RouterConfig) *mux.Router {
 
 	// Protected comment routes
 	protectedCommentRouter := router.PathPrefix("").Subrouter()
-	protectedCommentRouter.Use(middleware.AuthMiddlewareFunc(config.JWTManager))
-	protectedCommentRouter.HandleFunc("/blogposts/{blogPostId}/comments", config.CommentController.CreateComment).Methods("POST")
-	protectedCommentRouter.HandleFunc("/comments/{commentId}", config.CommentController.UpdateComment).Methods("PUT")
-	protectedCommentRouter.HandleFunc("/comments/{commentId}", config.CommentController.DeleteComment).Methods("DELETE")
+	protectedCommentRouter.Use(authenticate)
+	protectedCommentRouter.Handle("/blogposts/{blogPostId}/comments", middleware.RequireScope(entities.ScopeCommentsWrite, config.CommentController.CreateComment)).Methods("POST")
+	protectedCommentRouter.Handle("/comments/{commentId}", middleware.RequireScope(entities.ScopeCommentsWrite, config.CommentController.UpdateComment)).Methods("PUT")
+	protectedCommentRouter.Handle("/comments/{commentId}", middleware.RequireScope(entities.ScopeCommentsWrite, config.CommentController.DeleteComment)).Methods("DELETE")
+
+	// Notification routes (requires authentication)
+	notificationRouter := router.PathPrefix("/notifications").Subrouter()
+	notificationRouter.Use(authenticate)
+	notificationRouter.Handle("", middleware.RequireScope(entities.ScopeNotificationsRead, config.NotificationController.ListNotifications)).Methods("GET")
+	notificationRouter.Handle("/{id}/read", middleware.RequireScope(entities.ScopeNotificationsWrite, config.NotificationController.MarkRead)).Methods("POST")
+
+	// OAuth2 authorization server for third-party apps: clients call the token
+	// and introspection endpoints; the consent screen calls /oauth/authorize as
+	// the signed-in user
+	if config.OAuthServerController != nil {
+		router.HandleFunc("/oauth/token", config.OAuthServerController.Token).Methods("POST")
+		router.HandleFunc("/oauth/introspect", config.OAuthServerController.Introspect).Methods("POST")
+		consentRouter := router.PathPrefix("/oauth").Subrouter()
+		consentRouter.Use(authenticate)
+		consentRouter.HandleFunc("/authorize", config.OAuthServerController.Authorize).Methods("GET")
+		consentRouter.HandleFunc("/authorize", config.OAuthServerController.Consent).Methods("POST")
+		adminRouter.HandleFunc("/oauth/clients", config.OAuthServerController.ListClients).Methods("GET")
+		adminRouter.HandleFunc("/oauth/clients", config.OAuthServerController.RegisterClient).Methods("POST")
+		adminRouter.HandleFunc("/oauth/clients/{id}", config.OAuthServerController.DeleteClient).Methods("DELETE")
+	}
 
-	// WebSocket endpoint (public - can be accessed by anyone)
-	router.HandleFunc("/ws", config.WebSocketHandler.HandleWebSocket).Methods("GET")
+	// WebSocket endpoint (public - a token is optional and identifies the user)
+	wsRouter := router.PathPrefix("/ws").Subrouter()
+	wsRouter.Use(middleware.OptionalAuthMiddlewareFunc(config.JWTManager, config.Revocations))
+	wsRouter.HandleFunc("", config.WebSocketHandler.HandleWebSocket).Methods("GET")
+	router.HandleFunc("/blogposts/{id}/presence", config.WebSocketHandler.GetPostPresence).Methods("GET")
+
+	// Public keys for verifying access tokens outside this service
+	router.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(config.JWTManager.JWKS())
+	}).Methods("GET")
 
 	// Swagger/API Documentation endpoint
 	router.HandleFunc("/swagger", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+)
+
+// Logger reports broker failures, with key/value pairs after the message.
+// The application's use case logger satisfies it.
+type Logger interface {
+	Error(msg string, fields ...interface{})
+}
+
+// stdLogger writes to the standard logger, for hubs and brokers created
+// without a Logger
+type stdLogger struct{}
+
+func (stdLogger) Error(msg string, fields ...interface{}) {
+	log.Println(append([]interface{}{msg}, fields...)...)
+}
+
+func loggerOrDefault(logger Logger) Logger {
+	if logger == nil {
+		return stdLogger{}
+	}
+	return logger
+}
+
+// Broker carries hub messages between server instances. Every hub publishes
+// its broadcasts to the broker and delivers whatever the broker hands back to
+// its local clients, so a message broadcast on one instance reaches clients
+// connected to any instance.
+type Broker interface {
+	Publish(message *Message) error
+	Subscribe(handler func(message *Message)) (unsubscribe func())
+	Close() error
+}
+
+// brokerEnvelope is how a message travels between processes. It carries the
+// origin, which a message's own JSON leaves out so that it never reaches
+// browsers.
+type brokerEnvelope struct {
+	Message *Message `json:"message"`
+	Origin  string   `json:"origin,omitempty"`
+}
+
+// EncodeBrokerMessage encodes a message for a broker that carries it to other
+// processes
+func EncodeBrokerMessage(message *Message) ([]byte, error) {
+	return json.Marshal(brokerEnvelope{Message: message, Origin: message.origin})
+}
+
+// DecodeBrokerMessage decodes a message encoded by EncodeBrokerMessage
+func DecodeBrokerMessage(payload []byte) (*Message, error) {
+	var envelope brokerEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Message == nil {
+		return nil, errors.New("broker payload has no message")
+	}
+	envelope.Message.origin = envelope.Origin
+	return envelope.Message, nil
+}
+
+// InMemoryBroker fans messages out to every hub in the same process. It is
+// the default for single-instance deployments and is handy in tests.
+type InMemoryBroker struct {
+	handlers map[int]func(message *Message)
+	nextID   int
+	mu       sync.RWMutex
+}
+
+// NewInMemoryBroker creates a new in-process broker
+func NewInMemoryBroker() *InMemoryBroker {
+	return &InMemoryBroker{
+		handlers: make(map[int]func(message *Message)),
+	}
+}
+
+// Publish delivers the message to every subscriber
+func (b *InMemoryBroker) Publish(message *Message) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, handler := range b.handlers {
+		handler(message)
+	}
+	return nil
+}
+
+// Subscribe registers a handler and returns a function that removes it
+func (b *InMemoryBroker) Subscribe(handler func(message *Message)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Close removes all subscribers
+func (b *InMemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = make(map[int]func(message *Message))
+	return nil
+}
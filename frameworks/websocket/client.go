@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -23,15 +24,6 @@ const (
 	maxMessageSize = 512
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	// Allow all origins (in production, configure this properly)
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
 // Client represents a WebSocket client connection
 type Client struct {
 	// The WebSocket hub
@@ -43,24 +35,54 @@ type Client struct {
 	// Buffered channel of outbound messages
 	send chan *Message
 
-	// User ID of the connected client (if authenticated)
-	userID string
+	// Unique ID of this connection
+	id string
+
+	// User ID and username of the connected client (if authenticated)
+	userID   string
+	username string
+
+	// Remote address counted against the per-IP connection cap
+	ip string
+
+	// Inbound frame rate limiter (readPump only)
+	limiter *tokenBucket
+
+	// Topics this client joined and whether the hub dropped it (guarded by hub.mu)
+	topics map[string]bool
+	closed bool
+
+	// Close code sent once send is closed, 0 for a bare close frame (set under hub.mu)
+	closeCode int
+
+	// Last typing frame relayed per topic, for throttling (readPump only)
+	lastTyping map[string]time.Time
 }
 
 // NewClient creates a new client instance
-func NewClient(hub *Hub, conn *websocket.Conn, userID string) *Client {
+func NewClient(hub *Hub, conn *websocket.Conn, userID, username, ip string) *Client {
 	return &Client{
-		hub:    hub,
-		conn:   conn,
-		send:   make(chan *Message, 256),
-		userID: userID,
+		hub:        hub,
+		conn:       conn,
+		send:       make(chan *Message, 256),
+		id:         uuid.New().String(),
+		userID:     userID,
+		username:   username,
+		ip:         ip,
+		limiter:    newTokenBucket(hub.config.MessageRate, hub.config.MessageBurst),
+		topics:     make(map[string]bool),
+		lastTyping: make(map[string]time.Time),
 	}
 }
 
 // readPump pumps messages from the WebSocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.leaveAll(c)
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
 		c.conn.Close()
 	}()
 
@@ -80,6 +102,12 @@ func (c *Client) readPump() {
 			break
 		}
 
+		if !c.limiter.allow() {
+			c.hub.metrics.recordRateLimited()
+			c.closeWith(websocket.ClosePolicyViolation, "rate limit exceeded")
+			break
+		}
+
 		// Parse message (clients can send messages if needed)
 		var msg Message
 		if err := json.Unmarshal(message, &msg); err != nil {
@@ -87,8 +115,31 @@ func (c *Client) readPump() {
 			continue
 		}
 
-		// For now, we don't process client messages
-		// In the future, you could handle client-to-server messages here
+		if err := c.handleMessage(&msg); err != nil {
+			c.hub.sendTo(c, &Message{Type: MessageTypeError, Topic: msg.Topic, Message: err.Error()})
+		}
+	}
+}
+
+// closeWith sends a close frame with the given code. WriteControl may be
+// called concurrently with writePump.
+func (c *Client) closeWith(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+}
+
+// handleMessage processes a client-to-server frame
+func (c *Client) handleMessage(msg *Message) error {
+	switch msg.Type {
+	case MessageTypeJoin:
+		return c.hub.join(c, msg.Topic)
+	case MessageTypeLeave:
+		c.hub.leave(c, msg.Topic)
+		return nil
+	case MessageTypeTyping:
+		return c.hub.typing(c, msg.Topic)
+	default:
+		// Unknown frames are ignored
+		return nil
 	}
 }
 
@@ -98,6 +149,7 @@ func (c *Client) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.writers.Done()
 	}()
 
 	for {
@@ -105,8 +157,12 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				// The hub closed the channel; queued messages were written first
+				closeMessage := []byte{}
+				if c.closeCode != 0 {
+					closeMessage = websocket.FormatCloseMessage(c.closeCode, "server shutting down")
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
@@ -150,22 +206,44 @@ func (c *Client) writePump() {
 }
 
 // ServeWs handles WebSocket requests from peers
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, userID string) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, userID, username string) {
+	// Reserve a slot before upgrading so rejected clients get a plain HTTP error
+	ip := clientIP(r)
+	if hub.isClosing() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if err := hub.connections.acquire(userID, ip, hub.config); err != nil {
+		hub.metrics.recordRejected()
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	conn, err := hub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		hub.connections.release(userID, ip)
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
-	client := NewClient(hub, conn, userID)
-	client.hub.register <- client
+	client := NewClient(hub, conn, userID, username, ip)
+	hub.writers.Add(1)
+	select {
+	case hub.register <- client:
+	case <-hub.done:
+		hub.writers.Done()
+		hub.connections.release(userID, ip)
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+		conn.Close()
+		return
+	}
 
 	// Send connection confirmation
 	welcomeMsg := &Message{
 		Type:    MessageTypeConnection,
 		Message: "Connected to real-time updates",
 	}
-	client.send <- welcomeMsg
+	hub.sendTo(client, welcomeMsg)
 
 	// Allow collection of memory referenced by the caller by doing all work in new goroutines
 	go client.writePump()
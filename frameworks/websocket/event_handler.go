@@ -0,0 +1,16 @@
+package websocket
+
+import "gocleanarchitecture/entities"
+
+// HandleEvent forwards domain events to connected clients. It is registered
+// as a subscriber on the event bus; events without a WebSocket representation
+// are ignored.
+func (h *Hub) HandleEvent(event entities.DomainEvent) error {
+	switch e := event.(type) {
+	case *entities.PostPublished:
+		return h.BroadcastJSON(MessageTypeNewBlogPost, e.Post)
+	case *entities.CommentCreated:
+		return h.BroadcastJSON(MessageTypeNewComment, e.Comment)
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 )
 
 // MessageType represents different types of WebSocket messages
@@ -13,13 +18,33 @@ const (
 	MessageTypeNewComment  MessageType = "new_comment"
 	MessageTypeConnection  MessageType = "connection"
 	MessageTypeError       MessageType = "error"
+
+	// Client-to-server frames
+	MessageTypeJoin   MessageType = "join"
+	MessageTypeLeave  MessageType = "leave"
+	MessageTypeTyping MessageType = "typing"
+
+	// Presence updates sent to topic members
+	MessageTypePresenceJoin  MessageType = "presence_join"
+	MessageTypePresenceLeave MessageType = "presence_leave"
+
+	// Presence reports between instances sharing a broker, never sent to
+	// clients
+	MessageTypePresenceSync        MessageType = "presence_sync"
+	MessageTypePresenceSyncRequest MessageType = "presence_sync_request"
 )
 
 // Message represents a WebSocket message
 type Message struct {
 	Type    MessageType     `json:"type"`
+	Topic   string          `json:"topic,omitempty"` // empty means every client
 	Data    json.RawMessage `json:"data,omitempty"`
 	Message string          `json:"message,omitempty"`
+
+	// origin is the connection that caused the message; it is not echoed back
+	// to that connection. It is never sent to clients; brokers carry it with
+	// EncodeBrokerMessage.
+	origin string
 }
 
 // Hub maintains the set of active clients and broadcasts messages to them
@@ -38,22 +63,96 @@ type Hub struct {
 
 	// Mutex to protect clients map
 	mu sync.RWMutex
+
+	// Optional broker used to reach clients connected to other instances
+	broker      Broker
+	unsubscribe func()
+	logger      Logger
+
+	// Local clients subscribed to each topic
+	topics map[string]map[*Client]bool
+
+	// Who is present on each topic, across all instances sharing the broker.
+	// instanceID tells this hub's connections apart in presence reports.
+	presence   *presenceTracker
+	instanceID string
+
+	// Origin allowlist, connection caps and frame rate limits
+	config      Config
+	upgrader    websocket.Upgrader
+	connections *connectionCounter
+
+	// Delivery and drop counters exposed through Stats
+	metrics *hubMetrics
+
+	// Shutdown state: closing rejects new clients (guarded by mu), stop asks
+	// Run to close every client, done is closed once it has, and writers
+	// tracks the write pumps still flushing their buffers
+	closing bool
+	stop    chan struct{}
+	done    chan struct{}
+	writers sync.WaitGroup
 }
 
-// NewHub creates a new Hub instance
+// NewHub creates a new Hub instance that only reaches clients connected to
+// this process
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan *Message, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+	return NewHubWithConfig(nil, DefaultConfig())
+}
+
+// NewHubWithBroker creates a Hub that publishes broadcasts to the broker and
+// delivers messages received from it, so hubs on different instances sharing
+// the broker reach each other's clients
+func NewHubWithBroker(broker Broker) *Hub {
+	return NewHubWithConfig(broker, DefaultConfig())
+}
+
+// NewHubWithConfig creates a Hub with an optional broker and explicit limits
+func NewHubWithConfig(broker Broker, config Config) *Hub {
+	h := &Hub{
+		clients:     make(map[*Client]bool),
+		broadcast:   make(chan *Message, 256),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		topics:      make(map[string]map[*Client]bool),
+		presence:    newPresenceTracker(0),
+		instanceID:  uuid.New().String(),
+		config:      config,
+		logger:      loggerOrDefault(config.Logger),
+		connections: newConnectionCounter(),
+		metrics:     newHubMetrics(),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
 	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     config.checkOrigin,
+	}
+
+	if broker != nil {
+		h.broker = broker
+		h.unsubscribe = broker.Subscribe(h.enqueue)
+		// Other instances don't announce that their clients left when they
+		// crash, so their readers expire unless they keep reporting them
+		h.presence = newPresenceTracker(presenceExpiryBeats * config.presenceHeartbeat())
+	}
+	return h
 }
 
-// Run starts the hub and handles client registration, unregistration, and broadcasting
+// Run starts the hub and handles client registration, unregistration, and
+// broadcasting until Shutdown is called
 func (h *Hub) Run() {
+	if h.broker != nil {
+		go h.presenceHeartbeat()
+	}
+
 	for {
 		select {
+		case <-h.stop:
+			h.closeAll()
+			return
+
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
@@ -61,32 +160,167 @@ func (h *Hub) Run() {
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-			}
+			h.removeClient(client)
 			h.mu.Unlock()
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					// Client's send channel is full or closed
-					// Close the channel and remove the client
-					close(client.send)
-					delete(h.clients, client)
-				}
-			}
-			h.mu.RUnlock()
+			h.deliver(message)
+		}
+	}
+}
+
+// deliver queues a message for its recipients and drops clients that can't
+// keep up
+func (h *Hub) deliver(message *Message) {
+	h.presence.apply(message, time.Now())
+	switch message.Type {
+	case MessageTypePresenceSync:
+		return
+	case MessageTypePresenceSyncRequest:
+		// Not from the hub loop: with an in-process broker the report comes
+		// straight back to it
+		go h.reportPresence()
+		return
+	}
+
+	var slow []*Client
+	delivered := 0
+	h.mu.RLock()
+	recipients := h.clients
+	if message.Topic != "" {
+		recipients = h.topics[message.Topic]
+	}
+	for client := range recipients {
+		if message.origin != "" && message.origin == client.id {
+			continue
+		}
+		select {
+		case client.send <- message:
+			delivered++
+		default:
+			// Client's send channel is full, drop the client
+			slow = append(slow, client)
+		}
+	}
+	h.mu.RUnlock()
+	h.metrics.recordBroadcast(message.Type, delivered)
+
+	if len(slow) > 0 {
+		h.metrics.recordDropped(len(slow))
+		h.mu.Lock()
+		for _, client := range slow {
+			h.removeClient(client)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// closeAll delivers the messages still queued, closes every client with a
+// "going away" code and marks the hub as stopped
+func (h *Hub) closeAll() {
+drain:
+	for {
+		select {
+		case message := <-h.broadcast:
+			h.deliver(message)
+		default:
+			break drain
+		}
+	}
+
+	h.mu.Lock()
+	for client := range h.clients {
+		client.closeCode = websocket.CloseGoingAway
+		h.removeClient(client)
+	}
+	h.mu.Unlock()
+	close(h.done)
+}
+
+// removeClient forgets a client and closes its send channel. Callers must
+// hold h.mu for writing.
+func (h *Hub) removeClient(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	h.connections.release(client.userID, client.ip)
+	client.closed = true
+	for topic, members := range h.topics {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.topics, topic)
 		}
 	}
+	close(client.send)
 }
 
-// Broadcast sends a message to all connected clients
+// Broadcast sends a message to all connected clients. With a broker the
+// message goes through it (and comes back to this hub like to any other); if
+// the broker fails, the message is still delivered to local clients.
 func (h *Hub) Broadcast(message *Message) {
-	h.broadcast <- message
+	if h.broker != nil {
+		err := h.broker.Publish(message)
+		if err == nil {
+			return
+		}
+		h.logger.Error("Broker publish failed, delivering to local clients only",
+			"error", err, "type", message.Type, "topic", message.Topic)
+	}
+	h.enqueue(message)
+}
+
+// enqueue hands a message to the hub loop; after Shutdown it is discarded
+func (h *Hub) enqueue(message *Message) {
+	select {
+	case h.broadcast <- message:
+	case <-h.done:
+	}
+}
+
+// Shutdown stops accepting clients, announces that every client left its
+// topics, and closes all connections with a "going away" close frame once
+// their queued messages are written. It returns when every connection is
+// flushed or ctx expires, whichever comes first. Run must be running.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if h.closing {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closing = true
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	// Other instances drop these clients from presence before we stop listening
+	for _, client := range clients {
+		h.leaveAll(client)
+	}
+	if h.unsubscribe != nil {
+		h.unsubscribe()
+	}
+
+	select {
+	case h.stop <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	flushed := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // BroadcastJSON sends a JSON-encoded message to all connected clients
@@ -105,6 +339,26 @@ func (h *Hub) BroadcastJSON(messageType MessageType, data interface{}) error {
 	return nil
 }
 
+// sendTo queues a message for a single client, if it is still connected
+func (h *Hub) sendTo(client *Client, message *Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if client.closed {
+		return
+	}
+	select {
+	case client.send <- message:
+	default:
+	}
+}
+
+func (h *Hub) isClosing() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.closing
+}
+
 // GetClientCount returns the number of connected clients
 func (h *Hub) GetClientCount() int {
 	h.mu.RLock()
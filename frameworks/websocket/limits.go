@@ -0,0 +1,179 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTooManyUserConnections = errors.New("too many connections for this user")
+	ErrTooManyIPConnections   = errors.New("too many connections from this address")
+)
+
+// Config holds the abuse limits applied to WebSocket clients
+type Config struct {
+	// Origins allowed to open a connection ("*" allows any). When empty only
+	// same-origin browsers are accepted. Requests without an Origin header
+	// (non-browser clients) are always allowed.
+	AllowedOrigins []string
+
+	// Concurrent connections allowed per authenticated user and per client IP
+	// (0 disables the cap)
+	MaxConnectionsPerUser int
+	MaxConnectionsPerIP   int
+
+	// Inbound frames: sustained rate per second and burst size. A client that
+	// exceeds it is disconnected with a policy-violation close code.
+	MessageRate  float64
+	MessageBurst int
+
+	// How often instances sharing a broker report who is present on their
+	// topics (0 uses 30 seconds). Readers of an instance that stops reporting
+	// are dropped after three missed reports.
+	PresenceHeartbeat time.Duration
+
+	// Logger reports broker failures; nil uses the standard logger
+	Logger Logger
+}
+
+// DefaultConfig returns conservative limits suitable for a single instance
+func DefaultConfig() Config {
+	return Config{
+		MaxConnectionsPerUser: 5,
+		MaxConnectionsPerIP:   20,
+		MessageRate:           5,
+		MessageBurst:          10,
+	}
+}
+
+// checkOrigin returns the upgrader origin check for the configured allowlist
+func (c Config) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	if len(c.AllowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	return false
+}
+
+// clientIP returns the address used for the per-IP connection cap: the one
+// the router's client IP middleware found, which knows whether to trust a
+// proxy, or else the connection's
+func clientIP(r *http.Request) string {
+	if ip, _ := r.Context().Value("clientIP").(string); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// connectionCounter tracks open connections per user and per IP
+type connectionCounter struct {
+	byUser map[string]int
+	byIP   map[string]int
+	mu     sync.Mutex
+}
+
+func newConnectionCounter() *connectionCounter {
+	return &connectionCounter{
+		byUser: make(map[string]int),
+		byIP:   make(map[string]int),
+	}
+}
+
+// acquire reserves a connection slot, or returns an error if a cap is reached
+func (c *connectionCounter) acquire(userID, ip string, config Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if userID != "" && config.MaxConnectionsPerUser > 0 && c.byUser[userID] >= config.MaxConnectionsPerUser {
+		return ErrTooManyUserConnections
+	}
+	if config.MaxConnectionsPerIP > 0 && c.byIP[ip] >= config.MaxConnectionsPerIP {
+		return ErrTooManyIPConnections
+	}
+
+	if userID != "" {
+		c.byUser[userID]++
+	}
+	c.byIP[ip]++
+	return nil
+}
+
+// release frees a slot reserved by acquire
+func (c *connectionCounter) release(userID, ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if userID != "" {
+		if c.byUser[userID]--; c.byUser[userID] <= 0 {
+			delete(c.byUser, userID)
+		}
+	}
+	if c.byIP[ip]--; c.byIP[ip] <= 0 {
+		delete(c.byIP, ip)
+	}
+}
+
+// tokenBucket limits the inbound frame rate of one client. It is only used
+// from the client's read goroutine.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow takes a token, reporting false when the bucket is empty. A nil bucket
+// allows everything.
+func (b *tokenBucket) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
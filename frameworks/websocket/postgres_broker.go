@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// maxNotifyPayload is PostgreSQL's limit on NOTIFY payloads (8000 bytes by default)
+const maxNotifyPayload = 7999
+
+// PostgresBroker uses PostgreSQL LISTEN/NOTIFY to fan hub messages out across
+// server instances that share the same database (e.g. Supabase)
+type PostgresBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	channel  string
+
+	handlers map[int]func(message *Message)
+	nextID   int
+	mu       sync.RWMutex
+	logger   Logger
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewPostgresBroker connects to PostgreSQL and starts listening on channel.
+// Listener and payload errors go to logger; nil uses the standard logger.
+func NewPostgresBroker(dsn, channel string, logger Logger) (*PostgresBroker, error) {
+	logger = loggerOrDefault(logger)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error("Postgres broker listener error", "error", err, "channel", channel)
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, err)
+	}
+
+	b := &PostgresBroker{
+		db:       db,
+		listener: listener,
+		channel:  channel,
+		handlers: make(map[int]func(message *Message)),
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	go b.listen()
+
+	return b, nil
+}
+
+// Publish sends the message to every instance listening on the channel,
+// including this one
+func (b *PostgresBroker) Publish(message *Message) error {
+	payload, err := EncodeBrokerMessage(message)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return fmt.Errorf("message of %d bytes exceeds NOTIFY payload limit", len(payload))
+	}
+
+	_, err = b.db.Exec("SELECT pg_notify($1, $2)", b.channel, string(payload))
+	return err
+}
+
+// Subscribe registers a handler and returns a function that removes it
+func (b *PostgresBroker) Subscribe(handler func(message *Message)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Close stops listening and releases the database connections. Later calls
+// return the result of the first.
+func (b *PostgresBroker) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.listener.Close()
+		b.closeErr = b.db.Close()
+	})
+	return b.closeErr
+}
+
+func (b *PostgresBroker) listen() {
+	for {
+		select {
+		case <-b.done:
+			return
+
+		case notification := <-b.listener.Notify:
+			// A nil notification means the connection was re-established;
+			// anything sent in between is lost, which is acceptable for live updates
+			if notification == nil {
+				continue
+			}
+
+			message, err := DecodeBrokerMessage([]byte(notification.Extra))
+			if err != nil {
+				b.logger.Error("Invalid postgres broker payload", "error", err, "channel", b.channel)
+				continue
+			}
+
+			b.mu.RLock()
+			for _, handler := range b.handlers {
+				handler(message)
+			}
+			b.mu.RUnlock()
+
+		case <-time.After(90 * time.Second):
+			// Check the connection is still alive
+			go b.listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Minimum interval between typing frames relayed for one client and topic
+	typingThrottle = 2 * time.Second
+
+	// Maximum number of topics a single connection may join
+	maxTopicsPerClient = 20
+
+	maxTopicLength = 128
+
+	// How often instances sharing a broker report their readers to each
+	// other by default. An instance that misses presenceExpiryBeats reports in
+	// a row, e.g. because it crashed, drops out of everyone's presence.
+	defaultPresenceHeartbeat = 30 * time.Second
+	presenceExpiryBeats      = 3
+)
+
+// PostTopic returns the topic clients join to follow a blog post
+func PostTopic(postID string) string {
+	return "post:" + postID
+}
+
+// presenceEntry describes one connection present on a topic
+type presenceEntry struct {
+	ConnectionID string `json:"connection_id"`
+	UserID       string `json:"user_id,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Instance     string `json:"instance,omitempty"` // the hub the connection is on
+}
+
+// presenceReport lists every connection present on one instance, per topic.
+// It is the data of a presence_sync message.
+type presenceReport struct {
+	Instance string                     `json:"instance"`
+	Topics   map[string][]presenceEntry `json:"topics"`
+}
+
+// PresenceUser is an authenticated user present on a topic
+type PresenceUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// PresenceSnapshot summarizes who is currently on a topic
+type PresenceSnapshot struct {
+	Topic     string         `json:"topic"`
+	Count     int            `json:"count"`     // open connections, including anonymous readers
+	Anonymous int            `json:"anonymous"` // connections without a user
+	Users     []PresenceUser `json:"users"`     // distinct authenticated users
+}
+
+// presenceTracker keeps per-topic presence. It is updated from the
+// presence_join/presence_leave messages flowing through the hub, so with a
+// shared broker every instance sees the same state. Instances also replace
+// their entries with periodic presence_sync reports; with an expiry set, the
+// entries of an instance that stopped reporting are dropped, since it won't
+// announce that its clients left.
+type presenceTracker struct {
+	topics map[string]map[string]presenceEntry
+	heard  map[string]time.Time // when each instance was last heard from
+	expiry time.Duration        // 0 keeps entries until they leave
+	mu     sync.RWMutex
+}
+
+func newPresenceTracker(expiry time.Duration) *presenceTracker {
+	return &presenceTracker{
+		topics: make(map[string]map[string]presenceEntry),
+		heard:  make(map[string]time.Time),
+		expiry: expiry,
+	}
+}
+
+func (p *presenceTracker) apply(message *Message, now time.Time) {
+	switch message.Type {
+	case MessageTypePresenceJoin, MessageTypePresenceLeave:
+	case MessageTypePresenceSync:
+		p.applyReport(message, now)
+		return
+	default:
+		return
+	}
+
+	var entry presenceEntry
+	if err := json.Unmarshal(message.Data, &entry); err != nil || entry.ConnectionID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if message.Type == MessageTypePresenceJoin {
+		p.add(message.Topic, entry)
+		p.heard[entry.Instance] = now
+		return
+	}
+
+	delete(p.topics[message.Topic], entry.ConnectionID)
+	if len(p.topics[message.Topic]) == 0 {
+		delete(p.topics, message.Topic)
+	}
+}
+
+// applyReport replaces everything known about an instance with its report
+func (p *presenceTracker) applyReport(message *Message, now time.Time) {
+	var report presenceReport
+	if err := json.Unmarshal(message.Data, &report); err != nil || report.Instance == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.removeInstance(report.Instance)
+	for topic, entries := range report.Topics {
+		for _, entry := range entries {
+			if entry.ConnectionID != "" {
+				entry.Instance = report.Instance
+				p.add(topic, entry)
+			}
+		}
+	}
+	p.heard[report.Instance] = now
+}
+
+// expire drops the entries of instances not heard from within the expiry
+func (p *presenceTracker) expire(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for instance := range p.heard {
+		if p.stale(instance, now) {
+			p.removeInstance(instance)
+			delete(p.heard, instance)
+		}
+	}
+}
+
+// stale reports whether an instance's entries have expired. Callers must hold
+// p.mu.
+func (p *presenceTracker) stale(instance string, now time.Time) bool {
+	return p.expiry > 0 && now.Sub(p.heard[instance]) > p.expiry
+}
+
+// add records an entry. Callers must hold p.mu for writing.
+func (p *presenceTracker) add(topic string, entry presenceEntry) {
+	if p.topics[topic] == nil {
+		p.topics[topic] = make(map[string]presenceEntry)
+	}
+	p.topics[topic][entry.ConnectionID] = entry
+}
+
+// removeInstance forgets every entry of an instance. Callers must hold p.mu
+// for writing.
+func (p *presenceTracker) removeInstance(instance string) {
+	for topic, entries := range p.topics {
+		for id, entry := range entries {
+			if entry.Instance == instance {
+				delete(entries, id)
+			}
+		}
+		if len(entries) == 0 {
+			delete(p.topics, topic)
+		}
+	}
+}
+
+func (p *presenceTracker) snapshot(topic string, now time.Time) *PresenceSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	snapshot := &PresenceSnapshot{Topic: topic, Users: []PresenceUser{}}
+	seen := make(map[string]bool)
+	for _, entry := range p.topics[topic] {
+		// Between sweeps, entries of a silent instance are already left out
+		if p.stale(entry.Instance, now) {
+			continue
+		}
+		snapshot.Count++
+		if entry.UserID == "" {
+			snapshot.Anonymous++
+			continue
+		}
+		if !seen[entry.UserID] {
+			seen[entry.UserID] = true
+			snapshot.Users = append(snapshot.Users, PresenceUser{UserID: entry.UserID, Username: entry.Username})
+		}
+	}
+
+	sort.Slice(snapshot.Users, func(i, j int) bool {
+		return snapshot.Users[i].Username < snapshot.Users[j].Username
+	})
+	return snapshot
+}
+
+// Presence returns the current presence snapshot for a topic
+func (h *Hub) Presence(topic string) *PresenceSnapshot {
+	return h.presence.snapshot(topic, time.Now())
+}
+
+// presenceHeartbeat asks the other instances sharing the broker for their
+// readers, so that a new instance knows them without waiting for joins, then
+// reports this instance's readers until the hub stops
+func (h *Hub) presenceHeartbeat() {
+	h.Broadcast(&Message{Type: MessageTypePresenceSyncRequest})
+	h.reportPresence()
+
+	ticker := time.NewTicker(h.config.presenceHeartbeat())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.reportPresence()
+			h.presence.expire(time.Now())
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// reportPresence sends the connections present on this instance's topics to
+// every instance, replacing what they knew about it
+func (h *Hub) reportPresence() {
+	report := presenceReport{Instance: h.instanceID, Topics: make(map[string][]presenceEntry)}
+	h.mu.RLock()
+	if h.closing {
+		h.mu.RUnlock()
+		return
+	}
+	for topic, members := range h.topics {
+		for client := range members {
+			report.Topics[topic] = append(report.Topics[topic], h.presenceEntry(client))
+		}
+	}
+	h.mu.RUnlock()
+
+	data, _ := json.Marshal(report)
+	h.Broadcast(&Message{Type: MessageTypePresenceSync, Data: data})
+}
+
+func (h *Hub) presenceEntry(client *Client) presenceEntry {
+	return presenceEntry{
+		ConnectionID: client.id,
+		UserID:       client.userID,
+		Username:     client.username,
+		Instance:     h.instanceID,
+	}
+}
+
+// presenceHeartbeat returns how often instances report their readers
+func (c Config) presenceHeartbeat() time.Duration {
+	if c.PresenceHeartbeat <= 0 {
+		return defaultPresenceHeartbeat
+	}
+	return c.PresenceHeartbeat
+}
+
+// join subscribes a client to a topic and announces it to the topic members
+func (h *Hub) join(client *Client, topic string) error {
+	if err := validateTopic(topic); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if client.closed {
+		h.mu.Unlock()
+		return errors.New("client is not connected")
+	}
+	if client.topics[topic] {
+		h.mu.Unlock()
+		return nil
+	}
+	if len(client.topics) >= maxTopicsPerClient {
+		h.mu.Unlock()
+		return errors.New("too many topics joined")
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Client]bool)
+	}
+	h.topics[topic][client] = true
+	client.topics[topic] = true
+	h.mu.Unlock()
+
+	h.announcePresence(client, topic, MessageTypePresenceJoin)
+	return nil
+}
+
+// leave unsubscribes a client from a topic and announces it
+func (h *Hub) leave(client *Client, topic string) {
+	h.mu.Lock()
+	if !client.topics[topic] {
+		h.mu.Unlock()
+		return
+	}
+	delete(client.topics, topic)
+	delete(h.topics[topic], client)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+	h.mu.Unlock()
+
+	h.announcePresence(client, topic, MessageTypePresenceLeave)
+}
+
+// leaveAll removes a disconnecting client from every topic it joined
+func (h *Hub) leaveAll(client *Client) {
+	h.mu.RLock()
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	h.mu.RUnlock()
+
+	for _, topic := range topics {
+		h.leave(client, topic)
+	}
+}
+
+func (h *Hub) announcePresence(client *Client, topic string, messageType MessageType) {
+	data, _ := json.Marshal(h.presenceEntry(client))
+	h.Broadcast(&Message{Type: messageType, Topic: topic, Data: data})
+}
+
+// typing relays an ephemeral typing indicator to the other members of a
+// topic. Frames are throttled per client and topic and never stored.
+func (h *Hub) typing(client *Client, topic string) error {
+	if client.userID == "" {
+		return errors.New("authentication required to send typing indicators")
+	}
+
+	h.mu.RLock()
+	joined := client.topics[topic]
+	h.mu.RUnlock()
+	if !joined {
+		return errors.New("join the topic before sending typing indicators")
+	}
+
+	now := time.Now()
+	if last, ok := client.lastTyping[topic]; ok && now.Sub(last) < typingThrottle {
+		return nil
+	}
+	client.lastTyping[topic] = now
+
+	data, _ := json.Marshal(PresenceUser{UserID: client.userID, Username: client.username})
+	h.Broadcast(&Message{Type: MessageTypeTyping, Topic: topic, Data: data, origin: client.id})
+	return nil
+}
+
+func validateTopic(topic string) error {
+	if topic == "" {
+		return errors.New("topic is required")
+	}
+	if len(topic) > maxTopicLength {
+		return errors.New("topic is too long")
+	}
+	if !strings.HasPrefix(topic, "post:") {
+		return errors.New("unknown topic")
+	}
+	return nil
+}
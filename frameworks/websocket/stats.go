@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// HubStats is a point-in-time view of the hub for operators
+type HubStats struct {
+	StartedAt           time.Time              `json:"started_at"`
+	Clients             int                    `json:"clients"`
+	AuthenticatedUsers  int                    `json:"authenticated_users"`
+	Topics              int                    `json:"topics"`
+	Broadcasts          map[MessageType]uint64 `json:"broadcasts"` // messages processed, per type
+	Delivered           map[MessageType]uint64 `json:"delivered"`  // messages queued to clients, per type
+	DroppedSlowClients  uint64                 `json:"dropped_slow_clients"`
+	RateLimitedClients  uint64                 `json:"rate_limited_clients"`
+	RejectedConnections uint64                 `json:"rejected_connections"`
+	ShuttingDown        bool                   `json:"shutting_down"`
+}
+
+// hubMetrics holds the hub counters. The hub loop writes while the stats
+// endpoint reads, so it has its own lock.
+type hubMetrics struct {
+	startedAt           time.Time
+	broadcasts          map[MessageType]uint64
+	delivered           map[MessageType]uint64
+	droppedSlowClients  uint64
+	rateLimitedClients  uint64
+	rejectedConnections uint64
+	mu                  sync.Mutex
+}
+
+func newHubMetrics() *hubMetrics {
+	return &hubMetrics{
+		startedAt:  time.Now(),
+		broadcasts: make(map[MessageType]uint64),
+		delivered:  make(map[MessageType]uint64),
+	}
+}
+
+func (m *hubMetrics) recordBroadcast(messageType MessageType, delivered int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcasts[messageType]++
+	m.delivered[messageType] += uint64(delivered)
+}
+
+func (m *hubMetrics) recordDropped(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.droppedSlowClients += uint64(n)
+}
+
+func (m *hubMetrics) recordRateLimited() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimitedClients++
+}
+
+func (m *hubMetrics) recordRejected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejectedConnections++
+}
+
+// Stats returns live hub statistics
+func (h *Hub) Stats() *HubStats {
+	h.mu.RLock()
+	stats := &HubStats{
+		Clients:      len(h.clients),
+		Topics:       len(h.topics),
+		ShuttingDown: h.closing,
+	}
+	users := make(map[string]bool)
+	for client := range h.clients {
+		if client.userID != "" {
+			users[client.userID] = true
+		}
+	}
+	stats.AuthenticatedUsers = len(users)
+	h.mu.RUnlock()
+
+	m := h.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats.StartedAt = m.startedAt
+	stats.Broadcasts = make(map[MessageType]uint64, len(m.broadcasts))
+	for messageType, n := range m.broadcasts {
+		stats.Broadcasts[messageType] = n
+	}
+	stats.Delivered = make(map[MessageType]uint64, len(m.delivered))
+	for messageType, n := range m.delivered {
+		stats.Delivered[messageType] = n
+	}
+	stats.DroppedSlowClients = m.droppedSlowClients
+	stats.RateLimitedClients = m.rateLimitedClients
+	stats.RejectedConnections = m.rejectedConnections
+	return stats
+}
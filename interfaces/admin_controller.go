@@ -4,25 +4,41 @@ import (
 	"encoding/json"
 	"gocleanarchitecture/entities"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
 type AdminController struct {
 	UserUseCase AdminUserUseCase
+	RoleUseCase AdminRoleUseCase
+	Sessions    SessionManager
 }
 
 // AdminUserUseCase defines the interface for admin user management operations
 type AdminUserUseCase interface {
 	GetAllUsers() ([]*entities.User, error)
 	GetUserByID(userID string) (*entities.User, error)
-	UpdateUserRole(userID string, newRole entities.UserRole) error
-	DeleteUser(userID string) error
+	UpdateUserRole(actor Actor, userID string, newRole entities.UserRole) error
+	DeleteUser(actor Actor, userID string) error
+	UnlockUser(actor Actor, userID string) error
 }
 
-func NewAdminController(userUseCase AdminUserUseCase) *AdminController {
+// AdminRoleUseCase defines the interface for managing roles
+type AdminRoleUseCase interface {
+	ListRoles() ([]*entities.Role, error)
+	CreateRole(actor Actor, name, description string, permissions []entities.Permission) (*entities.Role, error)
+	UpdateRole(actor Actor, name entities.UserRole, description string, permissions []entities.Permission) (*entities.Role, error)
+	DeleteRole(actor Actor, name entities.UserRole) error
+	ListMFARequiredRoles() ([]entities.UserRole, error)
+	SetRoleMFARequired(actor Actor, name entities.UserRole, required bool) error
+}
+
+func NewAdminController(userUseCase AdminUserUseCase, roleUseCase AdminRoleUseCase, sessions SessionManager) *AdminController {
 	return &AdminController{
 		UserUseCase: userUseCase,
+		RoleUseCase: roleUseCase,
+		Sessions:    sessions,
 	}
 }
 
@@ -102,12 +118,17 @@ func (c *AdminController) UpdateUserRole(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Update role
-	if err := c.UserUseCase.UpdateUserRole(userID, entities.UserRole(req.Role)); err != nil {
+	if err := c.UserUseCase.UpdateUserRole(actorFrom(r), userID, entities.UserRole(req.Role)); err != nil {
 		if err.Error() == "user not found" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
+		if err.Error() == "role not found" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
@@ -136,7 +157,7 @@ func (c *AdminController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.UserUseCase.DeleteUser(userID); err != nil {
+	if err := c.UserUseCase.DeleteUser(actorFrom(r), userID); err != nil {
 		if err.Error() == "user not found" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -150,3 +171,184 @@ func (c *AdminController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
 }
+
+// UnlockUser lifts a lockout caused by failed logins (admin only)
+func (c *AdminController) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	if err := c.UserUseCase.UnlockUser(actorFrom(r), userID); err != nil {
+		switch err.Error() {
+		case "user not found":
+			w.WriteHeader(http.StatusNotFound)
+		case "login lockout is not enabled":
+			w.WriteHeader(http.StatusNotImplemented)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "User unlocked successfully"})
+}
+
+// GetUserSessions returns a user's active sessions (admin only)
+func (c *AdminController) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+
+	sessions, err := c.Sessions.ListSessions(userID, "")
+	if err != nil {
+		w.WriteHeader(sessionErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeUserSession signs out one of a user's sessions (admin only)
+func (c *AdminController) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := c.Sessions.RevokeSession(vars["id"], vars["sessionId"]); err != nil {
+		w.WriteHeader(sessionErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked successfully"})
+}
+
+// roleRequest is the body for creating or updating a role
+type roleRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Permissions []entities.Permission `json:"permissions"`
+}
+
+// ListRoles returns the built-in and custom roles (admin only)
+func (c *AdminController) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.RoleUseCase.ListRoles()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roles)
+}
+
+// CreateRole defines a custom role (admin only)
+func (c *AdminController) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	role, err := c.RoleUseCase.CreateRole(actorFrom(r), req.Name, req.Description, req.Permissions)
+	if err != nil {
+		w.WriteHeader(roleErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(role)
+}
+
+// UpdateRole changes the description and permissions of a custom role (admin only)
+func (c *AdminController) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	role, err := c.RoleUseCase.UpdateRole(actorFrom(r), entities.UserRole(name), req.Description, req.Permissions)
+	if err != nil {
+		w.WriteHeader(roleErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(role)
+}
+
+// DeleteRole removes a custom role (admin only)
+func (c *AdminController) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	if err := c.RoleUseCase.DeleteRole(actorFrom(r), entities.UserRole(name)); err != nil {
+		w.WriteHeader(roleErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role deleted successfully"})
+}
+
+// ListMFARequiredRoles returns the roles that require two-factor
+// authentication (admin only)
+func (c *AdminController) ListMFARequiredRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.RoleUseCase.ListMFARequiredRoles()
+	if err != nil {
+		w.WriteHeader(roleErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"roles": roles})
+}
+
+// SetRoleMFARequired sets whether a role requires two-factor authentication
+// (admin only)
+func (c *AdminController) SetRoleMFARequired(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req struct {
+		Required *bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Required == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if err := c.RoleUseCase.SetRoleMFARequired(actorFrom(r), entities.UserRole(name), *req.Required); err != nil {
+		w.WriteHeader(roleErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"role": name, "mfa_required": *req.Required})
+}
+
+// roleErrorStatus maps role management errors to HTTP status codes
+func roleErrorStatus(err error) int {
+	switch {
+	case err.Error() == "role not found":
+		return http.StatusNotFound
+	case err.Error() == "role already exists", err.Error() == "role is assigned to users":
+		return http.StatusConflict
+	case err.Error() == "custom roles are not enabled", err.Error() == "two-factor authentication is not enabled":
+		return http.StatusNotImplemented
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// APITokenRepository stores users' hashed personal API tokens
+type APITokenRepository interface {
+	Save(token *entities.APIToken) error
+	FindByHash(tokenHash string) (*entities.APIToken, error)
+	FindByUser(userID string) ([]*entities.APIToken, error)
+	// Delete removes one of a user's tokens and reports whether it existed
+	Delete(userID, id string) (bool, error)
+	UpdateLastUsed(id string, lastUsedAt time.Time) error
+	DeleteExpired(before time.Time) error
+}
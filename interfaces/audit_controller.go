@@ -0,0 +1,117 @@
+package interfaces
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditLogReader defines the interface for reading the audit log
+type AuditLogReader interface {
+	ListEvents(filter AuditFilter) ([]*entities.AuditEvent, error)
+}
+
+type AuditController struct {
+	Audit AuditLogReader
+}
+
+func NewAuditController(audit AuditLogReader) *AuditController {
+	return &AuditController{Audit: audit}
+}
+
+var auditCSVHeader = []string{
+	"id", "occurred_at", "actor_id", "action", "target_type", "target_id",
+	"ip", "user_agent", "request_id", "before", "after",
+}
+
+// ListEvents returns audit events, newest first (admin only). Filters:
+// actor_id, action, target_type, target_id, since and until (RFC 3339) and
+// limit. With format=csv or Accept: text/csv the events are exported as CSV.
+func (c *AuditController) ListEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := AuditFilter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Limit:      100,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 1000 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		filter.Limit = n
+	}
+	for param, dest := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": param + " must be an RFC 3339 timestamp"})
+			return
+		}
+		*dest = t
+	}
+
+	events, err := c.Audit.ListEvents(filter)
+	if err != nil {
+		if err.Error() == "audit log is not enabled" {
+			w.WriteHeader(http.StatusNotImplemented)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if query.Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		writeAuditCSV(w, events)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func writeAuditCSV(w http.ResponseWriter, events []*entities.AuditEvent) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write(auditCSVHeader)
+	for _, event := range events {
+		writer.Write([]string{
+			event.ID,
+			event.OccurredAt.UTC().Format(time.RFC3339),
+			csvCell(event.ActorID),
+			event.Action,
+			event.TargetType,
+			csvCell(event.TargetID),
+			csvCell(event.IP),
+			csvCell(event.UserAgent),
+			csvCell(event.RequestID),
+			csvCell(event.Before),
+			csvCell(event.After),
+		})
+	}
+	writer.Flush()
+}
+
+// csvCell keeps spreadsheets from evaluating user-controlled values as
+// formulas
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// AuditFilter narrows an audit log query; zero fields match all
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time // inclusive
+	Until      time.Time // exclusive
+	Limit      int       // 0 means no limit
+}
+
+// AuditRepository is an append-only store of audit events
+type AuditRepository interface {
+	Append(event *entities.AuditEvent) error
+	// Find returns matching events, newest first
+	Find(filter AuditFilter) ([]*entities.AuditEvent, error)
+}
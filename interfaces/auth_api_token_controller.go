@@ -0,0 +1,105 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateAPITokenRequest names a new personal API token and what it may do
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 for a token that doesn't expire
+}
+
+// CreateAPITokenResponse carries the token's value, shown this once only
+type CreateAPITokenResponse struct {
+	Token    string             `json:"token"`
+	APIToken *entities.APIToken `json:"api_token"`
+}
+
+// CreateAPIToken creates a personal API token for the authenticated user
+func (c *AuthController) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	scopes := make([]entities.Scope, len(request.Scopes))
+	for i, scope := range request.Scopes {
+		scopes[i] = entities.Scope(scope)
+	}
+
+	lifetime := time.Duration(request.ExpiresInDays) * 24 * time.Hour
+	token, value, err := c.AuthUseCase.CreateAPIToken(userID, request.Name, scopes, lifetime, clientInfo(r))
+	if err != nil {
+		http.Error(w, err.Error(), apiTokenErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateAPITokenResponse{Token: value, APIToken: token})
+}
+
+// ListAPITokens lists the authenticated user's personal API tokens
+func (c *AuthController) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := c.AuthUseCase.ListAPITokens(userID)
+	if err != nil {
+		http.Error(w, err.Error(), apiTokenErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RevokeAPIToken deletes one of the authenticated user's personal API tokens
+func (c *AuthController) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.AuthUseCase.RevokeAPIToken(userID, mux.Vars(r)["id"], clientInfo(r)); err != nil {
+		http.Error(w, err.Error(), apiTokenErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiTokenErrorStatus maps API token errors to HTTP status codes
+func apiTokenErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasSuffix(message, "is not enabled"), strings.HasSuffix(message, "are not enabled"):
+		return http.StatusNotImplemented
+	case strings.HasSuffix(message, "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, "too many"):
+		return http.StatusConflict
+	case strings.HasPrefix(message, "failed to"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
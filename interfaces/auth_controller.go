@@ -2,7 +2,13 @@ package interfaces
 
 import (
 	"encoding/json"
+	"errors"
+	"gocleanarchitecture/entities"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -31,8 +37,12 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 		request.Email,
 		request.Password,
 		request.FullName,
+		clientInfo(r),
 	)
 	if err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -55,8 +65,14 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := c.AuthUseCase.Login(request.EmailOrUsername, request.Password)
+	response, err := c.AuthUseCase.Login(request.EmailOrUsername, request.Password, clientInfo(r))
 	if err != nil {
+		var locked *entities.LoginLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
@@ -66,6 +82,38 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// Refresh exchanges a refresh token for a new access token and refresh token
+func (c *AuthController) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	response, err := c.AuthUseCase.Refresh(request.RefreshToken, clientInfo(r))
+	if err != nil {
+		switch err.Error() {
+		case "refresh token is required":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case "refresh tokens are not enabled":
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		case "failed to refresh token", "failed to generate authentication token":
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // GetProfile retrieves the authenticated user's profile
 func (c *AuthController) GetProfile(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
@@ -134,8 +182,11 @@ func (c *AuthController) ChangePassword(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = c.AuthUseCase.ChangePassword(userID, request.OldPassword, request.NewPassword)
+	err = c.AuthUseCase.ChangePassword(userID, request.OldPassword, request.NewPassword, clientInfo(r))
 	if err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -143,6 +194,156 @@ func (c *AuthController) ChangePassword(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Logout revokes the current access token and, if provided, the refresh token
+func (c *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	tokenID, _ := r.Context().Value("tokenID").(string)
+	tokenExpiresAt, _ := r.Context().Value("tokenExpiresAt").(time.Time)
+
+	// The body is optional
+	var request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := c.AuthUseCase.Logout(userID, sessionID, tokenID, tokenExpiresAt, request.RefreshToken, clientInfo(r))
+	if err != nil {
+		if err.Error() == "token revocation is not enabled" {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll revokes every token of the authenticated user on every device
+func (c *AuthController) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.AuthUseCase.LogoutAll(userID, clientInfo(r))
+	if err != nil {
+		if err.Error() == "token revocation is not enabled" {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSessions returns the authenticated user's active sessions
+func (c *AuthController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, _ := r.Context().Value("sessionID").(string)
+
+	sessions, err := c.AuthUseCase.ListSessions(userID, sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession signs one of the authenticated user's sessions out
+func (c *AuthController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := c.AuthUseCase.RevokeSession(userID, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sessionErrorStatus maps session management errors to HTTP status codes
+func sessionErrorStatus(err error) int {
+	switch err.Error() {
+	case "sessions are not enabled":
+		return http.StatusNotImplemented
+	case "session not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writePasswordPolicyError answers a rejected password with every rule it
+// broke, as JSON the client can show next to the field. It reports whether
+// err was a password policy error.
+func writePasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policy *entities.PasswordPolicyError
+	if !errors.As(err, &policy) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error(), "violations": policy.Violations})
+	return true
+}
+
+// clientInfo describes the device making the request, for session tracking.
+// The IP comes from the router's client IP middleware, which knows whether to
+// trust a proxy.
+func clientInfo(r *http.Request) ClientInfo {
+	ip, _ := r.Context().Value("clientIP").(string)
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	requestID, _ := r.Context().Value("requestID").(string)
+	apiTokenID, _ := r.Context().Value("apiTokenID").(string)
+	oauthClientID, _ := r.Context().Value("oauthClientID").(string)
+	scopes, _ := r.Context().Value("tokenScopes").([]entities.Scope)
+	return ClientInfo{
+		UserAgent:     r.UserAgent(),
+		IP:            ip,
+		RequestID:     requestID,
+		APITokenID:    apiTokenID,
+		OAuthClientID: oauthClientID,
+		Scopes:        scopes,
+	}
+}
+
+// actorFrom identifies the authenticated user making the request
+func actorFrom(r *http.Request) Actor {
+	userID, _ := r.Context().Value("userID").(string)
+	return Actor{UserID: userID, Client: clientInfo(r)}
+}
+
 // GetUserByUsername retrieves a public user profile by username
 func (c *AuthController) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -0,0 +1,104 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ResendVerificationEmail sends the authenticated user a new email
+// verification link
+func (c *AuthController) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.AuthUseCase.ResendVerificationEmail(userID); err != nil {
+		http.Error(w, err.Error(), emailErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// VerifyEmail marks the email address of the token's user as verified
+func (c *AuthController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.AuthUseCase.VerifyEmail(request.Token, clientInfo(r)); err != nil {
+		http.Error(w, err.Error(), emailErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email address verified"})
+}
+
+// RequestPasswordReset emails a password reset link. The response is the same
+// whether or not an account uses the address.
+func (c *AuthController) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.AuthUseCase.RequestPasswordReset(request.Email, clientInfo(r)); err != nil {
+		http.Error(w, err.Error(), emailErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "If an account uses this email address, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a password reset token
+func (c *AuthController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.AuthUseCase.ResetPassword(request.Token, request.NewPassword, clientInfo(r)); err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), emailErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successfully"})
+}
+
+// emailErrorStatus maps email verification and password reset errors to HTTP
+// status codes
+func emailErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "is not enabled"):
+		return http.StatusNotImplemented
+	case err.Error() == "email is already verified":
+		return http.StatusConflict
+	case err.Error() == "user not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
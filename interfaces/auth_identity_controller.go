@@ -0,0 +1,42 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ListIdentities lists the provider accounts linked to the authenticated user
+func (c *AuthController) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := c.AuthUseCase.ListIdentities(userID)
+	if err != nil {
+		http.Error(w, err.Error(), identityErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// UnlinkIdentity removes the link to the user's account at a provider
+func (c *AuthController) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.AuthUseCase.UnlinkIdentity(userID, mux.Vars(r)["provider"], clientInfo(r)); err != nil {
+		http.Error(w, err.Error(), identityErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
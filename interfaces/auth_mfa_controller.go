@@ -0,0 +1,147 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"gocleanarchitecture/entities"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetMFAStatus reports whether the authenticated user has two-factor
+// authentication enabled
+func (c *AuthController) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := c.AuthUseCase.MFAStatus(userID)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// EnrollMFA starts setting up a TOTP second factor and returns the secret and
+// its provisioning URI
+func (c *AuthController) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	info, err := c.AuthUseCase.EnrollMFA(userID)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// ConfirmMFA enables the second factor with a first code and returns the
+// recovery codes
+func (c *AuthController) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := c.AuthUseCase.ConfirmMFA(userID, request.Code, clientInfo(r))
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"recovery_codes": codes})
+}
+
+// DisableMFA turns two-factor authentication off, given a current code
+func (c *AuthController) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.AuthUseCase.DisableMFA(userID, request.Code, clientInfo(r)); err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyMFA completes a login that returned a two-factor challenge
+func (c *AuthController) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	response, err := c.AuthUseCase.VerifyMFA(request.MFAToken, request.Code, clientInfo(r))
+	if err != nil {
+		var locked *entities.LoginLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// mfaErrorStatus maps two-factor authentication errors to HTTP status codes
+func mfaErrorStatus(err error) int {
+	switch {
+	case err.Error() == "two-factor authentication is not enabled":
+		return http.StatusNotImplemented
+	case err.Error() == "two-factor authentication is already enabled":
+		return http.StatusConflict
+	case err.Error() == "two-factor authentication is required for your role":
+		return http.StatusForbidden
+	case err.Error() == "user not found":
+		return http.StatusNotFound
+	case err.Error() == "invalid two-factor code", err.Error() == "invalid or expired two-factor challenge":
+		return http.StatusUnauthorized
+	case strings.HasPrefix(err.Error(), "failed to"), err.Error() == "authentication failed":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
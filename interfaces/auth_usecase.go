@@ -1,20 +1,144 @@
 package interfaces
 
-import "gocleanarchitecture/entities"
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
 
+// LoginResponse is a new session, or a two-factor challenge when
+// MFARequired is set: the client then posts MFAToken and a code to complete
+// the login
 type LoginResponse struct {
-	User  *entities.User
-	Token string
+	User         *entities.User `json:",omitempty"`
+	Token        string         `json:",omitempty"`
+	RefreshToken string         `json:",omitempty"`
+	MFARequired  bool           `json:",omitempty"`
+	MFAToken     string         `json:",omitempty"`
+}
+
+// ClientInfo identifies the device a request comes from, for sessions and
+// the audit log, and the token it was made with when that token is limited
+// to some scopes
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+	RequestID string
+	// APITokenID is the personal API token the request was made with, if any
+	APITokenID string
+	// OAuthClientID is the third-party app the request was made by, if any
+	OAuthClientID string
+	// Scopes are what APITokenID or OAuthClientID's token allows
+	Scopes []entities.Scope
+}
+
+// Actor is the signed-in user performing an audited action
+type Actor struct {
+	UserID string
+	Client ClientInfo
+}
+
+// SessionInfo is a session as shown to its owner or to an admin
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session making the request
+}
+
+// SessionManager lists and revokes a user's sessions
+type SessionManager interface {
+	ListSessions(userID, currentSessionID string) ([]*SessionInfo, error)
+	RevokeSession(userID, sessionID string) error
+}
+
+// MFAStatus describes a user's second factor
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // the user's role requires a second factor
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollmentInfo is what an authenticator app needs to set up a TOTP
+// second factor
+type MFAEnrollmentInfo struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// MFAManager sets up second factors and completes two-factor logins
+type MFAManager interface {
+	MFAStatus(userID string) (*MFAStatus, error)
+	EnrollMFA(userID string) (*MFAEnrollmentInfo, error)
+	ConfirmMFA(userID, code string, client ClientInfo) ([]string, error)
+	DisableMFA(userID, code string, client ClientInfo) error
+	VerifyMFA(mfaToken, code string, client ClientInfo) (*LoginResponse, error)
+}
+
+// EmailManager verifies email addresses and resets forgotten passwords
+type EmailManager interface {
+	ResendVerificationEmail(userID string) error
+	VerifyEmail(token string, client ClientInfo) error
+	RequestPasswordReset(email string, client ClientInfo) error
+	ResetPassword(token, newPassword string, client ClientInfo) error
+}
+
+// ExternalIdentity is an account at a sign-in provider, as the provider
+// describes it
+type ExternalIdentity struct {
+	Provider      string
+	ProviderID    string // the provider's stable account ID
+	Email         string
+	EmailVerified bool // the provider vouches that the user owns Email
+	Name          string
+	AvatarURL     string
+}
+
+// IdentityManager signs users in with provider accounts and links those
+// accounts to existing users
+type IdentityManager interface {
+	OAuthLogin(identity ExternalIdentity, client ClientInfo) (*LoginResponse, error)
+	// IssueLoginCode signs in like OAuthLogin but returns a short-lived,
+	// single-use code that ExchangeLoginCode redeems for the LoginResponse
+	IssueLoginCode(identity ExternalIdentity, client ClientInfo) (string, error)
+	ExchangeLoginCode(code string, client ClientInfo) (*LoginResponse, error)
+	// BeginIdentityLink returns a short-lived, single-use ticket that
+	// CompleteIdentityLink exchanges for a link to the signed-in user
+	BeginIdentityLink(userID string) (string, error)
+	CompleteIdentityLink(ticket string, identity ExternalIdentity, client ClientInfo) (*entities.Identity, error)
+	ListIdentities(userID string) ([]*entities.Identity, error)
+	UnlinkIdentity(userID, provider string, client ClientInfo) error
+}
+
+// APITokenManager manages users' personal API tokens
+type APITokenManager interface {
+	// CreateAPIToken returns the new token and its value, which is shown
+	// once; a zero lifetime never expires
+	CreateAPIToken(userID, name string, scopes []entities.Scope, lifetime time.Duration, client ClientInfo) (*entities.APIToken, string, error)
+	ListAPITokens(userID string) ([]*entities.APIToken, error)
+	RevokeAPIToken(userID, tokenID string, client ClientInfo) error
+	AuthenticateAPIToken(value string) (*entities.APIToken, *entities.User, error)
 }
 
 type AuthUseCase interface {
-	Register(username, email, password, fullName string) (*LoginResponse, error)
-	Login(emailOrUsername, password string) (*LoginResponse, error)
+	SessionManager
+	MFAManager
+	EmailManager
+	IdentityManager
+	APITokenManager
+	Register(username, email, password, fullName string, client ClientInfo) (*LoginResponse, error)
+	RegisterVerified(username, email, password, fullName string, client ClientInfo) (*LoginResponse, error)
+	Login(emailOrUsername, password string, client ClientInfo) (*LoginResponse, error)
+	Refresh(refreshToken string, client ClientInfo) (*LoginResponse, error)
+	Logout(userID, sessionID, tokenID string, tokenExpiresAt time.Time, refreshToken string, client ClientInfo) error
+	LogoutAll(userID string, client ClientInfo) error
 	GetProfile(userID string) (*entities.User, error)
 	UpdateProfile(userID, fullName, bio, avatarURL string) (*entities.User, error)
-	ChangePassword(userID, oldPassword, newPassword string) error
+	ChangePassword(userID, oldPassword, newPassword string, client ClientInfo) error
 	GetUserByUsername(username string) (*entities.User, error)
 	GetUserByEmail(email string) (*entities.User, error)
-	GenerateTokenForUser(userID string) (string, error)
+	GenerateTokenForUser(userID string, client ClientInfo) (*LoginResponse, error)
 }
 
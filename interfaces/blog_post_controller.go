@@ -3,23 +3,21 @@ package interfaces
 import (
 	"encoding/json"
 	"gocleanarchitecture/entities"
-	"gocleanarchitecture/frameworks/websocket"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
 type BlogPostUseCase interface {
-	CreateBlogPost(id, title, content, authorID string) (*entities.BlogPost, error)
+	CreateBlogPost(id, title, content, authorID string, client ClientInfo) (*entities.BlogPost, error)
 	GetAllBlogPosts() ([]*entities.BlogPost, error)
 	GetBlogPost(id string) (*entities.BlogPost, error)
-	UpdateBlogPost(id, title, content, userID string) (*entities.BlogPost, error)
-	DeleteBlogPost(id, userID string) error
+	UpdateBlogPost(id, title, content, userID, reason string, client ClientInfo) (*entities.BlogPost, error)
+	DeleteBlogPost(id, userID, reason string, client ClientInfo) error
 }
 
 type BlogPostController struct {
 	BlogPostUseCase BlogPostUseCase
-	WebSocketHub    *websocket.Hub
 }
 
 func (c *BlogPostController) CreateBlogPost(w http.ResponseWriter, r *http.Request) {
@@ -42,17 +40,16 @@ func (c *BlogPostController) CreateBlogPost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	blogPost, err := c.BlogPostUseCase.CreateBlogPost(request.ID, request.Title, request.Content, userID)
+	blogPost, err := c.BlogPostUseCase.CreateBlogPost(request.ID, request.Title, request.Content, userID, clientInfo(r))
 	if err != nil {
+		if err.Error() == "unauthorized: you are not allowed to publish blog posts" || err.Error() == "email address is not verified" {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Broadcast new blog post via WebSocket
-	if c.WebSocketHub != nil {
-		c.WebSocketHub.BroadcastJSON(websocket.MessageTypeNewBlogPost, blogPost)
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(blogPost)
@@ -102,6 +99,7 @@ func (c *BlogPostController) UpdateBlogPost(w http.ResponseWriter, r *http.Reque
 	var request struct {
 		Title   string `json:"title"`
 		Content string `json:"content"`
+		Reason  string `json:"reason"` // required when staff edit someone else's post
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -110,7 +108,7 @@ func (c *BlogPostController) UpdateBlogPost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	blogPost, err := c.BlogPostUseCase.UpdateBlogPost(id, request.Title, request.Content, userID)
+	blogPost, err := c.BlogPostUseCase.UpdateBlogPost(id, request.Title, request.Content, userID, request.Reason, clientInfo(r))
 	if err != nil {
 		// Check for authorization error
 		if err.Error() == "unauthorized: you can only update your own blog posts" {
@@ -137,16 +135,28 @@ func (c *BlogPostController) DeleteBlogPost(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	err := c.BlogPostUseCase.DeleteBlogPost(id, userID)
+	// Staff deleting someone else's post pass the reason as a query parameter
+	err := c.BlogPostUseCase.DeleteBlogPost(id, userID, r.URL.Query().Get("reason"), clientInfo(r))
 	if err != nil {
 		// Check for authorization error
 		if err.Error() == "unauthorized: you can only delete your own blog posts" {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if isModerationReasonError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// isModerationReasonError reports whether staff acting on someone else's
+// content gave a missing or invalid reason
+func isModerationReasonError(err error) bool {
+	return err.Error() == "a reason is required when acting on another author's content" ||
+		err.Error() == "reason cannot exceed 500 characters"
+}
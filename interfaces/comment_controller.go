@@ -3,7 +3,6 @@ package interfaces
 import (
 	"encoding/json"
 	"gocleanarchitecture/entities"
-	"gocleanarchitecture/frameworks/websocket"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -12,15 +11,14 @@ import (
 
 type CommentController struct {
 	CommentUseCase CommentUseCaseInterface
-	WebSocketHub   *websocket.Hub
 }
 
 type CommentUseCaseInterface interface {
-	CreateComment(id, blogPostID, authorID, content, parentID string) (*entities.Comment, error)
+	CreateComment(id, blogPostID, authorID, content, parentID string, client ClientInfo) (*entities.Comment, error)
 	GetCommentsByBlogPostID(blogPostID string) ([]*entities.Comment, error)
 	GetRepliesByCommentID(commentID string) ([]*entities.Comment, error)
-	UpdateComment(id, content, userID string) (*entities.Comment, error)
-	DeleteComment(id, userID string) error
+	UpdateComment(id, content, userID string, client ClientInfo) (*entities.Comment, error)
+	DeleteComment(id, userID, reason string, client ClientInfo) error
 }
 
 func NewCommentController(commentUseCase CommentUseCaseInterface) *CommentController {
@@ -56,8 +54,13 @@ func (c *CommentController) CreateComment(w http.ResponseWriter, r *http.Request
 	// Generate UUID for comment
 	commentID := uuid.New().String()
 
-	comment, err := c.CommentUseCase.CreateComment(commentID, blogPostID, userID, req.Content, req.ParentID)
+	comment, err := c.CommentUseCase.CreateComment(commentID, blogPostID, userID, req.Content, req.ParentID, clientInfo(r))
 	if err != nil {
+		if err.Error() == "email address is not verified" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		if err.Error() == "blog post not found" || err.Error() == "author not found" || err.Error() == "parent comment not found" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -68,11 +71,6 @@ func (c *CommentController) CreateComment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Broadcast new comment via WebSocket
-	if c.WebSocketHub != nil {
-		c.WebSocketHub.BroadcastJSON(websocket.MessageTypeNewComment, comment)
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(comment)
@@ -133,7 +131,7 @@ func (c *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	comment, err := c.CommentUseCase.UpdateComment(commentID, req.Content, userID)
+	comment, err := c.CommentUseCase.UpdateComment(commentID, req.Content, userID, clientInfo(r))
 	if err != nil {
 		if err.Error() == "comment not found" {
 			w.WriteHeader(http.StatusNotFound)
@@ -154,7 +152,8 @@ func (c *CommentController) UpdateComment(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(comment)
 }
 
-// DeleteComment handles DELETE /comments/{commentId}
+// DeleteComment handles DELETE /comments/{commentId}. Moderators deleting
+// someone else's comment pass the reason as a query parameter.
 func (c *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	commentID := vars["commentId"]
@@ -167,18 +166,23 @@ func (c *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := c.CommentUseCase.DeleteComment(commentID, userID)
+	err := c.CommentUseCase.DeleteComment(commentID, userID, r.URL.Query().Get("reason"), clientInfo(r))
 	if err != nil {
 		if err.Error() == "comment not found" {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
-		if err.Error() == "unauthorized: only the author or admin can delete this comment" {
+		if err.Error() == "unauthorized: only the author or a moderator can delete this comment" {
 			w.WriteHeader(http.StatusForbidden)
 			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
+		if isModerationReasonError(err) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
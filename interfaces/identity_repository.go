@@ -0,0 +1,15 @@
+package interfaces
+
+import "gocleanarchitecture/entities"
+
+// IdentityRepository stores the provider accounts linked to users. A provider
+// account links to at most one user, and a user links at most one account
+// per provider.
+type IdentityRepository interface {
+	Save(identity *entities.Identity) error
+	FindByProvider(provider, providerID string) (*entities.Identity, error)
+	FindByUser(userID string) ([]*entities.Identity, error)
+	// Delete removes the user's identity at a provider and reports whether
+	// one was removed
+	Delete(userID, provider string) (bool, error)
+}
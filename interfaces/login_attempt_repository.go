@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// LoginAttemptRepository stores failed login counters per account and per
+// client IP
+type LoginAttemptRepository interface {
+	Find(key string) (*entities.LoginThrottle, error)
+	Save(throttle *entities.LoginThrottle) error
+	Delete(key string) error
+	DeleteExpired(before time.Time) error
+}
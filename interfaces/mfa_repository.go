@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// MFARepository stores second factors, pending login challenges and the
+// roles that require a second factor
+type MFARepository interface {
+	SaveEnrollment(enrollment *entities.MFAEnrollment) error
+	FindEnrollment(userID string) (*entities.MFAEnrollment, error)
+	DeleteEnrollment(userID string) error
+	// ConsumeTOTPStep records that a user's code for a time step was used,
+	// unless one for that step or a later one already was, and reports
+	// whether this call recorded it, so that a code works once even under
+	// concurrent requests
+	ConsumeTOTPStep(userID string, step int64) (bool, error)
+	// ConsumeRecoveryCode removes one of a user's recovery codes and reports
+	// whether this call removed it
+	ConsumeRecoveryCode(userID, codeHash string) (bool, error)
+
+	SaveChallenge(challenge *entities.MFAChallenge) error
+	FindChallengeByHash(tokenHash string) (*entities.MFAChallenge, error)
+	DeleteChallenge(id string) error
+	// DeleteExpired removes challenges that expired before the given time
+	DeleteExpired(before time.Time) error
+
+	SetRoleRequired(role entities.UserRole, required bool) error
+	FindRequiredRoles() ([]entities.UserRole, error)
+}
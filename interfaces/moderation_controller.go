@@ -0,0 +1,53 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"net/http"
+	"strconv"
+)
+
+// ModerationLog defines the interface for reading the moderation log
+type ModerationLog interface {
+	ListActions(filter ModerationFilter) ([]*entities.ModerationAction, error)
+}
+
+type ModerationController struct {
+	Moderation ModerationLog
+}
+
+func NewModerationController(moderation ModerationLog) *ModerationController {
+	return &ModerationController{Moderation: moderation}
+}
+
+// ListActions returns staff actions on users' content, newest first (admin
+// only). Filters: target_type, target_id, actor_id, author_id and limit.
+func (c *ModerationController) ListActions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := ModerationFilter{
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		ActorID:    query.Get("actor_id"),
+		AuthorID:   query.Get("author_id"),
+		Limit:      100,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 1000 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	actions, err := c.Moderation.ListActions(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actions)
+}
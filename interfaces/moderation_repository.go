@@ -0,0 +1,19 @@
+package interfaces
+
+import "gocleanarchitecture/entities"
+
+// ModerationFilter narrows a moderation log query; empty fields match all
+type ModerationFilter struct {
+	TargetType string
+	TargetID   string
+	ActorID    string
+	AuthorID   string
+	Limit      int // 0 means no limit
+}
+
+type ModerationRepository interface {
+	// Save stores an action; saving the same ID again is a no-op
+	Save(action *entities.ModerationAction) error
+	// Find returns matching actions, newest first
+	Find(filter ModerationFilter) ([]*entities.ModerationAction, error)
+}
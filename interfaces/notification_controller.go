@@ -0,0 +1,68 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NotificationUseCase defines the notification operations available to users
+type NotificationUseCase interface {
+	ListNotifications(userID string, unreadOnly bool) ([]*entities.Notification, error)
+	MarkRead(userID, id string) error
+}
+
+type NotificationController struct {
+	NotificationUseCase NotificationUseCase
+}
+
+func NewNotificationController(notificationUseCase NotificationUseCase) *NotificationController {
+	return &NotificationController{NotificationUseCase: notificationUseCase}
+}
+
+// ListNotifications handles GET /notifications; ?unread=true returns unread
+// notifications only
+func (c *NotificationController) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok || userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Authentication required"})
+		return
+	}
+
+	notifications, err := c.NotificationUseCase.ListNotifications(userID, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// MarkRead handles POST /notifications/{id}/read
+func (c *NotificationController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok || userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Authentication required"})
+		return
+	}
+
+	if err := c.NotificationUseCase.MarkRead(userID, mux.Vars(r)["id"]); err != nil {
+		if err.Error() == "notification not found" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Notification marked as read"})
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "gocleanarchitecture/entities"
+
+type NotificationRepository interface {
+	Save(notification *entities.Notification) error
+	FindByID(id string) (*entities.Notification, error)
+	// FindByUserID returns the user's notifications, newest first
+	FindByUserID(userID string, unreadOnly bool) ([]*entities.Notification, error)
+}
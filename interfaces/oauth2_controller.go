@@ -1,294 +1,314 @@
 package interfaces
 
 import (
-	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
-	"gocleanarchitecture/entities"
 	"gocleanarchitecture/frameworks/auth"
 
-	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
-// OAuth2Controller handles OAuth2 authentication
+// OAuth2Controller handles sign-in with external identity providers
 type OAuth2Controller struct {
-	GoogleProvider *auth.OAuth2Provider
-	GitHubProvider *auth.OAuth2Provider
-	AuthUseCase    AuthUseCase
+	Providers   *auth.ProviderRegistry
+	AuthUseCase AuthUseCase
+	// FrontendURL is where callbacks send the browser, with a one-time code
+	// for ExchangeCode or an error. When empty, callbacks answer with JSON.
+	FrontendURL string
 }
 
 // NewOAuth2Controller creates a new OAuth2Controller
-func NewOAuth2Controller(googleProvider, githubProvider *auth.OAuth2Provider, authUseCase AuthUseCase) *OAuth2Controller {
+func NewOAuth2Controller(providers *auth.ProviderRegistry, authUseCase AuthUseCase, frontendURL string) *OAuth2Controller {
 	return &OAuth2Controller{
-		GoogleProvider: googleProvider,
-		GitHubProvider: githubProvider,
-		AuthUseCase:    authUseCase,
+		Providers:   providers,
+		AuthUseCase: authUseCase,
+		FrontendURL: frontendURL,
 	}
 }
 
-// generateState generates a random state string for OAuth2
-func generateState() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// ListProviders returns the configured providers and where to sign in with
+// each
+func (c *OAuth2Controller) ListProviders(w http.ResponseWriter, r *http.Request) {
+	providers := []map[string]string{}
+	for _, name := range c.Providers.Names() {
+		providers = append(providers, map[string]string{
+			"name":      name,
+			"login_url": loginPath(name, c.Providers.Get(name)),
+		})
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"providers": providers})
 }
 
-// InitiateGoogleLogin redirects to Google OAuth2 consent screen
-func (c *OAuth2Controller) InitiateGoogleLogin(w http.ResponseWriter, r *http.Request) {
-	if c.GoogleProvider == nil {
-		http.Error(w, "Google OAuth2 not configured", http.StatusServiceUnavailable)
+// InitiateLogin redirects to the consent screen of the provider named in the
+// URL. A link ticket from BeginIdentityLink is kept in a cookie, never in the
+// provider's URLs.
+func (c *OAuth2Controller) InitiateLogin(w http.ResponseWriter, r *http.Request) {
+	name := providerName(r)
+	provider := c.Providers.Get(name)
+	if provider == nil {
+		http.Error(w, "Unknown provider", http.StatusNotFound)
 		return
 	}
 
-	state, err := generateState()
+	flow, err := auth.NewAuthFlow(name)
 	if err != nil {
 		http.Error(w, "Failed to generate state", http.StatusInternalServerError)
 		return
 	}
+	authURL, err := provider.AuthURL(r.Context(), flow)
+	if err != nil {
+		http.Error(w, "Failed to reach the provider", http.StatusBadGateway)
+		return
+	}
 
-	// Store state in a cookie for verification (expires in 10 minutes)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Expires:  time.Now().Add(10 * time.Minute),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-	})
+	// The flow's secrets stay in the browser for the callback (10 minutes).
+	// A link ticket is only ever set by BeginIdentityLink, so that no one can
+	// start a link for someone else's browser.
+	setOAuthCookie(w, "oauth_flow", flow.Encode(), 10*time.Minute)
 
-	authURL := c.GoogleProvider.GetAuthURL(state)
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-// GoogleCallback handles the Google OAuth2 callback
-func (c *OAuth2Controller) GoogleCallback(w http.ResponseWriter, r *http.Request) {
-	if c.GoogleProvider == nil {
-		http.Error(w, "Google OAuth2 not configured", http.StatusServiceUnavailable)
+// Callback completes a sign-in, or links the account when the sign-in was
+// started by BeginIdentityLink. With a FrontendURL, the browser is sent there
+// with a one-time code that the frontend exchanges for tokens at
+// ExchangeCode, so that no token appears in a URL.
+func (c *OAuth2Controller) Callback(w http.ResponseWriter, r *http.Request) {
+	name := providerName(r)
+	provider := c.Providers.Get(name)
+	if provider == nil {
+		http.Error(w, "Unknown provider", http.StatusNotFound)
 		return
 	}
 
-	// Verify state
-	stateCookie, err := r.Cookie("oauth_state")
+	// Verify the state against the flow this browser started
+	flowCookie, err := r.Cookie("oauth_flow")
 	if err != nil {
-		http.Error(w, "Missing state cookie", http.StatusBadRequest)
+		c.callbackError(w, r, "Missing state cookie", http.StatusBadRequest)
 		return
 	}
-
-	stateParam := r.URL.Query().Get("state")
-	if stateParam != stateCookie.Value {
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+	flow, err := auth.DecodeAuthFlow(flowCookie.Value)
+	if err != nil || flow.Provider != name ||
+		subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(flow.State)) != 1 {
+		c.callbackError(w, r, "Invalid state parameter", http.StatusBadRequest)
 		return
 	}
 
-	// Clear the state cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    "",
-		Expires:  time.Now().Add(-1 * time.Hour),
-		HttpOnly: true,
-		Secure:   true,
-	})
+	// Clear the flow and link cookies
+	var linkTicket string
+	if linkCookie, err := r.Cookie("oauth_link"); err == nil {
+		linkTicket = linkCookie.Value
+	}
+	setOAuthCookie(w, "oauth_flow", "", -time.Hour)
+	setOAuthCookie(w, "oauth_link", "", -time.Hour)
 
-	// Exchange code for token
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		c.callbackError(w, r, "Missing authorization code", http.StatusBadRequest)
 		return
 	}
 
-	token, err := c.GoogleProvider.ExchangeCode(context.Background(), code)
+	userInfo, err := provider.Authenticate(r.Context(), code, flow)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to exchange code: %v", err), http.StatusInternalServerError)
+		if errors.Is(err, auth.ErrNoVerifiedEmail) {
+			c.callbackError(w, r, err.Error(), http.StatusForbidden)
+			return
+		}
+		c.callbackError(w, r, fmt.Sprintf("Failed to sign in with %s: %v", name, err), http.StatusBadGateway)
 		return
 	}
+	external := ExternalIdentity{
+		Provider:      name,
+		ProviderID:    userInfo.ProviderID,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		Name:          userInfo.Name,
+		AvatarURL:     userInfo.AvatarURL,
+	}
 
-	// Get user info from Google
-	userInfo, err := c.GoogleProvider.GetUserInfo(context.Background(), token)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get user info: %v", err), http.StatusInternalServerError)
+	if linkTicket != "" {
+		identity, err := c.AuthUseCase.CompleteIdentityLink(linkTicket, external, clientInfo(r))
+		if err != nil {
+			c.callbackError(w, r, err.Error(), identityErrorStatus(err))
+			return
+		}
+		if c.FrontendURL != "" {
+			http.Redirect(w, r, c.frontendURL(url.Values{"linked": {name}}), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"linked":   identity,
+			"provider": name,
+		})
 		return
 	}
 
-	// Process OAuth2 login (create or find existing user)
-	user, jwtToken, err := c.processOAuth2Login(userInfo)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to process login: %v", err), http.StatusInternalServerError)
+	// Sign in, creating the user for a new provider account
+	if c.FrontendURL != "" {
+		loginCode, err := c.AuthUseCase.IssueLoginCode(external, clientInfo(r))
+		if err != nil {
+			c.callbackError(w, r, err.Error(), identityErrorStatus(err))
+			return
+		}
+		http.Redirect(w, r, c.frontendURL(url.Values{"code": {loginCode}, "provider": {name}}), http.StatusFound)
 		return
 	}
 
-	// Return user and JWT token
-	response := map[string]interface{}{
-		"user":     user.Sanitize(),
-		"token":    jwtToken,
-		"provider": "google",
+	tokens, err := c.AuthUseCase.OAuthLogin(external, clientInfo(r))
+	if err != nil {
+		http.Error(w, err.Error(), identityErrorStatus(err))
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(oauth2LoginResponse(tokens, name))
 }
 
-// InitiateGitHubLogin redirects to GitHub OAuth2 consent screen
-func (c *OAuth2Controller) InitiateGitHubLogin(w http.ResponseWriter, r *http.Request) {
-	if c.GitHubProvider == nil {
-		http.Error(w, "GitHub OAuth2 not configured", http.StatusServiceUnavailable)
+// ExchangeCode redeems the one-time code a callback sent the frontend for
+// the user's tokens, or for a two-factor challenge to complete at
+// /auth/mfa/verify
+func (c *OAuth2Controller) ExchangeCode(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
 		return
 	}
 
-	state, err := generateState()
+	response, err := c.AuthUseCase.ExchangeLoginCode(request.Code, clientInfo(r))
 	if err != nil {
-		http.Error(w, "Failed to generate state", http.StatusInternalServerError)
+		http.Error(w, err.Error(), identityErrorStatus(err))
 		return
 	}
 
-	// Store state in a cookie for verification (expires in 10 minutes)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Expires:  time.Now().Add(10 * time.Minute),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-	})
-
-	authURL := c.GitHubProvider.GetAuthURL(state)
-	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-// GitHubCallback handles the GitHub OAuth2 callback
-func (c *OAuth2Controller) GitHubCallback(w http.ResponseWriter, r *http.Request) {
-	if c.GitHubProvider == nil {
-		http.Error(w, "GitHub OAuth2 not configured", http.StatusServiceUnavailable)
+// callbackError reports a failed callback to the frontend, or as plain text
+// when there is no frontend to send the browser to
+func (c *OAuth2Controller) callbackError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	if c.FrontendURL == "" {
+		http.Error(w, message, status)
 		return
 	}
+	http.Redirect(w, r, c.frontendURL(url.Values{"error": {message}}), http.StatusFound)
+}
 
-	// Verify state
-	stateCookie, err := r.Cookie("oauth_state")
+// frontendURL adds params to the query of FrontendURL
+func (c *OAuth2Controller) frontendURL(params url.Values) string {
+	target, err := url.Parse(c.FrontendURL)
 	if err != nil {
-		http.Error(w, "Missing state cookie", http.StatusBadRequest)
-		return
+		return c.FrontendURL
 	}
-
-	stateParam := r.URL.Query().Get("state")
-	if stateParam != stateCookie.Value {
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Clear the state cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    "",
-		Expires:  time.Now().Add(-1 * time.Hour),
-		HttpOnly: true,
-	})
-
-	// Exchange code for token
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
-		return
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
 	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
 
-	token, err := c.GitHubProvider.ExchangeCode(context.Background(), code)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to exchange code: %v", err), http.StatusInternalServerError)
+// BeginIdentityLink starts linking a provider account to the signed-in user.
+// The link ticket is set as a cookie, so the request must come from the
+// browser that is then sent to the returned URL, which continues like a
+// sign-in but links the account instead.
+func (c *OAuth2Controller) BeginIdentityLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get user info from GitHub
-	userInfo, err := c.GitHubProvider.GetUserInfo(context.Background(), token)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get user info: %v", err), http.StatusInternalServerError)
+	name := providerName(r)
+	provider := c.Providers.Get(name)
+	if provider == nil {
+		http.Error(w, "Unknown provider", http.StatusNotFound)
 		return
 	}
 
-	// Process OAuth2 login (create or find existing user)
-	user, jwtToken, err := c.processOAuth2Login(userInfo)
+	ticket, err := c.AuthUseCase.BeginIdentityLink(userID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to process login: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), identityErrorStatus(err))
 		return
 	}
 
-	// Return user and JWT token
-	response := map[string]interface{}{
-		"user":     user.Sanitize(),
-		"token":    jwtToken,
-		"provider": "github",
-	}
-
+	// The ticket stays out of the URL, where it could leak or be planted
+	setOAuthCookie(w, "oauth_link", ticket, 10*time.Minute)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]string{
+		"url": loginPath(name, provider),
+	})
 }
 
-// processOAuth2Login handles the user creation or retrieval after OAuth2 authentication
-func (c *OAuth2Controller) processOAuth2Login(userInfo *auth.UserInfo) (*entities.User, string, error) {
-	// Try to find existing user by email
-	user, err := c.AuthUseCase.GetUserByEmail(userInfo.Email)
-
-	var token string
-
-	if err != nil || user == nil {
-		// User doesn't exist, create a new one
-		// Generate a username from email or name
-		username := generateUsernameFromEmail(userInfo.Email)
-		
-		// Create random password (won't be used for OAuth logins)
-		randomPassword := uuid.New().String()
-
-		loginResp, err := c.AuthUseCase.Register(username, userInfo.Email, randomPassword, userInfo.Name)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to create user: %w", err)
-		}
+func providerName(r *http.Request) string {
+	return mux.Vars(r)["provider"]
+}
 
-		user = loginResp.User
-		token = loginResp.Token
+// loginPath is where a browser starts signing in with a provider
+func loginPath(name string, provider auth.Provider) string {
+	if _, ok := provider.(*auth.OIDCProvider); ok {
+		return "/auth/oidc/" + name
+	}
+	return "/auth/" + name
+}
 
-		// Update avatar URL if provided
-		if userInfo.AvatarURL != "" {
-			user, err = c.AuthUseCase.UpdateProfile(user.ID, user.FullName, user.Bio, userInfo.AvatarURL)
-			if err != nil {
-				// Log but don't fail - avatar update is optional
-			}
-		}
-	} else {
-		// User exists, update their avatar if provided and generate a new token
-		if userInfo.AvatarURL != "" && userInfo.AvatarURL != user.AvatarURL {
-			user, err = c.AuthUseCase.UpdateProfile(user.ID, user.FullName, user.Bio, userInfo.AvatarURL)
-			if err != nil {
-				// Log but don't fail - avatar update is optional
-			}
-		}
+// setOAuthCookie sets a short-lived cookie for the sign-in round trip; a
+// negative maxAge clears it
+func setOAuthCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth",
+		Expires:  time.Now().Add(maxAge),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
 
-		// Generate a JWT token for the existing user
-		token, err = c.AuthUseCase.GenerateTokenForUser(user.ID)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to generate token: %w", err)
+// oauth2LoginResponse returns the user and their tokens, or the two-factor
+// challenge to complete at /auth/mfa/verify
+func oauth2LoginResponse(tokens *LoginResponse, provider string) map[string]interface{} {
+	if tokens.MFARequired {
+		return map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    tokens.MFAToken,
+			"provider":     provider,
 		}
 	}
-
-	return user, token, nil
+	return map[string]interface{}{
+		"user":          tokens.User,
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"provider":      provider,
+	}
 }
 
-// generateUsernameFromEmail generates a username from an email address
-func generateUsernameFromEmail(email string) string {
-	parts := strings.Split(email, "@")
-	if len(parts) > 0 {
-		username := strings.ToLower(parts[0])
-		username = strings.ReplaceAll(username, ".", "_")
-		username = strings.ReplaceAll(username, "+", "_")
-		
-		// Add a random suffix to avoid collisions
-		suffix := uuid.New().String()[:8]
-		return username + "_" + suffix
+// identityErrorStatus maps provider sign-in and account linking errors to
+// HTTP status codes
+func identityErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "is not enabled"):
+		return http.StatusNotImplemented
+	case strings.Contains(err.Error(), "already"):
+		return http.StatusConflict
+	case err.Error() == "user not found", err.Error() == "linked account not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"), err.Error() == "authentication failed":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
 	}
-	return "user_" + uuid.New().String()[:8]
 }
-
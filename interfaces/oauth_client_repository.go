@@ -0,0 +1,13 @@
+package interfaces
+
+import "gocleanarchitecture/entities"
+
+// OAuthClientRepository stores the third-party apps registered by admins
+type OAuthClientRepository interface {
+	Save(client *entities.OAuthClient) error
+	FindByID(id string) (*entities.OAuthClient, error)
+	FindAll() ([]*entities.OAuthClient, error)
+	// Delete removes a client; its outstanding authorization codes can no
+	// longer be exchanged
+	Delete(id string) (bool, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// OAuthCodeRepository stores hashed authorization codes until a client
+// exchanges them
+type OAuthCodeRepository interface {
+	Save(code *entities.OAuthAuthorizationCode) error
+	FindByHash(codeHash string) (*entities.OAuthAuthorizationCode, error)
+	// Consume marks a code as exchanged for the access token with the given
+	// ID and reports whether this call marked it, so that a code is exchanged
+	// once even under concurrent requests
+	Consume(codeHash, tokenID string, tokenExpiresAt, at time.Time) (bool, error)
+	// DeleteExpired removes codes that expired before the given time, keeping
+	// exchanged ones until their token has expired too
+	DeleteExpired(before time.Time) error
+}
@@ -0,0 +1,281 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// OAuthServerController serves the OAuth2 authorization server: client
+// registration for admins, the consent endpoints for the frontend's consent
+// screen, and the token and introspection endpoints for clients
+type OAuthServerController struct {
+	OAuthServer OAuthServerUseCase
+}
+
+func NewOAuthServerController(oauthServer OAuthServerUseCase) *OAuthServerController {
+	return &OAuthServerController{OAuthServer: oauthServer}
+}
+
+// RegisterClientRequest describes a third-party app to register
+type RegisterClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"` // issue a secret, for apps with a server
+}
+
+// RegisterClientResponse carries a confidential client's secret, shown this
+// once only
+type RegisterClientResponse struct {
+	Client       *entities.OAuthClient `json:"client"`
+	ClientSecret string                `json:"client_secret,omitempty"`
+}
+
+// ConsentRequest is the user's answer to an authorization request, posted by
+// the consent screen with the request's parameters
+type ConsentRequest struct {
+	AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+// RegisterClient handles POST /admin/oauth/clients
+func (c *OAuthServerController) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	var req RegisterClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	scopes := make([]entities.Scope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = entities.Scope(scope)
+	}
+
+	client, secret, err := c.OAuthServer.RegisterClient(actorFrom(r), req.Name, req.RedirectURIs, scopes, req.Confidential)
+	if err != nil {
+		w.WriteHeader(clientErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RegisterClientResponse{Client: client, ClientSecret: secret})
+}
+
+// ListClients handles GET /admin/oauth/clients
+func (c *OAuthServerController) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := c.OAuthServer.ListClients()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clients)
+}
+
+// DeleteClient handles DELETE /admin/oauth/clients/{id}
+func (c *OAuthServerController) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	if err := c.OAuthServer.DeleteClient(actorFrom(r), mux.Vars(r)["id"]); err != nil {
+		w.WriteHeader(clientErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Authorize handles GET /oauth/authorize. The frontend forwards the client's
+// query parameters with the signed-in user's token and gets back what to show
+// on the consent screen.
+func (c *OAuthServerController) Authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	prompt, err := c.OAuthServer.Authorize(request)
+	if err != nil {
+		writeAuthorizationError(w, request, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prompt)
+}
+
+// Consent handles POST /oauth/authorize. The response's redirect_to is where
+// the frontend sends the browser: back to the client with a code, or with
+// access_denied.
+func (c *OAuthServerController) Consent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	redirect, err := c.OAuthServer.Consent(userID, req.AuthorizationRequest, req.Approve, clientInfo(r))
+	if err != nil {
+		writeAuthorizationError(w, req.AuthorizationRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"redirect_to": redirect})
+}
+
+// Token handles POST /oauth/token, where clients exchange an authorization
+// code for an access token. Confidential clients authenticate with HTTP Basic
+// or client_secret in the form.
+func (c *OAuthServerController) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Invalid form body")
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	response, err := c.OAuthServer.Token(TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	})
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Introspect handles POST /oauth/introspect, where confidential clients check
+// whether a token is active
+func (c *OAuthServerController) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Invalid form body")
+		return
+	}
+	clientID, clientSecret := clientCredentials(r)
+
+	introspection, err := c.OAuthServer.Introspect(clientID, clientSecret, r.PostForm.Get("token"))
+	if err != nil {
+		writeClientError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(introspection)
+}
+
+// clientCredentials reads the client's ID and secret from HTTP Basic
+// authentication, or else from the form
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		// Both are form-encoded before going in the header (RFC 6749
+		// section 2.3.1)
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// writeAuthorizationError reports a bad authorization request. Unless the
+// client or redirect URI is at fault, redirect_to sends the browser back to
+// the client with the error.
+func writeAuthorizationError(w http.ResponseWriter, request AuthorizationRequest, err error) {
+	code, status := oauthErrorCode(err)
+	if code == "invalid_client" {
+		status = http.StatusBadRequest
+	}
+	body := map[string]string{"error": code, "error_description": err.Error()}
+	if code != "invalid_client" && code != "server_error" && err.Error() != "redirect URI is not registered for this client" {
+		body["redirect_to"] = request.RedirectWith(url.Values{"error": {code}, "error_description": {err.Error()}})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeClientError reports an error to a client calling the token or
+// introspection endpoint
+func writeClientError(w http.ResponseWriter, r *http.Request, err error) {
+	code, status := oauthErrorCode(err)
+	if _, _, ok := r.BasicAuth(); ok && status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	writeOAuthError(w, status, code, err.Error())
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
+
+// oauthErrorCode maps authorization server errors to OAuth2 error codes (RFC
+// 6749 sections 4.1.2.1 and 5.2) and HTTP status codes
+func oauthErrorCode(err error) (string, int) {
+	message := err.Error()
+	switch {
+	case message == "unknown client", message == "client authentication failed":
+		return "invalid_client", http.StatusUnauthorized
+	case message == "response type must be code":
+		return "unsupported_response_type", http.StatusBadRequest
+	case message == "grant type must be authorization_code":
+		return "unsupported_grant_type", http.StatusBadRequest
+	case message == "scope is not allowed for this client", message == "at least one scope is required",
+		strings.HasPrefix(message, "unknown scope"):
+		return "invalid_scope", http.StatusBadRequest
+	case message == "invalid or expired authorization code",
+		message == "redirect URI does not match the authorization request",
+		message == "code verifier does not match the code challenge":
+		return "invalid_grant", http.StatusBadRequest
+	case strings.HasPrefix(message, "failed to"):
+		return "server_error", http.StatusInternalServerError
+	default:
+		return "invalid_request", http.StatusBadRequest
+	}
+}
+
+// clientErrorStatus maps client registration errors to HTTP status codes
+func clientErrorStatus(err error) int {
+	switch {
+	case err.Error() == "client not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
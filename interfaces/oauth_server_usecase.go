@@ -0,0 +1,88 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"net/url"
+)
+
+// AuthorizationRequest is a third-party app asking for a user's consent, as
+// sent to the authorization endpoint
+type AuthorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"` // space-separated; empty asks for all the client's scopes
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// RedirectWith returns the request's redirect URI with params and the
+// client's state added to its query
+func (r AuthorizationRequest) RedirectWith(params url.Values) string {
+	redirect, err := url.Parse(r.RedirectURI)
+	if err != nil {
+		return ""
+	}
+	query := redirect.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if r.State != "" {
+		query.Set("state", r.State)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect.String()
+}
+
+// ConsentPrompt is what the consent screen shows the user
+type ConsentPrompt struct {
+	ClientID    string           `json:"client_id"`
+	ClientName  string           `json:"client_name"`
+	Scopes      []entities.Scope `json:"scopes"`
+	RedirectURI string           `json:"redirect_uri"`
+}
+
+// TokenRequest is a client redeeming an authorization code at the token
+// endpoint. Public clients leave ClientSecret empty.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+}
+
+// TokenResponse is an access token issued to a client (RFC 6749 section 5.1)
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// TokenIntrospection describes a token to a client that asks about it (RFC
+// 7662). Only Active is set for tokens that aren't valid.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+}
+
+// OAuthServerUseCase lets third-party apps act for users who consent to them:
+// admins register the apps, users approve their requests, and the apps
+// exchange the resulting codes for scoped access tokens
+type OAuthServerUseCase interface {
+	RegisterClient(actor Actor, name string, redirectURIs []string, scopes []entities.Scope, confidential bool) (*entities.OAuthClient, string, error)
+	ListClients() ([]*entities.OAuthClient, error)
+	DeleteClient(actor Actor, id string) error
+	Authorize(request AuthorizationRequest) (*ConsentPrompt, error)
+	Consent(userID string, request AuthorizationRequest, approved bool, client ClientInfo) (string, error)
+	Token(request TokenRequest) (*TokenResponse, error)
+	Introspect(clientID, clientSecret, token string) (*TokenIntrospection, error)
+}
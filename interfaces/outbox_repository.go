@@ -0,0 +1,39 @@
+package interfaces
+
+import "time"
+
+// Outbox message statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusDead      = "dead"
+)
+
+// OutboxMessage is a serialized domain event waiting to be delivered to
+// subscribers. ID is the event ID and doubles as the idempotency key, so a
+// subscriber that sees the same ID twice can safely ignore the duplicate.
+type OutboxMessage struct {
+	ID            string
+	EventName     string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+}
+
+// OutboxWriter appends messages to the outbox
+type OutboxWriter interface {
+	Append(messages ...*OutboxMessage) error
+}
+
+type OutboxRepository interface {
+	OutboxWriter
+	FetchPending(limit int, now time.Time) ([]*OutboxMessage, error)
+	MarkDelivered(id string, deliveredAt time.Time) error
+	MarkFailed(id string, lastError string, nextAttemptAt time.Time) error
+	MarkDead(id string, lastError string) error
+	DeleteDeliveredBefore(before time.Time) error
+}
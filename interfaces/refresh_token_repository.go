@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+type RefreshTokenRepository interface {
+	Save(token *entities.RefreshToken) error
+	FindByHash(tokenHash string) (*entities.RefreshToken, error)
+	// MarkRotated records that the token was exchanged. It only succeeds for a
+	// token that has not been rotated yet, so two concurrent refreshes with the
+	// same token can't both win; the loser gets false.
+	MarkRotated(id, replacedBy string, at time.Time) (bool, error)
+	RevokeFamily(familyID string, at time.Time) error
+	RevokeAllForUser(userID string, at time.Time) error
+	DeleteExpired(before time.Time) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "gocleanarchitecture/entities"
+
+// RoleRepository stores custom roles. Built-in roles are defined in code and
+// never stored.
+type RoleRepository interface {
+	Save(role *entities.Role) error
+	FindByName(name entities.UserRole) (*entities.Role, error)
+	FindAll() ([]*entities.Role, error)
+	Delete(name entities.UserRole) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+type SessionRepository interface {
+	Save(session *entities.Session) error
+	FindByID(id string) (*entities.Session, error)
+	// FindByUserID returns the user's sessions, most recently seen first
+	FindByUserID(userID string) ([]*entities.Session, error)
+	RevokeAllForUser(userID string, at time.Time) error
+	DeleteExpired(before time.Time) error
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import "time"
+
+// TokenRevocationRepository records access tokens that must be rejected
+// before they expire. Individual tokens are revoked by their jti claim; all
+// tokens of a user are revoked at once by bumping the user's token
+// generation, since tokens carry the generation they were issued with.
+type TokenRevocationRepository interface {
+	// RevokeToken blocks a token until expiresAt, after which the entry is
+	// removed by DeleteExpired
+	RevokeToken(tokenID string, expiresAt time.Time) error
+	IsRevoked(tokenID string) (bool, error)
+	// BumpUserGeneration increments and returns the user's token generation
+	BumpUserGeneration(userID string) (int, error)
+	GetUserGeneration(userID string) (int, error)
+	DeleteExpired(now time.Time) error
+}
@@ -4,6 +4,10 @@ import "gocleanarchitecture/entities"
 
 type UserRepository interface {
 	Save(user *entities.User) error
+	// UpdatePasswordHash replaces only the user's password hash, and only
+	// while it is still oldHash, so that upgrading a hash can't overwrite
+	// other changes made meanwhile or undo a password change
+	UpdatePasswordHash(id, oldHash, newHash string) error
 	FindByID(id string) (*entities.User, error)
 	FindByEmail(email string) (*entities.User, error)
 	FindByUsername(username string) (*entities.User, error)
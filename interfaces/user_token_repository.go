@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"gocleanarchitecture/entities"
+	"time"
+)
+
+// UserTokenRepository stores the hashed email verification, password reset
+// and identity link tokens
+type UserTokenRepository interface {
+	Save(token *entities.UserToken) error
+	FindByHash(tokenHash string) (*entities.UserToken, error)
+	// Consume deletes a token and reports whether this call deleted it, so
+	// that a token works once even under concurrent requests
+	Consume(tokenHash string) (bool, error)
+	// DeleteByUser invalidates a user's outstanding tokens for a purpose
+	DeleteByUser(userID, purpose string) error
+	DeleteExpired(before time.Time) error
+}
@@ -1,8 +1,11 @@
 package interfaces
 
 import (
+	"encoding/json"
 	"gocleanarchitecture/frameworks/websocket"
 	"net/http"
+
+	"github.com/gorilla/mux"
 )
 
 type WebSocketHandler struct {
@@ -18,12 +21,24 @@ func NewWebSocketHandler(hub *websocket.Hub) *WebSocketHandler {
 // HandleWebSocket handles WebSocket connections
 // This endpoint allows both authenticated and anonymous connections
 func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Try to get user ID from context (if authenticated)
-	userID := ""
-	if uid, ok := r.Context().Value("userID").(string); ok {
-		userID = uid
-	}
+	// Try to get user info from context (if authenticated)
+	userID, _ := r.Context().Value("userID").(string)
+	username, _ := r.Context().Value("username").(string)
 
 	// Upgrade to WebSocket and serve
-	websocket.ServeWs(h.Hub, w, r, userID)
+	websocket.ServeWs(h.Hub, w, r, userID, username)
+}
+
+// GetPostPresence returns who is currently viewing a blog post
+func (h *WebSocketHandler) GetPostPresence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Hub.Presence(websocket.PostTopic(id)))
+}
+
+// GetHubStats returns live WebSocket hub statistics (admin only)
+func (h *WebSocketHandler) GetHubStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Hub.Stats())
 }
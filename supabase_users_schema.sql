@@ -9,7 +9,7 @@ CREATE TABLE IF NOT EXISTS users (
     full_name TEXT,
     bio TEXT,
     avatar_url TEXT,
-    role TEXT DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    role TEXT DEFAULT 'user',
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
@@ -0,0 +1,40 @@
+package entities_test
+
+import (
+	"gocleanarchitecture/entities"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleBacksOffExponentially(t *testing.T) {
+	policy := entities.LockoutPolicy{MaxFailures: 3, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute, Window: time.Hour}
+	throttle := entities.NewLoginThrottle("account:user-1")
+	now := time.Now()
+
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	for i, expected := range want {
+		if lock := throttle.RecordFailure(now, policy); lock != expected {
+			t.Errorf("Failure %d: expected lock %v, got %v", i+1, expected, lock)
+		}
+	}
+	if throttle.RetryAfter(now) != 5*time.Minute {
+		t.Errorf("Expected to retry after 5m, got %v", throttle.RetryAfter(now))
+	}
+	if throttle.RetryAfter(now.Add(5*time.Minute)) != 0 {
+		t.Error("Expected the lock to expire")
+	}
+}
+
+func TestLoginThrottleForgetsOldFailures(t *testing.T) {
+	policy := entities.LockoutPolicy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+	throttle := entities.NewLoginThrottle("ip:203.0.113.7")
+	now := time.Now()
+
+	throttle.RecordFailure(now, policy)
+	if lock := throttle.RecordFailure(now.Add(2*time.Hour), policy); lock != 0 {
+		t.Errorf("Expected failures outside the window to be forgotten, got lock %v", lock)
+	}
+	if throttle.Failures != 1 {
+		t.Errorf("Expected 1 failure, got %d", throttle.Failures)
+	}
+}
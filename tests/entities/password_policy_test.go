@@ -0,0 +1,129 @@
+package entities_test
+
+import (
+	"gocleanarchitecture/entities"
+	"strings"
+	"testing"
+)
+
+func violatedRules(violations []entities.PasswordViolation) []string {
+	rules := make([]string, len(violations))
+	for i, violation := range violations {
+		rules[i] = violation.Rule
+	}
+	return rules
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := entities.PasswordPolicy{
+		MinLength:          12,
+		RequireLowercase:   true,
+		RequireUppercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		RejectPersonalInfo: true,
+	}
+
+	testCases := []struct {
+		password string
+		expected []string
+	}{
+		{"Kx9#vq2Lm!pRt", nil},
+		{"Kx9#vq2L", []string{entities.PasswordRuleMinLength}},
+		{"kx9#vq2lm!prt", []string{entities.PasswordRuleUppercase}},
+		{"KX9#VQ2LM!PRT", []string{entities.PasswordRuleLowercase}},
+		{"Kxw#vqzLm!pRt", []string{entities.PasswordRuleDigit}},
+		{"Kx9avq2Lm0pRt", []string{entities.PasswordRuleSymbol}},
+		{"short", []string{entities.PasswordRuleMinLength, entities.PasswordRuleUppercase, entities.PasswordRuleDigit, entities.PasswordRuleSymbol}},
+		{"x9#Alice_Smith!", []string{entities.PasswordRulePersonalInfo}},
+		{"Kx9#ALICE@example.COM", []string{entities.PasswordRulePersonalInfo}},
+		{strings.Repeat("Kx9#", 65), []string{entities.PasswordRuleMaxLength}},
+	}
+
+	for _, tc := range testCases {
+		violations := policy.Check(tc.password, "alice_smith", "alice@example.com")
+		if got := violatedRules(violations); strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%q: expected violations %v, got %v", tc.password, tc.expected, got)
+		}
+		for _, violation := range violations {
+			if violation.Message == "" {
+				t.Errorf("%q: expected a message for rule %s", tc.password, violation.Rule)
+			}
+		}
+	}
+}
+
+func TestPasswordPolicyMinLengthFloor(t *testing.T) {
+	violations := entities.PasswordPolicy{MinLength: 4}.Check("abcdefg")
+	if len(violations) != 1 || violations[0].Message != "password must be at least 8 characters" {
+		t.Errorf("Expected the minimum length never to drop below 8, got %v", violations)
+	}
+
+	// Length is counted in characters, not bytes
+	if violations := (entities.PasswordPolicy{MinLength: 8}).Check("пароль12"); len(violations) != 0 {
+		t.Errorf("Expected 8 Cyrillic characters to be long enough, got %v", violations)
+	}
+}
+
+func TestPasswordPolicyIgnoresShortPersonalInfo(t *testing.T) {
+	policy := entities.DefaultPasswordPolicy()
+	if violations := policy.Check("bobcat-tundra-77", "bo", "bo@example.com"); len(violations) != 0 {
+		t.Errorf("Expected inputs under 3 characters to be ignored, got %v", violations)
+	}
+}
+
+func TestPasswordPolicyStrength(t *testing.T) {
+	policy := entities.PasswordPolicy{MinLength: 8, MinStrength: 3}
+
+	violations := policy.Check("password123")
+	if len(violations) != 1 || violations[0].Rule != entities.PasswordRuleStrength {
+		t.Fatalf("Expected a strength violation, got %v", violations)
+	}
+	if !strings.Contains(violations[0].Message, "common password") {
+		t.Errorf("Expected the message to say why, got %q", violations[0].Message)
+	}
+
+	if violations := policy.Check("correct horse battery staple"); len(violations) != 0 {
+		t.Errorf("Expected a long passphrase to pass, got %v", violations)
+	}
+}
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	testCases := []struct {
+		password string
+		maxScore int
+		minScore int
+		warning  string
+	}{
+		{"password", 0, 0, "common password"},
+		{"P@ssw0rd", 0, 0, "common password"},
+		{"qwertyuiop", 0, 0, "common password"},
+		{"zxcvfdsa", 1, 0, "keyboard pattern"},
+		{"abcdefghij", 0, 0, "sequence"},
+		{"9876543210", 1, 0, "sequence"},
+		{"aaaaaaaaaaaa", 0, 0, "repeats"},
+		{"xk3xk3xk3xk3", 1, 0, "repeats"},
+		{"alice1990", 2, 0, ""},
+		{"alice_smith2024", 1, 0, "username"},
+		{"kX9#vq2Lm!pR", 4, 4, ""},
+		{"correct horse battery staple", 4, 4, ""},
+	}
+
+	for _, tc := range testCases {
+		strength := entities.EstimatePasswordStrength(tc.password, "alice_smith", "alice@example.com")
+		if strength.Score > tc.maxScore || strength.Score < tc.minScore {
+			t.Errorf("%q: expected a score from %d to %d, got %d (%g guesses)", tc.password, tc.minScore, tc.maxScore, strength.Score, strength.Guesses)
+		}
+		if tc.warning != "" && !strings.Contains(strength.Warning, tc.warning) {
+			t.Errorf("%q: expected a warning about %q, got %q", tc.password, tc.warning, strength.Warning)
+		}
+	}
+}
+
+func TestEstimatePasswordStrengthLongInput(t *testing.T) {
+	// Only the first 100 characters are analysed, so this stays fast
+	strength := entities.EstimatePasswordStrength(strings.Repeat("ab", 1000))
+	if strength.Score > 1 {
+		t.Errorf("Expected a long repeated pattern to stay weak, got %d", strength.Score)
+	}
+}
@@ -0,0 +1,148 @@
+package entities_test
+
+import (
+	"gocleanarchitecture/entities"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2idHasherPHCFormat(t *testing.T) {
+	hash, err := hasher.Hash("password123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Expected a PHC-encoded argon2id hash, got %q", hash)
+	}
+	if len(strings.Split(hash, "$")) != 6 {
+		t.Errorf("Expected six $-separated fields, got %q", hash)
+	}
+
+	other, _ := hasher.Hash("password123")
+	if other == hash {
+		t.Error("Expected a new salt for every hash")
+	}
+}
+
+func TestArgon2idHasherVerify(t *testing.T) {
+	hash, _ := hasher.Hash("password123")
+
+	if !hasher.Verify("password123", hash) {
+		t.Error("Expected the password to verify")
+	}
+	if hasher.Verify("password124", hash) {
+		t.Error("Expected a wrong password to fail")
+	}
+	for _, malformed := range []string{"", "plaintext", "$argon2id$v=19$m=19456,t=2,p=1$%%%$abc", "$argon2id$v=16$m=19456,t=2,p=1$c2FsdA$aGFzaA", "$argon2i$v=19$m=19456,t=2,p=1$c2FsdA$aGFzaA"} {
+		if hasher.Verify("password123", malformed) {
+			t.Errorf("Expected %q not to verify", malformed)
+		}
+	}
+}
+
+func TestArgon2idHasherAcceptsLongPasswords(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	user, err := entities.NewUser("testuser", "test@example.com", long, "Test User", hasher)
+	if err != nil {
+		t.Fatalf("Expected a 100-character password to be accepted, got %v", err)
+	}
+
+	// bcrypt ignored everything past 72 bytes
+	if user.VerifyPassword(hasher, strings.Repeat("a", 72)+strings.Repeat("b", 28)) {
+		t.Error("Expected every character of the password to count")
+	}
+
+	_, err = entities.NewUser("testuser", "test@example.com", strings.Repeat("a", entities.MaxPasswordLength+1), "Test User", hasher)
+	if err == nil {
+		t.Error("Expected an error for a password over the maximum length")
+	}
+}
+
+func TestArgon2idHasherVerifiesLegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt failed: %v", err)
+	}
+
+	if !hasher.Verify("password123", string(legacy)) {
+		t.Error("Expected a bcrypt hash to verify")
+	}
+	if hasher.Verify("wrongpassword", string(legacy)) {
+		t.Error("Expected a wrong password to fail against a bcrypt hash")
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Error("Expected a bcrypt hash to need a rehash")
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	hash, _ := hasher.Hash("password123")
+	if hasher.NeedsRehash(hash) {
+		t.Error("Expected a hash with the current parameters not to need a rehash")
+	}
+
+	params := entities.DefaultArgon2idParams()
+	params.Iterations++
+	stronger := entities.NewArgon2idHasher(params)
+	if !stronger.NeedsRehash(hash) {
+		t.Error("Expected a hash with fewer iterations to need a rehash")
+	}
+	if !stronger.Verify("password123", hash) {
+		t.Error("Expected a hash with other parameters to still verify")
+	}
+
+	if hasher.NeedsRehash("plaintext") {
+		t.Error("Expected an unrecognised hash not to need a rehash")
+	}
+}
+
+func TestRehashPassword(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &entities.User{PasswordHash: string(legacy)}
+
+	changed, err := user.RehashPassword(hasher, "password123")
+	if err != nil || !changed {
+		t.Fatalf("Expected the bcrypt hash to be replaced, got changed=%v err=%v", changed, err)
+	}
+	if !strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+		t.Errorf("Expected an argon2id hash, got %q", user.PasswordHash)
+	}
+	if !user.VerifyPassword(hasher, "password123") {
+		t.Error("Expected the password to verify against the new hash")
+	}
+
+	changed, _ = user.RehashPassword(hasher, "password123")
+	if changed {
+		t.Error("Expected a current hash to be left alone")
+	}
+}
+
+func TestArgon2idHasherWithLimitUnderLoad(t *testing.T) {
+	limited := entities.NewArgon2idHasherWithLimit(entities.DefaultArgon2idParams(), 1)
+	hash, err := limited.Hash("password123")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+
+	// Callers beyond the limit wait their turn rather than fail
+	var wg sync.WaitGroup
+	failures := make(chan string, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !limited.Verify("password123", hash) {
+				failures <- "Expected the password to verify"
+			}
+		}()
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
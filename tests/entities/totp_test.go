@@ -0,0 +1,86 @@
+package entities_test
+
+import (
+	"gocleanarchitecture/entities"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for SHA-1, truncated to 6 digits
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // base32 of "12345678901234567890"
+
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		got, err := entities.TOTPCode(secret, time.Unix(c.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) failed: %v", c.unix, err)
+		}
+		if got != c.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", c.unix, got, c.want)
+		}
+	}
+}
+
+func TestMFAEnrollmentVerifyCode(t *testing.T) {
+	enrollment, err := entities.NewMFAEnrollment("user-1")
+	if err != nil {
+		t.Fatalf("Failed to create enrolment: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	previous, _ := entities.TOTPCode(enrollment.Secret, now.Add(-entities.TOTPPeriod*time.Second))
+	if !enrollment.VerifyCode(previous, now) {
+		t.Fatal("Expected the previous step's code to be accepted")
+	}
+	if enrollment.VerifyCode(previous, now) {
+		t.Error("Expected a used code to be rejected")
+	}
+
+	current, _ := entities.TOTPCode(enrollment.Secret, now)
+	if !enrollment.VerifyCode(current, now) {
+		t.Error("Expected the current code to be accepted")
+	}
+
+	stale, _ := entities.TOTPCode(enrollment.Secret, now.Add(-5*time.Minute))
+	if enrollment.VerifyCode(stale, now.Add(time.Minute)) {
+		t.Error("Expected a code from several steps ago to be rejected")
+	}
+}
+
+func TestMFAEnrollmentUseRecoveryCode(t *testing.T) {
+	enrollment := &entities.MFAEnrollment{UserID: "user-1"}
+	enrollment.Confirm(time.Now(), []string{"hash-a", "hash-b"})
+
+	if !enrollment.UseRecoveryCode("hash-a") {
+		t.Fatal("Expected the recovery code to be accepted")
+	}
+	if enrollment.UseRecoveryCode("hash-a") {
+		t.Error("Expected a recovery code to work once")
+	}
+	if len(enrollment.RecoveryCodeHashes) != 1 || enrollment.RecoveryCodeHashes[0] != "hash-b" {
+		t.Errorf("Expected hash-b to remain, got %v", enrollment.RecoveryCodeHashes)
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := entities.TOTPProvisioningURI("Go Blog", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Go%20Blog:alice@example.com?") {
+		t.Errorf("Unexpected label in %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Go+Blog", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("Expected %s in %s", part, uri)
+		}
+	}
+}
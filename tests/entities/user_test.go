@@ -7,8 +7,10 @@ import (
 	"time"
 )
 
+var hasher = entities.DefaultPasswordHasher()
+
 func TestNewUser(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
@@ -58,7 +60,7 @@ func TestNewUserValidation(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		_, err := entities.NewUser(tc.username, tc.email, tc.password, tc.fullName)
+		_, err := entities.NewUser(tc.username, tc.email, tc.password, tc.fullName, hasher)
 		if err == nil {
 			t.Errorf("Expected error for username=%s, email=%s, password=%s, fullName=%s, got nil",
 				tc.username, tc.email, tc.password, tc.fullName)
@@ -72,24 +74,24 @@ func TestNewUserValidation(t *testing.T) {
 }
 
 func TestVerifyPassword(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
 
 	// Test correct password
-	if !user.VerifyPassword("password123") {
+	if !user.VerifyPassword(hasher, "password123") {
 		t.Error("Expected password verification to succeed with correct password")
 	}
 
 	// Test incorrect password
-	if user.VerifyPassword("wrongpassword") {
+	if user.VerifyPassword(hasher, "wrongpassword") {
 		t.Error("Expected password verification to fail with wrong password")
 	}
 }
 
 func TestUpdate(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
@@ -123,7 +125,7 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestUpdateValidation(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
@@ -144,7 +146,7 @@ func TestUpdateValidation(t *testing.T) {
 }
 
 func TestChangePassword(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
@@ -152,7 +154,7 @@ func TestChangePassword(t *testing.T) {
 	originalHash := user.PasswordHash
 
 	// Change password
-	err = user.ChangePassword("password123", "newpassword456")
+	err = user.ChangePassword(hasher, "password123", "newpassword456")
 	if err != nil {
 		t.Fatalf("Expected no error changing password, got %v", err)
 	}
@@ -162,24 +164,24 @@ func TestChangePassword(t *testing.T) {
 	}
 
 	// Verify old password doesn't work
-	if user.VerifyPassword("password123") {
+	if user.VerifyPassword(hasher, "password123") {
 		t.Error("Expected old password to not work after change")
 	}
 
 	// Verify new password works
-	if !user.VerifyPassword("newpassword456") {
+	if !user.VerifyPassword(hasher, "newpassword456") {
 		t.Error("Expected new password to work after change")
 	}
 }
 
 func TestChangePasswordWrongOldPassword(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
 
 	// Try to change password with wrong old password
-	err = user.ChangePassword("wrongpassword", "newpassword456")
+	err = user.ChangePassword(hasher, "wrongpassword", "newpassword456")
 	if err == nil {
 		t.Fatal("Expected error for wrong old password, got nil")
 	}
@@ -190,20 +192,20 @@ func TestChangePasswordWrongOldPassword(t *testing.T) {
 }
 
 func TestChangePasswordValidation(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
 
 	// New password too short
-	err = user.ChangePassword("password123", "pass")
+	err = user.ChangePassword(hasher, "password123", "pass")
 	if err == nil {
 		t.Error("Expected error for short new password, got nil")
 	}
 }
 
 func TestSanitize(t *testing.T) {
-	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User")
+	user, err := entities.NewUser("testuser", "test@example.com", "password123", "Test User", hasher)
 	if err != nil {
 		t.Fatalf("Expected no error creating user, got %v", err)
 	}
@@ -0,0 +1,126 @@
+package auth_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"gocleanarchitecture/frameworks/auth"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachedFile writes a corpus of the given passwords plus filler hashes,
+// sorted like the real one
+func writeBreachedFile(t *testing.T, lineEnding string, passwords map[string]int) string {
+	t.Helper()
+	var lines []string
+	for password, count := range passwords {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), count))
+	}
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("filler-%d", i)), i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, lineEnding)+lineEnding), 0o600); err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+	return path
+}
+
+func TestBreachedPasswordFileRange(t *testing.T) {
+	for _, lineEnding := range []string{"\n", "\r\n"} {
+		path := writeBreachedFile(t, lineEnding, map[string]int{"password123": 2500000, "hunter2": 17})
+		file, err := auth.OpenBreachedPasswordFile(path)
+		if err != nil {
+			t.Fatalf("Failed to open corpus: %v", err)
+		}
+		defer file.Close()
+
+		for password, count := range map[string]int{"password123": 2500000, "hunter2": 17, "filler-0": 1, "filler-1999": 2000} {
+			hash := sha1Hex(password)
+			suffixes, err := file.Range(strings.ToLower(hash[:5]))
+			if err != nil {
+				t.Fatalf("Range failed: %v", err)
+			}
+			if suffixes[hash[5:]] != count {
+				t.Errorf("Expected %s to have been seen %d times, got %d", password, count, suffixes[hash[5:]])
+			}
+			for suffix := range suffixes {
+				if len(suffix) != 35 {
+					t.Errorf("Expected 35-digit suffixes, got %q", suffix)
+				}
+			}
+		}
+
+		hash := sha1Hex("kX9#vq2Lm!pR")
+		suffixes, err := file.Range(hash[:5])
+		if err != nil {
+			t.Fatalf("Range failed: %v", err)
+		}
+		if _, found := suffixes[hash[5:]]; found {
+			t.Error("Expected a password not in the corpus not to be found")
+		}
+	}
+}
+
+func TestBreachedPasswordFileEdges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edges.txt")
+	corpus := "00000" + strings.Repeat("A", 35) + ":3\n" +
+		"00000" + strings.Repeat("B", 35) + ":4\n" +
+		"7FFFF" + strings.Repeat("C", 35) + ":5\n" +
+		"FFFFF" + strings.Repeat("D", 35) + ":6" // no newline at the end
+	if err := os.WriteFile(path, []byte(corpus), 0o600); err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+	file, err := auth.OpenBreachedPasswordFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open corpus: %v", err)
+	}
+	defer file.Close()
+
+	testCases := map[string]int{"00000": 2, "7FFFF": 1, "FFFFF": 1, "12345": 0}
+	for prefix, expected := range testCases {
+		suffixes, err := file.Range(prefix)
+		if err != nil {
+			t.Fatalf("Range(%s) failed: %v", prefix, err)
+		}
+		if len(suffixes) != expected {
+			t.Errorf("Range(%s): expected %d hashes, got %v", prefix, expected, suffixes)
+		}
+	}
+
+	for _, prefix := range []string{"", "0000", "000000", "0000G"} {
+		if _, err := file.Range(prefix); err == nil {
+			t.Errorf("Expected an error for prefix %q", prefix)
+		}
+	}
+}
+
+func TestOpenBreachedPasswordFileRejectsOtherFormats(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"empty.txt": "",
+		"words.txt": "password\nletmein\n",
+		"ntlm.txt":  "00000011059407D743D40689940F858C:3\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o600)
+		if _, err := auth.OpenBreachedPasswordFile(path); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+
+	if _, err := auth.OpenBreachedPasswordFile(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Expected a missing file to be rejected")
+	}
+}
@@ -0,0 +1,161 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"gocleanarchitecture/frameworks/auth"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaKey(t *testing.T, id string) *auth.SigningKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	key, err := auth.ParseKeyPEM(id, data)
+	if err != nil {
+		t.Fatalf("Failed to parse RSA key: %v", err)
+	}
+	return key
+}
+
+func ed25519Key(t *testing.T, id string) *auth.SigningKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 key: %v", err)
+	}
+	key, err := auth.ParseKeyPEM(id, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("Failed to parse Ed25519 key: %v", err)
+	}
+	return key
+}
+
+func newManager(t *testing.T, config auth.JWTConfig) *auth.JWTManager {
+	t.Helper()
+	if config.TokenDuration == 0 {
+		config.TokenDuration = 15 * time.Minute
+	}
+	manager, err := auth.NewJWTManagerWithConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to create JWT manager: %v", err)
+	}
+	return manager
+}
+
+func headerOf(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	return parsed.Header
+}
+
+func TestAsymmetricKeysSignWithKid(t *testing.T) {
+	for _, key := range []*auth.SigningKey{rsaKey(t, "rsa-1"), ed25519Key(t, "ed-1")} {
+		manager := newManager(t, auth.JWTConfig{Keys: []*auth.SigningKey{key}})
+
+		token, err := manager.GenerateToken(auth.Subject{UserID: "user-1"})
+		if err != nil {
+			t.Fatalf("Failed to sign with %s: %v", key.Algorithm, err)
+		}
+		header := headerOf(t, token)
+		if header["kid"] != key.ID || header["alg"] != key.Algorithm {
+			t.Errorf("Expected kid %s and alg %s, got %v", key.ID, key.Algorithm, header)
+		}
+
+		claims, err := manager.ValidateToken(token)
+		if err != nil || claims.UserID != "user-1" {
+			t.Errorf("Expected %s token to validate, got %v", key.Algorithm, err)
+		}
+	}
+}
+
+func TestRotationKeepsRetiredKeyForVerification(t *testing.T) {
+	now := time.Now()
+	old := rsaKey(t, "old")
+	oldManager := newManager(t, auth.JWTConfig{Keys: []*auth.SigningKey{old}})
+	oldToken, _ := oldManager.GenerateToken(auth.Subject{UserID: "user-1"})
+
+	// The old key retired a minute ago; tokens it signed are still valid
+	old.RetireAt = now.Add(-time.Minute)
+	current := ed25519Key(t, "current")
+	current.ActivateAt = now.Add(-time.Minute)
+	next := rsaKey(t, "next")
+	next.ActivateAt = now.Add(time.Hour)
+	manager := newManager(t, auth.JWTConfig{Keys: []*auth.SigningKey{old, current, next}})
+
+	token, _ := manager.GenerateToken(auth.Subject{UserID: "user-1"})
+	if kid := headerOf(t, token)["kid"]; kid != "current" {
+		t.Errorf("Expected the active key to sign, got %v", kid)
+	}
+	if _, err := manager.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected token from retired key to validate, got %v", err)
+	}
+
+	kids := []string{}
+	for _, jwk := range manager.JWKS().Keys {
+		kids = append(kids, jwk.KeyID)
+	}
+	if strings.Join(kids, ",") != "old,current,next" {
+		t.Errorf("Expected JWKS to publish retired, active and upcoming keys, got %v", kids)
+	}
+
+	// Once every token of the old key has expired, it is dropped
+	old.RetireAt = now.Add(-time.Hour)
+	if _, err := manager.ValidateToken(oldToken); err == nil {
+		t.Error("Expected token from a long-retired key to be rejected")
+	}
+	if len(manager.JWKS().Keys) != 2 {
+		t.Errorf("Expected the expired key to leave the JWKS, got %d keys", len(manager.JWKS().Keys))
+	}
+}
+
+func TestHS256AcceptedDuringMigration(t *testing.T) {
+	legacy := auth.NewJWTManager("secret", 15*time.Minute)
+	legacyToken, _ := legacy.GenerateToken(auth.Subject{UserID: "user-1"})
+
+	keys := []*auth.SigningKey{rsaKey(t, "rsa-1")}
+	migrating := newManager(t, auth.JWTConfig{Secret: "secret", AcceptHS256: true, Keys: keys})
+	if _, err := migrating.ValidateToken(legacyToken); err != nil {
+		t.Errorf("Expected HS256 token to validate during migration, got %v", err)
+	}
+
+	migrated := newManager(t, auth.JWTConfig{Secret: "secret", Keys: keys})
+	if _, err := migrated.ValidateToken(legacyToken); err == nil {
+		t.Error("Expected HS256 token to be rejected once migration is over")
+	}
+}
+
+func TestUnknownKidRejected(t *testing.T) {
+	signer := newManager(t, auth.JWTConfig{Keys: []*auth.SigningKey{rsaKey(t, "a")}})
+	verifier := newManager(t, auth.JWTConfig{Keys: []*auth.SigningKey{rsaKey(t, "b")}})
+
+	token, _ := signer.GenerateToken(auth.Subject{UserID: "user-1"})
+	if _, err := verifier.ValidateToken(token); err == nil {
+		t.Error("Expected token signed by an unknown key to be rejected")
+	}
+}
+
+func TestParseKeyPEMRejectsWeakRSAKeys(t *testing.T) {
+	private, _ := rsa.GenerateKey(rand.Reader, 1024)
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	if _, err := auth.ParseKeyPEM("weak", data); err == nil {
+		t.Error("Expected 1024-bit RSA key to be rejected")
+	}
+}
@@ -0,0 +1,169 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gocleanarchitecture/frameworks/auth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// verifier is the PKCE verifier the fake provider expects
+const verifier = "pkce-verifier-0123456789-abcdefghijklmnopqrstuvwxyz"
+
+// fakeProvider stands in for GitHub's and Google's token and API endpoints
+func fakeProvider(t *testing.T, user, emails interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") != verifier {
+			http.Error(w, `{"error":"bad_verification_code"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access-token", "token_type": "bearer"})
+	})
+	api := func(body interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer access-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(body)
+		}
+	}
+	mux.HandleFunc("/user", api(user))
+	mux.HandleFunc("/user/emails", api(emails))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func endpoints(server *httptest.Server) auth.OAuth2Endpoints {
+	return auth.OAuth2Endpoints{
+		AuthURL:     server.URL + "/authorize",
+		TokenURL:    server.URL + "/token",
+		UserInfoURL: server.URL + "/user",
+		EmailsURL:   server.URL + "/user/emails",
+	}
+}
+
+func signIn(provider *auth.OAuth2Provider) (*auth.UserInfo, error) {
+	token, err := provider.ExchangeCode(context.Background(), "good-code", verifier)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetUserInfo(context.Background(), token)
+}
+
+var githubUser = map[string]interface{}{
+	"id":         42,
+	"login":      "octocat",
+	"email":      "public@example.com",
+	"avatar_url": "https://example.com/octocat.png",
+}
+
+func TestGitHubUsesPrimaryVerifiedEmail(t *testing.T) {
+	server := fakeProvider(t, githubUser, []map[string]interface{}{
+		{"email": "old@example.com", "primary": false, "verified": true},
+		{"email": "octo@example.com", "primary": true, "verified": true},
+	})
+	provider := auth.NewGitHubOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	info, err := signIn(provider)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if info.Email != "octo@example.com" || !info.EmailVerified {
+		t.Errorf("Expected the primary verified address, got %q (verified %v)", info.Email, info.EmailVerified)
+	}
+	if info.ProviderID != "42" || info.Name != "octocat" || info.Provider != "github" {
+		t.Errorf("Unexpected user info: %+v", info)
+	}
+}
+
+func TestGitHubRefusesUnverifiedPrimaryEmail(t *testing.T) {
+	server := fakeProvider(t, githubUser, []map[string]interface{}{
+		{"email": "octo@example.com", "primary": true, "verified": false},
+		{"email": "other@example.com", "primary": false, "verified": true},
+	})
+	provider := auth.NewGitHubOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	if _, err := signIn(provider); !errors.Is(err, auth.ErrNoVerifiedEmail) {
+		t.Errorf("Expected ErrNoVerifiedEmail, got %v", err)
+	}
+}
+
+func TestGitHubWithoutEmails(t *testing.T) {
+	server := fakeProvider(t, githubUser, []map[string]interface{}{})
+	provider := auth.NewGitHubOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	if _, err := signIn(provider); !errors.Is(err, auth.ErrNoVerifiedEmail) {
+		t.Errorf("Expected ErrNoVerifiedEmail, got %v", err)
+	}
+}
+
+func TestGoogleUserInfo(t *testing.T) {
+	server := fakeProvider(t, map[string]interface{}{
+		"id":             "1234",
+		"email":          "user@example.com",
+		"verified_email": true,
+		"name":           "Example User",
+		"picture":        "https://example.com/user.png",
+	}, nil)
+	provider := auth.NewGoogleOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	info, err := signIn(provider)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if info.ProviderID != "1234" || info.Email != "user@example.com" || !info.EmailVerified || info.Name != "Example User" {
+		t.Errorf("Unexpected user info: %+v", info)
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	server := fakeProvider(t, githubUser, nil)
+	provider := auth.NewGitHubOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	if _, err := provider.ExchangeCode(context.Background(), "bad-code", verifier); err == nil {
+		t.Error("Expected a rejected code to fail")
+	}
+
+	// API errors aren't parsed as user info
+	provider.Endpoints.UserInfoURL = server.URL + "/missing"
+	token, err := provider.ExchangeCode(context.Background(), "good-code", verifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if _, err := provider.GetUserInfo(context.Background(), token); err == nil {
+		t.Error("Expected a failed API call to fail")
+	}
+}
+
+func TestOAuth2UsesPKCE(t *testing.T) {
+	server := fakeProvider(t, githubUser, nil)
+	provider := auth.NewGitHubOAuth2ProviderWithEndpoints("id", "secret", "http://localhost/callback", endpoints(server))
+
+	authURL, err := url.Parse(provider.GetAuthURL("state", verifier))
+	if err != nil {
+		t.Fatalf("Invalid authorization URL: %v", err)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	query := authURL.Query()
+	if query.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(sum[:]) || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("Expected an S256 challenge, got %s", authURL)
+	}
+
+	// A code can't be redeemed without the verifier of the flow it was
+	// issued to
+	if _, err := provider.ExchangeCode(context.Background(), "good-code", "another-verifier-0123456789-abcdefghijklmnopqrstuvwxyz"); err == nil {
+		t.Error("Expected the wrong verifier to be rejected")
+	}
+}
@@ -0,0 +1,300 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"gocleanarchitecture/frameworks/auth"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIdP stands in for an OpenID Connect provider such as Keycloak. It
+// remembers the PKCE challenge and nonce of the last authorization request,
+// and claims lets a test tamper with the ID token it issues.
+type fakeIdP struct {
+	server    *httptest.Server
+	issuer    string // reported in discovery, normally the server URL
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	claims    func(jwt.MapClaims)
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	idp := &fakeIdP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 idp.issuer,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"userinfo_endpoint":      idp.server.URL + "/userinfo",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "idp-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") != "good-code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     idp.idToken(t),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"sub": "user-123", "email": "info@example.com", "email_verified": true})
+	})
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) idToken(t *testing.T) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.issuer,
+		"sub":            "user-123",
+		"aud":            "client-id",
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          idp.nonce,
+		"email":          "kc@example.com",
+		"email_verified": true,
+		"name":           "Kay Cloak",
+	}
+	if idp.claims != nil {
+		idp.claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("Failed to sign ID token: %v", err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func newOIDCProvider(t *testing.T, idp *fakeIdP, config auth.OIDCConfig) *auth.OIDCProvider {
+	t.Helper()
+	config.Name = "keycloak"
+	config.IssuerURL = idp.server.URL
+	config.ClientID = "client-id"
+	config.ClientSecret = "client-secret"
+	config.RedirectURL = "http://localhost/auth/oidc/keycloak/callback"
+	provider, err := auth.NewOIDCProvider(config)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return provider
+}
+
+// oidcSignIn runs a sign-in as a browser would: the provider's authorization
+// URL carries the challenge and nonce, then the callback redeems the code
+func oidcSignIn(t *testing.T, idp *fakeIdP, provider *auth.OIDCProvider) (*auth.UserInfo, error) {
+	t.Helper()
+	flow, err := auth.NewAuthFlow("keycloak")
+	if err != nil {
+		t.Fatalf("Failed to start flow: %v", err)
+	}
+	authURL, err := provider.AuthURL(context.Background(), flow)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || !strings.Contains(query.Get("scope"), "openid") {
+		t.Errorf("Expected a PKCE openid request, got %s", authURL)
+	}
+	idp.challenge = query.Get("code_challenge")
+	idp.nonce = query.Get("nonce")
+
+	return provider.Authenticate(context.Background(), "good-code", flow)
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := newOIDCProvider(t, idp, auth.OIDCConfig{})
+
+	info, err := oidcSignIn(t, idp, provider)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if info.Provider != "keycloak" || info.ProviderID != "user-123" {
+		t.Errorf("Unexpected identity: %+v", info)
+	}
+	if info.Email != "kc@example.com" || !info.EmailVerified || info.Name != "Kay Cloak" {
+		t.Errorf("Unexpected profile: %+v", info)
+	}
+}
+
+func TestOIDCClaimMapping(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = func(claims jwt.MapClaims) {
+		// Azure AD style: the address is in upn and isn't marked verified
+		delete(claims, "email")
+		delete(claims, "email_verified")
+		claims["upn"] = "kay@corp.example.com"
+		claims["preferred_username"] = "kay"
+	}
+	provider := newOIDCProvider(t, idp, auth.OIDCConfig{
+		Claims:     auth.OIDCClaims{Email: "upn", Name: "preferred_username"},
+		TrustEmail: true,
+	})
+
+	info, err := oidcSignIn(t, idp, provider)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if info.Email != "kay@corp.example.com" || !info.EmailVerified || info.Name != "kay" {
+		t.Errorf("Unexpected profile: %+v", info)
+	}
+}
+
+func TestOIDCFallsBackToUserInfo(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = func(claims jwt.MapClaims) {
+		delete(claims, "email")
+		delete(claims, "email_verified")
+	}
+	provider := newOIDCProvider(t, idp, auth.OIDCConfig{})
+
+	info, err := oidcSignIn(t, idp, provider)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if info.Email != "info@example.com" || !info.EmailVerified {
+		t.Errorf("Expected the address from the userinfo endpoint, got %+v", info)
+	}
+}
+
+func TestOIDCRejectsInvalidIDTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(idp *fakeIdP, claims jwt.MapClaims)
+	}{
+		{"wrong nonce", func(idp *fakeIdP, claims jwt.MapClaims) { claims["nonce"] = "replayed" }},
+		{"wrong audience", func(idp *fakeIdP, claims jwt.MapClaims) { claims["aud"] = "another-client" }},
+		{"wrong issuer", func(idp *fakeIdP, claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{"expired", func(idp *fakeIdP, claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"other authorized party", func(idp *fakeIdP, claims jwt.MapClaims) {
+			claims["aud"] = []string{"client-id", "another-client"}
+			claims["azp"] = "another-client"
+		}},
+		// The JWKS keeps publishing the original key
+		{"bad signature", func(idp *fakeIdP, claims jwt.MapClaims) { idp.key = otherKey }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.claims = func(claims jwt.MapClaims) { tt.tamper(idp, claims) }
+			provider := newOIDCProvider(t, idp, auth.OIDCConfig{})
+
+			if _, err := oidcSignIn(t, idp, provider); err == nil {
+				t.Error("Expected the ID token to be rejected")
+			}
+		})
+	}
+}
+
+func TestOIDCRequiresPKCEVerifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := newOIDCProvider(t, idp, auth.OIDCConfig{})
+
+	if _, err := oidcSignIn(t, idp, provider); err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+
+	// A code redeemed with another flow's verifier is refused by the provider
+	other, _ := auth.NewAuthFlow("keycloak")
+	other.Nonce = idp.nonce
+	if _, err := provider.Authenticate(context.Background(), "good-code", other); err == nil {
+		t.Error("Expected a mismatched PKCE verifier to be rejected")
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.issuer = "https://evil.example.com"
+	provider := newOIDCProvider(t, idp, auth.OIDCConfig{})
+
+	flow, _ := auth.NewAuthFlow("keycloak")
+	if _, err := provider.AuthURL(context.Background(), flow); err == nil {
+		t.Error("Expected discovery to fail when the issuer doesn't match")
+	}
+}
+
+func TestProviderRegistry(t *testing.T) {
+	registry := auth.NewProviderRegistry()
+	github := auth.NewGitHubOAuth2Provider("id", "secret", "http://localhost/callback")
+	if err := registry.Register("github", github); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if err := registry.Register("github", github); err == nil {
+		t.Error("Expected a duplicate name to be rejected")
+	}
+	if err := registry.Register("Bad Name", github); err == nil {
+		t.Error("Expected an invalid name to be rejected")
+	}
+	if registry.Get("github") == nil || registry.Get("gitlab") != nil {
+		t.Error("Unexpected lookup result")
+	}
+
+	flow, _ := auth.NewAuthFlow("github")
+	decoded, err := auth.DecodeAuthFlow(flow.Encode())
+	if err != nil || *decoded != *flow {
+		t.Errorf("Expected the flow to round-trip, got %+v, %v", decoded, err)
+	}
+}
@@ -0,0 +1,82 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteAPITokenRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_api_tokens_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	users := sqlite.NewSQLiteUserRepository(db)
+	user, err := entities.NewUser("alice", "alice@example.com", "password123", "Alice", entities.DefaultPasswordHasher())
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	user.ID = "user-1"
+	if err := users.Save(user); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+
+	repo := sqlite.NewSQLiteAPITokenRepository(db)
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	live, err := entities.NewAPIToken("token-1", "user-1", "CI", []entities.Scope{entities.ScopePostsWrite, entities.ScopeProfileRead},
+		"pat_abcdef", "hash-1", now, nil)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	old, _ := entities.NewAPIToken("token-2", "user-1", "Old", []entities.Scope{entities.ScopeProfileRead},
+		"pat_ghijkl", "hash-2", now.Add(-time.Minute), &expired)
+	for _, token := range []*entities.APIToken{live, old} {
+		if err := repo.Save(token); err != nil {
+			t.Fatalf("Failed to save token: %v", err)
+		}
+	}
+
+	found, err := repo.FindByHash("hash-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find token, got %v, %v", found, err)
+	}
+	if found.Name != "CI" || len(found.Scopes) != 2 || !found.HasScope(entities.ScopePostsWrite) || found.ExpiresAt != nil {
+		t.Errorf("Unexpected token: %+v", found)
+	}
+
+	if err := repo.UpdateLastUsed("token-1", now); err != nil {
+		t.Fatalf("Failed to record use: %v", err)
+	}
+	if found, _ := repo.FindByHash("hash-1"); found.LastUsedAt == nil || found.LastUsedAt.Sub(now).Abs() > time.Second {
+		t.Errorf("Expected the last use to be recorded, got %v", found.LastUsedAt)
+	}
+
+	if err := repo.DeleteExpired(now); err != nil {
+		t.Fatalf("Failed to prune tokens: %v", err)
+	}
+	tokens, err := repo.FindByUser("user-1")
+	if err != nil || len(tokens) != 1 || tokens[0].ID != "token-1" {
+		t.Fatalf("Expected only the live token, got %v, %v", tokens, err)
+	}
+
+	// Tokens are deleted only by their owner
+	if deleted, err := repo.Delete("user-2", "token-1"); err != nil || deleted {
+		t.Errorf("Expected another user's delete to do nothing, got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.Delete("user-1", "token-1"); err != nil || !deleted {
+		t.Errorf("Expected to delete token, got %v, %v", deleted, err)
+	}
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"gocleanarchitecture/interfaces"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteAuditRepositoryIsAppendOnly(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_audit_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteAuditRepository(db)
+	first := entities.NewAuditEvent(entities.AuditLoginFailed, "", entities.AuditTargetUser, "user-1")
+	first.OccurredAt = time.Now().Add(-time.Hour)
+	first.IP = "203.0.113.7"
+	second := entities.NewAuditEvent(entities.AuditUserRoleChange, "admin-1", entities.AuditTargetUser, "user-1")
+	second.Before, second.After = `{"role":"user"}`, `{"role":"editor"}`
+	for _, event := range []*entities.AuditEvent{first, second} {
+		if err := repo.Append(event); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	events, err := repo.Find(interfaces.AuditFilter{TargetID: "user-1"})
+	if err != nil {
+		t.Fatalf("Failed to find events: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != second.ID || events[1].IP != "203.0.113.7" {
+		t.Fatalf("Expected events newest first, got %+v", events)
+	}
+	if events[0].After != `{"role":"editor"}` {
+		t.Errorf("Expected after snapshot, got %q", events[0].After)
+	}
+
+	recent, _ := repo.Find(interfaces.AuditFilter{Since: time.Now().Add(-time.Minute)})
+	if len(recent) != 1 || recent[0].ID != second.ID {
+		t.Errorf("Expected only the recent event, got %d", len(recent))
+	}
+
+	if _, err := db.Exec(`UPDATE audit_events SET actor_id = 'someone-else'`); err == nil {
+		t.Error("Expected updating audit events to fail")
+	}
+	if _, err := db.Exec(`DELETE FROM audit_events`); err == nil {
+		t.Error("Expected deleting audit events to fail")
+	}
+}
@@ -0,0 +1,97 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteIdentityRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_identities_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	users := sqlite.NewSQLiteUserRepository(db)
+	for _, id := range []string{"user-1", "user-2"} {
+		user, err := entities.NewUser("name_"+id[len(id)-1:], id+"@example.com", "password123", id, entities.DefaultPasswordHasher())
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user.ID = id
+		if err := users.Save(user); err != nil {
+			t.Fatalf("Failed to save user: %v", err)
+		}
+	}
+
+	repo := sqlite.NewSQLiteIdentityRepository(db)
+	now := time.Now()
+	identity := &entities.Identity{
+		ID:         "identity-1",
+		UserID:     "user-1",
+		Provider:   "github",
+		ProviderID: "42",
+		Email:      "octo@example.com",
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := repo.Save(identity); err != nil {
+		t.Fatalf("Failed to save identity: %v", err)
+	}
+
+	// Saving again updates the email address and last use
+	identity.Email = "renamed@example.com"
+	identity.LastUsedAt = now.Add(time.Hour)
+	if err := repo.Save(identity); err != nil {
+		t.Fatalf("Failed to update identity: %v", err)
+	}
+	found, err := repo.FindByProvider("github", "42")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find identity, got %v, %v", found, err)
+	}
+	if found.UserID != "user-1" || found.Email != "renamed@example.com" || !found.LastUsedAt.After(now) {
+		t.Errorf("Unexpected identity: %+v", found)
+	}
+
+	// The same provider account can't be linked to another user, and the
+	// attempt leaves the existing link alone
+	stolen := *identity
+	stolen.ID, stolen.UserID = "identity-2", "user-2"
+	if err := repo.Save(&stolen); err == nil {
+		t.Error("Expected linking a linked provider account to fail")
+	}
+	// Nor can a user link two accounts at one provider
+	second := *identity
+	second.ID, second.ProviderID = "identity-3", "43"
+	if err := repo.Save(&second); err == nil {
+		t.Error("Expected a second account at the same provider to fail")
+	}
+	if found, _ := repo.FindByProvider("github", "42"); found == nil || found.UserID != "user-1" {
+		t.Errorf("Expected the original link to remain, got %+v", found)
+	}
+
+	identities, err := repo.FindByUser("user-1")
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("Expected one identity, got %v, %v", identities, err)
+	}
+
+	deleted, err := repo.Delete("user-1", "github")
+	if err != nil || !deleted {
+		t.Fatalf("Expected to delete identity, got %v, %v", deleted, err)
+	}
+	deleted, err = repo.Delete("user-1", "github")
+	if err != nil || deleted {
+		t.Errorf("Expected nothing left to delete, got %v, %v", deleted, err)
+	}
+}
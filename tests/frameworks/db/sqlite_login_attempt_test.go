@@ -0,0 +1,56 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteLoginAttemptRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_login_attempts_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteLoginAttemptRepository(db)
+	policy := entities.LockoutPolicy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+	now := time.Now()
+
+	throttle := entities.NewLoginThrottle("account:user-1")
+	throttle.RecordFailure(now, policy)
+	if err := repo.Save(throttle); err != nil {
+		t.Fatalf("Failed to save throttle: %v", err)
+	}
+	found, err := repo.Find("account:user-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find throttle, got %v, %v", found, err)
+	}
+	if found.Failures != 1 || !found.LockedUntil.IsZero() {
+		t.Errorf("Unexpected throttle: %+v", found)
+	}
+
+	found.RecordFailure(now, policy)
+	repo.Save(found)
+	found, _ = repo.Find("account:user-1")
+	if found.RetryAfter(now) != time.Minute {
+		t.Errorf("Expected a one minute lock, got %v", found.RetryAfter(now))
+	}
+
+	if err := repo.DeleteExpired(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("Failed to delete expired throttles: %v", err)
+	}
+	if found, _ := repo.Find("account:user-1"); found != nil {
+		t.Error("Expected expired throttle to be deleted")
+	}
+}
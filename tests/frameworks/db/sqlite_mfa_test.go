@@ -0,0 +1,98 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteMFARepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_mfa_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteMFARepository(db)
+	now := time.Now()
+
+	enrollment, _ := entities.NewMFAEnrollment("user-1")
+	if err := repo.SaveEnrollment(enrollment); err != nil {
+		t.Fatalf("Failed to save enrolment: %v", err)
+	}
+	found, err := repo.FindEnrollment("user-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find enrolment, got %v, %v", found, err)
+	}
+	if found.Secret != enrollment.Secret || found.IsConfirmed() || len(found.RecoveryCodeHashes) != 0 {
+		t.Errorf("Unexpected enrolment: %+v", found)
+	}
+
+	enrollment.Confirm(now, []string{"hash-a", "hash-b"})
+	enrollment.LastUsedStep = 42
+	repo.SaveEnrollment(enrollment)
+	found, _ = repo.FindEnrollment("user-1")
+	if !found.IsConfirmed() || found.LastUsedStep != 42 || len(found.RecoveryCodeHashes) != 2 {
+		t.Errorf("Expected confirmed enrolment, got %+v", found)
+	}
+
+	// A step is used once, and an earlier one can't be used after it
+	if used, err := repo.ConsumeTOTPStep("user-1", 43); err != nil || !used {
+		t.Fatalf("Expected step 43 to be used, got %v, %v", used, err)
+	}
+	for _, step := range []int64{43, 42} {
+		if used, _ := repo.ConsumeTOTPStep("user-1", step); used {
+			t.Errorf("Expected step %d to be refused", step)
+		}
+	}
+	if used, err := repo.ConsumeRecoveryCode("user-1", "hash-a"); err != nil || !used {
+		t.Fatalf("Expected the recovery code to be used, got %v, %v", used, err)
+	}
+	if used, _ := repo.ConsumeRecoveryCode("user-1", "hash-a"); used {
+		t.Error("Expected a recovery code to work once")
+	}
+	found, _ = repo.FindEnrollment("user-1")
+	if found.LastUsedStep != 43 || len(found.RecoveryCodeHashes) != 1 || found.RecoveryCodeHashes[0] != "hash-b" {
+		t.Errorf("Expected step 43 and hash-b to remain, got %+v", found)
+	}
+
+	challenge := &entities.MFAChallenge{ID: "c1", UserID: "user-1", TokenHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+	expired := &entities.MFAChallenge{ID: "c2", UserID: "user-1", TokenHash: "hash-2", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)}
+	repo.SaveChallenge(challenge)
+	repo.SaveChallenge(expired)
+	if err := repo.DeleteExpired(now); err != nil {
+		t.Fatalf("Failed to delete expired challenges: %v", err)
+	}
+	if found, _ := repo.FindChallengeByHash("hash-2"); found != nil {
+		t.Error("Expected the expired challenge to be deleted")
+	}
+	if found, _ := repo.FindChallengeByHash("hash-1"); found == nil || found.UserID != "user-1" {
+		t.Errorf("Expected to find challenge, got %+v", found)
+	}
+
+	repo.SetRoleRequired(entities.RoleAdmin, true)
+	repo.SetRoleRequired(entities.RoleAdmin, true)
+	roles, err := repo.FindRequiredRoles()
+	if err != nil || len(roles) != 1 || roles[0] != entities.RoleAdmin {
+		t.Errorf("Expected admin to require 2FA, got %v, %v", roles, err)
+	}
+	repo.SetRoleRequired(entities.RoleAdmin, false)
+	if roles, _ := repo.FindRequiredRoles(); len(roles) != 0 {
+		t.Errorf("Expected no roles to require 2FA, got %v", roles)
+	}
+
+	repo.DeleteEnrollment("user-1")
+	if found, _ := repo.FindEnrollment("user-1"); found != nil {
+		t.Error("Expected the enrolment to be deleted")
+	}
+}
@@ -0,0 +1,113 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteOAuthRepositories(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_oauth_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	clients := sqlite.NewSQLiteOAuthClientRepository(db)
+	codes := sqlite.NewSQLiteOAuthCodeRepository(db)
+	now := time.Now()
+
+	client, err := entities.NewOAuthClient("client-1", "Partner", []string{"https://partner.example.com/callback"},
+		[]entities.Scope{entities.ScopeProfileRead}, "secret-hash", "admin-1", now)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := clients.Save(client); err != nil {
+		t.Fatalf("Failed to save client: %v", err)
+	}
+	found, err := clients.FindByID("client-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find client, got %v, %v", found, err)
+	}
+	if found.Name != "Partner" || !found.IsConfidential() || !found.HasRedirectURI("https://partner.example.com/callback") || len(found.Scopes) != 1 {
+		t.Errorf("Unexpected client: %+v", found)
+	}
+	if all, err := clients.FindAll(); err != nil || len(all) != 1 {
+		t.Errorf("Expected one client, got %v, %v", all, err)
+	}
+
+	code := &entities.OAuthAuthorizationCode{
+		CodeHash:      "code-hash",
+		ClientID:      "client-1",
+		UserID:        "user-1",
+		RedirectURI:   "https://partner.example.com/callback",
+		Scopes:        []entities.Scope{entities.ScopeProfileRead},
+		CodeChallenge: "challenge",
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(5 * time.Minute),
+	}
+	if err := codes.Save(code); err != nil {
+		t.Fatalf("Failed to save code: %v", err)
+	}
+	stored, err := codes.FindByHash("code-hash")
+	if err != nil || stored == nil || stored.UserID != "user-1" || len(stored.Scopes) != 1 || stored.CodeChallenge != "challenge" {
+		t.Fatalf("Unexpected code: %+v, %v", stored, err)
+	}
+
+	// A code is consumed once, and remembers the token issued for it
+	if consumed, err := codes.Consume("code-hash", "jti-1", now.Add(15*time.Minute), now); err != nil || !consumed {
+		t.Fatalf("Expected to consume code, got %v, %v", consumed, err)
+	}
+	if consumed, err := codes.Consume("code-hash", "jti-2", now.Add(15*time.Minute), now); err != nil || consumed {
+		t.Errorf("Expected the code to be used up, got %v, %v", consumed, err)
+	}
+	stored, _ = codes.FindByHash("code-hash")
+	if stored == nil || stored.UsedAt == nil || stored.TokenID != "jti-1" || !stored.TokenExpiresAt.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("Expected the used code to keep its token, got %+v", stored)
+	}
+
+	// Expired codes are pruned, exchanged ones once their token expired too,
+	// and deleting a client removes its codes
+	expired := *code
+	expired.CodeHash, expired.ExpiresAt = "expired-hash", now.Add(-time.Minute)
+	exchanged := expired
+	exchanged.CodeHash = "exchanged-hash"
+	live := *code
+	live.CodeHash = "live-hash"
+	codes.Save(&expired)
+	codes.Save(&exchanged)
+	codes.Save(&live)
+	codes.Consume("exchanged-hash", "jti-3", now.Add(time.Minute), now.Add(-2*time.Minute))
+	if err := codes.DeleteExpired(now); err != nil {
+		t.Fatalf("Failed to prune codes: %v", err)
+	}
+	if found, _ := codes.FindByHash("expired-hash"); found != nil {
+		t.Error("Expected the expired code to be pruned")
+	}
+	if found, _ := codes.FindByHash("exchanged-hash"); found == nil {
+		t.Error("Expected an exchanged code to be kept while its token is valid")
+	}
+	codes.DeleteExpired(now.Add(2 * time.Minute))
+	if found, _ := codes.FindByHash("exchanged-hash"); found != nil {
+		t.Error("Expected the exchanged code to be pruned once its token expired")
+	}
+	if deleted, err := clients.Delete("client-1"); err != nil || !deleted {
+		t.Fatalf("Expected to delete client, got %v, %v", deleted, err)
+	}
+	if found, _ := codes.FindByHash("live-hash"); found != nil {
+		t.Error("Expected the client's codes to be deleted with it")
+	}
+	if deleted, _ := clients.Delete("client-1"); deleted {
+		t.Error("Expected nothing left to delete")
+	}
+}
@@ -0,0 +1,81 @@
+package db_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"gocleanarchitecture/usecases"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteTransactorWritesEntityAndOutboxAtomically(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_outbox_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	blogRepo := sqlite.NewSQLiteBlogPostRepository(db)
+	outbox := sqlite.NewSQLiteOutboxRepository(db)
+	transactor := sqlite.NewSQLiteTransactor(db)
+
+	// Committed transaction: post and event are both stored
+	post, _ := entities.NewBlogPost("1", "Title", "Content", "author-1")
+	err = transactor.WithinTransaction(func(tx *usecases.Tx) error {
+		if err := tx.BlogPosts.Save(post); err != nil {
+			return err
+		}
+		return tx.Events.Publish(entities.NewPostPublished(post))
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+
+	saved, _ := blogRepo.FindByID("1")
+	if saved == nil {
+		t.Fatal("Expected blog post to be committed")
+	}
+
+	pending, err := outbox.FetchPending(10, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to fetch outbox: %v", err)
+	}
+	if len(pending) != 1 || pending[0].EventName != entities.EventPostPublished {
+		t.Fatalf("Expected 1 post.published outbox message, got %d", len(pending))
+	}
+
+	// Rolled back transaction: neither post nor event is stored
+	post2, _ := entities.NewBlogPost("2", "Title 2", "Content 2", "author-1")
+	err = transactor.WithinTransaction(func(tx *usecases.Tx) error {
+		if err := tx.BlogPosts.Save(post2); err != nil {
+			return err
+		}
+		if err := tx.Events.Publish(entities.NewPostPublished(post2)); err != nil {
+			return err
+		}
+		return errors.New("simulated failure")
+	})
+	if err == nil {
+		t.Fatal("Expected transaction error")
+	}
+
+	rolledBack, _ := blogRepo.FindByID("2")
+	if rolledBack != nil {
+		t.Error("Expected blog post write to be rolled back")
+	}
+
+	pending, _ = outbox.FetchPending(10, time.Now().Add(time.Second))
+	if len(pending) != 1 {
+		t.Errorf("Expected rolled back event to be discarded, got %d pending", len(pending))
+	}
+}
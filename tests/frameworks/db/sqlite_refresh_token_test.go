@@ -0,0 +1,66 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteRefreshTokenRotationIsSingleUse(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_refresh_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteRefreshTokenRepository(db)
+	now := time.Now()
+	token := &entities.RefreshToken{
+		ID:        "token-1",
+		UserID:    "user-1",
+		FamilyID:  "family-1",
+		TokenHash: "hash-1",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	if err := repo.Save(token); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	won, err := repo.MarkRotated("token-1", "token-2", now)
+	if err != nil || !won {
+		t.Fatalf("Expected first rotation to win, got %v, %v", won, err)
+	}
+	won, err = repo.MarkRotated("token-1", "token-3", now)
+	if err != nil || won {
+		t.Fatalf("Expected second rotation to lose, got %v, %v", won, err)
+	}
+
+	if err := repo.RevokeFamily("family-1", now); err != nil {
+		t.Fatalf("Failed to revoke family: %v", err)
+	}
+	stored, err := repo.FindByHash("hash-1")
+	if err != nil || stored == nil {
+		t.Fatalf("Failed to find token: %v", err)
+	}
+	if !stored.IsRotated() || stored.ReplacedBy != "token-2" || !stored.IsRevoked() {
+		t.Errorf("Unexpected stored token: %+v", stored)
+	}
+
+	if err := repo.DeleteExpired(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("Failed to delete expired tokens: %v", err)
+	}
+	if stored, _ := repo.FindByHash("hash-1"); stored != nil {
+		t.Error("Expected expired token to be deleted")
+	}
+}
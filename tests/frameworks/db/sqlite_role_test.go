@@ -0,0 +1,111 @@
+package db_test
+
+import (
+	"database/sql"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteRoleRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_roles_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteRoleRepository(db)
+	role, _ := entities.NewRole("reviewer", "Reviews posts", []entities.Permission{entities.PermissionPostEditAny})
+	if err := repo.Save(role); err != nil {
+		t.Fatalf("Failed to save role: %v", err)
+	}
+
+	found, err := repo.FindByName("reviewer")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find role, got %v, %v", found, err)
+	}
+	if !found.HasPermission(entities.PermissionPostEditAny) || found.Description != "Reviews posts" {
+		t.Errorf("Unexpected role: %+v", found)
+	}
+
+	found.Update("Reviews and removes posts", []entities.Permission{entities.PermissionPostEditAny, entities.PermissionPostDeleteAny})
+	if err := repo.Save(found); err != nil {
+		t.Fatalf("Failed to update role: %v", err)
+	}
+	roles, err := repo.FindAll()
+	if err != nil || len(roles) != 1 {
+		t.Fatalf("Expected 1 role, got %d, %v", len(roles), err)
+	}
+	if len(roles[0].Permissions) != 2 {
+		t.Errorf("Expected 2 permissions, got %v", roles[0].Permissions)
+	}
+
+	if err := repo.Delete("reviewer"); err != nil {
+		t.Fatalf("Failed to delete role: %v", err)
+	}
+	if found, _ := repo.FindByName("reviewer"); found != nil {
+		t.Error("Expected role to be deleted")
+	}
+}
+
+func TestSQLiteInitDBDropsUserRoleCheck(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_roles_migration_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	// A users table as created before custom roles existed
+	legacy, err := sql.Open("sqlite3", tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = legacy.Exec(`
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		full_name TEXT NOT NULL,
+		bio TEXT DEFAULT '',
+		avatar_url TEXT DEFAULT '',
+		role TEXT DEFAULT 'user' CHECK(role IN ('user', 'admin')),
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	INSERT INTO users (id, username, email, password_hash, full_name, created_at, updated_at)
+	VALUES ('user-1', 'alice', 'alice@example.com', 'hash', 'Alice', '2024-01-01', '2024-01-01');
+	`)
+	legacy.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy users table: %v", err)
+	}
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	users := sqlite.NewSQLiteUserRepository(db)
+	user, err := users.FindByID("user-1")
+	if err != nil || user == nil {
+		t.Fatalf("Expected existing user to survive the migration, got %v, %v", user, err)
+	}
+
+	user.SetRole(entities.RoleEditor)
+	user.UpdatedAt = time.Now()
+	if err := users.Save(user); err != nil {
+		t.Fatalf("Expected editor role to be accepted, got %v", err)
+	}
+}
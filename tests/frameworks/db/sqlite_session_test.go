@@ -0,0 +1,70 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteSessionRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_sessions_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteSessionRepository(db)
+	now := time.Now()
+	for i, id := range []string{"session-1", "session-2"} {
+		session := &entities.Session{
+			ID:                   id,
+			UserID:               "user-1",
+			UserAgent:            "Firefox",
+			IP:                   "10.0.0.1",
+			CreatedAt:            now,
+			LastSeenAt:           now.Add(time.Duration(i) * time.Minute),
+			ExpiresAt:            now.Add(time.Hour),
+			AccessTokenID:        "token-" + id,
+			AccessTokenExpiresAt: now.Add(15 * time.Minute),
+		}
+		if err := repo.Save(session); err != nil {
+			t.Fatalf("Failed to save session: %v", err)
+		}
+	}
+
+	sessions, err := repo.FindByUserID("user-1")
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "session-2" {
+		t.Fatalf("Expected 2 sessions, most recent first, got %+v", sessions)
+	}
+
+	if err := repo.RevokeAllForUser("user-1", now); err != nil {
+		t.Fatalf("Failed to revoke sessions: %v", err)
+	}
+	session, err := repo.FindByID("session-1")
+	if err != nil || session == nil {
+		t.Fatalf("Failed to find session: %v", err)
+	}
+	if session.IsActive(now) {
+		t.Error("Expected session to be revoked")
+	}
+
+	if err := repo.DeleteExpired(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("Failed to delete expired sessions: %v", err)
+	}
+	if session, _ := repo.FindByID("session-1"); session != nil {
+		t.Error("Expected expired session to be deleted")
+	}
+}
@@ -0,0 +1,59 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteUpdatePasswordHash(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_users_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteUserRepository(db)
+	now := time.Now()
+	user := &entities.User{
+		ID:           "user-1",
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: "old-hash",
+		Role:         entities.RoleUser,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := repo.Save(user); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+
+	// Other columns are left alone
+	user.Bio = "Hello"
+	repo.Save(user)
+	if err := repo.UpdatePasswordHash("user-1", "old-hash", "new-hash"); err != nil {
+		t.Fatalf("Failed to update password hash: %v", err)
+	}
+	found, _ := repo.FindByID("user-1")
+	if found.PasswordHash != "new-hash" || found.Bio != "Hello" {
+		t.Errorf("Expected only the hash to change, got %+v", found)
+	}
+
+	// A hash that changed meanwhile, e.g. by a password change, is kept
+	if err := repo.UpdatePasswordHash("user-1", "old-hash", "stale-hash"); err != nil {
+		t.Fatalf("Failed to update password hash: %v", err)
+	}
+	if found, _ := repo.FindByID("user-1"); found.PasswordHash != "new-hash" {
+		t.Errorf("Expected the newer hash to be kept, got %q", found.PasswordHash)
+	}
+}
@@ -0,0 +1,82 @@
+package db_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db/sqlite"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteUserTokenRepository(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test_user_tokens_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	db, err := sqlite.InitDB(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	repo := sqlite.NewSQLiteUserTokenRepository(db)
+	now := time.Now()
+
+	token := &entities.UserToken{
+		ID:        "token-1",
+		UserID:    "user-1",
+		Purpose:   entities.TokenPurposePasswordReset,
+		Email:     "user@example.com",
+		TokenHash: "hash-1",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	if err := repo.Save(token); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+	found, err := repo.FindByHash("hash-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find token, got %v, %v", found, err)
+	}
+	if found.UserID != "user-1" || found.Purpose != entities.TokenPurposePasswordReset || found.Email != "user@example.com" {
+		t.Errorf("Unexpected token: %+v", found)
+	}
+
+	// Tokens can only be consumed once
+	consumed, err := repo.Consume("hash-1")
+	if err != nil || !consumed {
+		t.Fatalf("Expected to consume token, got %v, %v", consumed, err)
+	}
+	consumed, err = repo.Consume("hash-1")
+	if err != nil || consumed {
+		t.Errorf("Expected a second consume to fail, got %v, %v", consumed, err)
+	}
+
+	// DeleteByUser only removes tokens for the given purpose
+	verify := *token
+	verify.ID, verify.TokenHash, verify.Purpose = "token-2", "hash-2", entities.TokenPurposeEmailVerification
+	reset := *token
+	reset.ID, reset.TokenHash = "token-3", "hash-3"
+	repo.Save(&verify)
+	repo.Save(&reset)
+	if err := repo.DeleteByUser("user-1", entities.TokenPurposePasswordReset); err != nil {
+		t.Fatalf("Failed to delete tokens: %v", err)
+	}
+	if found, _ := repo.FindByHash("hash-3"); found != nil {
+		t.Error("Expected the reset token to be deleted")
+	}
+	if found, _ := repo.FindByHash("hash-2"); found == nil {
+		t.Error("Expected the verification token to remain")
+	}
+
+	if err := repo.DeleteExpired(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("Failed to delete expired tokens: %v", err)
+	}
+	if found, _ := repo.FindByHash("hash-2"); found != nil {
+		t.Error("Expected the expired token to be deleted")
+	}
+}
@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/events"
+	"gocleanarchitecture/frameworks/logger"
+	"sync/atomic"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, fields ...logger.LogField) {}
+func (nopLogger) Info(msg string, fields ...logger.LogField)  {}
+func (nopLogger) Warn(msg string, fields ...logger.LogField)  {}
+func (nopLogger) Error(msg string, fields ...logger.LogField) {}
+
+func newPost() *entities.BlogPost {
+	post, _ := entities.NewBlogPost("1", "Title", "Content", "author-1")
+	return post
+}
+
+func TestBusDeliversToMatchingSubscribers(t *testing.T) {
+	bus := events.NewBus(nopLogger{})
+
+	var all, posts, comments int
+	bus.Subscribe("all", func(e entities.DomainEvent) error { all++; return nil })
+	bus.Subscribe("posts", func(e entities.DomainEvent) error { posts++; return nil }, entities.EventPostPublished)
+	bus.Subscribe("comments", func(e entities.DomainEvent) error { comments++; return nil }, entities.EventCommentCreated)
+
+	if err := bus.Publish(entities.NewPostPublished(newPost())); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if all != 1 || posts != 1 || comments != 0 {
+		t.Errorf("unexpected deliveries: all=%d posts=%d comments=%d", all, posts, comments)
+	}
+}
+
+func TestBusIsolatesPanics(t *testing.T) {
+	bus := events.NewBus(nopLogger{})
+
+	delivered := false
+	bus.Subscribe("panics", func(e entities.DomainEvent) error { panic("boom") })
+	bus.Subscribe("after", func(e entities.DomainEvent) error { delivered = true; return nil })
+
+	err := bus.Publish(entities.NewPostPublished(newPost()))
+	if err == nil {
+		t.Fatal("expected panic to be reported as an error")
+	}
+	if !delivered {
+		t.Error("expected subscriber after the panicking one to still receive the event")
+	}
+}
+
+func TestBusReturnsSyncHandlerErrors(t *testing.T) {
+	bus := events.NewBus(nopLogger{})
+	bus.Subscribe("failing", func(e entities.DomainEvent) error { return errors.New("failed") })
+
+	if err := bus.Publish(entities.NewPostPublished(newPost())); err == nil {
+		t.Fatal("expected handler error to be returned")
+	}
+}
+
+func TestBusAsyncHandlers(t *testing.T) {
+	bus := events.NewBus(nopLogger{})
+
+	var count int32
+	bus.SubscribeAsync("async", func(e entities.DomainEvent) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	bus.SubscribeAsync("async-panic", func(e entities.DomainEvent) error { panic("boom") })
+
+	if err := bus.Publish(entities.NewPostPublished(newPost()), entities.NewUserDeleted("user-1")); err != nil {
+		t.Fatalf("async handler failures must not be returned, got %v", err)
+	}
+	bus.Wait()
+
+	if got := atomic.LoadInt32(&count); got != 2 {
+		t.Errorf("expected 2 async deliveries, got %d", got)
+	}
+}
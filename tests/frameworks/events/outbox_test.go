@@ -0,0 +1,98 @@
+package events_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/events"
+	"gocleanarchitecture/interfaces"
+	"testing"
+	"time"
+)
+
+func TestRelayRetriesUntilDelivered(t *testing.T) {
+	outbox := db.NewInMemoryOutboxRepository()
+	bus := events.NewBus(nopLogger{})
+
+	var received []string
+	failures := 1
+	bus.Subscribe("flaky", func(e entities.DomainEvent) error {
+		if failures > 0 {
+			failures--
+			return errors.New("temporarily unavailable")
+		}
+		received = append(received, e.EventID())
+		return nil
+	})
+
+	event := entities.NewPostPublished(newPost())
+	if err := events.NewOutboxPublisher(outbox).Publish(event); err != nil {
+		t.Fatalf("Failed to write outbox: %v", err)
+	}
+
+	config := events.DefaultRelayConfig()
+	config.BaseBackoff = 0
+	relay := events.NewRelay(outbox, bus, nopLogger{}, config)
+
+	if delivered := relay.ProcessBatch(); delivered != 0 {
+		t.Fatalf("Expected first attempt to fail, got %d delivered", delivered)
+	}
+	if delivered := relay.ProcessBatch(); delivered != 1 {
+		t.Fatalf("Expected retry to deliver, got %d delivered", delivered)
+	}
+
+	if len(received) != 1 || received[0] != event.EventID() {
+		t.Errorf("Expected event %s to be received with its idempotency key, got %v", event.EventID(), received)
+	}
+
+	pending, _ := outbox.FetchPending(10, time.Now().Add(time.Hour))
+	if len(pending) != 0 {
+		t.Errorf("Expected outbox to be drained, got %d pending", len(pending))
+	}
+}
+
+func TestRelayDeadLettersAfterMaxAttempts(t *testing.T) {
+	outbox := db.NewInMemoryOutboxRepository()
+	bus := events.NewBus(nopLogger{})
+	bus.Subscribe("broken", func(e entities.DomainEvent) error { return errors.New("always fails") })
+
+	events.NewOutboxPublisher(outbox).Publish(entities.NewUserDeleted("user-1"))
+
+	config := events.DefaultRelayConfig()
+	config.BaseBackoff = 0
+	config.MaxAttempts = 2
+	relay := events.NewRelay(outbox, bus, nopLogger{}, config)
+
+	relay.ProcessBatch()
+	relay.ProcessBatch()
+
+	pending, _ := outbox.FetchPending(10, time.Now().Add(time.Hour))
+	if len(pending) != 0 {
+		t.Errorf("Expected message to be dead-lettered, got %d pending", len(pending))
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	event := entities.NewUserRoleChanged("user-1", entities.RoleUser, entities.RoleAdmin)
+
+	msg, err := events.Encode(event)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if msg.ID != event.EventID() || msg.Status != interfaces.OutboxStatusPending {
+		t.Fatalf("Unexpected outbox message: %+v", msg)
+	}
+
+	decoded, err := events.Decode(msg)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	roleChanged, ok := decoded.(*entities.UserRoleChanged)
+	if !ok {
+		t.Fatalf("Expected *UserRoleChanged, got %T", decoded)
+	}
+	if roleChanged.EventID() != event.EventID() || roleChanged.NewRole != entities.RoleAdmin {
+		t.Errorf("Decoded event does not match: %+v", roleChanged)
+	}
+}
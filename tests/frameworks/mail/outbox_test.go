@@ -0,0 +1,62 @@
+package mail_test
+
+import (
+	"gocleanarchitecture/frameworks/mail"
+	"gocleanarchitecture/usecases"
+	"mime"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileOutboxWritesEmail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox, err := mail.NewFileOutbox(dir, "Blog <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	err = outbox.Send(usecases.EmailMessage{To: "user@example.com", Subject: "Vérifiez", Body: "Hello\nWorld\n"})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one .eml file, got %v", files)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Failed to open email: %v", err)
+	}
+	defer file.Close()
+
+	message, err := netmail.ReadMessage(file)
+	if err != nil {
+		t.Fatalf("Failed to parse email: %v", err)
+	}
+	if message.Header.Get("To") != "user@example.com" {
+		t.Errorf("Unexpected To header: %q", message.Header.Get("To"))
+	}
+	if !strings.HasSuffix(message.Header.Get("Message-Id"), "@example.com>") {
+		t.Errorf("Unexpected Message-ID: %q", message.Header.Get("Message-Id"))
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Vérifiez" {
+		t.Errorf("Expected the subject to round-trip, got %q, %v", subject, err)
+	}
+}
+
+func TestFileOutboxRejectsHeaderInjection(t *testing.T) {
+	outbox, err := mail.NewFileOutbox(t.TempDir(), "no-reply@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	err = outbox.Send(usecases.EmailMessage{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil {
+		t.Error("Expected line breaks in headers to be rejected")
+	}
+}
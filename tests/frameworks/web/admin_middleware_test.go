@@ -0,0 +1,111 @@
+package web_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/web/middleware"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// countingUserRepository counts role lookups made by the admin middleware
+type countingUserRepository struct {
+	interfaces.UserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) FindByID(id string) (*entities.User, error) {
+	r.lookups++
+	return r.UserRepository.FindByID(id)
+}
+
+func newAdminHandler(t *testing.T) (http.Handler, *auth.JWTManager, interfaces.TokenRevocationRepository, *countingUserRepository) {
+	t.Helper()
+	jwtManager := auth.NewJWTManager("test-secret", time.Minute)
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	users := &countingUserRepository{UserRepository: db.NewInMemoryUserRepository()}
+
+	admin, _ := entities.NewUser("admin", "admin@example.com", "password123", "Admin", entities.DefaultPasswordHasher())
+	admin.ID = "admin-1"
+	admin.SetRole(entities.RoleAdmin)
+	users.Save(admin)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	policy := usecases.NewPolicy(users, db.NewInMemoryRoleRepository(), nil, nil)
+	handler := middleware.AuthMiddlewareFunc(jwtManager, revocations)(middleware.AdminMiddlewareFunc(users, policy)(ok))
+	return handler, jwtManager, revocations, users
+}
+
+func TestAdminMiddlewareTrustsRoleClaim(t *testing.T) {
+	handler, jwtManager, _, users := newAdminHandler(t)
+
+	token, _ := jwtManager.GenerateToken(auth.Subject{UserID: "admin-1", Role: string(entities.RoleAdmin)})
+	if code := authenticate(t, handler, token); code != http.StatusOK {
+		t.Fatalf("Expected admin token to pass, got %d", code)
+	}
+	if users.lookups != 0 {
+		t.Errorf("Expected no user lookup with a role claim, got %d", users.lookups)
+	}
+
+	userToken, _ := jwtManager.GenerateToken(auth.Subject{UserID: "admin-1", Role: string(entities.RoleUser)})
+	if code := authenticate(t, handler, userToken); code != http.StatusForbidden {
+		t.Errorf("Expected user role claim to be forbidden, got %d", code)
+	}
+}
+
+func TestAdminMiddlewareChecksUserManagePermission(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Minute)
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	roles := db.NewInMemoryRoleRepository()
+	support, _ := entities.NewRole("support", "", []entities.Permission{entities.PermissionUserManage})
+	roles.Save(support)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	policy := usecases.NewPolicy(db.NewInMemoryUserRepository(), roles, nil, nil)
+	handler := middleware.AuthMiddlewareFunc(jwtManager, revocations)(middleware.AdminMiddlewareFunc(nil, policy)(ok))
+
+	cases := map[entities.UserRole]int{
+		"support":              http.StatusOK,
+		entities.RoleEditor:    http.StatusForbidden,
+		entities.RoleModerator: http.StatusForbidden,
+		"deleted-role":         http.StatusForbidden,
+	}
+	for role, want := range cases {
+		token, _ := jwtManager.GenerateToken(auth.Subject{UserID: "user-1", Role: string(role)})
+		if code := authenticate(t, handler, token); code != want {
+			t.Errorf("Expected %d for role %s, got %d", want, role, code)
+		}
+	}
+}
+
+func TestAdminMiddlewareFallsBackToLookupWithoutRoleClaim(t *testing.T) {
+	handler, jwtManager, _, users := newAdminHandler(t)
+
+	token, _ := jwtManager.GenerateToken(auth.Subject{UserID: "admin-1"})
+	if code := authenticate(t, handler, token); code != http.StatusOK {
+		t.Fatalf("Expected admin to pass via lookup, got %d", code)
+	}
+	if users.lookups != 1 {
+		t.Errorf("Expected one user lookup, got %d", users.lookups)
+	}
+}
+
+func TestAdminMiddlewareRejectsRoleClaimAfterRoleChange(t *testing.T) {
+	handler, jwtManager, revocations, _ := newAdminHandler(t)
+
+	token, _ := jwtManager.GenerateToken(auth.Subject{UserID: "admin-1", Role: string(entities.RoleAdmin)})
+
+	// A role change bumps the token generation
+	revocations.BumpUserGeneration("admin-1")
+	if code := authenticate(t, handler, token); code != http.StatusUnauthorized {
+		t.Errorf("Expected stale admin token to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,77 @@
+package web_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/web/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// stubAPITokens knows a single token, allowed to write posts
+type stubAPITokens struct{}
+
+func (stubAPITokens) AuthenticateAPIToken(value string) (*entities.APIToken, *entities.User, error) {
+	if value != "pat_good" {
+		return nil, nil, errors.New("invalid API token")
+	}
+	token := &entities.APIToken{ID: "token-1", UserID: "user-1", Scopes: []entities.Scope{entities.ScopePostsWrite}}
+	return token, &entities.User{ID: "user-1", Username: "alice"}, nil
+}
+
+func newScopedRouter(jwtManager *auth.JWTManager) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value("userID").(string)
+		w.Write([]byte(userID))
+	}
+	router := mux.NewRouter()
+	router.Use(middleware.AuthMiddlewareFuncWithAPITokens(jwtManager, nil, stubAPITokens{}))
+	router.Handle("/posts", middleware.RequireScope(entities.ScopePostsWrite, ok)).Methods("POST")
+	router.Handle("/profile", middleware.RequireScope(entities.ScopeProfileWrite, ok)).Methods("PUT")
+	router.HandleFunc("/tokens", ok).Methods("GET")
+	return router
+}
+
+func TestAPITokensNeedRouteScope(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Minute)
+	router := newScopedRouter(jwtManager)
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("POST", "/posts", "pat_good"); rec.Code != http.StatusOK || rec.Body.String() != "user-1" {
+		t.Errorf("Expected a token with the scope to pass, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec := request("PUT", "/profile", "pat_good")
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Header().Get("WWW-Authenticate"), `scope="profile:write"`) {
+		t.Errorf("Expected a missing scope to be refused, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// Routes without a scope are for signed-in users only
+	if rec := request("GET", "/tokens", "pat_good"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected an unscoped route to refuse API tokens, got %d", rec.Code)
+	}
+	if rec := request("POST", "/posts", "pat_bad"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown token to be rejected, got %d", rec.Code)
+	}
+
+	// Access tokens are unaffected by scopes
+	token, _ := jwtManager.GenerateToken(auth.Subject{UserID: "user-2", Username: "bob"})
+	for _, route := range [][2]string{{"PUT", "/profile"}, {"GET", "/tokens"}} {
+		if rec := request(route[0], route[1], token); rec.Code != http.StatusOK {
+			t.Errorf("Expected an access token to reach %s %s, got %d", route[0], route[1], rec.Code)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package web_test
+
+import (
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/web/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func authenticate(t *testing.T, handler http.Handler, token string) int {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/auth/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthMiddlewareRejectsRevokedTokens(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Minute)
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	handler := middleware.AuthMiddlewareFunc(jwtManager, revocations)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	token, err := jwtManager.GenerateToken(auth.Subject{UserID: "user-1", Username: "alice"})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if code := authenticate(t, handler, token); code != http.StatusOK {
+		t.Fatalf("Expected valid token to pass, got %d", code)
+	}
+
+	// Revoking by jti only affects that token
+	claims, _ := jwtManager.ValidateToken(token)
+	revocations.RevokeToken(claims.ID, claims.ExpiresAt.Time)
+	if code := authenticate(t, handler, token); code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked token to be rejected, got %d", code)
+	}
+
+	other, _ := jwtManager.GenerateToken(auth.Subject{UserID: "user-1", Username: "alice"})
+	if code := authenticate(t, handler, other); code != http.StatusOK {
+		t.Fatalf("Expected another token to pass, got %d", code)
+	}
+
+	// Bumping the generation rejects every older token, but not new ones
+	generation, _ := revocations.BumpUserGeneration("user-1")
+	if code := authenticate(t, handler, other); code != http.StatusUnauthorized {
+		t.Errorf("Expected token from an older generation to be rejected, got %d", code)
+	}
+	fresh, _ := jwtManager.GenerateToken(auth.Subject{UserID: "user-1", Username: "alice", Generation: generation})
+	if code := authenticate(t, handler, fresh); code != http.StatusOK {
+		t.Errorf("Expected token from the current generation to pass, got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package web_test
+
+import (
+	"gocleanarchitecture/frameworks/web/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func resolveClientIP(trustProxy bool, forwardedFor string) string {
+	var ip string
+	handler := middleware.ClientIPMiddleware(trustProxy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _ = r.Context().Value("clientIP").(string)
+	}))
+
+	req := httptest.NewRequest("POST", "/auth/login", nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return ip
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	cases := []struct {
+		name         string
+		trustProxy   bool
+		forwardedFor string
+		want         string
+	}{
+		{"connection address", false, "", "10.0.0.1"},
+		{"header ignored without a proxy", false, "203.0.113.7", "10.0.0.1"},
+		{"proxy's address for the client", true, "203.0.113.7", "203.0.113.7"},
+		{"client-sent addresses ignored", true, "198.51.100.1, 203.0.113.7", "203.0.113.7"},
+		{"no header", true, "", "10.0.0.1"},
+		{"malformed header", true, "not-an-ip", "10.0.0.1"},
+	}
+	for _, c := range cases {
+		if got := resolveClientIP(c.trustProxy, c.forwardedFor); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
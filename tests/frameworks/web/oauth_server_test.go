@@ -0,0 +1,256 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/logger"
+	"gocleanarchitecture/frameworks/web"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, fields ...logger.LogField) {}
+func (nopLogger) Info(msg string, fields ...logger.LogField)  {}
+func (nopLogger) Warn(msg string, fields ...logger.LogField)  {}
+func (nopLogger) Error(msg string, fields ...logger.LogField) {}
+
+// handlerTransport hands a client's requests straight to the router, so a
+// third-party app can be run in the test process without opening a socket
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
+
+type authorizationServer struct {
+	router    http.Handler
+	userToken string // the signed-in user's access token, as held by the frontend
+	client    *entities.OAuthClient
+	secret    string
+	oauth     *usecases.OAuthServerUseCase
+}
+
+func newAuthorizationServer(t *testing.T) *authorizationServer {
+	t.Helper()
+	jwtManager := auth.NewJWTManager("test-secret", 15*time.Minute)
+	tokens := auth.NewTokenGeneratorAdapter(jwtManager)
+	users := db.NewInMemoryUserRepository()
+	useCaseLogger := logger.NewUseCaseLoggerAdapter(nopLogger{})
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	config := usecases.DefaultAuthConfig()
+	config.Revocations = revocations
+	authUseCase := usecases.NewAuthUseCaseWithConfig(users, tokens, useCaseLogger, config)
+	oauthServer := usecases.NewOAuthServerUseCase(db.NewInMemoryOAuthClientRepository(), db.NewInMemoryOAuthCodeRepository(),
+		users, tokens, tokens, revocations, nil, useCaseLogger)
+
+	session, err := authUseCase.Register("alice", "alice@example.com", "password123", "Alice", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	client, secret, err := oauthServer.RegisterClient(interfaces.Actor{UserID: "admin-1"}, "Partner",
+		[]string{"https://partner.example.com/callback"},
+		[]entities.Scope{entities.ScopeProfileRead, entities.ScopeProfileWrite}, true)
+	if err != nil {
+		t.Fatalf("Failed to register client: %v", err)
+	}
+
+	router := web.NewRouter(&web.RouterConfig{
+		AuthController:        &interfaces.AuthController{AuthUseCase: authUseCase},
+		OAuthServerController: interfaces.NewOAuthServerController(oauthServer),
+		UserRepo:              users,
+		JWTManager:            jwtManager,
+		Revocations:           revocations,
+		OAuthClients:          oauthServer,
+		Logger:                nopLogger{},
+	})
+	return &authorizationServer{router: router, userToken: session.Token, client: client, secret: secret, oauth: oauthServer}
+}
+
+// do sends a request as the signed-in user
+func (s *authorizationServer) do(method, target string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, target, &payload)
+	req.Header.Set("Authorization", "Bearer "+s.userToken)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// consent plays the frontend's consent screen: it forwards the client's
+// authorization URL and posts the user's answer, returning the redirect
+func (s *authorizationServer) consent(t *testing.T, authURL string, approve bool) *url.URL {
+	t.Helper()
+	parsed, _ := url.Parse(authURL)
+	rec := s.do("GET", "/oauth/authorize?"+parsed.RawQuery, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected a consent prompt, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var prompt interfaces.ConsentPrompt
+	if err := json.NewDecoder(rec.Body).Decode(&prompt); err != nil || prompt.ClientName != "Partner" {
+		t.Fatalf("Unexpected consent prompt: %+v, %v", prompt, err)
+	}
+
+	answer := map[string]interface{}{"approve": approve}
+	for key := range parsed.Query() {
+		answer[key] = parsed.Query().Get(key)
+	}
+	rec = s.do("POST", "/oauth/authorize", answer)
+	var response map[string]string
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&response) != nil {
+		t.Fatalf("Failed to consent: %d %s", rec.Code, rec.Body.String())
+	}
+	redirect, _ := url.Parse(response["redirect_to"])
+	return redirect
+}
+
+func TestOAuthServerWithInProcessClient(t *testing.T) {
+	server := newAuthorizationServer(t)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: handlerTransport{server.router}})
+
+	// The partner app is a stock OAuth2 client
+	partner := &oauth2.Config{
+		ClientID:     server.client.ID,
+		ClientSecret: server.secret,
+		RedirectURL:  "https://partner.example.com/callback",
+		Scopes:       []string{"profile:read"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "http://blog.test/oauth/authorize",
+			TokenURL: "http://blog.test/oauth/token",
+		},
+	}
+	verifier := oauth2.GenerateVerifier()
+	redirect := server.consent(t, partner.AuthCodeURL("xyz", oauth2.S256ChallengeOption(verifier)), true)
+	if redirect.Host != "partner.example.com" || redirect.Query().Get("state") != "xyz" {
+		t.Fatalf("Unexpected redirect: %s", redirect)
+	}
+
+	token, err := partner.Exchange(ctx, redirect.Query().Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if token.Extra("scope") != "profile:read" {
+		t.Errorf("Expected the consented scope, got %v", token.Extra("scope"))
+	}
+
+	// The token works only where its scopes allow
+	api := partner.Client(ctx, token)
+	call := func(method, path string) int {
+		req, _ := http.NewRequest(method, "http://blog.test"+path, strings.NewReader(`{"full_name":"Mallory"}`))
+		resp, err := api.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := call("GET", "/auth/profile"); code != http.StatusOK {
+		t.Errorf("Expected profile:read to allow reading the profile, got %d", code)
+	}
+	if code := call("PUT", "/auth/profile"); code != http.StatusForbidden {
+		t.Errorf("Expected a missing scope to be refused, got %d", code)
+	}
+	if code := call("GET", "/auth/sessions"); code != http.StatusForbidden {
+		t.Errorf("Expected an unscoped route to refuse the token, got %d", code)
+	}
+	if code := call("POST", "/oauth/authorize"); code != http.StatusForbidden {
+		t.Errorf("Expected the token not to grant consent, got %d", code)
+	}
+
+	// Introspection with the client's credentials
+	form := url.Values{"token": {token.AccessToken}}
+	req := httptest.NewRequest("POST", "/oauth/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(server.client.ID), url.QueryEscape(server.secret))
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	var introspection interfaces.TokenIntrospection
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&introspection) != nil || !introspection.Active || introspection.Username != "alice" {
+		t.Errorf("Expected the token to be active, got %d %+v", rec.Code, introspection)
+	}
+
+	// Presenting the code again is refused and revokes the token it bought
+	if _, err := partner.Exchange(ctx, redirect.Query().Get("code"), oauth2.VerifierOption(verifier)); err == nil {
+		t.Error("Expected a used code to be rejected")
+	}
+	if code := call("GET", "/auth/profile"); code != http.StatusUnauthorized {
+		t.Errorf("Expected a replayed code's token to be refused, got %d", code)
+	}
+
+	// Deleting the app stops its tokens at once
+	verifier = oauth2.GenerateVerifier()
+	redirect = server.consent(t, partner.AuthCodeURL("xyz", oauth2.S256ChallengeOption(verifier)), true)
+	token, err = partner.Exchange(ctx, redirect.Query().Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	api = partner.Client(ctx, token)
+	if code := call("GET", "/auth/profile"); code != http.StatusOK {
+		t.Fatalf("Expected a fresh token to work, got %d", code)
+	}
+	if err := server.oauth.DeleteClient(interfaces.Actor{UserID: "admin-1"}, server.client.ID); err != nil {
+		t.Fatalf("Failed to delete client: %v", err)
+	}
+	if code := call("GET", "/auth/profile"); code != http.StatusUnauthorized {
+		t.Errorf("Expected a deleted app's token to be refused, got %d", code)
+	}
+}
+
+func TestOAuthServerErrors(t *testing.T) {
+	server := newAuthorizationServer(t)
+
+	// A denial sends the browser back to the client
+	partner := &oauth2.Config{ClientID: server.client.ID, RedirectURL: "https://partner.example.com/callback",
+		Endpoint: oauth2.Endpoint{AuthURL: "http://blog.test/oauth/authorize"}}
+	redirect := server.consent(t, partner.AuthCodeURL("xyz", oauth2.S256ChallengeOption(oauth2.GenerateVerifier())), false)
+	if redirect.Query().Get("error") != "access_denied" || redirect.Query().Get("state") != "xyz" {
+		t.Errorf("Expected access_denied, got %s", redirect)
+	}
+
+	// An unregistered redirect URI is never redirected to
+	rec := server.do("GET", "/oauth/authorize?response_type=code&client_id="+server.client.ID+"&redirect_uri=https://evil.example.com/", nil)
+	var body map[string]string
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusBadRequest || body["redirect_to"] != "" {
+		t.Errorf("Expected an error without a redirect, got %d %v", rec.Code, body)
+	}
+
+	// Other problems are reported to the client
+	rec = server.do("GET", "/oauth/authorize?response_type=code&client_id="+server.client.ID+"&redirect_uri=https://partner.example.com/callback&state=xyz", nil)
+	body = map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusBadRequest || body["error"] != "invalid_request" || !strings.Contains(body["redirect_to"], "state=xyz") {
+		t.Errorf("Expected an error redirect for a missing PKCE challenge, got %d %v", rec.Code, body)
+	}
+
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {"made-up"}, "client_id": {server.client.ID}, "client_secret": {"wrong"}}
+	req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	body = map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusUnauthorized || body["error"] != "invalid_client" {
+		t.Errorf("Expected invalid_client, got %d %v", rec.Code, body)
+	}
+}
@@ -0,0 +1,103 @@
+package web_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/logger"
+	"gocleanarchitecture/frameworks/web/middleware"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// moderatorAPITokens knows a moderator's tokens: "pat_write" may write posts,
+// "pat_moderate" may also moderate them
+type moderatorAPITokens struct{}
+
+func (moderatorAPITokens) AuthenticateAPIToken(value string) (*entities.APIToken, *entities.User, error) {
+	scopes := map[string][]entities.Scope{
+		"pat_write":    {entities.ScopePostsWrite},
+		"pat_moderate": {entities.ScopePostsModerate, entities.ScopePostsWrite},
+	}[value]
+	if scopes == nil {
+		return nil, nil, errors.New("invalid API token")
+	}
+	token := &entities.APIToken{ID: value, UserID: "moderator", Scopes: scopes}
+	return token, &entities.User{ID: "moderator", Username: "moderator", Role: entities.RoleModerator}, nil
+}
+
+// newModerationRouter serves the blog post routes for a moderator and
+// another user's post
+func newModerationRouter(t *testing.T, jwtManager *auth.JWTManager) *mux.Router {
+	t.Helper()
+	users := db.NewInMemoryUserRepository()
+	for id, role := range map[string]entities.UserRole{"moderator": entities.RoleModerator, "author": entities.RoleUser} {
+		user, err := entities.NewUser(id+"_name", id+"@example.com", "password123", id, entities.DefaultPasswordHasher())
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user.ID = id
+		user.Role = role
+		users.Save(user)
+	}
+	posts := db.NewInMemoryBlogPostRepository()
+	post, _ := entities.NewBlogPost("post-1", "Title", "Content", "author")
+	posts.Save(post)
+
+	useCaseLogger := logger.NewUseCaseLoggerAdapter(nopLogger{})
+	policy := usecases.NewPolicy(users, nil, nil, useCaseLogger)
+	controller := &interfaces.BlogPostController{
+		BlogPostUseCase: usecases.NewBlogPostUseCase(posts, useCaseLogger, nil, nil, policy, nil),
+	}
+
+	router := mux.NewRouter()
+	router.Use(middleware.AuthMiddlewareFuncWithAPITokens(jwtManager, nil, moderatorAPITokens{}))
+	router.Handle("/blogposts/{id}", middleware.RequireScope(entities.ScopePostsWrite, controller.UpdateBlogPost)).Methods("PUT")
+	return router
+}
+
+func editOthersPost(router *mux.Router, token string) *httptest.ResponseRecorder {
+	body := `{"title":"Edited","content":"Content","reason":"Fixed a broken link"}`
+	req := httptest.NewRequest("PUT", "/blogposts/post-1", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaffAPITokenNeedsModerationScope(t *testing.T) {
+	router := newModerationRouter(t, auth.NewJWTManager("test-secret", time.Minute))
+
+	if rec := editOthersPost(router, "pat_write"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a staff token without posts:moderate to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := editOthersPost(router, "pat_moderate"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a staff token with posts:moderate to edit the post, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaffOAuthTokenIsOwnerOnly(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Minute)
+	router := newModerationRouter(t, jwtManager)
+
+	token, err := jwtManager.GenerateToken(auth.Subject{
+		UserID:   "moderator",
+		Username: "moderator",
+		ClientID: "client-1",
+		Scope:    string(entities.ScopePostsWrite),
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if rec := editOthersPost(router, token); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a staff member's app token to be refused, got %d %s", rec.Code, rec.Body.String())
+	}
+}
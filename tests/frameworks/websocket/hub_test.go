@@ -0,0 +1,201 @@
+package websocket_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	ws "gocleanarchitecture/frameworks/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startInstance runs a hub behind its own HTTP server, like one replica.
+// The ?user= query parameter stands in for an authenticated user.
+func startInstance(t *testing.T, hub *ws.Hub) *httptest.Server {
+	t.Helper()
+	go hub.Run()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.URL.Query().Get("user")
+		ws.ServeWs(hub, w, r, user, user)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	return dialAs(t, server, "")
+}
+
+func dialAs(t *testing.T, server *httptest.Server, user string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?user=" + user
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// The welcome message is sent after the client is registered with the hub
+	readMessage(t, conn)
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) *ws.Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	var msg ws.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	return &msg
+}
+
+func TestHubsSharingBrokerReachEachOthersClients(t *testing.T) {
+	broker := ws.NewInMemoryBroker()
+	hubA := ws.NewHubWithBroker(broker)
+	hubB := ws.NewHubWithBroker(broker)
+
+	serverA := startInstance(t, hubA)
+	serverB := startInstance(t, hubB)
+
+	clientA := dial(t, serverA)
+	clientB := dial(t, serverB)
+
+	if err := hubA.BroadcastJSON(ws.MessageTypeNewBlogPost, map[string]string{"id": "1"}); err != nil {
+		t.Fatalf("Failed to broadcast: %v", err)
+	}
+
+	for name, conn := range map[string]*websocket.Conn{"A": clientA, "B": clientB} {
+		msg := readMessage(t, conn)
+		if msg.Type != ws.MessageTypeNewBlogPost {
+			t.Errorf("client on instance %s: expected %s, got %s", name, ws.MessageTypeNewBlogPost, msg.Type)
+		}
+	}
+}
+
+func TestHubWithoutBrokerOnlyReachesLocalClients(t *testing.T) {
+	hubA := ws.NewHub()
+	hubB := ws.NewHub()
+
+	serverA := startInstance(t, hubA)
+	serverB := startInstance(t, hubB)
+
+	clientA := dial(t, serverA)
+	clientB := dial(t, serverB)
+
+	hubA.BroadcastJSON(ws.MessageTypeNewComment, map[string]string{"id": "1"})
+
+	if msg := readMessage(t, clientA); msg.Type != ws.MessageTypeNewComment {
+		t.Errorf("expected local client to receive %s, got %s", ws.MessageTypeNewComment, msg.Type)
+	}
+
+	clientB.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, _, err := clientB.ReadMessage(); err == nil {
+		t.Error("expected client on the other instance not to receive the message")
+	}
+}
+
+// wireBroker encodes every message the way a broker between processes does,
+// like PostgresBroker's NOTIFY payloads
+type wireBroker struct {
+	*ws.InMemoryBroker
+	t *testing.T
+}
+
+func (b wireBroker) Publish(message *ws.Message) error {
+	payload, err := ws.EncodeBrokerMessage(message)
+	if err != nil {
+		return err
+	}
+	decoded, err := ws.DecodeBrokerMessage(payload)
+	if err != nil {
+		b.t.Errorf("Failed to decode broker payload: %v", err)
+		return err
+	}
+	return b.InMemoryBroker.Publish(decoded)
+}
+
+func TestBrokerRoundTripDoesNotEchoToSender(t *testing.T) {
+	broker := wireBroker{InMemoryBroker: ws.NewInMemoryBroker(), t: t}
+	hubA := ws.NewHubWithBroker(broker)
+	hubB := ws.NewHubWithBroker(broker)
+	serverA := startInstance(t, hubA)
+	serverB := startInstance(t, hubB)
+	topic := ws.PostTopic("post-1")
+
+	alice := dialAs(t, serverB, "alice")
+	bob := dialAs(t, serverA, "bob")
+	send(t, alice, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+	send(t, bob, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+
+	send(t, bob, ws.Message{Type: ws.MessageTypeTyping, Topic: topic})
+	if readUntil(t, alice, ws.MessageTypeTyping, 2*time.Second) == nil {
+		t.Fatal("expected alice on the other instance to see bob typing")
+	}
+	if readUntil(t, bob, ws.MessageTypeTyping, 300*time.Millisecond) != nil {
+		t.Error("expected the typing frame not to be echoed to the sender through the broker")
+	}
+}
+
+// downBroker fails every publish, like a broker whose database is unreachable
+type downBroker struct {
+	*ws.InMemoryBroker
+}
+
+func (downBroker) Publish(message *ws.Message) error {
+	return errors.New("connection refused")
+}
+
+// recordingLogger keeps every error logged through it
+type recordingLogger struct {
+	entries []string
+	mu      sync.Mutex
+}
+
+func (l *recordingLogger) Error(msg string, fields ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprint(append([]interface{}{msg}, fields...)...))
+}
+
+func (l *recordingLogger) contains(parts ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range l.entries {
+		found := true
+		for _, part := range parts {
+			found = found && strings.Contains(entry, part)
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBrokerPublishFailureIsLoggedAndDeliveredLocally(t *testing.T) {
+	logger := &recordingLogger{}
+	hub := ws.NewHubWithConfig(downBroker{ws.NewInMemoryBroker()}, ws.Config{Logger: logger})
+	client := dial(t, startInstance(t, hub))
+
+	hub.BroadcastJSON(ws.MessageTypeNewBlogPost, map[string]string{"id": "1"})
+	if msg := readMessage(t, client); msg.Type != ws.MessageTypeNewBlogPost {
+		t.Errorf("expected the local client to receive %s, got %s", ws.MessageTypeNewBlogPost, msg.Type)
+	}
+	if !logger.contains("Broker publish failed", "connection refused", string(ws.MessageTypeNewBlogPost)) {
+		t.Error("expected the failure to be logged with its message type")
+	}
+}
@@ -0,0 +1,105 @@
+package websocket_test
+
+import (
+	ws "gocleanarchitecture/frameworks/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func startLimitedInstance(t *testing.T, config ws.Config) *httptest.Server {
+	t.Helper()
+	return startInstance(t, ws.NewHubWithConfig(nil, config))
+}
+
+func tryDial(server *httptest.Server, user string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?user=" + user
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+func TestOriginAllowlist(t *testing.T) {
+	server := startLimitedInstance(t, ws.Config{AllowedOrigins: []string{"https://blog.example.com"}})
+
+	conn, _, err := tryDial(server, "", http.Header{"Origin": {"https://blog.example.com"}})
+	if err != nil {
+		t.Fatalf("expected allowed origin to connect: %v", err)
+	}
+	conn.Close()
+
+	_, resp, err := tryDial(server, "", http.Header{"Origin": {"https://evil.example.com"}})
+	if err == nil {
+		t.Fatal("expected unknown origin to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for unknown origin, got %v", resp)
+	}
+}
+
+func TestConnectionCapPerUser(t *testing.T) {
+	server := startLimitedInstance(t, ws.Config{MaxConnectionsPerUser: 2})
+
+	first := dialAs(t, server, "alice")
+	dialAs(t, server, "alice")
+
+	_, resp, err := tryDial(server, "alice", nil)
+	if err == nil {
+		t.Fatal("expected third connection for the same user to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %v", resp)
+	}
+
+	// Other users are unaffected
+	dialAs(t, server, "bob")
+
+	// Closing a socket frees its slot
+	first.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, _, err := tryDial(server, "alice", nil)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected slot to be released after disconnect: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestConnectionCapPerIP(t *testing.T) {
+	server := startLimitedInstance(t, ws.Config{MaxConnectionsPerIP: 1})
+
+	dial(t, server)
+	if _, resp, err := tryDial(server, "someone", nil); err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected second connection from the same IP to be rejected with 429")
+	}
+}
+
+func TestFloodingClientIsClosedWithPolicyViolation(t *testing.T) {
+	server := startLimitedInstance(t, ws.Config{MessageRate: 1, MessageBurst: 3})
+	conn := dial(t, server)
+
+	for i := 0; i < 10; i++ {
+		if err := conn.WriteJSON(ws.Message{Type: ws.MessageTypeLeave, Topic: ws.PostTopic("1")}); err != nil {
+			break
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+			t.Fatalf("expected policy violation close, got %v", err)
+		}
+		return
+	}
+}
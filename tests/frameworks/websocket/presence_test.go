@@ -0,0 +1,200 @@
+package websocket_test
+
+import (
+	"bytes"
+	"encoding/json"
+	ws "gocleanarchitecture/frameworks/websocket"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func send(t *testing.T, conn *websocket.Conn, msg ws.Message) {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+}
+
+// readUntil returns the first message of the given type. Queued messages may
+// be batched into one frame separated by newlines.
+func readUntil(t *testing.T, conn *websocket.Conn, messageType ws.MessageType, timeout time.Duration) *ws.Message {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		conn.SetReadDeadline(deadline)
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return nil
+		}
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			var msg ws.Message
+			if err := json.Unmarshal(line, &msg); err == nil && msg.Type == messageType {
+				return &msg
+			}
+		}
+	}
+}
+
+func TestPresenceTracksJoinedConnections(t *testing.T) {
+	hub := ws.NewHub()
+	server := startInstance(t, hub)
+	topic := ws.PostTopic("post-1")
+
+	alice := dialAs(t, server, "alice")
+	bob := dialAs(t, server, "bob")
+	reader := dial(t, server)
+
+	send(t, alice, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+	send(t, bob, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+	send(t, reader, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	if readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second) == nil {
+		t.Fatal("expected presence_join for the anonymous reader")
+	}
+
+	snapshot := hub.Presence(topic)
+	if snapshot.Count != 3 || snapshot.Anonymous != 1 || len(snapshot.Users) != 2 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+
+	bob.Close()
+	if readUntil(t, alice, ws.MessageTypePresenceLeave, 2*time.Second) == nil {
+		t.Fatal("expected presence_leave when bob disconnects")
+	}
+	if snapshot := hub.Presence(topic); snapshot.Count != 2 || len(snapshot.Users) != 1 {
+		t.Errorf("unexpected snapshot after leave: %+v", snapshot)
+	}
+}
+
+func TestTypingIsThrottledAndNotEchoed(t *testing.T) {
+	hub := ws.NewHub()
+	server := startInstance(t, hub)
+	topic := ws.PostTopic("post-1")
+
+	alice := dialAs(t, server, "alice")
+	bob := dialAs(t, server, "bob")
+	send(t, alice, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+	send(t, bob, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	readUntil(t, alice, ws.MessageTypePresenceJoin, 2*time.Second)
+
+	send(t, bob, ws.Message{Type: ws.MessageTypeTyping, Topic: topic})
+	send(t, bob, ws.Message{Type: ws.MessageTypeTyping, Topic: topic})
+
+	if readUntil(t, alice, ws.MessageTypeTyping, 2*time.Second) == nil {
+		t.Fatal("expected alice to see bob typing")
+	}
+	if readUntil(t, alice, ws.MessageTypeTyping, 300*time.Millisecond) != nil {
+		t.Error("expected the second typing frame to be throttled")
+	}
+	if readUntil(t, bob, ws.MessageTypeTyping, 300*time.Millisecond) != nil {
+		t.Error("expected typing frame not to be echoed to the sender")
+	}
+}
+
+func TestAnonymousClientsCannotSendTyping(t *testing.T) {
+	hub := ws.NewHub()
+	server := startInstance(t, hub)
+	topic := ws.PostTopic("post-1")
+
+	reader := dial(t, server)
+	send(t, reader, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	send(t, reader, ws.Message{Type: ws.MessageTypeTyping, Topic: topic})
+
+	if readUntil(t, reader, ws.MessageTypeError, 2*time.Second) == nil {
+		t.Error("expected an error for an anonymous typing frame")
+	}
+}
+
+// severableBroker connects one hub to a shared broker until it is cut off,
+// like an instance that crashed without saying goodbye
+type severableBroker struct {
+	*ws.InMemoryBroker
+	cut int32
+}
+
+func (b *severableBroker) sever() {
+	atomic.StoreInt32(&b.cut, 1)
+}
+
+func (b *severableBroker) Publish(message *ws.Message) error {
+	if atomic.LoadInt32(&b.cut) == 1 {
+		return nil
+	}
+	return b.InMemoryBroker.Publish(message)
+}
+
+func (b *severableBroker) Subscribe(handler func(message *ws.Message)) func() {
+	return b.InMemoryBroker.Subscribe(func(message *ws.Message) {
+		if atomic.LoadInt32(&b.cut) == 0 {
+			handler(message)
+		}
+	})
+}
+
+// waitForPresence polls a hub until the topic has the given number of
+// connections
+func waitForPresence(hub *ws.Hub, topic string, count int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if hub.Presence(topic).Count == count {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestPresenceOfSilentInstanceExpires(t *testing.T) {
+	broker := ws.NewInMemoryBroker()
+	config := ws.Config{PresenceHeartbeat: 50 * time.Millisecond}
+	crashing := &severableBroker{InMemoryBroker: broker}
+	first := ws.NewHubWithConfig(crashing, config)
+	second := ws.NewHubWithConfig(broker, config)
+	server := startInstance(t, first)
+	startInstance(t, second)
+	topic := ws.PostTopic("post-1")
+
+	reader := dial(t, server)
+	send(t, reader, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	if !waitForPresence(second, topic, 1, 2*time.Second) {
+		t.Fatalf("expected the reader on the other instance, got %+v", second.Presence(topic))
+	}
+
+	crashing.sever()
+	if !waitForPresence(second, topic, 0, 2*time.Second) {
+		t.Errorf("expected the crashed instance's reader to expire, got %+v", second.Presence(topic))
+	}
+}
+
+func TestNewInstanceAsksForPresence(t *testing.T) {
+	broker := ws.NewInMemoryBroker()
+	// Heartbeats too rare to matter: only the startup request can fill in
+	// the new instance
+	config := ws.Config{PresenceHeartbeat: time.Hour}
+	running := ws.NewHubWithConfig(broker, config)
+	server := startInstance(t, running)
+	topic := ws.PostTopic("post-1")
+
+	reader := dialAs(t, server, "alice")
+	send(t, reader, ws.Message{Type: ws.MessageTypeJoin, Topic: topic})
+	if readUntil(t, reader, ws.MessageTypePresenceJoin, 2*time.Second) == nil {
+		t.Fatal("expected presence_join")
+	}
+
+	started := ws.NewHubWithConfig(broker, config)
+	startInstance(t, started)
+	if !waitForPresence(started, topic, 1, 2*time.Second) {
+		t.Fatalf("expected the new instance to learn about the reader, got %+v", started.Presence(topic))
+	}
+	if users := started.Presence(topic).Users; len(users) != 1 || users[0].Username != "alice" {
+		t.Errorf("expected alice, got %+v", users)
+	}
+	if readUntil(t, reader, ws.MessageTypePresenceSync, 300*time.Millisecond) != nil {
+		t.Error("expected presence reports not to reach clients")
+	}
+}
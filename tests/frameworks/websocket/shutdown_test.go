@@ -0,0 +1,75 @@
+package websocket_test
+
+import (
+	"context"
+	ws "gocleanarchitecture/frameworks/websocket"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdownFlushesQueuedMessagesAndSendsGoingAway(t *testing.T) {
+	hub := ws.NewHub()
+	server := startInstance(t, hub)
+	conn := dial(t, server)
+
+	hub.BroadcastJSON(ws.MessageTypeNewBlogPost, map[string]string{"id": "1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	if readUntil(t, conn, ws.MessageTypeNewBlogPost, 2*time.Second) == nil {
+		t.Fatal("expected the queued message to be delivered before closing")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+			t.Fatalf("expected going away close, got %v", err)
+		}
+		break
+	}
+
+	if _, resp, err := tryDial(server, "", nil); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Error("expected new connections to be refused after shutdown")
+	}
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Errorf("expected second Shutdown to be a no-op, got %v", err)
+	}
+}
+
+func TestStatsCountDeliveriesPerMessageType(t *testing.T) {
+	hub := ws.NewHubWithConfig(nil, ws.Config{MaxConnectionsPerUser: 1})
+	server := startInstance(t, hub)
+
+	first := dialAs(t, server, "alice")
+	second := dial(t, server)
+	tryDial(server, "alice", nil)
+
+	hub.BroadcastJSON(ws.MessageTypeNewComment, map[string]string{"id": "1"})
+	readMessage(t, first)
+	readMessage(t, second)
+
+	stats := hub.Stats()
+	if stats.Clients != 2 || stats.AuthenticatedUsers != 1 {
+		t.Errorf("expected 2 clients and 1 user, got %d and %d", stats.Clients, stats.AuthenticatedUsers)
+	}
+	if stats.Broadcasts[ws.MessageTypeNewComment] != 1 {
+		t.Errorf("expected 1 broadcast, got %d", stats.Broadcasts[ws.MessageTypeNewComment])
+	}
+	if stats.Delivered[ws.MessageTypeNewComment] != 2 {
+		t.Errorf("expected 2 deliveries, got %d", stats.Delivered[ws.MessageTypeNewComment])
+	}
+	if stats.RejectedConnections != 1 {
+		t.Errorf("expected 1 rejected connection, got %d", stats.RejectedConnections)
+	}
+}
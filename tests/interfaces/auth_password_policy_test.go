@@ -0,0 +1,53 @@
+package interfaces_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegisterReportsPasswordViolations(t *testing.T) {
+	config := usecases.DefaultAuthConfig()
+	config.PasswordPolicy = entities.PasswordPolicy{MinLength: 12, RequireSymbol: true, RejectPersonalInfo: true}
+	tokens := auth.NewTokenGeneratorAdapter(auth.NewJWTManager("test-secret", 15*time.Minute))
+	controller := &interfaces.AuthController{
+		AuthUseCase: usecases.NewAuthUseCaseWithConfig(db.NewInMemoryUserRepository(), tokens, nopLogger{}, config),
+	}
+
+	body, _ := json.Marshal(map[string]string{"username": "alice", "email": "alice@example.com", "password": "alice2024"})
+	rec := httptest.NewRecorder()
+	controller.Register(rec, httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", rec.Code)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected a JSON body, got %q", contentType)
+	}
+
+	var response struct {
+		Error      string                       `json:"error"`
+		Violations []entities.PasswordViolation `json:"violations"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	rules := make(map[string]string)
+	for _, violation := range response.Violations {
+		rules[violation.Rule] = violation.Message
+	}
+	if len(rules) != 3 || rules["min_length"] != "password must be at least 12 characters" || rules["symbol"] == "" || rules["personal_info"] == "" {
+		t.Errorf("Expected min_length, symbol and personal_info violations, got %v", response.Violations)
+	}
+	if response.Error == "" {
+		t.Error("Expected an error message as well")
+	}
+}
@@ -17,7 +17,7 @@ type MockBlogPostUseCase struct {
 	blogPosts map[string]*entities.BlogPost
 }
 
-func (m *MockBlogPostUseCase) CreateBlogPost(id, title, content, authorID string) (*entities.BlogPost, error) {
+func (m *MockBlogPostUseCase) CreateBlogPost(id, title, content, authorID string, client interfaces.ClientInfo) (*entities.BlogPost, error) {
 	blogPost, err := entities.NewBlogPost(id, title, content, authorID)
 	if err != nil {
 		return nil, err
@@ -38,7 +38,7 @@ func (m *MockBlogPostUseCase) GetBlogPost(id string) (*entities.BlogPost, error)
 	return m.blogPosts[id], nil
 }
 
-func (m *MockBlogPostUseCase) UpdateBlogPost(id, title, content, userID string) (*entities.BlogPost, error) {
+func (m *MockBlogPostUseCase) UpdateBlogPost(id, title, content, userID, reason string, client interfaces.ClientInfo) (*entities.BlogPost, error) {
 	blogPost := m.blogPosts[id]
 	if blogPost == nil {
 		return nil, nil
@@ -51,7 +51,7 @@ func (m *MockBlogPostUseCase) UpdateBlogPost(id, title, content, userID string)
 	return blogPost, nil
 }
 
-func (m *MockBlogPostUseCase) DeleteBlogPost(id, userID string) error {
+func (m *MockBlogPostUseCase) DeleteBlogPost(id, userID, reason string, client interfaces.ClientInfo) error {
 	delete(m.blogPosts, id)
 	return nil
 }
@@ -0,0 +1,208 @@
+package interfaces_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (nopLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Warn(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Error(msg string, keysAndValues ...interface{}) {}
+
+// stubProvider signs everyone in as the same account, provided the callback
+// hands back the flow it started
+type stubProvider struct {
+	verifier string // the PKCE verifier of the last flow
+}
+
+func (p *stubProvider) AuthURL(ctx context.Context, flow *auth.AuthFlow) (string, error) {
+	p.verifier = flow.CodeVerifier
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(flow.State), nil
+}
+
+func (p *stubProvider) Authenticate(ctx context.Context, code string, flow *auth.AuthFlow) (*auth.UserInfo, error) {
+	if code != "good-code" || flow.CodeVerifier != p.verifier {
+		return nil, auth.ErrNoVerifiedEmail
+	}
+	return &auth.UserInfo{ProviderID: "42", Email: "octo@example.com", EmailVerified: true, Name: "Octo"}, nil
+}
+
+func newOAuth2Router(frontendURL string) *mux.Router {
+	config := usecases.DefaultAuthConfig()
+	config.Identities = db.NewInMemoryIdentityRepository()
+	config.UserTokens = db.NewInMemoryUserTokenRepository()
+	tokens := auth.NewTokenGeneratorAdapter(auth.NewJWTManager("test-secret", 15*time.Minute))
+	authUseCase := usecases.NewAuthUseCaseWithConfig(db.NewInMemoryUserRepository(), tokens, nopLogger{}, config)
+
+	providers := auth.NewProviderRegistry()
+	providers.Register("keycloak", &stubProvider{})
+	providers.Register("github", &stubProvider{})
+	controller := interfaces.NewOAuth2Controller(providers, authUseCase, frontendURL)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/auth/{provider:google|github}", controller.InitiateLogin).Methods("GET")
+	router.HandleFunc("/auth/oidc/{provider}", controller.InitiateLogin).Methods("GET")
+	router.HandleFunc("/auth/oidc/{provider}/callback", controller.Callback).Methods("GET")
+	router.HandleFunc("/auth/oauth/exchange", controller.ExchangeCode).Methods("POST")
+	router.HandleFunc("/auth/identities/{provider}", func(w http.ResponseWriter, r *http.Request) {
+		// Signed in as the user named by the test
+		//nolint:staticcheck // Using string key to match what the actual auth middleware uses
+		ctx := context.WithValue(r.Context(), "userID", r.Header.Get("X-Test-User"))
+		controller.BeginIdentityLink(w, r.WithContext(ctx))
+	}).Methods("POST")
+	return router
+}
+
+// startSignIn follows /auth/oidc/keycloak and returns the provider redirect's
+// state and the flow cookie
+func startSignIn(t *testing.T, router *mux.Router) (string, *http.Cookie) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/auth/oidc/keycloak", nil))
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("Expected a redirect to the provider, got %d", rr.Code)
+	}
+	location, _ := url.Parse(rr.Header().Get("Location"))
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == "oauth_flow" {
+			return location.Query().Get("state"), cookie
+		}
+	}
+	t.Fatal("Expected a flow cookie")
+	return "", nil
+}
+
+func TestOAuthCallbackRedirectsWithOneTimeCode(t *testing.T) {
+	router := newOAuth2Router("https://app.example.com/auth/callback?from=api")
+
+	state, cookie := startSignIn(t, router)
+	request := httptest.NewRequest("GET", "/auth/oidc/keycloak/callback?code=good-code&state="+url.QueryEscape(state), nil)
+	request.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+
+	if rr.Code != http.StatusFound {
+		t.Fatalf("Expected a redirect to the frontend, got %d: %s", rr.Code, rr.Body.String())
+	}
+	location, _ := url.Parse(rr.Header().Get("Location"))
+	if location.Host != "app.example.com" || location.Query().Get("from") != "api" || location.Query().Get("provider") != "keycloak" {
+		t.Errorf("Unexpected redirect: %s", location)
+	}
+	if strings.Contains(location.String(), "token") {
+		t.Errorf("Expected no token in the redirect, got %s", location)
+	}
+
+	exchange := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"code": location.Query().Get("code")})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/auth/oauth/exchange", bytes.NewReader(body)))
+		return rr
+	}
+
+	rr = exchange()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the code to be exchanged, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response interfaces.LoginResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil || response.Token == "" || response.User == nil {
+		t.Errorf("Expected a session, got %+v, %v", response, err)
+	}
+
+	// Codes work once
+	if rr := exchange(); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected a used code to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestOAuthCallbackErrorsGoToFrontend(t *testing.T) {
+	router := newOAuth2Router("https://app.example.com/auth/callback")
+
+	_, cookie := startSignIn(t, router)
+	request := httptest.NewRequest("GET", "/auth/oidc/keycloak/callback?code=good-code&state=forged", nil)
+	request.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+
+	location, _ := url.Parse(rr.Header().Get("Location"))
+	if rr.Code != http.StatusFound || location.Query().Get("error") == "" || location.Query().Get("code") != "" {
+		t.Errorf("Expected an error redirect, got %d %s", rr.Code, location)
+	}
+}
+
+func TestOAuthCallbackWithoutFrontendAnswersJSON(t *testing.T) {
+	router := newOAuth2Router("")
+
+	state, cookie := startSignIn(t, router)
+	request := httptest.NewRequest("GET", "/auth/oidc/keycloak/callback?code=good-code&state="+url.QueryEscape(state), nil)
+	request.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+
+	var response map[string]interface{}
+	if rr.Code != http.StatusOK || json.NewDecoder(rr.Body).Decode(&response) != nil || response["token"] == nil {
+		t.Errorf("Expected tokens in the response, got %d %v", rr.Code, response)
+	}
+}
+
+func TestIdentityLinkTicketOnlySetBySignedInUser(t *testing.T) {
+	router := newOAuth2Router("")
+
+	// Sign in once to have a user
+	state, cookie := startSignIn(t, router)
+	request := httptest.NewRequest("GET", "/auth/oidc/keycloak/callback?code=good-code&state="+url.QueryEscape(state), nil)
+	request.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+	var session struct {
+		User struct{ ID string }
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&session); err != nil || session.User.ID == "" {
+		t.Fatalf("Failed to sign in: %d %v", rr.Code, err)
+	}
+
+	// The ticket comes back as a cookie, not in the URL
+	request = httptest.NewRequest("POST", "/auth/identities/github", nil)
+	request.Header.Set("X-Test-User", session.User.ID)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+	var response map[string]string
+	if rr.Code != http.StatusOK || json.NewDecoder(rr.Body).Decode(&response) != nil || response["url"] != "/auth/github" {
+		t.Errorf("Expected the plain login path, got %d %v", rr.Code, response)
+	}
+	if linkCookie(rr) == "" {
+		t.Error("Expected the link ticket in a cookie")
+	}
+
+	// A ticket in the login URL is ignored, so it can't be planted in
+	// someone else's browser
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/auth/github?link=planted", nil))
+	if rr.Code != http.StatusTemporaryRedirect || linkCookie(rr) != "" {
+		t.Errorf("Expected the link parameter to be ignored, got %d %q", rr.Code, linkCookie(rr))
+	}
+}
+
+func linkCookie(rr *httptest.ResponseRecorder) string {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == "oauth_link" {
+			return cookie.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"strings"
+	"testing"
+)
+
+func TestAuthUseCaseRecordsLoginAttempts(t *testing.T) {
+	repo := db.NewInMemoryAuditRepository()
+	audit := usecases.NewAuditLog(repo, &mockLogger{})
+	authUseCase := usecases.NewAuthUseCaseWithConfig(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{}, usecases.AuthConfig{
+		Audit: audit,
+	})
+	client := interfaces.ClientInfo{IP: "203.0.113.7", UserAgent: "test-agent", RequestID: "req-1"}
+
+	registered, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", client)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	authUseCase.Login("testuser", "wrongpassword", client)
+	authUseCase.Login("nobody", "password123", client)
+	if _, err := authUseCase.Login("testuser", "password123", client); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	failed, _ := audit.ListEvents(interfaces.AuditFilter{Action: entities.AuditLoginFailed})
+	if len(failed) != 2 {
+		t.Fatalf("Expected 2 failed logins, got %d", len(failed))
+	}
+	if failed[0].After != `{"identifier":"nobody"}` || failed[0].IP != "203.0.113.7" || failed[0].RequestID != "req-1" {
+		t.Errorf("Unexpected failed login event: %+v", failed[0])
+	}
+	if failed[1].TargetID != registered.User.ID {
+		t.Errorf("Expected failed login to target the user, got %q", failed[1].TargetID)
+	}
+
+	events, _ := audit.ListEvents(interfaces.AuditFilter{ActorID: registered.User.ID})
+	if len(events) != 2 || events[0].Action != entities.AuditLogin || events[1].Action != entities.AuditUserRegister {
+		t.Fatalf("Expected login and register events, got %+v", events)
+	}
+	if strings.Contains(events[1].After, "password") {
+		t.Errorf("Snapshot must not include the password hash: %s", events[1].After)
+	}
+}
+
+func TestAdminRoleChangeIsAudited(t *testing.T) {
+	f := newPolicyFixture(t)
+	audit := usecases.NewAuditLog(db.NewInMemoryAuditRepository(), &mockLogger{})
+	admin := usecases.NewAdminUseCase(f.users, &mockLogger{}, nil, nil, nil, f.policy, audit, nil)
+	actor := interfaces.Actor{UserID: "admin-1", Client: interfaces.ClientInfo{IP: "198.51.100.1"}}
+
+	if err := admin.UpdateUserRole(actor, "user", entities.RoleEditor); err != nil {
+		t.Fatalf("Failed to update role: %v", err)
+	}
+	if err := admin.DeleteUser(actor, "author"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	events, _ := audit.ListEvents(interfaces.AuditFilter{TargetType: entities.AuditTargetUser, TargetID: "user"})
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event for the user, got %d", len(events))
+	}
+	event := events[0]
+	if event.Action != entities.AuditUserRoleChange || event.ActorID != "admin-1" || event.IP != "198.51.100.1" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if !strings.Contains(event.Before, `"role":"user"`) || !strings.Contains(event.After, `"role":"editor"`) {
+		t.Errorf("Expected role in snapshots, got %s -> %s", event.Before, event.After)
+	}
+
+	deleted, _ := audit.ListEvents(interfaces.AuditFilter{Action: entities.AuditUserDelete})
+	if len(deleted) != 1 || deleted[0].Before == "" || deleted[0].After != "" {
+		t.Errorf("Expected delete event with a before snapshot, got %+v", deleted)
+	}
+}
+
+func TestContentChangesAreAudited(t *testing.T) {
+	f := newPolicyFixture(t)
+	audit := usecases.NewAuditLog(db.NewInMemoryAuditRepository(), &mockLogger{})
+	posts := usecases.NewBlogPostUseCase(f.posts, &mockLogger{}, nil, nil, f.policy, audit)
+	comments := usecases.NewCommentUseCase(f.comments, f.posts, f.users, &mockLogger{}, nil, nil, f.policy, audit)
+
+	if _, err := posts.UpdateBlogPost("post-1", "Edited", "Content", "editor", "Typo", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to update post: %v", err)
+	}
+	if err := comments.DeleteComment("comment-1", "author", "", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to delete comment: %v", err)
+	}
+
+	updated, _ := audit.ListEvents(interfaces.AuditFilter{Action: entities.AuditPostUpdate})
+	if len(updated) != 1 || !strings.Contains(updated[0].Before, `"title":"Title"`) || !strings.Contains(updated[0].After, `"title":"Edited"`) {
+		t.Errorf("Unexpected post update events: %+v", updated)
+	}
+	deleted, _ := audit.ListEvents(interfaces.AuditFilter{TargetType: entities.AuditTargetComment})
+	if len(deleted) != 1 || deleted[0].Action != entities.AuditCommentDelete || deleted[0].ActorID != "author" {
+		t.Errorf("Unexpected comment events: %+v", deleted)
+	}
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	var audit *usecases.AuditLog
+	audit.Record(entities.NewAuditEvent(entities.AuditLogin, "user", entities.AuditTargetUser, "user"), interfaces.ClientInfo{}, nil, nil)
+
+	if _, err := audit.ListEvents(interfaces.AuditFilter{}); err == nil || err.Error() != "audit log is not enabled" {
+		t.Errorf("Expected disabled audit log error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAPITokenAuthUseCase(t *testing.T) (interfaces.AuthUseCase, string) {
+	t.Helper()
+	config := usecases.DefaultAuthConfig()
+	config.APITokens = db.NewInMemoryAPITokenRepository()
+	authUseCase := usecases.NewAuthUseCaseWithConfig(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{}, config)
+
+	response, err := authUseCase.Register("alice", "alice@example.com", "password123", "Alice", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	return authUseCase, response.User.ID
+}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	authUseCase, userID := newAPITokenAuthUseCase(t)
+
+	token, value, err := authUseCase.CreateAPIToken(userID, "CI deploys",
+		[]entities.Scope{entities.ScopePostsWrite, entities.ScopePostsWrite}, 30*24*time.Hour, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if !strings.HasPrefix(value, entities.APITokenPrefix) || !strings.HasPrefix(value, token.Prefix) {
+		t.Errorf("Expected a prefixed token, got %q with prefix %q", value, token.Prefix)
+	}
+	if token.TokenHash == value || len(token.Scopes) != 1 || token.ExpiresAt == nil {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+
+	found, user, err := authUseCase.AuthenticateAPIToken(value)
+	if err != nil || found.ID != token.ID || user.ID != userID {
+		t.Fatalf("Expected the token to authenticate, got %v, %v, %v", found, user, err)
+	}
+	if !found.HasScope(entities.ScopePostsWrite) || found.HasScope(entities.ScopeProfileWrite) {
+		t.Errorf("Unexpected scopes: %v", found.Scopes)
+	}
+
+	tokens, err := authUseCase.ListAPITokens(userID)
+	if err != nil || len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Fatalf("Expected one used token, got %v, %v", tokens, err)
+	}
+
+	// Only the owner can revoke a token
+	if err := authUseCase.RevokeAPIToken("someone-else", token.ID, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected another user's revocation to fail")
+	}
+	if err := authUseCase.RevokeAPIToken(userID, token.ID, interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, _, err := authUseCase.AuthenticateAPIToken(value); err == nil {
+		t.Error("Expected a revoked token to be rejected")
+	}
+}
+
+func TestAPITokenValidation(t *testing.T) {
+	authUseCase, userID := newAPITokenAuthUseCase(t)
+
+	tests := []struct {
+		name     string
+		tokName  string
+		scopes   []entities.Scope
+		lifetime time.Duration
+	}{
+		{"no name", "", []entities.Scope{entities.ScopeProfileRead}, 0},
+		{"no scopes", "script", nil, 0},
+		{"unknown scope", "script", []entities.Scope{"admin:everything"}, 0},
+		{"too long", "script", []entities.Scope{entities.ScopeProfileRead}, usecases.MaxAPITokenLifetime + time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := authUseCase.CreateAPIToken(userID, tt.tokName, tt.scopes, tt.lifetime, interfaces.ClientInfo{}); err == nil {
+				t.Error("Expected the token to be refused")
+			}
+		})
+	}
+
+	if _, _, err := authUseCase.AuthenticateAPIToken(entities.APITokenPrefix + "made-up"); err == nil {
+		t.Error("Expected an unknown token to be rejected")
+	}
+}
+
+func TestAPITokensRequireRepository(t *testing.T) {
+	authUseCase := usecases.NewAuthUseCase(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{})
+
+	if _, _, err := authUseCase.CreateAPIToken("user-1", "script", []entities.Scope{entities.ScopeProfileRead}, 0, interfaces.ClientInfo{}); err == nil || err.Error() != "API tokens are not enabled" {
+		t.Errorf("Expected API tokens to be disabled, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package usecases_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/mail"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var tokenLinkPattern = regexp.MustCompile(`https?://\S+\?token=(\S+)`)
+
+type emailFixture struct {
+	auth   interfaces.AuthUseCase
+	users  interfaces.UserRepository
+	outbox *mail.InMemoryOutbox
+	userID string
+}
+
+func newEmailFixture(t *testing.T) *emailFixture {
+	t.Helper()
+	f := &emailFixture{
+		users:  newMockUserRepository(),
+		outbox: mail.NewInMemoryOutbox(),
+	}
+	config := usecases.DefaultAuthConfig()
+	config.UserTokens = db.NewInMemoryUserTokenRepository()
+	config.Mailer = f.outbox
+	f.auth = usecases.NewAuthUseCaseWithConfig(f.users, newMockTokenGenerator(), &mockLogger{}, config)
+
+	registered, err := f.auth.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	f.userID = registered.User.ID
+	return f
+}
+
+// lastToken returns the token in the link of the most recent email
+func (f *emailFixture) lastToken(t *testing.T) string {
+	t.Helper()
+	messages := f.outbox.Messages()
+	if len(messages) == 0 {
+		t.Fatal("Expected an email to be sent")
+	}
+	match := tokenLinkPattern.FindStringSubmatch(messages[len(messages)-1].Body)
+	if match == nil {
+		t.Fatalf("Expected a link in the email, got %q", messages[len(messages)-1].Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("Failed to decode token: %v", err)
+	}
+	return token
+}
+
+// waitForMessages waits for emails sent in the background
+func (f *emailFixture) waitForMessages(t *testing.T, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(f.outbox.Messages()) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d emails, got %d", count, len(f.outbox.Messages()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (f *emailFixture) user(t *testing.T) *entities.User {
+	t.Helper()
+	user, err := f.users.FindByID(f.userID)
+	if err != nil || user == nil {
+		t.Fatalf("Failed to find user: %v", err)
+	}
+	return user
+}
+
+func TestRegisterSendsVerificationEmail(t *testing.T) {
+	f := newEmailFixture(t)
+
+	messages := f.outbox.Messages()
+	if len(messages) != 1 || messages[0].To != "test@example.com" {
+		t.Fatalf("Expected one email to the new user, got %+v", messages)
+	}
+	if f.user(t).IsEmailVerified() {
+		t.Fatal("Expected the email address to be unverified")
+	}
+
+	token := f.lastToken(t)
+	if err := f.auth.VerifyEmail(token, interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to verify email: %v", err)
+	}
+	if !f.user(t).IsEmailVerified() {
+		t.Error("Expected the email address to be verified")
+	}
+
+	// Tokens are single use
+	if err := f.auth.VerifyEmail(token, interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired token" {
+		t.Errorf("Expected the used token to be rejected, got %v", err)
+	}
+	if err := f.auth.ResendVerificationEmail(f.userID); err == nil || err.Error() != "email is already verified" {
+		t.Errorf("Expected resending to a verified address to fail, got %v", err)
+	}
+}
+
+func TestResendVerificationEmailInvalidatesEarlierLinks(t *testing.T) {
+	f := newEmailFixture(t)
+	first := f.lastToken(t)
+
+	if err := f.auth.ResendVerificationEmail(f.userID); err != nil {
+		t.Fatalf("Failed to resend: %v", err)
+	}
+	second := f.lastToken(t)
+
+	if err := f.auth.VerifyEmail(first, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected the earlier link to stop working")
+	}
+	if err := f.auth.VerifyEmail(second, interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Failed to verify with the new link: %v", err)
+	}
+}
+
+func TestRegisterVerifiedSendsNoEmail(t *testing.T) {
+	f := newEmailFixture(t)
+
+	registered, err := f.auth.RegisterVerified("oauthuser", "oauth@example.com", "password123", "OAuth User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if registered.User.EmailVerifiedAt == nil {
+		t.Error("Expected the email address to be verified")
+	}
+	if len(f.outbox.Messages()) != 1 {
+		t.Errorf("Expected no verification email, got %d messages", len(f.outbox.Messages()))
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	f := newEmailFixture(t)
+
+	before := len(f.outbox.Messages())
+	if err := f.auth.RequestPasswordReset("TEST@example.com", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to request reset: %v", err)
+	}
+	f.waitForMessages(t, before+1)
+	token := f.lastToken(t)
+
+	// Verification tokens can't reset passwords and vice versa
+	if err := f.auth.VerifyEmail(token, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected a reset token to be rejected for verification")
+	}
+	if err := f.auth.ResetPassword(token, "short", interfaces.ClientInfo{}); err == nil {
+		t.Fatal("Expected a short password to be rejected")
+	}
+	if err := f.auth.ResetPassword(token, "newpassword456", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to reset password: %v", err)
+	}
+
+	if _, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected the old password to stop working")
+	}
+	if _, err := f.auth.Login("testuser", "newpassword456", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Failed to log in with the new password: %v", err)
+	}
+	if !f.user(t).IsEmailVerified() {
+		t.Error("Expected a reset to verify the email address")
+	}
+	if err := f.auth.ResetPassword(token, "anotherpassword789", interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired token" {
+		t.Errorf("Expected the used token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordResetForUnknownEmail(t *testing.T) {
+	f := newEmailFixture(t)
+	before := len(f.outbox.Messages())
+
+	if err := f.auth.RequestPasswordReset("nobody@example.com", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected no error for an unknown address, got %v", err)
+	}
+	if len(f.outbox.Messages()) != before {
+		t.Error("Expected no email for an unknown address")
+	}
+}
+
+// failingMailer can't send anything
+type failingMailer struct{}
+
+func (failingMailer) Send(message usecases.EmailMessage) error {
+	return errors.New("connection refused")
+}
+
+func TestPasswordResetHidesMailFailures(t *testing.T) {
+	users := newMockUserRepository()
+	config := usecases.DefaultAuthConfig()
+	config.UserTokens = db.NewInMemoryUserTokenRepository()
+	config.Mailer = failingMailer{}
+	auth := usecases.NewAuthUseCaseWithConfig(users, newMockTokenGenerator(), &mockLogger{}, config)
+	user, _ := entities.NewUser("testuser", "test@example.com", "password123", "Test User", entities.DefaultPasswordHasher())
+	user.ID = "user-1"
+	users.Save(user)
+
+	// A known address answers like an unknown one even when the email fails
+	if err := auth.RequestPasswordReset("test@example.com", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected no error for a known address, got %v", err)
+	}
+	if err := auth.RequestPasswordReset("nobody@example.com", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected no error for an unknown address, got %v", err)
+	}
+}
+
+func TestEmailNotEnabled(t *testing.T) {
+	auth := usecases.NewAuthUseCase(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{})
+
+	if err := auth.RequestPasswordReset("test@example.com", interfaces.ClientInfo{}); err == nil || err.Error() != "password reset is not enabled" {
+		t.Errorf("Expected password reset to be disabled, got %v", err)
+	}
+	if err := auth.VerifyEmail("token", interfaces.ClientInfo{}); err == nil || err.Error() != "email verification is not enabled" {
+		t.Errorf("Expected email verification to be disabled, got %v", err)
+	}
+}
+
+func TestUnverifiedUserRestrictions(t *testing.T) {
+	f := newPolicyFixture(t)
+	f.policy.UnverifiedRestrictions = []entities.Restriction{entities.RestrictionComment}
+	uc := usecases.NewCommentUseCase(f.comments, f.posts, f.users, &mockLogger{}, nil, nil, f.policy, nil)
+
+	if _, err := uc.CreateComment("comment-2", "post-1", "user", "Hello", "", interfaces.ClientInfo{}); err == nil || err.Error() != "email address is not verified" {
+		t.Fatalf("Expected unverified users to be unable to comment, got %v", err)
+	}
+	if err := f.policy.RequireVerifiedEmail("user", entities.RestrictionPost); err != nil {
+		t.Errorf("Expected posting to be allowed, got %v", err)
+	}
+
+	user, _ := f.users.FindByID("user")
+	user.MarkEmailVerified(user.CreatedAt)
+	f.users.Save(user)
+	if _, err := uc.CreateComment("comment-2", "post-1", "user", "Hello", "", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected verified users to comment, got %v", err)
+	}
+}
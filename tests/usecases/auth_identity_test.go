@@ -0,0 +1,180 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/frameworks/mail"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+)
+
+type identityFixture struct {
+	auth  interfaces.AuthUseCase
+	users interfaces.UserRepository
+}
+
+// newIdentityFixture sets up provider sign-in, with password reset by email
+// when a mailer is given
+func newIdentityFixture(mailer usecases.Mailer) *identityFixture {
+	f := &identityFixture{users: newMockUserRepository()}
+	config := usecases.DefaultAuthConfig()
+	config.Identities = db.NewInMemoryIdentityRepository()
+	config.UserTokens = db.NewInMemoryUserTokenRepository()
+	config.Mailer = mailer
+	f.auth = usecases.NewAuthUseCaseWithConfig(f.users, newMockTokenGenerator(), &mockLogger{}, config)
+	return f
+}
+
+func githubAccount(id, email string, verified bool) interfaces.ExternalIdentity {
+	return interfaces.ExternalIdentity{
+		Provider:      "github",
+		ProviderID:    id,
+		Email:         email,
+		EmailVerified: verified,
+		Name:          "Octo Cat",
+	}
+}
+
+func TestOAuthLoginCreatesAndReusesIdentity(t *testing.T) {
+	f := newIdentityFixture(nil)
+
+	first, err := f.auth.OAuthLogin(githubAccount("42", "octo@example.com", true), interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if first.Token == "" || first.User == nil || first.User.EmailVerifiedAt == nil {
+		t.Fatalf("Expected a session for a verified new user, got %+v", first)
+	}
+
+	// The provider account ID, not the address, identifies the user
+	second, err := f.auth.OAuthLogin(githubAccount("42", "renamed@example.com", true), interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to sign in again: %v", err)
+	}
+	if second.User.ID != first.User.ID {
+		t.Errorf("Expected the same user, got %s and %s", first.User.ID, second.User.ID)
+	}
+
+	identities, err := f.auth.ListIdentities(first.User.ID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("Expected one linked account, got %v, %v", identities, err)
+	}
+	if identities[0].ProviderID != "42" || identities[0].Email != "renamed@example.com" {
+		t.Errorf("Unexpected identity: %+v", identities[0])
+	}
+}
+
+func TestOAuthLoginDoesNotTakeOverAccountsByEmail(t *testing.T) {
+	f := newIdentityFixture(nil)
+	victim, err := f.auth.Register("victim", "victim@example.com", "password123", "Victim", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	_, err = f.auth.OAuthLogin(githubAccount("1", "victim@example.com", true), interfaces.ClientInfo{})
+	if err == nil {
+		t.Fatal("Expected sign-in to an unverified account to be refused")
+	}
+
+	// Even when both sides verified the address, the provider's word isn't
+	// proof that its account belongs to the user
+	user, _ := f.users.FindByID(victim.User.ID)
+	user.MarkEmailVerified(user.CreatedAt)
+	f.users.Save(user)
+	for _, verified := range []bool{false, true} {
+		if _, err := f.auth.OAuthLogin(githubAccount("1", "victim@example.com", verified), interfaces.ClientInfo{}); err == nil {
+			t.Fatalf("Expected sign-in with a matching address to be refused (provider verified: %v)", verified)
+		}
+	}
+
+	if identities, _ := f.auth.ListIdentities(victim.User.ID); len(identities) != 0 {
+		t.Errorf("Expected no linked accounts, got %d", len(identities))
+	}
+}
+
+func TestIdentityLinkAndUnlink(t *testing.T) {
+	f := newIdentityFixture(nil)
+	registered, err := f.auth.Register("octo", "octo@example.com", "password123", "Octo", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	userID := registered.User.ID
+
+	ticket, err := f.auth.BeginIdentityLink(userID)
+	if err != nil {
+		t.Fatalf("Failed to start linking: %v", err)
+	}
+	identity, err := f.auth.CompleteIdentityLink(ticket, githubAccount("42", "someone@example.com", false), interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to link: %v", err)
+	}
+	if identity.UserID != userID || identity.Provider != "github" {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+
+	// Tickets work once
+	if _, err := f.auth.CompleteIdentityLink(ticket, githubAccount("43", "", false), interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired link request" {
+		t.Errorf("Expected the used ticket to be rejected, got %v", err)
+	}
+
+	// The linked account now signs in as the user, whatever its address
+	response, err := f.auth.OAuthLogin(githubAccount("42", "someone@example.com", false), interfaces.ClientInfo{})
+	if err != nil || response.User.ID != userID {
+		t.Fatalf("Expected to sign in as the linked user, got %+v, %v", response, err)
+	}
+
+	// A provider account links to one user only
+	other, _ := f.auth.Register("other", "other@example.com", "password123", "Other", interfaces.ClientInfo{})
+	otherTicket, _ := f.auth.BeginIdentityLink(other.User.ID)
+	if _, err := f.auth.CompleteIdentityLink(otherTicket, githubAccount("42", "", false), interfaces.ClientInfo{}); err == nil || err.Error() != "this provider account is already linked to another user" {
+		t.Errorf("Expected the linked account to be refused, got %v", err)
+	}
+
+	// Without password reset by email, the last provider can't be unlinked
+	if err := f.auth.UnlinkIdentity(userID, "github", interfaces.ClientInfo{}); err == nil || err.Error() != "cannot unlink your only sign-in provider" {
+		t.Errorf("Expected unlinking the last provider to be refused, got %v", err)
+	}
+	if err := f.auth.UnlinkIdentity(userID, "google", interfaces.ClientInfo{}); err == nil || err.Error() != "linked account not found" {
+		t.Errorf("Expected an unknown provider to be reported, got %v", err)
+	}
+}
+
+func TestUnlinkIdentityWithPasswordReset(t *testing.T) {
+	f := newIdentityFixture(mail.NewInMemoryOutbox())
+
+	response, err := f.auth.OAuthLogin(githubAccount("42", "octo@example.com", true), interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	if err := f.auth.UnlinkIdentity(response.User.ID, "github", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to unlink: %v", err)
+	}
+	if identities, _ := f.auth.ListIdentities(response.User.ID); len(identities) != 0 {
+		t.Errorf("Expected no linked accounts, got %d", len(identities))
+	}
+}
+
+func TestLoginCodeExchange(t *testing.T) {
+	f := newIdentityFixture(nil)
+
+	code, err := f.auth.IssueLoginCode(githubAccount("42", "octo@example.com", true), interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to issue sign-in code: %v", err)
+	}
+
+	response, err := f.auth.ExchangeLoginCode(code, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to exchange sign-in code: %v", err)
+	}
+	if response.Token == "" || response.User == nil || response.User.Email != "octo@example.com" {
+		t.Errorf("Expected a session for the provider account, got %+v", response)
+	}
+
+	// Codes work once
+	if _, err := f.auth.ExchangeLoginCode(code, interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired sign-in code" {
+		t.Errorf("Expected the used code to be rejected, got %v", err)
+	}
+	if _, err := f.auth.ExchangeLoginCode("made-up", interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected an unknown code to be rejected")
+	}
+}
@@ -0,0 +1,77 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+	"time"
+)
+
+func newRevokingAuthUseCase(t *testing.T) (interfaces.AuthUseCase, interfaces.TokenRevocationRepository, *interfaces.LoginResponse) {
+	t.Helper()
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	authUseCase := usecases.NewAuthUseCaseWithConfig(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{}, usecases.AuthConfig{
+		RefreshTokens:        db.NewInMemoryRefreshTokenRepository(),
+		RefreshTokenDuration: time.Hour,
+		Revocations:          revocations,
+	})
+
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	return authUseCase, revocations, response
+}
+
+func TestLogoutRevokesAccessAndRefreshToken(t *testing.T) {
+	authUseCase, revocations, login := newRevokingAuthUseCase(t)
+
+	err := authUseCase.Logout(login.User.ID, "", "token-1", time.Now().Add(time.Minute), login.RefreshToken, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Expected logout to succeed, got %v", err)
+	}
+
+	if revoked, _ := revocations.IsRevoked("token-1"); !revoked {
+		t.Error("Expected access token to be revoked")
+	}
+	if _, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected refresh token to be revoked")
+	}
+}
+
+func TestLogoutAllBumpsGenerationAndRevokesRefreshTokens(t *testing.T) {
+	authUseCase, revocations, login := newRevokingAuthUseCase(t)
+	other, err := authUseCase.Login("testuser", "password123", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	if err := authUseCase.LogoutAll(login.User.ID, interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected logout-all to succeed, got %v", err)
+	}
+
+	if generation, _ := revocations.GetUserGeneration(login.User.ID); generation != 1 {
+		t.Errorf("Expected token generation 1, got %d", generation)
+	}
+	for _, refreshToken := range []string{login.RefreshToken, other.RefreshToken} {
+		if _, err := authUseCase.Refresh(refreshToken, interfaces.ClientInfo{}); err == nil {
+			t.Error("Expected every refresh token to be revoked")
+		}
+	}
+}
+
+func TestChangePasswordInvalidatesSessions(t *testing.T) {
+	authUseCase, revocations, login := newRevokingAuthUseCase(t)
+
+	if err := authUseCase.ChangePassword(login.User.ID, "password123", "newpassword456", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	if generation, _ := revocations.GetUserGeneration(login.User.ID); generation != 1 {
+		t.Errorf("Expected token generation to be bumped, got %d", generation)
+	}
+	if _, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected refresh token to be revoked after password change")
+	}
+}
@@ -0,0 +1,247 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roleRecordingTokenGenerator remembers the role of the last token it signed
+type roleRecordingTokenGenerator struct {
+	*mockTokenGenerator
+	role entities.UserRole
+}
+
+func (g *roleRecordingTokenGenerator) GenerateToken(token *entities.AccessToken) (string, error) {
+	g.role = token.Role
+	return g.mockTokenGenerator.GenerateToken(token)
+}
+
+type mfaFixture struct {
+	auth   interfaces.AuthUseCase
+	users  interfaces.UserRepository
+	mfa    interfaces.MFARepository
+	tokens *roleRecordingTokenGenerator
+	userID string
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+	return newMFAFixtureWithRepository(t, db.NewInMemoryMFARepository())
+}
+
+func newMFAFixtureWithRepository(t *testing.T, mfa interfaces.MFARepository) *mfaFixture {
+	t.Helper()
+	f := &mfaFixture{
+		users:  newMockUserRepository(),
+		mfa:    mfa,
+		tokens: &roleRecordingTokenGenerator{mockTokenGenerator: newMockTokenGenerator()},
+	}
+	config := usecases.DefaultAuthConfig()
+	config.MFA = f.mfa
+	f.auth = usecases.NewAuthUseCaseWithConfig(f.users, f.tokens, &mockLogger{}, config)
+
+	registered, err := f.auth.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	f.userID = registered.User.ID
+	return f
+}
+
+// enable sets up and confirms a second factor, returning the secret and the
+// recovery codes
+func (f *mfaFixture) enable(t *testing.T) (string, []string) {
+	t.Helper()
+	info, err := f.auth.EnrollMFA(f.userID)
+	if err != nil {
+		t.Fatalf("Failed to enrol: %v", err)
+	}
+	code, _ := entities.TOTPCode(info.Secret, time.Now())
+	recoveryCodes, err := f.auth.ConfirmMFA(f.userID, code, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to confirm: %v", err)
+	}
+	return info.Secret, recoveryCodes
+}
+
+func TestLoginWithTwoFactorReturnsChallenge(t *testing.T) {
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+
+	response, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if !response.MFARequired || response.MFAToken == "" || response.Token != "" || response.User != nil {
+		t.Fatalf("Expected only a two-factor challenge, got %+v", response)
+	}
+
+	// The code used to confirm the enrolment can't be replayed
+	used, _ := entities.TOTPCode(secret, time.Now())
+	if _, err := f.auth.VerifyMFA(response.MFAToken, used, interfaces.ClientInfo{}); err == nil || err.Error() != "invalid two-factor code" {
+		t.Fatalf("Expected the used code to be rejected, got %v", err)
+	}
+
+	next, _ := entities.TOTPCode(secret, time.Now().Add(entities.TOTPPeriod*time.Second))
+	tokens, err := f.auth.VerifyMFA(response.MFAToken, next, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to verify code: %v", err)
+	}
+	if tokens.Token == "" || tokens.User == nil || tokens.User.ID != f.userID {
+		t.Errorf("Expected a session, got %+v", tokens)
+	}
+
+	// Challenges work once
+	if _, err := f.auth.VerifyMFA(response.MFAToken, next, interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired two-factor challenge" {
+		t.Errorf("Expected the challenge to be used up, got %v", err)
+	}
+}
+
+func TestVerifyMFAWithRecoveryCode(t *testing.T) {
+	f := newMFAFixture(t)
+	_, recoveryCodes := f.enable(t)
+	if len(recoveryCodes) != 10 {
+		t.Fatalf("Expected 10 recovery codes, got %d", len(recoveryCodes))
+	}
+
+	challenge, _ := f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+	if _, err := f.auth.VerifyMFA(challenge.MFAToken, recoveryCodes[0], interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to verify recovery code: %v", err)
+	}
+
+	challenge, _ = f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+	if _, err := f.auth.VerifyMFA(challenge.MFAToken, recoveryCodes[0], interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected a recovery code to work once")
+	}
+
+	status, err := f.auth.MFAStatus(f.userID)
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if !status.Enabled || status.RecoveryCodesRemaining != 9 {
+		t.Errorf("Expected 2FA enabled with 9 recovery codes, got %+v", status)
+	}
+}
+
+// gatedMFARepository holds the next callers of FindEnrollment until all of
+// them have read the enrolment, so that concurrent verifications all see a
+// code as unused
+type gatedMFARepository struct {
+	interfaces.MFARepository
+	waiting int32
+	gate    sync.WaitGroup
+}
+
+func (r *gatedMFARepository) hold(callers int) {
+	r.gate.Add(callers)
+	atomic.StoreInt32(&r.waiting, int32(callers))
+}
+
+func (r *gatedMFARepository) FindEnrollment(userID string) (*entities.MFAEnrollment, error) {
+	enrollment, err := r.MFARepository.FindEnrollment(userID)
+	if atomic.AddInt32(&r.waiting, -1) >= 0 {
+		r.gate.Done()
+		r.gate.Wait()
+	}
+	return enrollment, err
+}
+
+func TestConcurrentVerifyMFAUsesCodeOnce(t *testing.T) {
+	const requests = 5
+	repo := &gatedMFARepository{MFARepository: db.NewInMemoryMFARepository()}
+	f := newMFAFixtureWithRepository(t, repo)
+	secret, recoveryCodes := f.enable(t)
+	// The confirmation used the current step's code, the next one is unused
+	next, _ := entities.TOTPCode(secret, time.Now().Add(entities.TOTPPeriod*time.Second))
+
+	for _, code := range []string{next, recoveryCodes[0]} {
+		challenges := make([]string, requests)
+		for i := range challenges {
+			challenge, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+			if err != nil {
+				t.Fatalf("Failed to log in: %v", err)
+			}
+			challenges[i] = challenge.MFAToken
+		}
+
+		repo.hold(requests)
+		var wg sync.WaitGroup
+		var succeeded int32
+		for _, challenge := range challenges {
+			wg.Add(1)
+			go func(challenge string) {
+				defer wg.Done()
+				if _, err := f.auth.VerifyMFA(challenge, code, interfaces.ClientInfo{}); err == nil {
+					atomic.AddInt32(&succeeded, 1)
+				}
+			}(challenge)
+		}
+		wg.Wait()
+
+		if succeeded != 1 {
+			t.Errorf("Expected code %s to sign in once, got %d sign-ins", code, succeeded)
+		}
+	}
+}
+
+func TestMFAChallengeDroppedAfterTooManyWrongCodes(t *testing.T) {
+	f := newMFAFixture(t)
+	f.enable(t)
+
+	challenge, _ := f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+	for i := 0; i < entities.MaxMFAChallengeAttempts; i++ {
+		if _, err := f.auth.VerifyMFA(challenge.MFAToken, "000000", interfaces.ClientInfo{}); err == nil {
+			t.Fatal("Expected a wrong code to be rejected")
+		}
+	}
+	if _, err := f.auth.VerifyMFA(challenge.MFAToken, "000000", interfaces.ClientInfo{}); err == nil || err.Error() != "invalid or expired two-factor challenge" {
+		t.Errorf("Expected the challenge to be dropped, got %v", err)
+	}
+}
+
+func TestRequiredTwoFactorForRole(t *testing.T) {
+	f := newMFAFixture(t)
+	user, _ := f.users.FindByID(f.userID)
+	user.Role = entities.RoleAdmin
+	f.users.Save(user)
+
+	policy := usecases.NewPolicy(f.users, nil, f.mfa, &mockLogger{})
+	admin := usecases.NewAdminUseCase(f.users, &mockLogger{}, nil, nil, nil, policy, nil, nil)
+	if err := admin.SetRoleMFARequired(interfaces.Actor{}, entities.RoleAdmin, true); err != nil {
+		t.Fatalf("Failed to require 2FA: %v", err)
+	}
+
+	// Without a second factor the admin only acts as a plain user
+	if _, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if f.tokens.role != entities.RoleUser {
+		t.Errorf("Expected a token with the user role, got %q", f.tokens.role)
+	}
+	if allowed, _ := policy.UserHasPermission(f.userID, entities.PermissionUserManage); allowed {
+		t.Error("Expected admin permissions to be withheld until 2FA is set up")
+	}
+
+	secret, _ := f.enable(t)
+	if allowed, _ := policy.UserHasPermission(f.userID, entities.PermissionUserManage); !allowed {
+		t.Error("Expected admin permissions once 2FA is set up")
+	}
+	challenge, _ := f.auth.Login("testuser", "password123", interfaces.ClientInfo{})
+	next, _ := entities.TOTPCode(secret, time.Now().Add(entities.TOTPPeriod*time.Second))
+	if _, err := f.auth.VerifyMFA(challenge.MFAToken, next, interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to verify code: %v", err)
+	}
+	if f.tokens.role != entities.RoleAdmin {
+		t.Errorf("Expected a token with the admin role, got %q", f.tokens.role)
+	}
+
+	if err := f.auth.DisableMFA(f.userID, "000000", interfaces.ClientInfo{}); err == nil || err.Error() != "two-factor authentication is required for your role" {
+		t.Errorf("Expected disabling 2FA to be refused, got %v", err)
+	}
+}
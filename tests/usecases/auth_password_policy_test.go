@@ -0,0 +1,146 @@
+package usecases_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"strings"
+	"testing"
+)
+
+// stubBreachedPasswords answers range queries from a set of passwords, and
+// records the prefixes it was asked for
+type stubBreachedPasswords struct {
+	hashes   map[string]int
+	prefixes []string
+	err      error
+}
+
+func newStubBreachedPasswords(passwords ...string) *stubBreachedPasswords {
+	stub := &stubBreachedPasswords{hashes: make(map[string]int)}
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		stub.hashes[strings.ToUpper(hex.EncodeToString(sum[:]))] = 42
+	}
+	return stub
+}
+
+func (s *stubBreachedPasswords) Range(prefix string) (map[string]int, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	if s.err != nil {
+		return nil, s.err
+	}
+	suffixes := make(map[string]int)
+	for hash, count := range s.hashes {
+		if strings.HasPrefix(hash, prefix) {
+			suffixes[hash[5:]] = count
+		}
+	}
+	return suffixes, nil
+}
+
+func newPolicyAuthUseCase(repo *mockUserRepository, breached usecases.BreachedPasswords) interfaces.AuthUseCase {
+	config := usecases.DefaultAuthConfig()
+	config.PasswordPolicy = entities.PasswordPolicy{MinLength: 10, RequireDigit: true, MinStrength: 2, RejectPersonalInfo: true}
+	config.BreachedPasswords = breached
+	return usecases.NewAuthUseCaseWithConfig(repo, newMockTokenGenerator(), &mockLogger{}, config)
+}
+
+func policyViolations(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *entities.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Expected a password policy error, got %v", err)
+	}
+	rules := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		rules[i] = violation.Rule
+	}
+	return rules
+}
+
+func TestRegisterAppliesPasswordPolicy(t *testing.T) {
+	repo := newMockUserRepository()
+	breached := newStubBreachedPasswords("Tr0ub4dor&3x")
+	authUseCase := newPolicyAuthUseCase(repo, breached)
+
+	_, err := authUseCase.Register("alice", "alice@example.com", "alice", "Alice", interfaces.ClientInfo{})
+	rules := policyViolations(t, err)
+	expected := []string{entities.PasswordRuleMinLength, entities.PasswordRuleDigit, entities.PasswordRulePersonalInfo, entities.PasswordRuleStrength}
+	if strings.Join(rules, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected violations %v, got %v", expected, rules)
+	}
+
+	_, err = authUseCase.Register("alice", "alice@example.com", "Tr0ub4dor&3x", "Alice", interfaces.ClientInfo{})
+	if rules := policyViolations(t, err); len(rules) != 1 || rules[0] != entities.PasswordRuleBreached {
+		t.Errorf("Expected only a breached violation, got %v", rules)
+	}
+
+	// Only the first five digits of the hash were looked up
+	for _, prefix := range breached.prefixes {
+		if len(prefix) != 5 {
+			t.Errorf("Expected a 5-digit prefix, got %q", prefix)
+		}
+	}
+	if len(repo.users) != 0 {
+		t.Fatal("Expected no account to be created")
+	}
+
+	if _, err := authUseCase.Register("alice", "alice@example.com", "vintage-lantern-47", "Alice", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected a good password to be accepted, got %v", err)
+	}
+}
+
+func TestChangePasswordAppliesPasswordPolicy(t *testing.T) {
+	repo := newMockUserRepository()
+	authUseCase := newPolicyAuthUseCase(repo, newStubBreachedPasswords("orchard-ladder-93"))
+	registered, err := authUseCase.Register("alice", "alice@example.com", "vintage-lantern-47", "Alice", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	err = authUseCase.ChangePassword(registered.User.ID, "vintage-lantern-47", "orchard-ladder-93", interfaces.ClientInfo{})
+	if rules := policyViolations(t, err); len(rules) != 1 || rules[0] != entities.PasswordRuleBreached {
+		t.Errorf("Expected a breached violation, got %v", rules)
+	}
+	err = authUseCase.ChangePassword(registered.User.ID, "vintage-lantern-47", "alice-was-here-2024", interfaces.ClientInfo{})
+	if rules := policyViolations(t, err); rules[0] != entities.PasswordRulePersonalInfo {
+		t.Errorf("Expected a personal info violation, got %v", rules)
+	}
+
+	if err := authUseCase.ChangePassword(registered.User.ID, "vintage-lantern-47", "copper-meadow-58", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected a good password to be accepted, got %v", err)
+	}
+}
+
+func TestChangePasswordChecksOldPasswordFirst(t *testing.T) {
+	repo := newMockUserRepository()
+	breached := newStubBreachedPasswords("orchard-ladder-93")
+	authUseCase := newPolicyAuthUseCase(repo, breached)
+	registered, err := authUseCase.Register("alice", "alice@example.com", "vintage-lantern-47", "Alice", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	lookups := len(breached.prefixes)
+
+	err = authUseCase.ChangePassword(registered.User.ID, "wrong-password-11", "orchard-ladder-93", interfaces.ClientInfo{})
+	if err == nil || err.Error() != "old password is incorrect" {
+		t.Errorf("Expected the wrong old password to be reported, got %v", err)
+	}
+	if len(breached.prefixes) != lookups {
+		t.Error("Expected no breach lookup without the old password")
+	}
+}
+
+func TestBreachedPasswordLookupFailureIsSkipped(t *testing.T) {
+	breached := newStubBreachedPasswords()
+	breached.err = errors.New("corpus unreadable")
+	authUseCase := newPolicyAuthUseCase(newMockUserRepository(), breached)
+
+	if _, err := authUseCase.Register("alice", "alice@example.com", "vintage-lantern-47", "Alice", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected sign-up to work without the corpus, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package usecases_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newLegacyUser saves a user whose password was hashed with bcrypt, as
+// accounts were before Argon2id
+func newLegacyUser(t *testing.T, repo *mockUserRepository) *entities.User {
+	t.Helper()
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt failed: %v", err)
+	}
+	user := &entities.User{ID: "user-1", Username: "legacy", Email: "legacy@example.com", PasswordHash: string(legacy), Role: entities.RoleUser}
+	repo.users[user.ID] = user
+	return user
+}
+
+func TestLoginUpgradesLegacyPasswordHash(t *testing.T) {
+	repo := newMockUserRepository()
+	user := newLegacyUser(t, repo)
+	authUseCase := usecases.NewAuthUseCase(repo, newMockTokenGenerator(), &mockLogger{})
+
+	if _, err := authUseCase.Login("legacy", "wrongpassword", interfaces.ClientInfo{}); err == nil {
+		t.Fatal("Expected a wrong password to fail")
+	}
+	if !strings.HasPrefix(user.PasswordHash, "$2a$") {
+		t.Fatal("Expected a failed login to leave the hash alone")
+	}
+
+	if _, err := authUseCase.Login("legacy", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected the bcrypt password to log in, got %v", err)
+	}
+	if !strings.HasPrefix(repo.users[user.ID].PasswordHash, "$argon2id$") {
+		t.Fatalf("Expected the hash to be upgraded to argon2id, got %q", repo.users[user.ID].PasswordHash)
+	}
+
+	if _, err := authUseCase.Login("legacy", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected the password to log in with the new hash, got %v", err)
+	}
+}
+
+func TestLoginUpgradesOutdatedArgon2idParameters(t *testing.T) {
+	repo := newMockUserRepository()
+	authUseCase := usecases.NewAuthUseCase(repo, newMockTokenGenerator(), &mockLogger{})
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	original := repo.users[response.User.ID].PasswordHash
+
+	params := entities.DefaultArgon2idParams()
+	params.Memory *= 2
+	config := usecases.DefaultAuthConfig()
+	config.PasswordHasher = entities.NewArgon2idHasher(params)
+	stronger := usecases.NewAuthUseCaseWithConfig(repo, newMockTokenGenerator(), &mockLogger{}, config)
+
+	if _, err := stronger.Login("testuser", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected login with the old parameters to work, got %v", err)
+	}
+	upgraded := repo.users[response.User.ID].PasswordHash
+	if upgraded == original || !strings.Contains(upgraded, "m=38912,") {
+		t.Errorf("Expected the hash to be remade with the new parameters, got %q", upgraded)
+	}
+}
+
+func TestLoginSucceedsWhenRehashCannotBeSaved(t *testing.T) {
+	repo := newMockUserRepository()
+	user := newLegacyUser(t, repo)
+	authUseCase := usecases.NewAuthUseCase(repo, newMockTokenGenerator(), &mockLogger{})
+
+	repo.saveError = errors.New("database unavailable")
+	if _, err := authUseCase.Login("legacy", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected login to succeed without the upgrade, got %v", err)
+	}
+	if !strings.HasPrefix(user.PasswordHash, "$2a$") {
+		t.Errorf("Expected the bcrypt hash to be kept when saving fails, got %q", user.PasswordHash)
+	}
+}
@@ -0,0 +1,97 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+	"time"
+)
+
+func newRefreshingAuthUseCase(t *testing.T) (interfaces.AuthUseCase, *interfaces.LoginResponse) {
+	t.Helper()
+	authUseCase := usecases.NewAuthUseCaseWithConfig(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{}, usecases.AuthConfig{
+		RefreshTokens:        db.NewInMemoryRefreshTokenRepository(),
+		RefreshTokenDuration: time.Hour,
+	})
+
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if response.RefreshToken == "" {
+		t.Fatal("Expected a refresh token on registration")
+	}
+	return authUseCase, response
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	authUseCase, login := newRefreshingAuthUseCase(t)
+
+	refreshed, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Expected refresh to succeed, got %v", err)
+	}
+	if refreshed.Token == "" || refreshed.RefreshToken == "" {
+		t.Fatal("Expected new access and refresh tokens")
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Expected the refresh token to be rotated")
+	}
+
+	if _, err := authUseCase.Refresh(refreshed.RefreshToken, interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected rotated token to work, got %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	authUseCase, login := newRefreshingAuthUseCase(t)
+
+	refreshed, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Expected refresh to succeed, got %v", err)
+	}
+
+	// Replaying the original token is treated as theft
+	_, err = authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{})
+	if err == nil || err.Error() != "refresh token reuse detected" {
+		t.Fatalf("Expected reuse to be detected, got %v", err)
+	}
+
+	// The legitimate successor is revoked along with the rest of the family
+	if _, err := authUseCase.Refresh(refreshed.RefreshToken, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected the whole token family to be revoked")
+	}
+
+	// Other logins are unaffected
+	other, err := authUseCase.Login("testuser", "password123", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if _, err := authUseCase.Refresh(other.RefreshToken, interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected a separate login to keep working, got %v", err)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	authUseCase, _ := newRefreshingAuthUseCase(t)
+
+	if _, err := authUseCase.Refresh("not-a-token", interfaces.ClientInfo{}); err == nil || err.Error() != "invalid refresh token" {
+		t.Errorf("Expected 'invalid refresh token', got %v", err)
+	}
+}
+
+func TestRefreshDisabledWithoutRepository(t *testing.T) {
+	authUseCase := usecases.NewAuthUseCase(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{})
+
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if response.RefreshToken != "" {
+		t.Error("Expected no refresh token when refresh tokens are disabled")
+	}
+	if _, err := authUseCase.Refresh("anything", interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected refresh to fail when disabled")
+	}
+}
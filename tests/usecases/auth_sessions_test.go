@@ -0,0 +1,128 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+	"time"
+)
+
+func newSessionAuthUseCase(t *testing.T) (interfaces.AuthUseCase, interfaces.TokenRevocationRepository) {
+	t.Helper()
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	authUseCase := usecases.NewAuthUseCaseWithConfig(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{}, usecases.AuthConfig{
+		RefreshTokens:        db.NewInMemoryRefreshTokenRepository(),
+		RefreshTokenDuration: time.Hour,
+		Revocations:          revocations,
+		Sessions:             db.NewInMemorySessionRepository(),
+	})
+	return authUseCase, revocations
+}
+
+func TestLoginRecordsSession(t *testing.T) {
+	authUseCase, _ := newSessionAuthUseCase(t)
+	client := interfaces.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"}
+
+	register, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", client)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if _, err := authUseCase.Login("testuser", "password123", interfaces.ClientInfo{UserAgent: "curl", IP: "10.0.0.2"}); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	sessions, err := authUseCase.ListSessions(register.User.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	agents := map[string]string{}
+	for _, session := range sessions {
+		agents[session.UserAgent] = session.IP
+	}
+	if agents["Firefox"] != "10.0.0.1" || agents["curl"] != "10.0.0.2" {
+		t.Errorf("Expected sessions to record user agent and IP, got %v", agents)
+	}
+}
+
+func TestRefreshUpdatesSession(t *testing.T) {
+	authUseCase, _ := newSessionAuthUseCase(t)
+	login, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{UserAgent: "Firefox"})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	before, _ := authUseCase.ListSessions(login.User.ID, "")
+
+	if _, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{UserAgent: "Firefox 2", IP: "10.0.0.9"}); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	after, _ := authUseCase.ListSessions(login.User.ID, "")
+	if len(after) != 1 {
+		t.Fatalf("Expected refresh to keep a single session, got %d", len(after))
+	}
+	if after[0].ID != before[0].ID {
+		t.Error("Expected refresh to keep the session ID")
+	}
+	if after[0].UserAgent != "Firefox 2" || after[0].IP != "10.0.0.9" {
+		t.Errorf("Expected refresh to update client info, got %+v", after[0])
+	}
+	if after[0].LastSeenAt.Before(before[0].LastSeenAt) {
+		t.Error("Expected refresh to update last seen time")
+	}
+}
+
+func TestRevokeSessionEndsItImmediately(t *testing.T) {
+	authUseCase, revocations := newSessionAuthUseCase(t)
+	login, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	sessions, _ := authUseCase.ListSessions(login.User.ID, "")
+
+	if err := authUseCase.RevokeSession("someone-else", sessions[0].ID); err == nil || err.Error() != "session not found" {
+		t.Errorf("Expected another user's session to be hidden, got %v", err)
+	}
+	if err := authUseCase.RevokeSession(login.User.ID, sessions[0].ID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+
+	// The mock generator numbers tokens, so the registration token is token-1
+	if revoked, _ := revocations.IsRevoked("token-1"); !revoked {
+		t.Error("Expected the session's access token to be revoked")
+	}
+	if _, err := authUseCase.Refresh(login.RefreshToken, interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected the session's refresh token to be revoked")
+	}
+	if remaining, _ := authUseCase.ListSessions(login.User.ID, ""); len(remaining) != 0 {
+		t.Errorf("Expected no active sessions, got %d", len(remaining))
+	}
+}
+
+func TestListSessionsFlagsCurrentSession(t *testing.T) {
+	authUseCase, _ := newSessionAuthUseCase(t)
+	login, _ := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	authUseCase.Login("testuser", "password123", interfaces.ClientInfo{})
+
+	sessions, _ := authUseCase.ListSessions(login.User.ID, "")
+	current := sessions[1].ID
+
+	sessions, _ = authUseCase.ListSessions(login.User.ID, current)
+	for _, session := range sessions {
+		if session.Current != (session.ID == current) {
+			t.Errorf("Expected only session %s to be current, got %+v", current, session)
+		}
+	}
+}
+
+func TestSessionsDisabled(t *testing.T) {
+	authUseCase := usecases.NewAuthUseCase(newMockUserRepository(), newMockTokenGenerator(), &mockLogger{})
+
+	if _, err := authUseCase.ListSessions("user-1", ""); err == nil || err.Error() != "sessions are not enabled" {
+		t.Errorf("Expected sessions to be disabled, got %v", err)
+	}
+}
@@ -2,9 +2,12 @@ package usecases_test
 
 import (
 	"errors"
+	"fmt"
 	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
 	"gocleanarchitecture/usecases"
 	"testing"
+	"time"
 )
 
 // Mock Logger
@@ -34,6 +37,16 @@ func (m *mockUserRepository) Save(user *entities.User) error {
 	return nil
 }
 
+func (m *mockUserRepository) UpdatePasswordHash(id, oldHash, newHash string) error {
+	if m.saveError != nil {
+		return m.saveError
+	}
+	if user := m.users[id]; user != nil && user.PasswordHash == oldHash {
+		user.PasswordHash = newHash
+	}
+	return nil
+}
+
 func (m *mockUserRepository) FindByID(id string) (*entities.User, error) {
 	if m.findError != nil {
 		return nil, m.findError
@@ -108,6 +121,7 @@ func (m *mockUserRepository) GetAll() ([]*entities.User, error) {
 // Mock TokenGenerator
 type mockTokenGenerator struct {
 	token         string
+	issued        int
 	generateError error
 	validateError error
 }
@@ -118,10 +132,13 @@ func newMockTokenGenerator() *mockTokenGenerator {
 	}
 }
 
-func (m *mockTokenGenerator) GenerateToken(userID, username, email string) (string, error) {
+func (m *mockTokenGenerator) GenerateToken(token *entities.AccessToken) (string, error) {
 	if m.generateError != nil {
 		return "", m.generateError
 	}
+	m.issued++
+	token.ID = fmt.Sprintf("token-%d", m.issued)
+	token.ExpiresAt = time.Now().Add(15 * time.Minute)
 	return m.token, nil
 }
 
@@ -138,7 +155,7 @@ func TestRegister(t *testing.T) {
 	logger := &mockLogger{}
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
-	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -167,13 +184,13 @@ func TestRegisterDuplicateEmail(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register first user
-	_, err := authUseCase.Register("user1", "test@example.com", "password123", "User One")
+	_, err := authUseCase.Register("user1", "test@example.com", "password123", "User One", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error on first registration, got %v", err)
 	}
 
 	// Try to register with same email
-	_, err = authUseCase.Register("user2", "test@example.com", "password456", "User Two")
+	_, err = authUseCase.Register("user2", "test@example.com", "password456", "User Two", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error for duplicate email, got nil")
 	}
@@ -190,13 +207,13 @@ func TestRegisterDuplicateUsername(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register first user
-	_, err := authUseCase.Register("testuser", "user1@example.com", "password123", "User One")
+	_, err := authUseCase.Register("testuser", "user1@example.com", "password123", "User One", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error on first registration, got %v", err)
 	}
 
 	// Try to register with same username
-	_, err = authUseCase.Register("testuser", "user2@example.com", "password456", "User Two")
+	_, err = authUseCase.Register("testuser", "user2@example.com", "password456", "User Two", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error for duplicate username, got nil")
 	}
@@ -213,13 +230,13 @@ func TestLogin(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user first
-	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
 
 	// Test login with email
-	response, err := authUseCase.Login("test@example.com", "password123")
+	response, err := authUseCase.Login("test@example.com", "password123", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during login with email, got %v", err)
 	}
@@ -229,7 +246,7 @@ func TestLogin(t *testing.T) {
 	}
 
 	// Test login with username
-	response, err = authUseCase.Login("testuser", "password123")
+	response, err = authUseCase.Login("testuser", "password123", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during login with username, got %v", err)
 	}
@@ -246,19 +263,19 @@ func TestLoginInvalidCredentials(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
 
 	// Test with wrong password
-	_, err = authUseCase.Login("test@example.com", "wrongpassword")
+	_, err = authUseCase.Login("test@example.com", "wrongpassword", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error for wrong password, got nil")
 	}
 
 	// Test with non-existent user
-	_, err = authUseCase.Login("nonexistent@example.com", "password123")
+	_, err = authUseCase.Login("nonexistent@example.com", "password123", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error for non-existent user, got nil")
 	}
@@ -271,7 +288,7 @@ func TestGetProfile(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
@@ -300,7 +317,7 @@ func TestUpdateProfile(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
@@ -333,7 +350,7 @@ func TestChangePassword(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
@@ -341,19 +358,19 @@ func TestChangePassword(t *testing.T) {
 	userID := response.User.ID
 
 	// Change password
-	err = authUseCase.ChangePassword(userID, "password123", "newpassword456")
+	err = authUseCase.ChangePassword(userID, "password123", "newpassword456", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error changing password, got %v", err)
 	}
 
 	// Verify new password works
-	_, err = authUseCase.Login("test@example.com", "newpassword456")
+	_, err = authUseCase.Login("test@example.com", "newpassword456", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected login to work with new password, got %v", err)
 	}
 
 	// Verify old password doesn't work
-	_, err = authUseCase.Login("test@example.com", "password123")
+	_, err = authUseCase.Login("test@example.com", "password123", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error logging in with old password, got nil")
 	}
@@ -366,7 +383,7 @@ func TestChangePasswordWrongOldPassword(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	response, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
@@ -374,7 +391,7 @@ func TestChangePasswordWrongOldPassword(t *testing.T) {
 	userID := response.User.ID
 
 	// Try to change password with wrong old password
-	err = authUseCase.ChangePassword(userID, "wrongpassword", "newpassword456")
+	err = authUseCase.ChangePassword(userID, "wrongpassword", "newpassword456", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error for wrong old password, got nil")
 	}
@@ -387,7 +404,7 @@ func TestGetUserByUsername(t *testing.T) {
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
 	// Register a user
-	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("Expected no error during registration, got %v", err)
 	}
@@ -414,7 +431,7 @@ func TestRegisterTokenGenerationError(t *testing.T) {
 	logger := &mockLogger{}
 	authUseCase := usecases.NewAuthUseCase(mockRepo, mockTokenGen, logger)
 
-	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User")
+	_, err := authUseCase.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("Expected error when token generation fails, got nil")
 	}
@@ -2,6 +2,7 @@ package usecases_test
 
 import (
 	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
 	"gocleanarchitecture/usecases"
 	"testing"
 )
@@ -42,7 +43,7 @@ func TestCreateBlogPost(t *testing.T) {
 	usecase := usecases.BlogPostUseCase{Repo: repo, Logger: mockLogger}
 
 	authorID := "user-123"
-	blogPost, err := usecase.CreateBlogPost("1", "Test Title", "Test Content", authorID)
+	blogPost, err := usecase.CreateBlogPost("1", "Test Title", "Test Content", authorID, interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -106,13 +107,13 @@ func TestUpdateBlogPost(t *testing.T) {
 
 	authorID := "user-123"
 	// Create initial blog post
-	_, err := usecase.CreateBlogPost("1", "Original Title", "Original Content", authorID)
+	_, err := usecase.CreateBlogPost("1", "Original Title", "Original Content", authorID, interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error creating blog post, got %v", err)
 	}
 
 	// Update the blog post as the author
-	updatedBlogPost, err := usecase.UpdateBlogPost("1", "Updated Title", "Updated Content", authorID)
+	updatedBlogPost, err := usecase.UpdateBlogPost("1", "Updated Title", "Updated Content", authorID, "", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error updating, got %v", err)
 	}
@@ -131,13 +132,13 @@ func TestUpdateBlogPostUnauthorized(t *testing.T) {
 	differentUserID := "user-456"
 
 	// Create blog post as user-123
-	_, err := usecase.CreateBlogPost("1", "Original Title", "Original Content", authorID)
+	_, err := usecase.CreateBlogPost("1", "Original Title", "Original Content", authorID, interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error creating blog post, got %v", err)
 	}
 
 	// Try to update as different user (should fail)
-	_, err = usecase.UpdateBlogPost("1", "Updated Title", "Updated Content", differentUserID)
+	_, err = usecase.UpdateBlogPost("1", "Updated Title", "Updated Content", differentUserID, "", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("expected error when non-author tries to update, got nil")
 	}
@@ -156,7 +157,7 @@ func TestDeleteBlogPost(t *testing.T) {
 	blogPost := &entities.BlogPost{ID: "1", Title: "Test Title", Content: "Test Content", AuthorID: authorID}
 	repo.Save(blogPost)
 
-	err := usecase.DeleteBlogPost("1", authorID)
+	err := usecase.DeleteBlogPost("1", authorID, "", interfaces.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -178,7 +179,7 @@ func TestDeleteBlogPostUnauthorized(t *testing.T) {
 	repo.Save(blogPost)
 
 	// Try to delete as different user (should fail)
-	err := usecase.DeleteBlogPost("1", differentUserID)
+	err := usecase.DeleteBlogPost("1", differentUserID, "", interfaces.ClientInfo{})
 	if err == nil {
 		t.Fatal("expected error when non-author tries to delete, got nil")
 	}
@@ -193,3 +194,35 @@ func TestDeleteBlogPostUnauthorized(t *testing.T) {
 		t.Fatal("expected blog post to still exist after unauthorized delete attempt")
 	}
 }
+
+type recordingPublisher struct {
+	events []entities.DomainEvent
+}
+
+func (p *recordingPublisher) Publish(events ...entities.DomainEvent) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestCreateBlogPostPublishesEvent(t *testing.T) {
+	repo := &MockBlogPostRepository{blogPosts: make(map[string]*entities.BlogPost)}
+	publisher := &recordingPublisher{}
+	usecase := usecases.NewBlogPostUseCase(repo, &MockLogger{}, publisher, nil, nil, nil)
+
+	blogPost, err := usecase.CreateBlogPost("1", "Test Title", "Test Content", "user-123", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(publisher.events))
+	}
+
+	event, ok := publisher.events[0].(*entities.PostPublished)
+	if !ok {
+		t.Fatalf("expected PostPublished event, got %T", publisher.events[0])
+	}
+	if event.Post.ID != blogPost.ID {
+		t.Errorf("expected event for post %s, got %s", blogPost.ID, event.Post.ID)
+	}
+}
@@ -0,0 +1,124 @@
+package usecases_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+	"time"
+)
+
+type lockoutFixture struct {
+	auth          interfaces.AuthUseCase
+	lockout       *usecases.LoginLockout
+	notifications *usecases.NotificationUseCase
+	users         interfaces.UserRepository
+	userID        string
+}
+
+func newLockoutFixture(t *testing.T) *lockoutFixture {
+	t.Helper()
+	f := &lockoutFixture{
+		users:         newMockUserRepository(),
+		notifications: usecases.NewNotificationUseCase(db.NewInMemoryNotificationRepository(), &mockLogger{}),
+	}
+	account := usecases.DefaultAccountLockoutPolicy()
+	account.MaxFailures = 3
+	ip := usecases.DefaultIPLockoutPolicy()
+	ip.MaxFailures = 10
+	f.lockout = usecases.NewLoginLockout(db.NewInMemoryLoginAttemptRepository(), account, ip, f.notifications, &mockLogger{})
+	f.auth = usecases.NewAuthUseCaseWithConfig(f.users, newMockTokenGenerator(), &mockLogger{}, usecases.AuthConfig{
+		Lockout: f.lockout,
+	})
+
+	registered, err := f.auth.Register("testuser", "test@example.com", "password123", "Test User", interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	f.userID = registered.User.ID
+	return f
+}
+
+func loginLockedFor(err error) time.Duration {
+	var locked *entities.LoginLockedError
+	if errors.As(err, &locked) {
+		return locked.RetryAfter
+	}
+	return 0
+}
+
+func TestLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	f := newLockoutFixture(t)
+	client := interfaces.ClientInfo{IP: "203.0.113.7"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.auth.Login("testuser", "wrongpassword", client); err == nil || err.Error() != "invalid credentials" {
+			t.Fatalf("Attempt %d: expected invalid credentials, got %v", i+1, err)
+		}
+	}
+
+	// The right password is refused while the account is locked, from any IP
+	_, err := f.auth.Login("test@example.com", "password123", interfaces.ClientInfo{IP: "198.51.100.1"})
+	if retry := loginLockedFor(err); retry <= 0 || retry > time.Minute {
+		t.Fatalf("Expected a lock of up to a minute, got %v (%v)", retry, err)
+	}
+
+	notifications, _ := f.notifications.ListNotifications(f.userID, false)
+	if len(notifications) != 1 || notifications[0].Type != entities.NotificationAccountLocked {
+		t.Errorf("Expected one account locked notification, got %+v", notifications)
+	}
+
+	admin := usecases.NewAdminUseCase(f.users, &mockLogger{}, nil, nil, nil, nil, nil, f.lockout)
+	if err := admin.UnlockUser(interfaces.Actor{UserID: "admin"}, f.userID); err != nil {
+		t.Fatalf("Failed to unlock user: %v", err)
+	}
+	if _, err := f.auth.Login("testuser", "password123", client); err != nil {
+		t.Errorf("Expected login after unlock, got %v", err)
+	}
+}
+
+func TestSuccessfulLoginResetsAccountFailures(t *testing.T) {
+	f := newLockoutFixture(t)
+
+	for i := 0; i < 2; i++ {
+		f.auth.Login("testuser", "wrongpassword", interfaces.ClientInfo{})
+	}
+	if _, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		f.auth.Login("testuser", "wrongpassword", interfaces.ClientInfo{})
+	}
+	if _, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected failures to be reset by the earlier login, got %v", err)
+	}
+}
+
+func TestUnknownAccountsLockLikeKnownOnes(t *testing.T) {
+	f := newLockoutFixture(t)
+
+	for i := 0; i < 3; i++ {
+		f.auth.Login("nobody", "password123", interfaces.ClientInfo{})
+	}
+	if _, err := f.auth.Login("Nobody", "password123", interfaces.ClientInfo{}); loginLockedFor(err) <= 0 {
+		t.Errorf("Expected unknown identifier to be locked, got %v", err)
+	}
+}
+
+func TestLoginLocksClientIP(t *testing.T) {
+	f := newLockoutFixture(t)
+	client := interfaces.ClientInfo{IP: "203.0.113.7"}
+
+	// Spread across identifiers so no single account locks
+	for i := 0; i < 10; i++ {
+		f.auth.Login("user"+string(rune('a'+i)), "password123", client)
+	}
+	if _, err := f.auth.Login("testuser", "password123", client); loginLockedFor(err) <= 0 {
+		t.Errorf("Expected the IP to be locked, got %v", err)
+	}
+	if _, err := f.auth.Login("testuser", "password123", interfaces.ClientInfo{IP: "198.51.100.1"}); err != nil {
+		t.Errorf("Expected other IPs to log in, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package usecases_test
+
+import (
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"strings"
+	"testing"
+)
+
+func TestStaffOverrideIsLoggedAndAuthorNotified(t *testing.T) {
+	f := newPolicyFixture(t)
+	publisher := &recordingPublisher{}
+	posts := usecases.NewBlogPostUseCase(f.posts, &mockLogger{}, publisher, nil, f.policy, nil)
+	moderation := usecases.NewModerationUseCase(db.NewInMemoryModerationRepository(), &mockLogger{})
+	notifications := usecases.NewNotificationUseCase(db.NewInMemoryNotificationRepository(), &mockLogger{})
+
+	if err := posts.DeleteBlogPost("post-1", "moderator", "  Spam links  ", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+
+	// Deliver twice, as the outbox may
+	for i := 0; i < 2; i++ {
+		for _, event := range publisher.events {
+			if err := moderation.HandleEvent(event); err != nil {
+				t.Fatalf("Moderation log failed: %v", err)
+			}
+			if err := notifications.HandleEvent(event); err != nil {
+				t.Fatalf("Notification failed: %v", err)
+			}
+		}
+	}
+
+	actions, _ := moderation.ListActions(interfaces.ModerationFilter{TargetType: entities.ModerationTargetPost, TargetID: "post-1"})
+	if len(actions) != 1 {
+		t.Fatalf("Expected 1 moderation action, got %d", len(actions))
+	}
+	action := actions[0]
+	if action.ActorID != "moderator" || action.AuthorID != "author" || action.Action != entities.ModerationActionDelete || action.Reason != "Spam links" {
+		t.Errorf("Unexpected moderation action: %+v", action)
+	}
+
+	notes, _ := notifications.ListNotifications("author", true)
+	if len(notes) != 1 {
+		t.Fatalf("Expected 1 notification for the author, got %d", len(notes))
+	}
+	if !strings.Contains(notes[0].Message, `"Title" was removed`) || !strings.Contains(notes[0].Message, "Spam links") {
+		t.Errorf("Unexpected notification message: %q", notes[0].Message)
+	}
+
+	if err := notifications.MarkRead("moderator", notes[0].ID); err == nil || err.Error() != "notification not found" {
+		t.Errorf("Expected other users' notifications to be hidden, got %v", err)
+	}
+	if err := notifications.MarkRead("author", notes[0].ID); err != nil {
+		t.Fatalf("Failed to mark notification read: %v", err)
+	}
+	if unread, _ := notifications.ListNotifications("author", true); len(unread) != 0 {
+		t.Errorf("Expected no unread notifications, got %d", len(unread))
+	}
+}
+
+func TestAuthorChangesAreNotModerated(t *testing.T) {
+	f := newPolicyFixture(t)
+	publisher := &recordingPublisher{}
+	posts := usecases.NewBlogPostUseCase(f.posts, &mockLogger{}, publisher, nil, f.policy, nil)
+
+	if _, err := posts.UpdateBlogPost("post-1", "New title", "Content", "author", "", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Failed to update own post: %v", err)
+	}
+	for _, event := range publisher.events {
+		if _, ok := event.(*entities.ContentModerated); ok {
+			t.Error("Expected no moderation event for the author's own change")
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package usecases_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/auth"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pkceVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type oauthServerFixture struct {
+	server      *usecases.OAuthServerUseCase
+	revocations interfaces.TokenRevocationRepository
+	userID      string
+	client      *entities.OAuthClient
+	secret      string
+}
+
+func newOAuthServerFixture(t *testing.T) *oauthServerFixture {
+	t.Helper()
+	users := newMockUserRepository()
+	user, _ := entities.NewUser("alice", "alice@example.com", "password123", "Alice", entities.DefaultPasswordHasher())
+	user.ID = "user-1"
+	users.Save(user)
+
+	tokens := auth.NewTokenGeneratorAdapter(auth.NewJWTManager("test-secret", 15*time.Minute))
+	revocations := db.NewInMemoryTokenRevocationRepository()
+	server := usecases.NewOAuthServerUseCase(db.NewInMemoryOAuthClientRepository(), db.NewInMemoryOAuthCodeRepository(),
+		users, tokens, tokens, revocations, nil, &mockLogger{})
+
+	client, secret, err := server.RegisterClient(interfaces.Actor{UserID: "admin-1"}, "Partner",
+		[]string{"https://partner.example.com/callback"},
+		[]entities.Scope{entities.ScopeProfileRead, entities.ScopePostsWrite}, true)
+	if err != nil {
+		t.Fatalf("Failed to register client: %v", err)
+	}
+	return &oauthServerFixture{server: server, revocations: revocations, userID: user.ID, client: client, secret: secret}
+}
+
+func (f *oauthServerFixture) request(scope string) interfaces.AuthorizationRequest {
+	return interfaces.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            f.client.ID,
+		RedirectURI:         "https://partner.example.com/callback",
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       pkceChallenge(pkceVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// authorize approves a request and returns the code from the redirect
+func (f *oauthServerFixture) authorize(t *testing.T, scope string) string {
+	t.Helper()
+	redirect, err := f.server.Consent(f.userID, f.request(scope), true, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to consent: %v", err)
+	}
+	location, _ := url.Parse(redirect)
+	if location.Host != "partner.example.com" || location.Query().Get("state") != "xyz" || location.Query().Get("code") == "" {
+		t.Fatalf("Unexpected redirect: %s", redirect)
+	}
+	return location.Query().Get("code")
+}
+
+func (f *oauthServerFixture) exchange(code, verifier string) (*interfaces.TokenResponse, error) {
+	return f.server.Token(interfaces.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  "https://partner.example.com/callback",
+		ClientID:     f.client.ID,
+		ClientSecret: f.secret,
+		CodeVerifier: verifier,
+	})
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	f := newOAuthServerFixture(t)
+	if f.secret == "" || f.client.SecretHash == f.secret {
+		t.Fatalf("Expected a hashed secret for a confidential client")
+	}
+
+	prompt, err := f.server.Authorize(f.request("profile:read"))
+	if err != nil || prompt.ClientName != "Partner" || len(prompt.Scopes) != 1 {
+		t.Fatalf("Unexpected consent prompt: %+v, %v", prompt, err)
+	}
+
+	code := f.authorize(t, "profile:read")
+	token, err := f.exchange(code, pkceVerifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if token.TokenType != "Bearer" || token.Scope != "profile:read" || token.ExpiresIn <= 0 {
+		t.Errorf("Unexpected token response: %+v", token)
+	}
+
+	introspection, err := f.server.Introspect(f.client.ID, f.secret, token.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to introspect: %v", err)
+	}
+	if !introspection.Active || introspection.Subject != f.userID || introspection.ClientID != f.client.ID || introspection.Scope != "profile:read" {
+		t.Errorf("Unexpected introspection: %+v", introspection)
+	}
+
+	// Signing out everywhere deactivates third-party tokens too
+	f.revocations.BumpUserGeneration(f.userID)
+	if introspection, _ := f.server.Introspect(f.client.ID, f.secret, token.AccessToken); introspection.Active {
+		t.Error("Expected a token from an older generation to be inactive")
+	}
+}
+
+func TestOAuthCodeReplayRevokesItsToken(t *testing.T) {
+	f := newOAuthServerFixture(t)
+	code := f.authorize(t, "profile:read")
+	token, err := f.exchange(code, pkceVerifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+
+	// Codes work once, and a second try means the code leaked
+	if _, err := f.exchange(code, pkceVerifier); err == nil || err.Error() != "invalid or expired authorization code" {
+		t.Errorf("Expected a used code to be rejected, got %v", err)
+	}
+	if introspection, _ := f.server.Introspect(f.client.ID, f.secret, token.AccessToken); introspection.Active {
+		t.Error("Expected the token issued for a replayed code to be revoked")
+	}
+
+	// Other tokens of the client are unaffected
+	other, err := f.exchange(f.authorize(t, "profile:read"), pkceVerifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if introspection, _ := f.server.Introspect(f.client.ID, f.secret, other.AccessToken); !introspection.Active {
+		t.Error("Expected a token from another code to stay active")
+	}
+}
+
+func TestOAuthAuthorizeRejectsBadRequests(t *testing.T) {
+	f := newOAuthServerFixture(t)
+
+	tests := []struct {
+		name   string
+		tamper func(*interfaces.AuthorizationRequest)
+		err    string
+	}{
+		{"unknown client", func(r *interfaces.AuthorizationRequest) { r.ClientID = "someone" }, "unknown client"},
+		{"unregistered redirect", func(r *interfaces.AuthorizationRequest) { r.RedirectURI += "/../evil" }, "redirect URI is not registered for this client"},
+		{"implicit grant", func(r *interfaces.AuthorizationRequest) { r.ResponseType = "token" }, "response type must be code"},
+		{"plain PKCE", func(r *interfaces.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, "a PKCE code challenge using S256 is required"},
+		{"no PKCE", func(r *interfaces.AuthorizationRequest) { r.CodeChallenge = "" }, "a PKCE code challenge using S256 is required"},
+		{"scope not allowed", func(r *interfaces.AuthorizationRequest) { r.Scope = "profile:write" }, "scope is not allowed for this client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := f.request("")
+			tt.tamper(&request)
+			if _, err := f.server.Authorize(request); err == nil || err.Error() != tt.err {
+				t.Errorf("Expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	// Without a scope the client gets all it is allowed
+	prompt, err := f.server.Authorize(f.request(""))
+	if err != nil || len(prompt.Scopes) != 2 {
+		t.Errorf("Expected the client's scopes, got %+v, %v", prompt, err)
+	}
+
+	redirect, err := f.server.Consent(f.userID, f.request(""), false, interfaces.ClientInfo{})
+	if err != nil || !strings.Contains(redirect, "error=access_denied") {
+		t.Errorf("Expected a denial to redirect with access_denied, got %q, %v", redirect, err)
+	}
+}
+
+func TestOAuthTokenRequiresVerifierAndSecret(t *testing.T) {
+	f := newOAuthServerFixture(t)
+
+	// A wrong verifier uses up the code
+	code := f.authorize(t, "")
+	if _, err := f.exchange(code, strings.Repeat("a", 43)); err == nil {
+		t.Error("Expected a wrong verifier to be rejected")
+	}
+	if _, err := f.exchange(code, pkceVerifier); err == nil {
+		t.Error("Expected the code to be used up")
+	}
+
+	code = f.authorize(t, "")
+	f.secret = "wrong"
+	if _, err := f.exchange(code, pkceVerifier); err == nil || err.Error() != "client authentication failed" {
+		t.Errorf("Expected a wrong secret to be rejected, got %v", err)
+	}
+
+	// Public clients rely on PKCE alone, and can't introspect
+	public, _, err := f.server.RegisterClient(interfaces.Actor{UserID: "admin-1"}, "Mobile app",
+		[]string{"com.example.app:/callback"}, []entities.Scope{entities.ScopeNotificationsRead}, false)
+	if err != nil {
+		t.Fatalf("Failed to register public client: %v", err)
+	}
+	f.client, f.secret = public, ""
+	request := f.request("")
+	request.RedirectURI = "com.example.app:/callback"
+	redirect, err := f.server.Consent(f.userID, request, true, interfaces.ClientInfo{})
+	if err != nil {
+		t.Fatalf("Failed to consent: %v", err)
+	}
+	location, _ := url.Parse(redirect)
+	token, err := f.server.Token(interfaces.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         location.Query().Get("code"),
+		RedirectURI:  "com.example.app:/callback",
+		ClientID:     public.ID,
+		CodeVerifier: pkceVerifier,
+	})
+	if err != nil || token.Scope != "notifications:read" {
+		t.Fatalf("Expected a token for the public client, got %+v, %v", token, err)
+	}
+	if _, err := f.server.Introspect(public.ID, "", token.AccessToken); err == nil {
+		t.Error("Expected a public client to be refused introspection")
+	}
+}
+
+func TestOAuthClientRegistration(t *testing.T) {
+	f := newOAuthServerFixture(t)
+
+	invalid := [][]string{
+		{"http://partner.example.com/callback"}, // plain HTTP
+		{"https://partner.example.com/callback#fragment"},
+		{"/callback"},
+		nil,
+	}
+	for _, uris := range invalid {
+		if _, _, err := f.server.RegisterClient(interfaces.Actor{}, "Bad", uris, []entities.Scope{entities.ScopeProfileRead}, true); err == nil {
+			t.Errorf("Expected redirect URIs %v to be refused", uris)
+		}
+	}
+	if _, _, err := f.server.RegisterClient(interfaces.Actor{}, "Local", []string{"http://localhost:8080/callback"}, []entities.Scope{entities.ScopeProfileRead}, false); err != nil {
+		t.Errorf("Expected a loopback redirect URI to be allowed, got %v", err)
+	}
+	// Third-party apps act on the user's own content only
+	if _, _, err := f.server.RegisterClient(interfaces.Actor{}, "Moderator", []string{"https://partner.example.com/callback"}, []entities.Scope{entities.ScopePostsModerate}, true); err == nil {
+		t.Error("Expected a moderation scope to be refused")
+	}
+
+	token, err := f.exchange(f.authorize(t, ""), pkceVerifier)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if err := f.server.DeleteClient(interfaces.Actor{UserID: "admin-1"}, f.client.ID); err != nil {
+		t.Fatalf("Failed to delete client: %v", err)
+	}
+	if _, err := f.server.Authorize(f.request("")); err == nil {
+		t.Error("Expected a deleted client's requests to be refused")
+	}
+	if err := f.server.DeleteClient(interfaces.Actor{}, f.client.ID); err == nil || err.Error() != "client not found" {
+		t.Errorf("Expected the client to be gone, got %v", err)
+	}
+
+	// Another confidential client sees the deleted client's token as inactive
+	other, secret, _ := f.server.RegisterClient(interfaces.Actor{}, "Resource server", []string{"https://api.example.com/cb"}, []entities.Scope{entities.ScopeProfileRead}, true)
+	if introspection, err := f.server.Introspect(other.ID, secret, token.AccessToken); err != nil || introspection.Active {
+		t.Errorf("Expected the token to be inactive, got %+v, %v", introspection, err)
+	}
+}
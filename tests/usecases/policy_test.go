@@ -0,0 +1,221 @@
+package usecases_test
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/frameworks/db"
+	"gocleanarchitecture/interfaces"
+	"gocleanarchitecture/usecases"
+	"testing"
+)
+
+type policyFixture struct {
+	users    interfaces.UserRepository
+	roles    interfaces.RoleRepository
+	posts    interfaces.BlogPostRepository
+	comments interfaces.CommentRepository
+	policy   *usecases.Policy
+}
+
+func newPolicyFixture(t *testing.T) *policyFixture {
+	t.Helper()
+	f := &policyFixture{
+		users:    db.NewInMemoryUserRepository(),
+		roles:    db.NewInMemoryRoleRepository(),
+		posts:    db.NewInMemoryBlogPostRepository(),
+		comments: db.NewInMemoryCommentRepository(),
+	}
+	f.policy = usecases.NewPolicy(f.users, f.roles, nil, &mockLogger{})
+
+	for _, role := range []entities.UserRole{entities.RoleUser, entities.RoleEditor, entities.RoleModerator} {
+		f.addUser(t, string(role), role)
+	}
+	f.addUser(t, "author", entities.RoleUser)
+
+	post, _ := entities.NewBlogPost("post-1", "Title", "Content", "author")
+	f.posts.Save(post)
+	comment, _ := entities.NewComment("comment-1", "post-1", "author", "Nice post", "")
+	f.comments.Save(comment)
+	return f
+}
+
+func (f *policyFixture) addUser(t *testing.T, id string, role entities.UserRole) {
+	t.Helper()
+	user, err := entities.NewUser(id+"_name", id+"@example.com", "password123", id, entities.DefaultPasswordHasher())
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	user.ID = id
+	user.Role = role
+	f.users.Save(user)
+}
+
+func TestPolicyBuiltInRoles(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	cases := []struct {
+		role       entities.UserRole
+		permission entities.Permission
+		want       bool
+	}{
+		{entities.RoleUser, entities.PermissionPostPublish, true},
+		{entities.RoleUser, entities.PermissionPostEditAny, false},
+		{entities.RoleEditor, entities.PermissionPostEditAny, true},
+		{entities.RoleEditor, entities.PermissionCommentModerate, false},
+		{entities.RoleModerator, entities.PermissionCommentModerate, true},
+		{entities.RoleModerator, entities.PermissionUserManage, false},
+		{entities.RoleAdmin, entities.PermissionUserManage, true},
+		{"unknown", entities.PermissionPostPublish, false},
+	}
+	for _, c := range cases {
+		got, err := f.policy.RoleHasPermission(c.role, c.permission)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("%s %s: expected %v, got %v", c.role, c.permission, c.want, got)
+		}
+	}
+}
+
+func TestPolicyNilGrantsUserRole(t *testing.T) {
+	var policy *usecases.Policy
+
+	if ok, _ := policy.UserHasPermission("anyone", entities.PermissionPostPublish); !ok {
+		t.Error("Expected nil policy to allow publishing")
+	}
+	if ok, _ := policy.CanModify("someone", "owner", entities.PermissionPostEditAny, interfaces.ClientInfo{}); ok {
+		t.Error("Expected nil policy to deny editing others' posts")
+	}
+	if ok, _ := policy.CanModify("owner", "owner", entities.PermissionPostEditAny, interfaces.ClientInfo{}); !ok {
+		t.Error("Expected nil policy to allow owners")
+	}
+}
+
+func TestStaffCanUpdateAndDeleteAnyPost(t *testing.T) {
+	f := newPolicyFixture(t)
+	uc := usecases.NewBlogPostUseCase(f.posts, &mockLogger{}, nil, nil, f.policy, nil)
+
+	if _, err := uc.UpdateBlogPost("post-1", "Title", "Content", "user", "spam", interfaces.ClientInfo{}); err == nil || err.Error() != "unauthorized: you can only update your own blog posts" {
+		t.Errorf("Expected plain user to be refused, got %v", err)
+	}
+	if _, err := uc.UpdateBlogPost("post-1", "Edited", "Content", "editor", "", interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected editor to need a reason")
+	}
+	if _, err := uc.UpdateBlogPost("post-1", "Edited", "Content", "editor", "Fixed a broken link", interfaces.ClientInfo{}); err != nil {
+		t.Fatalf("Expected editor to update the post, got %v", err)
+	}
+	if err := uc.DeleteBlogPost("post-1", "user", "spam", interfaces.ClientInfo{}); err == nil {
+		t.Error("Expected plain user to be refused")
+	}
+	if err := uc.DeleteBlogPost("post-1", "moderator", "Spam", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected moderator to delete the post, got %v", err)
+	}
+}
+
+func TestCreateBlogPostRequiresPublishPermission(t *testing.T) {
+	f := newPolicyFixture(t)
+	readOnly, _ := entities.NewRole("reader", "", nil)
+	f.roles.Save(readOnly)
+	f.addUser(t, "reader", "reader")
+	uc := usecases.NewBlogPostUseCase(f.posts, &mockLogger{}, nil, nil, f.policy, nil)
+
+	if _, err := uc.CreateBlogPost("post-2", "Title", "Content", "reader", interfaces.ClientInfo{}); err == nil || err.Error() != "unauthorized: you are not allowed to publish blog posts" {
+		t.Errorf("Expected reader to be refused, got %v", err)
+	}
+	if _, err := uc.CreateBlogPost("post-2", "Title", "Content", "user", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected user to publish, got %v", err)
+	}
+}
+
+func TestModeratorCanDeleteAnyComment(t *testing.T) {
+	f := newPolicyFixture(t)
+	uc := usecases.NewCommentUseCase(f.comments, f.posts, f.users, &mockLogger{}, nil, nil, f.policy, nil)
+
+	if err := uc.DeleteComment("comment-1", "editor", "Off topic", interfaces.ClientInfo{}); err == nil || err.Error() != "unauthorized: only the author or a moderator can delete this comment" {
+		t.Errorf("Expected editor to be refused, got %v", err)
+	}
+	if err := uc.DeleteComment("comment-1", "moderator", "Off topic", interfaces.ClientInfo{}); err != nil {
+		t.Errorf("Expected moderator to delete the comment, got %v", err)
+	}
+}
+
+func TestAdminManagesCustomRoles(t *testing.T) {
+	f := newPolicyFixture(t)
+	admin := usecases.NewAdminUseCase(f.users, &mockLogger{}, nil, nil, nil, f.policy, nil, nil)
+
+	if _, err := admin.CreateRole(interfaces.Actor{}, "editor", "", nil); err == nil {
+		t.Error("Expected redefining a built-in role to fail")
+	}
+	if _, err := admin.CreateRole(interfaces.Actor{}, "curator", "Curates posts", []entities.Permission{"post:feature"}); err == nil {
+		t.Error("Expected unknown permission to be rejected")
+	}
+	if _, err := admin.CreateRole(interfaces.Actor{}, "curator", "Curates posts", []entities.Permission{entities.PermissionPostEditAny}); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+
+	if err := admin.UpdateUserRole(interfaces.Actor{}, "user", "missing"); err == nil || err.Error() != "role not found" {
+		t.Errorf("Expected unknown role to be rejected, got %v", err)
+	}
+	if err := admin.UpdateUserRole(interfaces.Actor{}, "user", "curator"); err != nil {
+		t.Fatalf("Failed to assign custom role: %v", err)
+	}
+	if ok, _ := f.policy.UserHasPermission("user", entities.PermissionPostEditAny); !ok {
+		t.Error("Expected custom role to grant its permission")
+	}
+
+	// Permission changes apply to users already holding the role
+	if _, err := admin.UpdateRole(interfaces.Actor{}, "curator", "", nil); err != nil {
+		t.Fatalf("Failed to update role: %v", err)
+	}
+	if ok, _ := f.policy.UserHasPermission("user", entities.PermissionPostEditAny); ok {
+		t.Error("Expected removed permission to be denied")
+	}
+
+	if err := admin.DeleteRole(interfaces.Actor{}, "curator"); err == nil || err.Error() != "role is assigned to users" {
+		t.Errorf("Expected assigned role to be kept, got %v", err)
+	}
+	admin.UpdateUserRole(interfaces.Actor{}, "user", entities.RoleUser)
+	if err := admin.DeleteRole(interfaces.Actor{}, "curator"); err != nil {
+		t.Errorf("Failed to delete role: %v", err)
+	}
+
+	roles, _ := admin.ListRoles()
+	if len(roles) != len(entities.BuiltInRoles()) {
+		t.Errorf("Expected only built-in roles to remain, got %d", len(roles))
+	}
+}
+
+func TestAPITokenNeedsModerationScope(t *testing.T) {
+	f := newPolicyFixture(t)
+	uc := usecases.NewCommentUseCase(f.comments, f.posts, f.users, &mockLogger{}, nil, nil, f.policy, nil)
+
+	writeOnly := interfaces.ClientInfo{APITokenID: "token-1", Scopes: []entities.Scope{entities.ScopeCommentsWrite}}
+	if err := uc.DeleteComment("comment-1", "moderator", "Off topic", writeOnly); err == nil || err.Error() != "unauthorized: only the author or a moderator can delete this comment" {
+		t.Errorf("Expected a moderator's token without comments:moderate to be refused, got %v", err)
+	}
+
+	moderate := interfaces.ClientInfo{APITokenID: "token-2", Scopes: []entities.Scope{entities.ScopeCommentsModerate, entities.ScopeCommentsWrite}}
+	if err := uc.DeleteComment("comment-1", "editor", "Off topic", moderate); err == nil {
+		t.Error("Expected the scope not to grant a permission the role lacks")
+	}
+	if err := uc.DeleteComment("comment-1", "moderator", "Off topic", moderate); err != nil {
+		t.Errorf("Expected a moderator's token with comments:moderate to delete the comment, got %v", err)
+	}
+}
+
+// failingRevoker can't revoke anything
+type failingRevoker struct{}
+
+func (failingRevoker) RevokeUserTokens(userID string) error {
+	return errors.New("database is locked")
+}
+
+func TestDeleteUserReportsRevocationFailure(t *testing.T) {
+	f := newPolicyFixture(t)
+	admin := usecases.NewAdminUseCase(f.users, &mockLogger{}, nil, nil, failingRevoker{}, f.policy, nil, nil)
+
+	if err := admin.DeleteUser(interfaces.Actor{}, "user"); err == nil || err.Error() != "user deleted but existing sessions could not be revoked" {
+		t.Errorf("Expected the revocation failure to be reported, got %v", err)
+	}
+}
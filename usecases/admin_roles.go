@@ -0,0 +1,199 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+// ListRoles returns the built-in roles followed by the custom roles
+func (uc *AdminUseCase) ListRoles() ([]*entities.Role, error) {
+	roles := entities.BuiltInRoles()
+	if uc.Policy == nil || uc.Policy.Roles == nil {
+		return roles, nil
+	}
+
+	custom, err := uc.Policy.Roles.FindAll()
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch roles", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, errors.New("failed to fetch roles")
+	}
+	return append(roles, custom...), nil
+}
+
+// CreateRole defines a custom role
+func (uc *AdminUseCase) CreateRole(actor interfaces.Actor, name, description string, permissions []entities.Permission) (*entities.Role, error) {
+	if uc.Policy == nil || uc.Policy.Roles == nil {
+		return nil, errors.New("custom roles are not enabled")
+	}
+
+	role, err := entities.NewRole(name, description, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.Policy.Roles.FindByName(role.Name)
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch role", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return nil, errors.New("failed to create role")
+	}
+	if existing != nil {
+		return nil, errors.New("role already exists")
+	}
+
+	if err := uc.Policy.Roles.Save(role); err != nil {
+		uc.Logger.Error("Admin: Failed to save role", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return nil, errors.New("failed to create role")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditRoleCreate, actor.UserID, entities.AuditTargetRole, string(role.Name)),
+		actor.Client, nil, snapshotRole(role))
+	return role, nil
+}
+
+// UpdateRole replaces the description and permissions of a custom role.
+// Permissions are resolved on every request, so the change applies to users
+// holding the role right away.
+func (uc *AdminUseCase) UpdateRole(actor interfaces.Actor, name entities.UserRole, description string, permissions []entities.Permission) (*entities.Role, error) {
+	role, err := uc.findCustomRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshotRole(role)
+	if err := role.Update(description, permissions); err != nil {
+		return nil, err
+	}
+
+	if err := uc.Policy.Roles.Save(role); err != nil {
+		uc.Logger.Error("Admin: Failed to save role", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return nil, errors.New("failed to update role")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditRoleUpdate, actor.UserID, entities.AuditTargetRole, string(name)),
+		actor.Client, before, snapshotRole(role))
+	return role, nil
+}
+
+// DeleteRole removes a custom role that is no longer assigned to any user
+func (uc *AdminUseCase) DeleteRole(actor interfaces.Actor, name entities.UserRole) error {
+	role, err := uc.findCustomRole(name)
+	if err != nil {
+		return err
+	}
+
+	users, err := uc.UserRepo.GetAll()
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch all users", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return errors.New("failed to delete role")
+	}
+	for _, user := range users {
+		if user.Role == name {
+			return errors.New("role is assigned to users")
+		}
+	}
+
+	if err := uc.Policy.Roles.Delete(name); err != nil {
+		uc.Logger.Error("Admin: Failed to delete role", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return errors.New("failed to delete role")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditRoleDelete, actor.UserID, entities.AuditTargetRole, string(name)),
+		actor.Client, snapshotRole(role), nil)
+	return nil
+}
+
+// findCustomRole fetches a stored role, rejecting built-in role names
+func (uc *AdminUseCase) findCustomRole(name entities.UserRole) (*entities.Role, error) {
+	if entities.BuiltInRole(name) != nil {
+		return nil, errors.New("built-in roles cannot be changed")
+	}
+	if uc.Policy == nil || uc.Policy.Roles == nil {
+		return nil, errors.New("custom roles are not enabled")
+	}
+
+	role, err := uc.Policy.Roles.FindByName(name)
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch role", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return nil, errors.New("failed to fetch role")
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+// ListMFARequiredRoles returns the roles whose users must set up a second
+// factor before they get the role's permissions
+func (uc *AdminUseCase) ListMFARequiredRoles() ([]entities.UserRole, error) {
+	if uc.Policy == nil || uc.Policy.MFA == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	roles, err := uc.Policy.MFA.FindRequiredRoles()
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch roles requiring two-factor authentication", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, errors.New("failed to fetch roles")
+	}
+	if roles == nil {
+		roles = []entities.UserRole{}
+	}
+	return roles, nil
+}
+
+// SetRoleMFARequired sets whether users with a role must have a second
+// factor. Until they set one up they only get the user role's permissions;
+// access tokens issued before the change keep their role until they expire.
+func (uc *AdminUseCase) SetRoleMFARequired(actor interfaces.Actor, name entities.UserRole, required bool) error {
+	if uc.Policy == nil || uc.Policy.MFA == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	role, err := uc.Policy.FindRole(name)
+	if err != nil {
+		return errors.New("failed to fetch role")
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
+	wasRequired, err := roleRequiresMFA(uc.Policy.MFA, name)
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to fetch roles requiring two-factor authentication", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return errors.New("failed to update role")
+	}
+	if err := uc.Policy.MFA.SetRoleRequired(name, required); err != nil {
+		uc.Logger.Error("Admin: Failed to update two-factor requirement", map[string]interface{}{
+			"error": err.Error(),
+			"role":  name,
+		})
+		return errors.New("failed to update role")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditRoleMFARequire, actor.UserID, entities.AuditTargetRole, string(name)),
+		actor.Client, map[string]bool{"mfa_required": wasRequired}, map[string]bool{"mfa_required": required})
+	return nil
+}
@@ -7,14 +7,26 @@ import (
 )
 
 type AdminUseCase struct {
-	UserRepo interfaces.UserRepository
-	Logger   Logger
+	UserRepo     interfaces.UserRepository
+	Logger       Logger
+	Events       EventPublisher
+	Transactor   Transactor
+	TokenRevoker UserTokenRevoker
+	Policy       *Policy
+	Audit        *AuditLog
+	Lockout      *LoginLockout
 }
 
-func NewAdminUseCase(userRepo interfaces.UserRepository, logger Logger) *AdminUseCase {
+func NewAdminUseCase(userRepo interfaces.UserRepository, logger Logger, events EventPublisher, transactor Transactor, tokenRevoker UserTokenRevoker, policy *Policy, audit *AuditLog, lockout *LoginLockout) *AdminUseCase {
 	return &AdminUseCase{
-		UserRepo: userRepo,
-		Logger:   logger,
+		UserRepo:     userRepo,
+		Logger:       logger,
+		Events:       events,
+		Transactor:   transactor,
+		TokenRevoker: tokenRevoker,
+		Policy:       policy,
+		Audit:        audit,
+		Lockout:      lockout,
 	}
 }
 
@@ -54,7 +66,7 @@ func (uc *AdminUseCase) GetUserByID(userID string) (*entities.User, error) {
 }
 
 // UpdateUserRole updates a user's role
-func (uc *AdminUseCase) UpdateUserRole(userID string, newRole entities.UserRole) error {
+func (uc *AdminUseCase) UpdateUserRole(actor interfaces.Actor, userID string, newRole entities.UserRole) error {
 	if userID == "" {
 		return errors.New("user ID is required")
 	}
@@ -73,13 +85,33 @@ func (uc *AdminUseCase) UpdateUserRole(userID string, newRole entities.UserRole)
 		return errors.New("user not found")
 	}
 
+	// The role must be built in or defined by an admin
+	role, err := uc.Policy.FindRole(newRole)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
 	// Update role
+	oldRole := user.Role
+	before := snapshotUser(user)
 	if err := user.SetRole(newRole); err != nil {
 		return err
 	}
 
 	// Save updated user
-	if err := uc.UserRepo.Save(user); err != nil {
+	err = uc.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.Users.Save(user); err != nil {
+			return err
+		}
+		if oldRole == newRole {
+			return nil
+		}
+		return tx.Events.Publish(entities.NewUserRoleChanged(userID, oldRole, newRole))
+	})
+	if err != nil {
 		uc.Logger.Error("Admin: Failed to save user", map[string]interface{}{
 			"error":  err.Error(),
 			"userID": userID,
@@ -87,11 +119,22 @@ func (uc *AdminUseCase) UpdateUserRole(userID string, newRole entities.UserRole)
 		return errors.New("failed to update user role")
 	}
 
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditUserRoleChange, actor.UserID, entities.AuditTargetUser, userID),
+		actor.Client, before, snapshotUser(user))
+
+	// Tokens carry the role, so tokens issued under the old role must stop
+	// working now. A demoted admin must not keep admin access until expiry.
+	if oldRole != newRole {
+		if err := uc.revokeTokens(userID); err != nil {
+			return errors.New("role updated but existing sessions could not be revoked")
+		}
+	}
+
 	return nil
 }
 
 // DeleteUser deletes a user from the system
-func (uc *AdminUseCase) DeleteUser(userID string) error {
+func (uc *AdminUseCase) DeleteUser(actor interfaces.Actor, userID string) error {
 	if userID == "" {
 		return errors.New("user ID is required")
 	}
@@ -111,7 +154,13 @@ func (uc *AdminUseCase) DeleteUser(userID string) error {
 	}
 
 	// Delete the user
-	if err := uc.UserRepo.Delete(userID); err != nil {
+	err = uc.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.Users.Delete(userID); err != nil {
+			return err
+		}
+		return tx.Events.Publish(entities.NewUserDeleted(userID))
+	})
+	if err != nil {
 		uc.Logger.Error("Admin: Failed to delete user", map[string]interface{}{
 			"error":  err.Error(),
 			"userID": userID,
@@ -119,5 +168,50 @@ func (uc *AdminUseCase) DeleteUser(userID string) error {
 		return errors.New("failed to delete user")
 	}
 
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditUserDelete, actor.UserID, entities.AuditTargetUser, userID),
+		actor.Client, snapshotUser(user), nil)
+
+	// A deleted user's tokens must stop working now, not at expiry
+	if err := uc.revokeTokens(userID); err != nil {
+		return errors.New("user deleted but existing sessions could not be revoked")
+	}
+
 	return nil
 }
+
+// UnlockUser lifts a lockout caused by failed logins and clears the user's
+// failure count
+func (uc *AdminUseCase) UnlockUser(actor interfaces.Actor, userID string) error {
+	user, err := uc.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.Lockout.Unlock(user.ID); err != nil {
+		return err
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditUserUnlock, actor.UserID, entities.AuditTargetUser, userID), actor.Client, nil, nil)
+	return nil
+}
+
+// revokeTokens invalidates every token of a user, logging failures
+func (uc *AdminUseCase) revokeTokens(userID string) error {
+	if uc.TokenRevoker == nil {
+		return nil
+	}
+	err := uc.TokenRevoker.RevokeUserTokens(userID)
+	if err != nil {
+		uc.Logger.Error("Admin: Failed to revoke user tokens", map[string]interface{}{
+			"error":  err.Error(),
+			"userID": userID,
+		})
+	}
+	return err
+}
+
+// transaction returns the transactor used for writes, falling back to the
+// plain repository when none is configured
+func (uc *AdminUseCase) transaction() Transactor {
+	return transactorOrDirect(uc.Transactor, &Tx{Users: uc.UserRepo, Events: uc.Events}, uc.Logger)
+}
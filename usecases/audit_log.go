@@ -0,0 +1,128 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+// AuditLog records security-relevant and administrative actions. Recording
+// happens after the action succeeded, so a failure is logged instead of
+// failing the action. A nil AuditLog records nothing.
+type AuditLog struct {
+	Repo   interfaces.AuditRepository
+	Logger Logger
+}
+
+func NewAuditLog(repo interfaces.AuditRepository, logger Logger) *AuditLog {
+	return &AuditLog{
+		Repo:   repo,
+		Logger: logger,
+	}
+}
+
+// Record appends an event with the client details and before/after
+// snapshots of the target. Snapshots are marshalled to JSON; nil means none.
+func (a *AuditLog) Record(event *entities.AuditEvent, client interfaces.ClientInfo, before, after interface{}) {
+	if a == nil || a.Repo == nil {
+		return
+	}
+
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+	event.RequestID = client.RequestID
+	event.Before = auditSnapshot(before)
+	event.After = auditSnapshot(after)
+	if err := a.Repo.Append(event); err != nil {
+		a.Logger.Error("Failed to record audit event", "error", err, "action", event.Action, "targetID", event.TargetID)
+	}
+}
+
+// ListEvents returns audit events, newest first
+func (a *AuditLog) ListEvents(filter interfaces.AuditFilter) ([]*entities.AuditEvent, error) {
+	if a == nil || a.Repo == nil {
+		return nil, errors.New("audit log is not enabled")
+	}
+
+	events, err := a.Repo.Find(filter)
+	if err != nil {
+		a.Logger.Error("Failed to fetch audit events", "error", err)
+		return nil, errors.New("failed to fetch audit events")
+	}
+	return events, nil
+}
+
+func auditSnapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// userSnapshot is the part of a user worth keeping in the audit log; the
+// password hash is left out
+type userSnapshot struct {
+	ID       string            `json:"id"`
+	Username string            `json:"username"`
+	Email    string            `json:"email"`
+	FullName string            `json:"full_name"`
+	Role     entities.UserRole `json:"role"`
+}
+
+func snapshotUser(user *entities.User) *userSnapshot {
+	return &userSnapshot{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		FullName: user.FullName,
+		Role:     user.Role,
+	}
+}
+
+type postSnapshot struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	AuthorID string `json:"author_id"`
+}
+
+func snapshotPost(post *entities.BlogPost) *postSnapshot {
+	return &postSnapshot{ID: post.ID, Title: post.Title, Content: post.Content, AuthorID: post.AuthorID}
+}
+
+type commentSnapshot struct {
+	ID         string `json:"id"`
+	BlogPostID string `json:"blog_post_id"`
+	AuthorID   string `json:"author_id"`
+	Content    string `json:"content"`
+	ParentID   string `json:"parent_id,omitempty"`
+}
+
+func snapshotComment(comment *entities.Comment) *commentSnapshot {
+	return &commentSnapshot{
+		ID:         comment.ID,
+		BlogPostID: comment.BlogPostID,
+		AuthorID:   comment.AuthorID,
+		Content:    comment.Content,
+		ParentID:   comment.ParentID,
+	}
+}
+
+type roleSnapshot struct {
+	Name        entities.UserRole     `json:"name"`
+	Description string                `json:"description"`
+	Permissions []entities.Permission `json:"permissions"`
+}
+
+func snapshotRole(role *entities.Role) *roleSnapshot {
+	return &roleSnapshot{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: append([]entities.Permission(nil), role.Permissions...),
+	}
+}
@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxAPITokensPerUser keeps a leaked account from minting tokens without
+	// bound
+	maxAPITokensPerUser = 50
+	// MaxAPITokenLifetime is the longest expiry a token can be given
+	MaxAPITokenLifetime = 366 * 24 * time.Hour
+	// apiTokenUseInterval limits how often a token's last use is written,
+	// so that a busy CI job doesn't write on every request
+	apiTokenUseInterval = time.Minute
+)
+
+// CreateAPIToken creates a personal API token limited to the given scopes. A
+// zero lifetime creates a token that doesn't expire. The token's value is
+// returned once and only its hash is stored.
+func (u *AuthUseCase) CreateAPIToken(userID, name string, scopes []entities.Scope, lifetime time.Duration, client interfaces.ClientInfo) (*entities.APIToken, string, error) {
+	if u.APITokens == nil {
+		return nil, "", errors.New("API tokens are not enabled")
+	}
+	if lifetime < 0 || lifetime > MaxAPITokenLifetime {
+		return nil, "", errors.New("token lifetime must be between 1 and 366 days")
+	}
+
+	existing, err := u.APITokens.FindByUser(userID)
+	if err != nil {
+		u.Logger.Error("Failed to list API tokens", "error", err, "userID", userID)
+		return nil, "", errors.New("failed to create API token")
+	}
+	if len(existing) >= maxAPITokensPerUser {
+		return nil, "", errors.New("too many API tokens: revoke one first")
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		u.Logger.Error("Failed to generate API token", "error", err)
+		return nil, "", errors.New("failed to create API token")
+	}
+	value := entities.APITokenPrefix + secret
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if lifetime > 0 {
+		expiry := now.Add(lifetime)
+		expiresAt = &expiry
+	}
+	token, err := entities.NewAPIToken(uuid.New().String(), userID, name, scopes,
+		value[:len(entities.APITokenPrefix)+6], hashToken(value), now, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := u.APITokens.Save(token); err != nil {
+		u.Logger.Error("Failed to save API token", "error", err, "userID", userID)
+		return nil, "", errors.New("failed to create API token")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditAPITokenCreate, userID, entities.AuditTargetUser, userID),
+		client, nil, token)
+	return token, value, nil
+}
+
+// ListAPITokens returns a user's API tokens, newest first
+func (u *AuthUseCase) ListAPITokens(userID string) ([]*entities.APIToken, error) {
+	if u.APITokens == nil {
+		return nil, errors.New("API tokens are not enabled")
+	}
+
+	tokens, err := u.APITokens.FindByUser(userID)
+	if err != nil {
+		u.Logger.Error("Failed to list API tokens", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve API tokens")
+	}
+	if tokens == nil {
+		tokens = []*entities.APIToken{}
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken deletes one of a user's API tokens; it stops working at once
+func (u *AuthUseCase) RevokeAPIToken(userID, tokenID string, client interfaces.ClientInfo) error {
+	tokens, err := u.ListAPITokens(userID)
+	if err != nil {
+		return err
+	}
+
+	var token *entities.APIToken
+	for _, candidate := range tokens {
+		if candidate.ID == tokenID {
+			token = candidate
+		}
+	}
+	if token == nil {
+		return errors.New("API token not found")
+	}
+
+	deleted, err := u.APITokens.Delete(userID, tokenID)
+	if err != nil {
+		u.Logger.Error("Failed to revoke API token", "error", err, "userID", userID, "tokenID", tokenID)
+		return errors.New("failed to revoke API token")
+	}
+	if !deleted {
+		return errors.New("API token not found")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditAPITokenRevoke, userID, entities.AuditTargetUser, userID),
+		client, token, nil)
+	return nil
+}
+
+// AuthenticateAPIToken returns the token and the user it acts for, recording
+// when it was last used
+func (u *AuthUseCase) AuthenticateAPIToken(value string) (*entities.APIToken, *entities.User, error) {
+	if u.APITokens == nil {
+		return nil, nil, errors.New("API tokens are not enabled")
+	}
+	if !strings.HasPrefix(value, entities.APITokenPrefix) {
+		return nil, nil, errors.New("invalid API token")
+	}
+
+	token, err := u.APITokens.FindByHash(hashToken(value))
+	if err != nil {
+		u.Logger.Error("Failed to find API token", "error", err)
+		return nil, nil, errors.New("failed to verify API token")
+	}
+	now := time.Now()
+	if token == nil || token.IsExpired(now) {
+		return nil, nil, errors.New("invalid API token")
+	}
+
+	user, err := u.UserRepo.FindByID(token.UserID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for API token", "error", err, "userID", token.UserID)
+		return nil, nil, errors.New("failed to verify API token")
+	}
+	if user == nil {
+		return nil, nil, errors.New("invalid API token")
+	}
+
+	// Failures are logged only; the last use is informational
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenUseInterval {
+		if err := u.APITokens.UpdateLastUsed(token.ID, now); err != nil {
+			u.Logger.Error("Failed to record API token use", "error", err, "tokenID", token.ID)
+		}
+		token.LastUsedAt = &now
+	}
+	return token, user, nil
+}
@@ -0,0 +1,253 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// emailEnabled reports whether verification and reset emails can be sent
+func (u *AuthUseCase) emailEnabled() bool {
+	return u.UserTokens != nil && u.Mailer != nil
+}
+
+// issueUserToken invalidates the user's outstanding tokens for a purpose and
+// stores a new one, returning its plaintext value
+func (u *AuthUseCase) issueUserToken(user *entities.User, purpose string, ttl time.Duration) (string, error) {
+	if err := u.UserTokens.DeleteByUser(user.ID, purpose); err != nil {
+		return "", err
+	}
+
+	value, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := &entities.UserToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := u.UserTokens.Save(token); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// findUserToken returns the unexpired token with the given value and purpose,
+// still sent to the user's current address, or nil
+func (u *AuthUseCase) findUserToken(value, purpose string) (*entities.UserToken, *entities.User, error) {
+	if value == "" {
+		return nil, nil, nil
+	}
+	token, err := u.UserTokens.FindByHash(hashToken(value))
+	if err != nil || token == nil || token.Purpose != purpose || token.IsExpired(time.Now()) {
+		return nil, nil, err
+	}
+	user, err := u.UserRepo.FindByID(token.UserID)
+	if err != nil || user == nil || !strings.EqualFold(user.Email, token.Email) {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+// appLink builds a link to a frontend page carrying a token
+func (u *AuthUseCase) appLink(path, token string) string {
+	return strings.TrimRight(u.AppURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendVerificationEmail mails the user a link to verify their address
+func (u *AuthUseCase) sendVerificationEmail(user *entities.User) error {
+	token, err := u.issueUserToken(user, entities.TokenPurposeEmailVerification, u.EmailVerificationTTL)
+	if err != nil {
+		u.Logger.Error("Failed to issue email verification token", "error", err, "userID", user.ID)
+		return errors.New("failed to send verification email")
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\nThe link expires in %s. If you didn't create an account, you can ignore this email.\n",
+		user.Username, u.appLink("/verify-email", token), formatTTL(u.EmailVerificationTTL))
+	err = u.Mailer.Send(EmailMessage{To: user.Email, Subject: "Verify your email address", Body: body})
+	if err != nil {
+		u.Logger.Error("Failed to send verification email", "error", err, "userID", user.ID)
+		return errors.New("failed to send verification email")
+	}
+	return nil
+}
+
+// ResendVerificationEmail mails the user a new verification link; earlier
+// links stop working
+func (u *AuthUseCase) ResendVerificationEmail(userID string) error {
+	if !u.emailEnabled() {
+		return errors.New("email verification is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for email verification", "error", err, "userID", userID)
+		return errors.New("failed to retrieve user")
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if user.IsEmailVerified() {
+		return errors.New("email is already verified")
+	}
+
+	return u.sendVerificationEmail(user)
+}
+
+// VerifyEmail marks the user's email address as verified with a token from a
+// verification email
+func (u *AuthUseCase) VerifyEmail(token string, client interfaces.ClientInfo) error {
+	if !u.emailEnabled() {
+		return errors.New("email verification is not enabled")
+	}
+
+	stored, user, err := u.findUserToken(token, entities.TokenPurposeEmailVerification)
+	if err != nil {
+		u.Logger.Error("Failed to find email verification token", "error", err)
+		return errors.New("failed to verify email")
+	}
+	if stored == nil {
+		return errors.New("invalid or expired token")
+	}
+	consumed, err := u.UserTokens.Consume(stored.TokenHash)
+	if err != nil {
+		u.Logger.Error("Failed to use email verification token", "error", err, "userID", user.ID)
+		return errors.New("failed to verify email")
+	}
+	if !consumed {
+		return errors.New("invalid or expired token")
+	}
+
+	user.MarkEmailVerified(time.Now())
+	if err := u.UserRepo.Save(user); err != nil {
+		u.Logger.Error("Failed to save verified email", "error", err, "userID", user.ID)
+		return errors.New("failed to verify email")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditEmailVerify, user.ID, entities.AuditTargetUser, user.ID),
+		client, nil, map[string]string{"email": user.Email})
+	return nil
+}
+
+// RequestPasswordReset mails a password reset link to the account with the
+// given email address. It succeeds whether or not the account exists, and as
+// quickly, so it can't be used to find out which addresses are registered:
+// the email is sent in the background and failures are only logged.
+func (u *AuthUseCase) RequestPasswordReset(email string, client interfaces.ClientInfo) error {
+	if !u.emailEnabled() {
+		return errors.New("password reset is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		u.Logger.Error("Failed to find user for password reset", "error", err)
+		return errors.New("failed to request password reset")
+	}
+	if user == nil {
+		return nil
+	}
+
+	go u.sendPasswordResetEmail(user, client)
+	return nil
+}
+
+// sendPasswordResetEmail mails the user a password reset link; earlier links
+// stop working
+func (u *AuthUseCase) sendPasswordResetEmail(user *entities.User, client interfaces.ClientInfo) {
+	token, err := u.issueUserToken(user, entities.TokenPurposePasswordReset, u.PasswordResetTTL)
+	if err != nil {
+		u.Logger.Error("Failed to issue password reset token", "error", err, "userID", user.ID)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open this link:\n\n%s\n\nThe link expires in %s. If it wasn't you, you can ignore this email; your password stays the same.\n",
+		user.Username, u.appLink("/reset-password", token), formatTTL(u.PasswordResetTTL))
+	if err := u.Mailer.Send(EmailMessage{To: user.Email, Subject: "Reset your password", Body: body}); err != nil {
+		u.Logger.Error("Failed to send password reset email", "error", err, "userID", user.ID)
+		return
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPasswordResetRequest, "", entities.AuditTargetUser, user.ID), client, nil, nil)
+}
+
+// ResetPassword sets a new password with a token from a password reset
+// email. Every session of the user is signed out, and since the link proved
+// they own the address, their email counts as verified.
+func (u *AuthUseCase) ResetPassword(token, newPassword string, client interfaces.ClientInfo) error {
+	if !u.emailEnabled() {
+		return errors.New("password reset is not enabled")
+	}
+
+	stored, user, err := u.findUserToken(token, entities.TokenPurposePasswordReset)
+	if err != nil {
+		u.Logger.Error("Failed to find password reset token", "error", err)
+		return errors.New("failed to reset password")
+	}
+	if stored == nil {
+		return errors.New("invalid or expired token")
+	}
+
+	// Check the new password before using the token up, so a rejected
+	// password doesn't cost the user their link
+	if err := u.checkPassword(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	if err := user.ResetPassword(u.PasswordHasher, newPassword); err != nil {
+		return err
+	}
+	consumed, err := u.UserTokens.Consume(stored.TokenHash)
+	if err != nil {
+		u.Logger.Error("Failed to use password reset token", "error", err, "userID", user.ID)
+		return errors.New("failed to reset password")
+	}
+	if !consumed {
+		return errors.New("invalid or expired token")
+	}
+
+	user.MarkEmailVerified(time.Now())
+	if err := u.UserRepo.Save(user); err != nil {
+		u.Logger.Error("Failed to save password reset", "error", err, "userID", user.ID)
+		return errors.New("failed to reset password")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPasswordReset, user.ID, entities.AuditTargetUser, user.ID), client, nil, nil)
+
+	// Whoever knew the old password is signed out, and the lockout it may
+	// have caused is lifted
+	if err := u.TokenRevoker.RevokeUserTokens(user.ID); err != nil {
+		u.Logger.Error("Failed to revoke sessions after password reset", "error", err, "userID", user.ID)
+	}
+	u.Lockout.RecordSuccess(accountKey(user, ""))
+	return nil
+}
+
+// formatTTL describes a token lifetime in an email, e.g. "1 hour"
+func formatTTL(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
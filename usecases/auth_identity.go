@@ -0,0 +1,329 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// identityLinkDuration is how long a user has to sign in at the provider
+// after starting to link an account
+const identityLinkDuration = 10 * time.Minute
+
+// loginCodeDuration is how long the frontend has to exchange the code it
+// was redirected with after a provider sign-in
+const loginCodeDuration = time.Minute
+
+// OAuthLogin signs a user in with a provider account. Accounts seen before
+// sign in to the user they are linked to, and new ones create a user. A new
+// provider account whose email address belongs to an existing user is never
+// linked to it here, whatever the provider says about the address: the user
+// has to sign in and link it themselves, so that nobody can take over an
+// account by claiming its address at a provider.
+func (u *AuthUseCase) OAuthLogin(external interfaces.ExternalIdentity, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	user, err := u.oauthUser(external, client)
+	if err != nil {
+		return nil, err
+	}
+	return u.completeLogin(user, client)
+}
+
+// IssueLoginCode signs a user in with a provider account like OAuthLogin, but
+// returns a single-use code for ExchangeLoginCode instead of tokens, so that
+// the frontend can be redirected with it without a token appearing in a URL
+func (u *AuthUseCase) IssueLoginCode(external interfaces.ExternalIdentity, client interfaces.ClientInfo) (string, error) {
+	if u.UserTokens == nil {
+		return "", errors.New("sign-in code exchange is not enabled")
+	}
+	user, err := u.oauthUser(external, client)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := u.issueUserToken(user, entities.TokenPurposeLoginCode, loginCodeDuration)
+	if err != nil {
+		u.Logger.Error("Failed to issue sign-in code", "error", err, "userID", user.ID)
+		return "", errors.New("failed to sign in")
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode redeems a code from IssueLoginCode for tokens, or for a
+// two-factor challenge when the user has a second factor
+func (u *AuthUseCase) ExchangeLoginCode(code string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	if u.UserTokens == nil {
+		return nil, errors.New("sign-in code exchange is not enabled")
+	}
+
+	token, user, err := u.findUserToken(code, entities.TokenPurposeLoginCode)
+	if err != nil {
+		u.Logger.Error("Failed to find sign-in code", "error", err)
+		return nil, errors.New("failed to sign in")
+	}
+	if token == nil {
+		return nil, errors.New("invalid or expired sign-in code")
+	}
+	consumed, err := u.UserTokens.Consume(token.TokenHash)
+	if err != nil {
+		u.Logger.Error("Failed to consume sign-in code", "error", err, "userID", user.ID)
+		return nil, errors.New("failed to sign in")
+	}
+	if !consumed {
+		return nil, errors.New("invalid or expired sign-in code")
+	}
+
+	return u.completeLogin(user, client)
+}
+
+// oauthUser finds or creates the user a provider account signs in as
+func (u *AuthUseCase) oauthUser(external interfaces.ExternalIdentity, client interfaces.ClientInfo) (*entities.User, error) {
+	if u.Identities == nil {
+		return nil, errors.New("provider sign-in is not enabled")
+	}
+	if external.Provider == "" || external.ProviderID == "" {
+		return nil, errors.New("invalid provider account")
+	}
+
+	identity, err := u.Identities.FindByProvider(external.Provider, external.ProviderID)
+	if err != nil {
+		u.Logger.Error("Failed to find identity", "error", err, "provider", external.Provider)
+		return nil, errors.New("failed to sign in")
+	}
+	if identity != nil {
+		user, err := u.UserRepo.FindByID(identity.UserID)
+		if err != nil {
+			u.Logger.Error("Failed to find user for identity", "error", err, "userID", identity.UserID)
+			return nil, errors.New("failed to sign in")
+		}
+		if user != nil {
+			u.touchIdentity(identity, external)
+			u.updateAvatar(user, external.AvatarURL)
+			return user, nil
+		}
+		// The user was deleted without the link, which can happen with
+		// stores that don't cascade
+		if _, err := u.Identities.Delete(identity.UserID, identity.Provider); err != nil {
+			u.Logger.Error("Failed to delete orphaned identity", "error", err, "userID", identity.UserID)
+			return nil, errors.New("failed to sign in")
+		}
+	}
+
+	if external.Email == "" {
+		return nil, errors.New("the provider did not share an email address")
+	}
+	existing, err := u.UserRepo.FindByEmail(external.Email)
+	if err != nil {
+		u.Logger.Error("Failed to find user by email", "error", err)
+		return nil, errors.New("failed to sign in")
+	}
+	if existing != nil {
+		return nil, errors.New("an account with this email address already exists: sign in and link this provider from your profile")
+	}
+
+	// Random password, never shown: the user signs in with the provider, or
+	// resets it by email
+	user, err := u.createUser(usernameFromEmail(external.Email), external.Email, uuid.New().String(), external.Name, external.EmailVerified, client)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.linkIdentity(user, external, client); err != nil {
+		return nil, err
+	}
+	u.updateAvatar(user, external.AvatarURL)
+	return user, nil
+}
+
+// BeginIdentityLink lets a signed-in user prove who they are when they come
+// back from the provider's sign-in page
+func (u *AuthUseCase) BeginIdentityLink(userID string) (string, error) {
+	if u.Identities == nil || u.UserTokens == nil {
+		return "", errors.New("account linking is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find user", "error", err, "userID", userID)
+		return "", errors.New("failed to retrieve user")
+	}
+	if user == nil {
+		return "", errors.New("user not found")
+	}
+
+	ticket, err := u.issueUserToken(user, entities.TokenPurposeIdentityLink, identityLinkDuration)
+	if err != nil {
+		u.Logger.Error("Failed to issue identity link ticket", "error", err, "userID", userID)
+		return "", errors.New("failed to start linking")
+	}
+	return ticket, nil
+}
+
+// CompleteIdentityLink links a provider account to the user who started
+// linking it
+func (u *AuthUseCase) CompleteIdentityLink(ticket string, external interfaces.ExternalIdentity, client interfaces.ClientInfo) (*entities.Identity, error) {
+	if u.Identities == nil || u.UserTokens == nil {
+		return nil, errors.New("account linking is not enabled")
+	}
+	if external.Provider == "" || external.ProviderID == "" {
+		return nil, errors.New("invalid provider account")
+	}
+
+	token, user, err := u.findUserToken(ticket, entities.TokenPurposeIdentityLink)
+	if err != nil {
+		u.Logger.Error("Failed to find identity link ticket", "error", err)
+		return nil, errors.New("failed to link account")
+	}
+	if token == nil {
+		return nil, errors.New("invalid or expired link request")
+	}
+	consumed, err := u.UserTokens.Consume(token.TokenHash)
+	if err != nil {
+		u.Logger.Error("Failed to consume identity link ticket", "error", err, "userID", user.ID)
+		return nil, errors.New("failed to link account")
+	}
+	if !consumed {
+		return nil, errors.New("invalid or expired link request")
+	}
+
+	return u.linkIdentity(user, external, client)
+}
+
+// ListIdentities returns the provider accounts linked to a user
+func (u *AuthUseCase) ListIdentities(userID string) ([]*entities.Identity, error) {
+	if u.Identities == nil {
+		return nil, errors.New("account linking is not enabled")
+	}
+
+	identities, err := u.Identities.FindByUser(userID)
+	if err != nil {
+		u.Logger.Error("Failed to list identities", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve linked accounts")
+	}
+	if identities == nil {
+		identities = []*entities.Identity{}
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes the link to a provider account. The last one can
+// only go when the user can reset their password by email, so that they
+// can't lock themselves out.
+func (u *AuthUseCase) UnlinkIdentity(userID, provider string, client interfaces.ClientInfo) error {
+	identities, err := u.ListIdentities(userID)
+	if err != nil {
+		return err
+	}
+
+	var identity *entities.Identity
+	for _, candidate := range identities {
+		if candidate.Provider == provider {
+			identity = candidate
+		}
+	}
+	if identity == nil {
+		return errors.New("linked account not found")
+	}
+	if len(identities) == 1 && !u.emailEnabled() {
+		return errors.New("cannot unlink your only sign-in provider")
+	}
+
+	deleted, err := u.Identities.Delete(userID, provider)
+	if err != nil {
+		u.Logger.Error("Failed to unlink identity", "error", err, "userID", userID, "provider", provider)
+		return errors.New("failed to unlink account")
+	}
+	if !deleted {
+		return errors.New("linked account not found")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditIdentityUnlink, userID, entities.AuditTargetUser, userID),
+		client, identity, nil)
+	return nil
+}
+
+// linkIdentity links a provider account to a user, unless it is linked to
+// someone else or the user already linked another account at the provider
+func (u *AuthUseCase) linkIdentity(user *entities.User, external interfaces.ExternalIdentity, client interfaces.ClientInfo) (*entities.Identity, error) {
+	existing, err := u.Identities.FindByProvider(external.Provider, external.ProviderID)
+	if err != nil {
+		u.Logger.Error("Failed to find identity", "error", err, "provider", external.Provider)
+		return nil, errors.New("failed to link account")
+	}
+	if existing != nil {
+		if existing.UserID != user.ID {
+			return nil, errors.New("this provider account is already linked to another user")
+		}
+		u.touchIdentity(existing, external)
+		return existing, nil
+	}
+
+	linked, err := u.Identities.FindByUser(user.ID)
+	if err != nil {
+		u.Logger.Error("Failed to list identities", "error", err, "userID", user.ID)
+		return nil, errors.New("failed to link account")
+	}
+	for _, identity := range linked {
+		if identity.Provider == external.Provider {
+			return nil, errors.New("another account at this provider is already linked")
+		}
+	}
+
+	now := time.Now()
+	identity := &entities.Identity{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		Provider:   external.Provider,
+		ProviderID: external.ProviderID,
+		Email:      external.Email,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := u.Identities.Save(identity); err != nil {
+		u.Logger.Error("Failed to save identity", "error", err, "userID", user.ID, "provider", external.Provider)
+		return nil, errors.New("failed to link account")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditIdentityLink, user.ID, entities.AuditTargetUser, user.ID),
+		client, nil, identity)
+	return identity, nil
+}
+
+// touchIdentity records a sign-in and the provider's current email address.
+// Failures are logged only; they don't stop the sign-in.
+func (u *AuthUseCase) touchIdentity(identity *entities.Identity, external interfaces.ExternalIdentity) {
+	identity.Email = external.Email
+	identity.LastUsedAt = time.Now()
+	if err := u.Identities.Save(identity); err != nil {
+		u.Logger.Error("Failed to update identity", "error", err, "userID", identity.UserID)
+	}
+}
+
+// updateAvatar copies the provider's profile picture. Failures are logged
+// only; the avatar is cosmetic.
+func (u *AuthUseCase) updateAvatar(user *entities.User, avatarURL string) {
+	if avatarURL == "" || avatarURL == user.AvatarURL {
+		return
+	}
+	if err := user.Update(user.FullName, user.Bio, avatarURL); err != nil {
+		return
+	}
+	if err := u.UserRepo.Save(user); err != nil {
+		u.Logger.Error("Failed to update avatar", "error", err, "userID", user.ID)
+	}
+}
+
+// usernameFromEmail derives a username from an email address, with a random
+// suffix to avoid collisions
+func usernameFromEmail(email string) string {
+	parts := strings.Split(email, "@")
+	if len(parts) > 0 && parts[0] != "" {
+		username := strings.ToLower(parts[0])
+		username = strings.ReplaceAll(username, ".", "_")
+		username = strings.ReplaceAll(username, "+", "_")
+		return username + "_" + uuid.New().String()[:8]
+	}
+	return "user_" + uuid.New().String()[:8]
+}
@@ -0,0 +1,391 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// mfaChallengeDuration is how long a password login waits for its second
+	// factor
+	mfaChallengeDuration = 5 * time.Minute
+	recoveryCodeCount    = 10
+	// recoveryCodeAlphabet has 32 characters, so a random byte maps onto it
+	// without bias; i, l and o are left out to avoid misreading
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+)
+
+// roleRequiresMFA reports whether users with the role must have a second
+// factor. A nil repository requires none.
+func roleRequiresMFA(mfa interfaces.MFARepository, role entities.UserRole) (bool, error) {
+	if mfa == nil {
+		return false, nil
+	}
+	roles, err := mfa.FindRequiredRoles()
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// effectiveRole is the role a user acts with: a role that requires a second
+// factor only applies once the user has one, until then they act as a plain
+// user
+func effectiveRole(mfa interfaces.MFARepository, user *entities.User) (entities.UserRole, error) {
+	required, err := roleRequiresMFA(mfa, user.Role)
+	if err != nil || !required {
+		return user.Role, err
+	}
+	enrollment, err := mfa.FindEnrollment(user.ID)
+	if err != nil {
+		return "", err
+	}
+	if enrollment == nil || !enrollment.IsConfirmed() {
+		return entities.RoleUser, nil
+	}
+	return user.Role, nil
+}
+
+// completeLogin opens a session once the password is verified, unless the
+// user has a second factor: then a challenge is returned, to be completed
+// with VerifyMFA
+func (u *AuthUseCase) completeLogin(user *entities.User, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	if u.MFA == nil {
+		return u.issueTokens(user, client)
+	}
+
+	enrollment, err := u.MFA.FindEnrollment(user.ID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", user.ID)
+		return nil, errors.New("authentication failed")
+	}
+	if enrollment == nil || !enrollment.IsConfirmed() {
+		return u.issueTokens(user, client)
+	}
+
+	value, err := newOpaqueToken()
+	if err != nil {
+		u.Logger.Error("Failed to generate two-factor challenge", "error", err)
+		return nil, errors.New("authentication failed")
+	}
+	now := time.Now()
+	challenge := &entities.MFAChallenge{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(mfaChallengeDuration),
+	}
+	if err := u.MFA.SaveChallenge(challenge); err != nil {
+		u.Logger.Error("Failed to save two-factor challenge", "error", err, "userID", user.ID)
+		return nil, errors.New("authentication failed")
+	}
+
+	return &interfaces.LoginResponse{
+		MFARequired: true,
+		MFAToken:    value,
+	}, nil
+}
+
+// VerifyMFA completes a login challenge with a TOTP code or a recovery code.
+// Wrong codes count towards the account lockout, and a challenge is dropped
+// after a few of them.
+func (u *AuthUseCase) VerifyMFA(mfaToken, code string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	if u.MFA == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	if mfaToken == "" || code == "" {
+		return nil, errors.New("two-factor token and code are required")
+	}
+
+	challenge, err := u.MFA.FindChallengeByHash(hashToken(mfaToken))
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor challenge", "error", err)
+		return nil, errors.New("authentication failed")
+	}
+	now := time.Now()
+	if challenge == nil || challenge.IsExpired(now) {
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	key := "account:" + challenge.UserID
+	if err := u.Lockout.Check(key, client.IP, now); err != nil {
+		return nil, err
+	}
+
+	user, err := u.UserRepo.FindByID(challenge.UserID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for two-factor login", "error", err, "userID", challenge.UserID)
+		return nil, errors.New("authentication failed")
+	}
+	enrollment, err := u.MFA.FindEnrollment(challenge.UserID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", challenge.UserID)
+		return nil, errors.New("authentication failed")
+	}
+	if user == nil || enrollment == nil || !enrollment.IsConfirmed() {
+		u.deleteChallenge(challenge)
+		return nil, errors.New("invalid or expired two-factor challenge")
+	}
+
+	method, ok, err := u.verifySecondFactor(enrollment, code, now)
+	if err != nil {
+		u.Logger.Error("Failed to use two-factor code", "error", err, "userID", user.ID)
+		return nil, errors.New("authentication failed")
+	}
+	if !ok {
+		challenge.Attempts++
+		if challenge.Attempts >= entities.MaxMFAChallengeAttempts {
+			u.deleteChallenge(challenge)
+		} else if err := u.MFA.SaveChallenge(challenge); err != nil {
+			u.Logger.Error("Failed to save two-factor challenge", "error", err, "userID", user.ID)
+		}
+		u.mfaFailed(key, user, client, now)
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	u.deleteChallenge(challenge)
+	u.Lockout.RecordSuccess(key)
+
+	response, err := u.issueTokens(user, client)
+	if err != nil {
+		return nil, err
+	}
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditLogin, user.ID, entities.AuditTargetUser, user.ID),
+		client, nil, map[string]string{"mfa": method})
+	return response, nil
+}
+
+// verifySecondFactor uses up a TOTP code or a recovery code, and returns
+// which one was used. The repository consumes the code, so that it works
+// once even when several requests present it at the same time.
+func (u *AuthUseCase) verifySecondFactor(enrollment *entities.MFAEnrollment, code string, now time.Time) (string, bool, error) {
+	if step, ok := enrollment.MatchCode(code, now); ok {
+		used, err := u.MFA.ConsumeTOTPStep(enrollment.UserID, step)
+		return "totp", used, err
+	}
+	hash := hashToken(normalizeRecoveryCode(code))
+	if enrollment.HasRecoveryCode(hash) {
+		used, err := u.MFA.ConsumeRecoveryCode(enrollment.UserID, hash)
+		return "recovery_code", used, err
+	}
+	return "", false, nil
+}
+
+func (u *AuthUseCase) mfaFailed(key string, user *entities.User, client interfaces.ClientInfo, now time.Time) {
+	lock := u.Lockout.RecordFailure(key, user.ID, client.IP, now)
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditMFAFailed, "", entities.AuditTargetUser, user.ID), client, nil, nil)
+	if lock > 0 {
+		u.Audit.Record(entities.NewAuditEvent(entities.AuditAccountLocked, "", entities.AuditTargetUser, user.ID),
+			client, nil, map[string]int{"lock_seconds": int(lock.Seconds())})
+	}
+}
+
+func (u *AuthUseCase) deleteChallenge(challenge *entities.MFAChallenge) {
+	if err := u.MFA.DeleteChallenge(challenge.ID); err != nil {
+		u.Logger.Error("Failed to delete two-factor challenge", "error", err, "userID", challenge.UserID)
+	}
+}
+
+// MFAStatus reports whether the user has a second factor and whether their
+// role requires one
+func (u *AuthUseCase) MFAStatus(userID string) (*interfaces.MFAStatus, error) {
+	if u.MFA == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for two-factor status", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve user")
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	status := &interfaces.MFAStatus{}
+	status.Required, err = roleRequiresMFA(u.MFA, user.Role)
+	if err != nil {
+		u.Logger.Error("Failed to find roles requiring two-factor authentication", "error", err)
+		return nil, errors.New("failed to retrieve two-factor status")
+	}
+	enrollment, err := u.MFA.FindEnrollment(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve two-factor status")
+	}
+	if enrollment != nil && enrollment.IsConfirmed() {
+		status.Enabled = true
+		status.RecoveryCodesRemaining = len(enrollment.RecoveryCodeHashes)
+	}
+	return status, nil
+}
+
+// EnrollMFA starts setting up a second factor, replacing any unconfirmed
+// one. It only takes effect once confirmed with ConfirmMFA.
+func (u *AuthUseCase) EnrollMFA(userID string) (*interfaces.MFAEnrollmentInfo, error) {
+	if u.MFA == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve user")
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	existing, err := u.MFA.FindEnrollment(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+	if existing != nil && existing.IsConfirmed() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	enrollment, err := entities.NewMFAEnrollment(userID)
+	if err != nil {
+		u.Logger.Error("Failed to generate TOTP secret", "error", err, "userID", userID)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+	if err := u.MFA.SaveEnrollment(enrollment); err != nil {
+		u.Logger.Error("Failed to save two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to set up two-factor authentication")
+	}
+
+	return &interfaces.MFAEnrollmentInfo{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: entities.TOTPProvisioningURI(u.MFAIssuer, user.Email, enrollment.Secret),
+	}, nil
+}
+
+// ConfirmMFA turns on the second factor set up by EnrollMFA once the user
+// proves it works with a first code. It returns the recovery codes, which
+// are shown this once and only stored hashed.
+func (u *AuthUseCase) ConfirmMFA(userID, code string, client interfaces.ClientInfo) ([]string, error) {
+	if u.MFA == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	enrollment, err := u.MFA.FindEnrollment(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+	if enrollment == nil {
+		return nil, errors.New("two-factor authentication is not set up")
+	}
+	if enrollment.IsConfirmed() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	now := time.Now()
+	if !enrollment.VerifyCode(code, now) {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		u.Logger.Error("Failed to generate recovery codes", "error", err, "userID", userID)
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+	enrollment.Confirm(now, hashes)
+	if err := u.MFA.SaveEnrollment(enrollment); err != nil {
+		u.Logger.Error("Failed to save two-factor enrolment", "error", err, "userID", userID)
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditMFAEnable, userID, entities.AuditTargetUser, userID), client, nil, nil)
+	return codes, nil
+}
+
+// DisableMFA removes the user's second factor. It takes a current TOTP or
+// recovery code, and is refused while the user's role requires one.
+func (u *AuthUseCase) DisableMFA(userID, code string, client interfaces.ClientInfo) error {
+	if u.MFA == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	user, err := u.UserRepo.FindByID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find user to disable two-factor authentication", "error", err, "userID", userID)
+		return errors.New("failed to retrieve user")
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	enrollment, err := u.MFA.FindEnrollment(userID)
+	if err != nil {
+		u.Logger.Error("Failed to find two-factor enrolment", "error", err, "userID", userID)
+		return errors.New("failed to disable two-factor authentication")
+	}
+	if enrollment == nil || !enrollment.IsConfirmed() {
+		return errors.New("two-factor authentication is not set up")
+	}
+
+	required, err := roleRequiresMFA(u.MFA, user.Role)
+	if err != nil {
+		u.Logger.Error("Failed to find roles requiring two-factor authentication", "error", err)
+		return errors.New("failed to disable two-factor authentication")
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	_, ok, err := u.verifySecondFactor(enrollment, code, time.Now())
+	if err != nil {
+		u.Logger.Error("Failed to use two-factor code", "error", err, "userID", userID)
+		return errors.New("failed to disable two-factor authentication")
+	}
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+	if err := u.MFA.DeleteEnrollment(userID); err != nil {
+		u.Logger.Error("Failed to delete two-factor enrolment", "error", err, "userID", userID)
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditMFADisable, userID, entities.AuditTargetUser, userID), client, nil, nil)
+	return nil
+}
+
+// generateRecoveryCodes returns recovery codes formatted as "xxxxx-xxxxx"
+// and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes without the dash or
+// in upper case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
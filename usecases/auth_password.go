@@ -0,0 +1,64 @@
+package usecases
+
+import "gocleanarchitecture/entities"
+
+// verifyDummyPassword does the same work as checking a real password, against
+// a hash made once with the configured hasher. Login calls it for unknown
+// accounts so the response time doesn't reveal whether an account exists.
+func (u *AuthUseCase) verifyDummyPassword(password string) {
+	u.dummyPasswordOnce.Do(func() {
+		hash, err := u.PasswordHasher.Hash("not-a-real-password")
+		if err != nil {
+			u.Logger.Error("Failed to hash dummy password", "error", err)
+		}
+		u.dummyPasswordHash = hash
+	})
+	u.PasswordHasher.Verify(password, u.dummyPasswordHash)
+}
+
+// upgradePasswordHash replaces the user's password hash after a successful
+// login when it was made with an outdated algorithm or parameters, e.g.
+// bcrypt before the switch to Argon2id. Only the hash is written, and only if
+// it hasn't changed since the user was read. Failures are logged only: the
+// old hash keeps working and the upgrade is retried on the next login.
+func (u *AuthUseCase) upgradePasswordHash(user *entities.User, password string) {
+	previous := user.PasswordHash
+	changed, err := user.RehashPassword(u.PasswordHasher, password)
+	if err != nil {
+		u.Logger.Error("Failed to rehash password", "error", err, "userID", user.ID)
+		return
+	}
+	if !changed {
+		return
+	}
+
+	if err := u.UserRepo.UpdatePasswordHash(user.ID, previous, user.PasswordHash); err != nil {
+		u.Logger.Error("Failed to save rehashed password", "error", err, "userID", user.ID)
+		user.PasswordHash = previous
+	}
+}
+
+// checkPassword applies the password policy to a new password and looks it
+// up in the breached password corpus. personalInfo is the account's username
+// and email address. A failed lookup is logged and skips the breach check
+// only, so the corpus going missing doesn't stop sign-ups.
+func (u *AuthUseCase) checkPassword(password string, personalInfo ...string) error {
+	violations := u.PasswordPolicy.Check(password, personalInfo...)
+
+	if u.BreachedPasswords != nil {
+		count, err := breachCount(u.BreachedPasswords, password)
+		if err != nil {
+			u.Logger.Error("Failed to look up breached passwords", "error", err)
+		} else if count > 0 {
+			violations = append(violations, entities.PasswordViolation{
+				Rule:    entities.PasswordRuleBreached,
+				Message: "password has appeared in a data breach and can't be used",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &entities.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
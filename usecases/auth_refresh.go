@@ -0,0 +1,185 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// issueTokens opens a new session for the user and issues its first access
+// token and, when refresh tokens are enabled, refresh token. The session ID is
+// also the refresh token family ID.
+func (u *AuthUseCase) issueTokens(user *entities.User, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	sessionID := uuid.New().String()
+	accessToken, token, err := u.generateAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.startSession(user.ID, sessionID, client, accessToken); err != nil {
+		return nil, err
+	}
+
+	response := &interfaces.LoginResponse{
+		User:  user.Sanitize(),
+		Token: token,
+	}
+	if u.RefreshTokens == nil {
+		return response, nil
+	}
+
+	refreshToken, _, err := u.newRefreshToken(user.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	response.RefreshToken = refreshToken
+	return response, nil
+}
+
+// generateAccessToken signs an access token for a session, carrying the
+// user's current token generation and the role they may act with
+func (u *AuthUseCase) generateAccessToken(user *entities.User, sessionID string) (*entities.AccessToken, string, error) {
+	role, err := effectiveRole(u.MFA, user)
+	if err != nil {
+		u.Logger.Error("Failed to check two-factor requirement", "error", err, "userID", user.ID)
+		return nil, "", errors.New("failed to generate authentication token")
+	}
+	accessToken := &entities.AccessToken{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		SessionID: sessionID,
+		Role:      role,
+	}
+	if u.Revocations != nil {
+		generation, err := u.Revocations.GetUserGeneration(user.ID)
+		if err != nil {
+			u.Logger.Error("Failed to read token generation", "error", err, "userID", user.ID)
+			return nil, "", errors.New("failed to generate authentication token")
+		}
+		accessToken.Generation = generation
+	}
+
+	token, err := u.TokenGenerator.GenerateToken(accessToken)
+	if err != nil {
+		u.Logger.Error("Failed to generate token", "error", err)
+		return nil, "", errors.New("failed to generate authentication token")
+	}
+	return accessToken, token, nil
+}
+
+// newRefreshToken stores a new refresh token and returns its plaintext value
+// and ID
+func (u *AuthUseCase) newRefreshToken(userID, familyID string) (string, string, error) {
+	value, err := newOpaqueToken()
+	if err != nil {
+		u.Logger.Error("Failed to generate refresh token", "error", err)
+		return "", "", errors.New("failed to generate authentication token")
+	}
+
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+	now := time.Now()
+	token := &entities.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.RefreshTokenDuration),
+	}
+	if err := u.RefreshTokens.Save(token); err != nil {
+		u.Logger.Error("Failed to save refresh token", "error", err, "userID", userID)
+		return "", "", errors.New("failed to generate authentication token")
+	}
+	return value, token.ID, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. Each refresh token works once; presenting one that was already
+// exchanged means it leaked, so its whole family is revoked.
+func (u *AuthUseCase) Refresh(refreshToken string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	if u.RefreshTokens == nil {
+		return nil, errors.New("refresh tokens are not enabled")
+	}
+	if refreshToken == "" {
+		return nil, errors.New("refresh token is required")
+	}
+
+	stored, err := u.RefreshTokens.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		u.Logger.Error("Failed to find refresh token", "error", err)
+		return nil, errors.New("failed to refresh token")
+	}
+
+	now := time.Now()
+	if stored == nil || stored.IsRevoked() || stored.IsExpired(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+	if stored.IsRotated() {
+		u.revokeFamily(stored, client)
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	user, err := u.UserRepo.FindByID(stored.UserID)
+	if err != nil {
+		u.Logger.Error("Failed to find user for refresh", "error", err, "userID", stored.UserID)
+		return nil, errors.New("failed to refresh token")
+	}
+	if user == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// A revoked session can't be refreshed even if its token slipped through
+	session, err := u.findSession(stored.FamilyID)
+	if err != nil {
+		return nil, errors.New("failed to refresh token")
+	}
+	if session != nil && !session.IsActive(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	accessToken, token, err := u.generateAccessToken(user, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, newID, err := u.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one exchange of a token may succeed; losing the race is a reuse
+	rotated, err := u.RefreshTokens.MarkRotated(stored.ID, newID, now)
+	if err != nil {
+		u.Logger.Error("Failed to rotate refresh token", "error", err)
+		return nil, errors.New("failed to refresh token")
+	}
+	if !rotated {
+		u.revokeFamily(stored, client)
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	if session != nil {
+		u.touchSession(session, client, accessToken, now)
+	}
+
+	return &interfaces.LoginResponse{
+		User:         user.Sanitize(),
+		Token:        token,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+func (u *AuthUseCase) revokeFamily(token *entities.RefreshToken, client interfaces.ClientInfo) {
+	u.Logger.Error("Refresh token reuse detected, revoking token family",
+		"userID", token.UserID, "familyID", token.FamilyID)
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditRefreshReuse, "", entities.AuditTargetUser, token.UserID),
+		client, nil, map[string]string{"session_id": token.FamilyID})
+	if err := u.RefreshTokens.RevokeFamily(token.FamilyID, time.Now()); err != nil {
+		u.Logger.Error("Failed to revoke refresh token family", "error", err, "familyID", token.FamilyID)
+	}
+}
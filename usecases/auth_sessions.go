@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+// startSession records a new session opened with the given access token
+func (u *AuthUseCase) startSession(userID, sessionID string, client interfaces.ClientInfo, accessToken *entities.AccessToken) error {
+	if u.Sessions == nil {
+		return nil
+	}
+
+	now := time.Now()
+	session := &entities.Session{
+		ID:                   sessionID,
+		UserID:               userID,
+		UserAgent:            client.UserAgent,
+		IP:                   client.IP,
+		CreatedAt:            now,
+		LastSeenAt:           now,
+		ExpiresAt:            u.sessionExpiry(now, accessToken),
+		AccessTokenID:        accessToken.ID,
+		AccessTokenExpiresAt: accessToken.ExpiresAt,
+	}
+	if err := u.Sessions.Save(session); err != nil {
+		u.Logger.Error("Failed to save session", "error", err, "userID", userID)
+		return errors.New("failed to generate authentication token")
+	}
+	return nil
+}
+
+// sessionExpiry is when a session lapses unless it is refreshed: with refresh
+// tokens, when the latest refresh token expires, otherwise with the access token
+func (u *AuthUseCase) sessionExpiry(now time.Time, accessToken *entities.AccessToken) time.Time {
+	if u.RefreshTokens != nil {
+		return now.Add(u.RefreshTokenDuration)
+	}
+	return accessToken.ExpiresAt
+}
+
+// findSession returns the session with the given ID, or nil when sessions are
+// disabled or the session doesn't exist
+func (u *AuthUseCase) findSession(sessionID string) (*entities.Session, error) {
+	if u.Sessions == nil || sessionID == "" {
+		return nil, nil
+	}
+
+	session, err := u.Sessions.FindByID(sessionID)
+	if err != nil {
+		u.Logger.Error("Failed to find session", "error", err, "sessionID", sessionID)
+		return nil, err
+	}
+	return session, nil
+}
+
+// touchSession records a refresh of the session. Failing to update it doesn't
+// fail the refresh.
+func (u *AuthUseCase) touchSession(session *entities.Session, client interfaces.ClientInfo, accessToken *entities.AccessToken, now time.Time) {
+	session.UserAgent = client.UserAgent
+	session.IP = client.IP
+	session.LastSeenAt = now
+	session.ExpiresAt = u.sessionExpiry(now, accessToken)
+	session.AccessTokenID = accessToken.ID
+	session.AccessTokenExpiresAt = accessToken.ExpiresAt
+	if err := u.Sessions.Save(session); err != nil {
+		u.Logger.Error("Failed to update session", "error", err, "sessionID", session.ID)
+	}
+}
+
+// ListSessions returns the user's active sessions, flagging the one making
+// the request
+func (u *AuthUseCase) ListSessions(userID, currentSessionID string) ([]*interfaces.SessionInfo, error) {
+	if u.Sessions == nil {
+		return nil, errors.New("sessions are not enabled")
+	}
+
+	sessions, err := u.Sessions.FindByUserID(userID)
+	if err != nil {
+		u.Logger.Error("Failed to list sessions", "error", err, "userID", userID)
+		return nil, errors.New("failed to retrieve sessions")
+	}
+
+	now := time.Now()
+	result := make([]*interfaces.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.IsActive(now) {
+			continue
+		}
+		result = append(result, &interfaces.SessionInfo{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession signs a session out: its refresh tokens stop working and its
+// latest access token is revoked immediately
+func (u *AuthUseCase) RevokeSession(userID, sessionID string) error {
+	if u.Sessions == nil {
+		return errors.New("sessions are not enabled")
+	}
+
+	session, err := u.findSession(sessionID)
+	if err != nil {
+		return errors.New("failed to revoke session")
+	}
+	// Don't reveal sessions of other users
+	if session == nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+
+	if err := u.endSession(session, time.Now()); err != nil {
+		u.Logger.Error("Failed to revoke session", "error", err, "userID", userID, "sessionID", sessionID)
+		return errors.New("failed to revoke session")
+	}
+	return nil
+}
+
+// endSession marks a session revoked along with its refresh token family and
+// latest access token
+func (u *AuthUseCase) endSession(session *entities.Session, now time.Time) error {
+	if session.RevokedAt == nil {
+		session.RevokedAt = &now
+		if err := u.Sessions.Save(session); err != nil {
+			return err
+		}
+	}
+
+	if u.RefreshTokens != nil {
+		if err := u.RefreshTokens.RevokeFamily(session.ID, now); err != nil {
+			return err
+		}
+	}
+	if u.Revocations != nil && session.AccessTokenID != "" && session.AccessTokenExpiresAt.After(now) {
+		if err := u.Revocations.RevokeToken(session.AccessTokenID, session.AccessTokenExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,31 +4,145 @@ import (
 	"errors"
 	"gocleanarchitecture/entities"
 	"gocleanarchitecture/interfaces"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// TokenGenerator signs access tokens. GenerateToken fills in the token's ID
+// and expiry.
 type TokenGenerator interface {
-	GenerateToken(userID, username, email string) (string, error)
+	GenerateToken(token *entities.AccessToken) (string, error)
 	ValidateToken(token string) (userID string, username string, email string, err error)
 }
 
 type AuthUseCase struct {
-	UserRepo       interfaces.UserRepository
-	TokenGenerator TokenGenerator
-	Logger         Logger
+	UserRepo             interfaces.UserRepository
+	TokenGenerator       TokenGenerator
+	Logger               Logger
+	RefreshTokens        interfaces.RefreshTokenRepository
+	RefreshTokenDuration time.Duration
+	Revocations          interfaces.TokenRevocationRepository
+	Sessions             interfaces.SessionRepository
+	TokenRevoker         UserTokenRevoker
+	Audit                *AuditLog
+	Lockout              *LoginLockout
+	MFA                  interfaces.MFARepository
+	MFAIssuer            string
+	UserTokens           interfaces.UserTokenRepository
+	Mailer               Mailer
+	AppURL               string
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	Identities           interfaces.IdentityRepository
+	APITokens            interfaces.APITokenRepository
+	PasswordHasher       entities.PasswordHasher
+	PasswordPolicy       entities.PasswordPolicy
+	BreachedPasswords    BreachedPasswords
+
+	dummyPasswordOnce sync.Once
+	dummyPasswordHash string
+}
+
+// AuthConfig holds the optional collaborators and settings of AuthUseCase.
+// Features whose repository is nil are disabled.
+type AuthConfig struct {
+	RefreshTokens        interfaces.RefreshTokenRepository
+	RefreshTokenDuration time.Duration
+	Revocations          interfaces.TokenRevocationRepository
+	Sessions             interfaces.SessionRepository
+	Audit                *AuditLog
+	Lockout              *LoginLockout // nil disables brute-force protection
+	MFA                  interfaces.MFARepository
+	MFAIssuer            string // shown next to the account in authenticator apps
+	UserTokens           interfaces.UserTokenRepository
+	Mailer               Mailer // with UserTokens, enables email verification and password reset
+	AppURL               string // frontend base URL for the links in emails
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	Identities           interfaces.IdentityRepository // provider accounts linked to users, for OAuth2 sign-in
+	APITokens            interfaces.APITokenRepository // personal API tokens for scripts and CI jobs
+	PasswordHasher       entities.PasswordHasher       // nil uses Argon2id with the default parameters
+	PasswordPolicy       entities.PasswordPolicy       // rules for new passwords
+	BreachedPasswords    BreachedPasswords             // nil skips the breached password check
+}
+
+// DefaultAuthConfig returns the settings used by NewAuthUseCase
+func DefaultAuthConfig() AuthConfig {
+	return AuthConfig{
+		RefreshTokenDuration: 30 * 24 * time.Hour,
+		MFAIssuer:            "GoCleanArchitecture",
+		AppURL:               "http://localhost:5173",
+		EmailVerificationTTL: 24 * time.Hour,
+		PasswordResetTTL:     time.Hour,
+		PasswordHasher:       entities.DefaultPasswordHasher(),
+		PasswordPolicy:       entities.DefaultPasswordPolicy(),
+	}
 }
 
 func NewAuthUseCase(userRepo interfaces.UserRepository, tokenGen TokenGenerator, logger Logger) interfaces.AuthUseCase {
+	return NewAuthUseCaseWithConfig(userRepo, tokenGen, logger, DefaultAuthConfig())
+}
+
+func NewAuthUseCaseWithConfig(userRepo interfaces.UserRepository, tokenGen TokenGenerator, logger Logger, config AuthConfig) interfaces.AuthUseCase {
+	if config.PasswordHasher == nil {
+		config.PasswordHasher = entities.DefaultPasswordHasher()
+	}
 	return &AuthUseCase{
-		UserRepo:       userRepo,
-		TokenGenerator: tokenGen,
-		Logger:         logger,
+		UserRepo:             userRepo,
+		TokenGenerator:       tokenGen,
+		Logger:               logger,
+		RefreshTokens:        config.RefreshTokens,
+		RefreshTokenDuration: config.RefreshTokenDuration,
+		Revocations:          config.Revocations,
+		Sessions:             config.Sessions,
+		TokenRevoker:         NewUserTokenRevoker(config.Revocations, config.RefreshTokens, config.Sessions, logger),
+		Audit:                config.Audit,
+		Lockout:              config.Lockout,
+		MFA:                  config.MFA,
+		MFAIssuer:            config.MFAIssuer,
+		UserTokens:           config.UserTokens,
+		Mailer:               config.Mailer,
+		AppURL:               config.AppURL,
+		EmailVerificationTTL: config.EmailVerificationTTL,
+		PasswordResetTTL:     config.PasswordResetTTL,
+		Identities:           config.Identities,
+		APITokens:            config.APITokens,
+		PasswordHasher:       config.PasswordHasher,
+		PasswordPolicy:       config.PasswordPolicy,
+		BreachedPasswords:    config.BreachedPasswords,
+	}
+}
+
+// Register creates a new user account. When email is enabled, the user is
+// sent a link to verify their address.
+func (u *AuthUseCase) Register(username, email, password, fullName string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	return u.register(username, email, password, fullName, false, client)
+}
+
+// RegisterVerified creates a new user account whose email address is already
+// verified, e.g. by an OAuth2 provider
+func (u *AuthUseCase) RegisterVerified(username, email, password, fullName string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	return u.register(username, email, password, fullName, true, client)
+}
+
+func (u *AuthUseCase) register(username, email, password, fullName string, emailVerified bool, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
+	if err := u.checkPassword(password, username, email); err != nil {
+		return nil, err
+	}
+
+	user, err := u.createUser(username, email, password, fullName, emailVerified, client)
+	if err != nil {
+		return nil, err
 	}
+
+	// Open a session with access and refresh tokens
+	return u.issueTokens(user, client)
 }
 
-// Register creates a new user account
-func (u *AuthUseCase) Register(username, email, password, fullName string) (*interfaces.LoginResponse, error) {
+// createUser saves a new account and sends the verification email
+func (u *AuthUseCase) createUser(username, email, password, fullName string, emailVerified bool, client interfaces.ClientInfo) (*entities.User, error) {
 	// Check if email already exists
 	existingEmail, err := u.UserRepo.ExistsByEmail(email)
 	if err != nil {
@@ -50,13 +164,16 @@ func (u *AuthUseCase) Register(username, email, password, fullName string) (*int
 	}
 
 	// Create new user using domain factory
-	user, err := entities.NewUser(username, email, password, fullName)
+	user, err := entities.NewUser(username, email, password, fullName, u.PasswordHasher)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate unique ID
 	user.ID = uuid.New().String()
+	if emailVerified {
+		user.MarkEmailVerified(user.CreatedAt)
+	}
 
 	// Save user to repository
 	err = u.UserRepo.Save(user)
@@ -65,21 +182,19 @@ func (u *AuthUseCase) Register(username, email, password, fullName string) (*int
 		return nil, errors.New("failed to create user account")
 	}
 
-	// Generate JWT token
-	token, err := u.TokenGenerator.GenerateToken(user.ID, user.Username, user.Email)
-	if err != nil {
-		u.Logger.Error("Failed to generate token", "error", err)
-		return nil, errors.New("failed to generate authentication token")
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditUserRegister, user.ID, entities.AuditTargetUser, user.ID),
+		client, nil, snapshotUser(user))
+
+	// The account works right away; a failed email can be resent later
+	if !emailVerified && u.emailEnabled() {
+		u.sendVerificationEmail(user)
 	}
 
-	return &interfaces.LoginResponse{
-		User:  user.Sanitize(),
-		Token: token,
-	}, nil
+	return user, nil
 }
 
 // Login authenticates a user and returns a token
-func (u *AuthUseCase) Login(emailOrUsername, password string) (*interfaces.LoginResponse, error) {
+func (u *AuthUseCase) Login(emailOrUsername, password string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
 	// Try to find user by email first, then by username
 	var user *entities.User
 	var err error
@@ -98,26 +213,57 @@ func (u *AuthUseCase) Login(emailOrUsername, password string) (*interfaces.Login
 		}
 	}
 
+	// Locked accounts and IPs are refused before the password is checked
+	now := time.Now()
+	key := accountKey(user, emailOrUsername)
+	if err := u.Lockout.Check(key, client.IP, now); err != nil {
+		return nil, err
+	}
+
+	// Unknown accounts cost the same password check as known ones
 	if user == nil {
+		u.verifyDummyPassword(password)
+		u.loginFailed(key, nil, emailOrUsername, client, now)
 		return nil, errors.New("invalid credentials")
 	}
 
 	// Verify password
-	if !user.VerifyPassword(password) {
+	if !user.VerifyPassword(u.PasswordHasher, password) {
+		u.loginFailed(key, user, emailOrUsername, client, now)
 		return nil, errors.New("invalid credentials")
 	}
+	u.upgradePasswordHash(user, password)
 
-	// Generate JWT token
-	token, err := u.TokenGenerator.GenerateToken(user.ID, user.Username, user.Email)
+	// Users with a second factor get a challenge instead of a session; the
+	// lockout is only cleared once the second factor is verified too
+	response, err := u.completeLogin(user, client)
 	if err != nil {
-		u.Logger.Error("Failed to generate token", "error", err)
-		return nil, errors.New("failed to generate authentication token")
+		return nil, err
+	}
+	if response.MFARequired {
+		return response, nil
+	}
+	u.Lockout.RecordSuccess(key)
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditLogin, user.ID, entities.AuditTargetUser, user.ID), client, nil, nil)
+	return response, nil
+}
+
+// loginFailed counts a failed login towards the lockout and records it; user
+// is nil when no account matched the identifier
+func (u *AuthUseCase) loginFailed(key string, user *entities.User, identifier string, client interfaces.ClientInfo, now time.Time) {
+	if user == nil {
+		u.Lockout.RecordFailure(key, "", client.IP, now)
+		u.Audit.Record(entities.NewAuditEvent(entities.AuditLoginFailed, "", entities.AuditTargetUser, ""),
+			client, nil, map[string]string{"identifier": identifier})
+		return
 	}
 
-	return &interfaces.LoginResponse{
-		User:  user.Sanitize(),
-		Token: token,
-	}, nil
+	lock := u.Lockout.RecordFailure(key, user.ID, client.IP, now)
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditLoginFailed, "", entities.AuditTargetUser, user.ID), client, nil, nil)
+	if lock > 0 {
+		u.Audit.Record(entities.NewAuditEvent(entities.AuditAccountLocked, "", entities.AuditTargetUser, user.ID),
+			client, nil, map[string]int{"lock_seconds": int(lock.Seconds())})
+	}
 }
 
 // GetProfile retrieves a user's profile by ID
@@ -164,7 +310,7 @@ func (u *AuthUseCase) UpdateProfile(userID, fullName, bio, avatarURL string) (*e
 }
 
 // ChangePassword allows a user to change their password
-func (u *AuthUseCase) ChangePassword(userID, oldPassword, newPassword string) error {
+func (u *AuthUseCase) ChangePassword(userID, oldPassword, newPassword string, client interfaces.ClientInfo) error {
 	user, err := u.UserRepo.FindByID(userID)
 	if err != nil {
 		u.Logger.Error("Failed to find user for password change", "error", err, "userID", userID)
@@ -175,9 +321,17 @@ func (u *AuthUseCase) ChangePassword(userID, oldPassword, newPassword string) er
 		return errors.New("user not found")
 	}
 
-	// Use domain method to change password
-	err = user.ChangePassword(oldPassword, newPassword)
-	if err != nil {
+	// Check the old password first, so that only the account owner gets to
+	// try new passwords against the policy and the breach lookup
+	if !user.VerifyPassword(u.PasswordHasher, oldPassword) {
+		return errors.New("old password is incorrect")
+	}
+	if err := u.checkPassword(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	// The old password was just verified, so it isn't hashed again
+	if err := user.ResetPassword(u.PasswordHasher, newPassword); err != nil {
 		return err
 	}
 
@@ -188,6 +342,13 @@ func (u *AuthUseCase) ChangePassword(userID, oldPassword, newPassword string) er
 		return errors.New("failed to change password")
 	}
 
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPasswordChange, userID, entities.AuditTargetUser, userID), client, nil, nil)
+
+	// Sessions opened with the old password must not outlive it
+	if err := u.TokenRevoker.RevokeUserTokens(userID); err != nil {
+		u.Logger.Error("Failed to revoke sessions after password change", "error", err, "userID", userID)
+	}
+
 	return nil
 }
 
@@ -221,24 +382,18 @@ func (u *AuthUseCase) GetUserByEmail(email string) (*entities.User, error) {
 	return user.Sanitize(), nil
 }
 
-// GenerateTokenForUser generates a JWT token for an existing user (for OAuth2)
-func (u *AuthUseCase) GenerateTokenForUser(userID string) (string, error) {
+// GenerateTokenForUser opens a session for an existing user (for OAuth2), or
+// returns a two-factor challenge when the user has a second factor
+func (u *AuthUseCase) GenerateTokenForUser(userID string, client interfaces.ClientInfo) (*interfaces.LoginResponse, error) {
 	user, err := u.UserRepo.FindByID(userID)
 	if err != nil {
 		u.Logger.Error("Failed to find user for token generation", "error", err, "userID", userID)
-		return "", errors.New("failed to retrieve user")
+		return nil, errors.New("failed to retrieve user")
 	}
 
 	if user == nil {
-		return "", errors.New("user not found")
-	}
-
-	// Generate JWT token
-	token, err := u.TokenGenerator.GenerateToken(user.ID, user.Username, user.Email)
-	if err != nil {
-		u.Logger.Error("Failed to generate token", "error", err)
-		return "", errors.New("failed to generate authentication token")
+		return nil, errors.New("user not found")
 	}
 
-	return token, nil
+	return u.completeLogin(user, client)
 }
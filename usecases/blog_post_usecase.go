@@ -12,37 +12,64 @@ type Logger interface {
 }
 
 type BlogPostUseCaseInterface interface {
-	CreateBlogPost(id, title, content, authorID string) (*entities.BlogPost, error)
+	CreateBlogPost(id, title, content, authorID string, client interfaces.ClientInfo) (*entities.BlogPost, error)
 	GetAllBlogPosts() ([]*entities.BlogPost, error)
 	GetBlogPost(id string) (*entities.BlogPost, error)
-	UpdateBlogPost(id, title, content, userID string) (*entities.BlogPost, error)
-	DeleteBlogPost(id, userID string) error
+	UpdateBlogPost(id, title, content, userID, reason string, client interfaces.ClientInfo) (*entities.BlogPost, error)
+	DeleteBlogPost(id, userID, reason string, client interfaces.ClientInfo) error
 }
 
 type BlogPostUseCase struct {
-	Repo   interfaces.BlogPostRepository
-	Logger Logger
+	Repo       interfaces.BlogPostRepository
+	Logger     Logger
+	Events     EventPublisher
+	Transactor Transactor
+	Policy     *Policy
+	Audit      *AuditLog
 }
 
-func NewBlogPostUseCase(repo interfaces.BlogPostRepository, logger Logger) BlogPostUseCaseInterface {
+func NewBlogPostUseCase(repo interfaces.BlogPostRepository, logger Logger, events EventPublisher, transactor Transactor, policy *Policy, audit *AuditLog) BlogPostUseCaseInterface {
 	return &BlogPostUseCase{
-		Repo:   repo,
-		Logger: logger,
+		Repo:       repo,
+		Logger:     logger,
+		Events:     events,
+		Transactor: transactor,
+		Policy:     policy,
+		Audit:      audit,
 	}
 }
 
-func (u *BlogPostUseCase) CreateBlogPost(id, title, content, authorID string) (*entities.BlogPost, error) {
+func (u *BlogPostUseCase) CreateBlogPost(id, title, content, authorID string, client interfaces.ClientInfo) (*entities.BlogPost, error) {
+	allowed, err := u.Policy.UserHasPermission(authorID, entities.PermissionPostPublish)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("unauthorized: you are not allowed to publish blog posts")
+	}
+	if err := u.Policy.RequireVerifiedEmail(authorID, entities.RestrictionPost); err != nil {
+		return nil, err
+	}
+
 	// Use domain factory method
 	blogPost, err := entities.NewBlogPost(id, title, content, authorID)
 	if err != nil {
 		return nil, err
 	}
 
-	err = u.Repo.Save(blogPost)
+	err = u.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.BlogPosts.Save(blogPost); err != nil {
+			return err
+		}
+		return tx.Events.Publish(entities.NewPostPublished(blogPost))
+	})
 	if err != nil {
 		u.Logger.Error("Failed to create blog post", "error", err)
 		return nil, err
 	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPostCreate, authorID, entities.AuditTargetPost, blogPost.ID),
+		client, nil, snapshotPost(blogPost))
 	return blogPost, nil
 }
 
@@ -64,7 +91,9 @@ func (u *BlogPostUseCase) GetBlogPost(id string) (*entities.BlogPost, error) {
 	return blogPost, nil
 }
 
-func (u *BlogPostUseCase) UpdateBlogPost(id, title, content, userID string) (*entities.BlogPost, error) {
+// UpdateBlogPost edits a post. Staff editing someone else's post must give a
+// reason, which is recorded and sent to the author.
+func (u *BlogPostUseCase) UpdateBlogPost(id, title, content, userID, reason string, client interfaces.ClientInfo) (*entities.BlogPost, error) {
 	// Get existing blog post
 	blogPost, err := u.Repo.FindByID(id)
 	if err != nil {
@@ -75,26 +104,50 @@ func (u *BlogPostUseCase) UpdateBlogPost(id, title, content, userID string) (*en
 		return nil, errors.New("blog post not found")
 	}
 
-	// Validate ownership
-	if !blogPost.IsAuthor(userID) {
+	// Authors edit their own posts; editing others' needs post:edit:any
+	allowed, err := u.Policy.CanModify(userID, blogPost.AuthorID, entities.PermissionPostEditAny, client)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
 		return nil, errors.New("unauthorized: you can only update your own blog posts")
 	}
 
+	events := []entities.DomainEvent{}
+	if !blogPost.IsAuthor(userID) {
+		if reason, err = entities.ValidateModerationReason(reason); err != nil {
+			return nil, err
+		}
+		events = append(events, entities.NewPostModerated(blogPost, userID, entities.ModerationActionEdit, reason))
+	}
+
 	// Use domain method to update
+	before := snapshotPost(blogPost)
 	err = blogPost.Update(title, content)
 	if err != nil {
 		return nil, err
 	}
 
-	err = u.Repo.Save(blogPost)
+	events = append(events, entities.NewPostUpdated(blogPost, userID))
+	err = u.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.BlogPosts.Save(blogPost); err != nil {
+			return err
+		}
+		return tx.Events.Publish(events...)
+	})
 	if err != nil {
 		u.Logger.Error("Failed to update blog post", "error", err, "id", blogPost.ID)
 		return nil, err
 	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPostUpdate, userID, entities.AuditTargetPost, blogPost.ID),
+		client, before, snapshotPost(blogPost))
 	return blogPost, nil
 }
 
-func (u *BlogPostUseCase) DeleteBlogPost(id, userID string) error {
+// DeleteBlogPost removes a post. Staff deleting someone else's post must give
+// a reason, which is recorded and sent to the author.
+func (u *BlogPostUseCase) DeleteBlogPost(id, userID, reason string, client interfaces.ClientInfo) error {
 	// Get existing blog post to validate ownership
 	blogPost, err := u.Repo.FindByID(id)
 	if err != nil {
@@ -105,15 +158,41 @@ func (u *BlogPostUseCase) DeleteBlogPost(id, userID string) error {
 		return errors.New("blog post not found")
 	}
 
-	// Validate ownership
-	if !blogPost.IsAuthor(userID) {
+	// Authors delete their own posts; deleting others' needs post:delete:any
+	allowed, err := u.Policy.CanModify(userID, blogPost.AuthorID, entities.PermissionPostDeleteAny, client)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("unauthorized: you can only delete your own blog posts")
 	}
 
-	err = u.Repo.Delete(id)
+	events := []entities.DomainEvent{entities.NewPostDeleted(blogPost, userID)}
+	if !blogPost.IsAuthor(userID) {
+		if reason, err = entities.ValidateModerationReason(reason); err != nil {
+			return err
+		}
+		events = append(events, entities.NewPostModerated(blogPost, userID, entities.ModerationActionDelete, reason))
+	}
+
+	err = u.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.BlogPosts.Delete(id); err != nil {
+			return err
+		}
+		return tx.Events.Publish(events...)
+	})
 	if err != nil {
 		u.Logger.Error("Failed to delete blog post", "error", err, "id", id)
 		return err
 	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditPostDelete, userID, entities.AuditTargetPost, id),
+		client, snapshotPost(blogPost), nil)
 	return nil
 }
+
+// transaction returns the transactor used for writes, falling back to the
+// plain repository when none is configured
+func (u *BlogPostUseCase) transaction() Transactor {
+	return transactorOrDirect(u.Transactor, &Tx{BlogPosts: u.Repo, Events: u.Events}, u.Logger)
+}
@@ -0,0 +1,28 @@
+package usecases
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+)
+
+// BreachedPasswords is the port password checks look up breached passwords
+// through, by k-anonymity like the Pwned Passwords range API: given the first
+// five hex digits of a password's SHA-1 hash, Range returns the remaining 35
+// of every breached hash sharing them, with how often each was seen. The
+// implementation in frameworks/auth searches a local copy of the corpus.
+type BreachedPasswords interface {
+	Range(prefix string) (map[string]int, error)
+}
+
+// breachCount returns how often password appears in breaches, 0 if never
+func breachCount(breached BreachedPasswords, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := breached.Range(hash[:5])
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[hash[5:]], nil
+}
@@ -7,11 +7,11 @@ import (
 )
 
 type CommentUseCaseInterface interface {
-	CreateComment(id, blogPostID, authorID, content, parentID string) (*entities.Comment, error)
+	CreateComment(id, blogPostID, authorID, content, parentID string, client interfaces.ClientInfo) (*entities.Comment, error)
 	GetCommentsByBlogPostID(blogPostID string) ([]*entities.Comment, error)
 	GetRepliesByCommentID(commentID string) ([]*entities.Comment, error)
-	UpdateComment(id, content, userID string) (*entities.Comment, error)
-	DeleteComment(id, userID string) error
+	UpdateComment(id, content, userID string, client interfaces.ClientInfo) (*entities.Comment, error)
+	DeleteComment(id, userID, reason string, client interfaces.ClientInfo) error
 }
 
 type CommentUseCase struct {
@@ -19,19 +19,31 @@ type CommentUseCase struct {
 	BlogPostRepo interfaces.BlogPostRepository
 	UserRepo     interfaces.UserRepository
 	Logger       Logger
+	Events       EventPublisher
+	Transactor   Transactor
+	Policy       *Policy
+	Audit        *AuditLog
 }
 
-func NewCommentUseCase(commentRepo interfaces.CommentRepository, blogPostRepo interfaces.BlogPostRepository, userRepo interfaces.UserRepository, logger Logger) *CommentUseCase {
+func NewCommentUseCase(commentRepo interfaces.CommentRepository, blogPostRepo interfaces.BlogPostRepository, userRepo interfaces.UserRepository, logger Logger, events EventPublisher, transactor Transactor, policy *Policy, audit *AuditLog) *CommentUseCase {
 	return &CommentUseCase{
 		CommentRepo:  commentRepo,
 		BlogPostRepo: blogPostRepo,
 		UserRepo:     userRepo,
 		Logger:       logger,
+		Events:       events,
+		Transactor:   transactor,
+		Policy:       policy,
+		Audit:        audit,
 	}
 }
 
 // CreateComment creates a new comment on a blog post
-func (uc *CommentUseCase) CreateComment(id, blogPostID, authorID, content, parentID string) (*entities.Comment, error) {
+func (uc *CommentUseCase) CreateComment(id, blogPostID, authorID, content, parentID string, client interfaces.ClientInfo) (*entities.Comment, error) {
+	if err := uc.Policy.RequireVerifiedEmail(authorID, entities.RestrictionComment); err != nil {
+		return nil, err
+	}
+
 	// Validate blog post exists
 	blogPost, err := uc.BlogPostRepo.FindByID(blogPostID)
 	if err != nil {
@@ -88,13 +100,21 @@ func (uc *CommentUseCase) CreateComment(id, blogPostID, authorID, content, paren
 	}
 
 	// Save comment
-	if err := uc.CommentRepo.Save(comment); err != nil {
+	err = uc.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.Comments.Save(comment); err != nil {
+			return err
+		}
+		return tx.Events.Publish(entities.NewCommentCreated(comment))
+	})
+	if err != nil {
 		uc.Logger.Error("Failed to save comment", map[string]interface{}{
 			"error": err.Error(),
 		})
 		return nil, errors.New("failed to create comment")
 	}
 
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditCommentCreate, authorID, entities.AuditTargetComment, comment.ID),
+		client, nil, snapshotComment(comment))
 	return comment, nil
 }
 
@@ -135,7 +155,7 @@ func (uc *CommentUseCase) GetRepliesByCommentID(commentID string) ([]*entities.C
 }
 
 // UpdateComment updates a comment's content (only by the author)
-func (uc *CommentUseCase) UpdateComment(id, content, userID string) (*entities.Comment, error) {
+func (uc *CommentUseCase) UpdateComment(id, content, userID string, client interfaces.ClientInfo) (*entities.Comment, error) {
 	if id == "" {
 		return nil, errors.New("comment ID is required")
 	}
@@ -164,12 +184,19 @@ func (uc *CommentUseCase) UpdateComment(id, content, userID string) (*entities.C
 	}
 
 	// Update content
+	before := snapshotComment(comment)
 	if err := comment.Update(content); err != nil {
 		return nil, err
 	}
 
 	// Save updated comment
-	if err := uc.CommentRepo.Save(comment); err != nil {
+	err = uc.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.Comments.Save(comment); err != nil {
+			return err
+		}
+		return tx.Events.Publish(entities.NewCommentUpdated(comment))
+	})
+	if err != nil {
 		uc.Logger.Error("Failed to update comment", map[string]interface{}{
 			"error":     err.Error(),
 			"commentID": id,
@@ -177,11 +204,15 @@ func (uc *CommentUseCase) UpdateComment(id, content, userID string) (*entities.C
 		return nil, errors.New("failed to update comment")
 	}
 
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditCommentUpdate, userID, entities.AuditTargetComment, id),
+		client, before, snapshotComment(comment))
 	return comment, nil
 }
 
-// DeleteComment deletes a comment (only by the author or admin)
-func (uc *CommentUseCase) DeleteComment(id, userID string) error {
+// DeleteComment deletes a comment (only by the author or a user allowed to
+// moderate comments). Moderators must give a reason, which is recorded and
+// sent to the author.
+func (uc *CommentUseCase) DeleteComment(id, userID, reason string, client interfaces.ClientInfo) error {
 	if id == "" {
 		return errors.New("comment ID is required")
 	}
@@ -204,26 +235,32 @@ func (uc *CommentUseCase) DeleteComment(id, userID string) error {
 		return errors.New("comment not found")
 	}
 
-	// Check if user is the author or admin
-	user, err := uc.UserRepo.FindByID(userID)
+	// Check if user is the author or a moderator
+	allowed, err := uc.policy().CanModify(userID, comment.AuthorID, entities.PermissionCommentModerate, client)
 	if err != nil {
-		uc.Logger.Error("Failed to fetch user", map[string]interface{}{
-			"error":  err.Error(),
-			"userID": userID,
-		})
 		return errors.New("failed to verify permissions")
 	}
 
-	if user == nil {
-		return errors.New("user not found")
+	if !allowed {
+		return errors.New("unauthorized: only the author or a moderator can delete this comment")
 	}
 
-	if !comment.IsAuthor(userID) && !user.IsAdmin() {
-		return errors.New("unauthorized: only the author or admin can delete this comment")
+	events := []entities.DomainEvent{entities.NewCommentDeleted(comment, userID)}
+	if !comment.IsAuthor(userID) {
+		if reason, err = entities.ValidateModerationReason(reason); err != nil {
+			return err
+		}
+		events = append(events, entities.NewCommentModerated(comment, userID, reason))
 	}
 
 	// Delete comment
-	if err := uc.CommentRepo.Delete(id); err != nil {
+	err = uc.transaction().WithinTransaction(func(tx *Tx) error {
+		if err := tx.Comments.Delete(id); err != nil {
+			return err
+		}
+		return tx.Events.Publish(events...)
+	})
+	if err != nil {
 		uc.Logger.Error("Failed to delete comment", map[string]interface{}{
 			"error":     err.Error(),
 			"commentID": id,
@@ -231,5 +268,27 @@ func (uc *CommentUseCase) DeleteComment(id, userID string) error {
 		return errors.New("failed to delete comment")
 	}
 
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditCommentDelete, userID, entities.AuditTargetComment, id),
+		client, snapshotComment(comment), nil)
 	return nil
 }
+
+// transaction returns the transactor used for writes, falling back to the
+// plain repositories when none is configured
+func (uc *CommentUseCase) transaction() Transactor {
+	return transactorOrDirect(uc.Transactor, &Tx{
+		BlogPosts: uc.BlogPostRepo,
+		Comments:  uc.CommentRepo,
+		Users:     uc.UserRepo,
+		Events:    uc.Events,
+	}, uc.Logger)
+}
+
+// policy returns the configured policy, falling back to the built-in roles
+// of the comment's users when none is configured
+func (uc *CommentUseCase) policy() *Policy {
+	if uc.Policy != nil {
+		return uc.Policy
+	}
+	return &Policy{Users: uc.UserRepo, Logger: uc.Logger}
+}
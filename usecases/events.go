@@ -0,0 +1,36 @@
+package usecases
+
+import "gocleanarchitecture/entities"
+
+// EventPublisher is the port use cases use to announce domain events.
+// Delivery (WebSocket, notifications, webhooks...) is handled by subscribers
+// registered in the outer layers.
+type EventPublisher interface {
+	Publish(events ...entities.DomainEvent) error
+}
+
+// noopPublisher is used when no publisher is configured
+type noopPublisher struct{}
+
+func (noopPublisher) Publish(events ...entities.DomainEvent) error { return nil }
+
+func publisherOrNoop(p EventPublisher) EventPublisher {
+	if p == nil {
+		return noopPublisher{}
+	}
+	return p
+}
+
+// bestEffortPublisher logs publish failures instead of returning them. Without
+// a transaction there is nothing to roll back once the entity is written.
+type bestEffortPublisher struct {
+	publisher EventPublisher
+	logger    Logger
+}
+
+func (p bestEffortPublisher) Publish(events ...entities.DomainEvent) error {
+	if err := publisherOrNoop(p.publisher).Publish(events...); err != nil {
+		p.logger.Error("Failed to publish events", "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"strings"
+	"time"
+)
+
+// UserNotifier sends a notification to a user
+type UserNotifier interface {
+	Notify(userID, notificationType, message string) error
+}
+
+// LoginLockout throttles failed logins per account and per client IP. When
+// the store fails, logins are let through rather than locking everyone out.
+// A nil LoginLockout never locks.
+type LoginLockout struct {
+	Repo     interfaces.LoginAttemptRepository
+	Account  entities.LockoutPolicy
+	IP       entities.LockoutPolicy
+	Notifier UserNotifier // nil skips the "account locked" notification
+	Logger   Logger
+}
+
+func NewLoginLockout(repo interfaces.LoginAttemptRepository, account, ip entities.LockoutPolicy, notifier UserNotifier, logger Logger) *LoginLockout {
+	return &LoginLockout{
+		Repo:     repo,
+		Account:  account,
+		IP:       ip,
+		Notifier: notifier,
+		Logger:   logger,
+	}
+}
+
+// DefaultAccountLockoutPolicy locks an account for a minute after 5 failures,
+// doubling up to an hour
+func DefaultAccountLockoutPolicy() entities.LockoutPolicy {
+	return entities.LockoutPolicy{
+		MaxFailures: 5,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      24 * time.Hour,
+	}
+}
+
+// DefaultIPLockoutPolicy allows more failures per IP, since many users may
+// share one address
+func DefaultIPLockoutPolicy() entities.LockoutPolicy {
+	return entities.LockoutPolicy{
+		MaxFailures: 20,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      24 * time.Hour,
+	}
+}
+
+// accountKey identifies the account a login targets. Unknown identifiers are
+// throttled like accounts, so locking doesn't reveal which accounts exist.
+func accountKey(user *entities.User, identifier string) string {
+	if user != nil {
+		return "account:" + user.ID
+	}
+	return "identifier:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func ipKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+// Check returns a *entities.LoginLockedError while the account or the IP is
+// locked
+func (l *LoginLockout) Check(account, ip string, now time.Time) error {
+	if l == nil || l.Repo == nil {
+		return nil
+	}
+
+	var retryAfter time.Duration
+	for _, key := range []string{account, ipKey(ip)} {
+		if key == "" {
+			continue
+		}
+		throttle, err := l.Repo.Find(key)
+		if err != nil {
+			l.Logger.Error("Failed to check login attempts", "error", err, "key", key)
+			continue
+		}
+		if throttle != nil && throttle.RetryAfter(now) > retryAfter {
+			retryAfter = throttle.RetryAfter(now)
+		}
+	}
+	if retryAfter > 0 {
+		return &entities.LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login against the account and the IP. It
+// returns the account lock it started, or zero. The user is notified the
+// first time their account is locked; userID is empty for unknown accounts.
+func (l *LoginLockout) RecordFailure(account, userID, ip string, now time.Time) time.Duration {
+	if l == nil || l.Repo == nil {
+		return 0
+	}
+
+	l.recordFailure(ipKey(ip), l.IP, now)
+	throttle, lock := l.recordFailure(account, l.Account, now)
+	if lock > 0 && userID != "" && throttle.Failures == l.Account.MaxFailures {
+		l.notifyLocked(userID, throttle.Failures, lock)
+	}
+	return lock
+}
+
+func (l *LoginLockout) recordFailure(key string, policy entities.LockoutPolicy, now time.Time) (*entities.LoginThrottle, time.Duration) {
+	if key == "" {
+		return nil, 0
+	}
+
+	throttle, err := l.Repo.Find(key)
+	if err != nil {
+		l.Logger.Error("Failed to find login attempts", "error", err, "key", key)
+		return nil, 0
+	}
+	if throttle == nil {
+		throttle = entities.NewLoginThrottle(key)
+	}
+
+	lock := throttle.RecordFailure(now, policy)
+	if err := l.Repo.Save(throttle); err != nil {
+		l.Logger.Error("Failed to save login attempts", "error", err, "key", key)
+	}
+	return throttle, lock
+}
+
+func (l *LoginLockout) notifyLocked(userID string, failures int, lock time.Duration) {
+	if l.Notifier == nil {
+		return
+	}
+	message := fmt.Sprintf("Your account was locked for %s after %d failed sign-in attempts. If this wasn't you, consider changing your password.",
+		lock.Round(time.Second), failures)
+	if err := l.Notifier.Notify(userID, entities.NotificationAccountLocked, message); err != nil {
+		l.Logger.Error("Failed to notify user of account lock", "error", err, "userID", userID)
+	}
+}
+
+// RecordSuccess clears the account's failures. The IP's failures are kept,
+// so an attacker can't reset them by signing in to their own account.
+func (l *LoginLockout) RecordSuccess(account string) {
+	if l == nil || l.Repo == nil {
+		return
+	}
+	if err := l.Repo.Delete(account); err != nil {
+		l.Logger.Error("Failed to clear login attempts", "error", err, "key", account)
+	}
+}
+
+// Unlock clears the failures and lock of a user's account
+func (l *LoginLockout) Unlock(userID string) error {
+	if l == nil || l.Repo == nil {
+		return errors.New("login lockout is not enabled")
+	}
+	if err := l.Repo.Delete("account:" + userID); err != nil {
+		l.Logger.Error("Failed to unlock account", "error", err, "userID", userID)
+		return errors.New("failed to unlock account")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package usecases
+
+// EmailMessage is a plain-text email to one recipient
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is the port use cases send email through. Implementations live in
+// frameworks/mail: SMTP for production, and outboxes that keep messages in
+// memory or write them to files for development and tests.
+type Mailer interface {
+	Send(message EmailMessage) error
+}
@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+// ModerationUseCase keeps the log of staff actions on other users' content.
+// It subscribes to ContentModerated events, so an entry is only written once
+// the change itself has been committed.
+type ModerationUseCase struct {
+	Repo   interfaces.ModerationRepository
+	Logger Logger
+}
+
+func NewModerationUseCase(repo interfaces.ModerationRepository, logger Logger) *ModerationUseCase {
+	return &ModerationUseCase{
+		Repo:   repo,
+		Logger: logger,
+	}
+}
+
+// HandleEvent records ContentModerated events and ignores the rest
+func (uc *ModerationUseCase) HandleEvent(event entities.DomainEvent) error {
+	moderated, ok := event.(*entities.ContentModerated)
+	if !ok {
+		return nil
+	}
+
+	if err := uc.Repo.Save(entities.NewModerationAction(moderated)); err != nil {
+		uc.Logger.Error("Failed to record moderation action", "error", err, "eventID", moderated.EventID())
+		return err
+	}
+	return nil
+}
+
+// ListActions returns the moderation log, newest first
+func (uc *ModerationUseCase) ListActions(filter interfaces.ModerationFilter) ([]*entities.ModerationAction, error) {
+	actions, err := uc.Repo.Find(filter)
+	if err != nil {
+		uc.Logger.Error("Failed to fetch moderation actions", "error", err)
+		return nil, errors.New("failed to fetch moderation actions")
+	}
+	return actions, nil
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+// NotificationUseCase stores messages for users and lets them read them
+type NotificationUseCase struct {
+	Repo   interfaces.NotificationRepository
+	Logger Logger
+}
+
+func NewNotificationUseCase(repo interfaces.NotificationRepository, logger Logger) *NotificationUseCase {
+	return &NotificationUseCase{
+		Repo:   repo,
+		Logger: logger,
+	}
+}
+
+// Notify stores a new notification for a user
+func (uc *NotificationUseCase) Notify(userID, notificationType, message string) error {
+	return uc.save(entities.NewNotification(userID, notificationType, message))
+}
+
+// HandleEvent tells authors when staff changed or removed their content
+func (uc *NotificationUseCase) HandleEvent(event entities.DomainEvent) error {
+	moderated, ok := event.(*entities.ContentModerated)
+	if !ok {
+		return nil
+	}
+
+	// The event ID keeps a redelivered event from notifying twice
+	existing, err := uc.Repo.FindByID(moderated.EventID())
+	if err != nil {
+		uc.Logger.Error("Failed to find notification", "error", err, "eventID", moderated.EventID())
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	notification := entities.NewNotification(moderated.AuthorID, entities.NotificationContentModerated, moderationMessage(moderated))
+	notification.ID = moderated.EventID()
+	return uc.save(notification)
+}
+
+func moderationMessage(event *entities.ContentModerated) string {
+	content := "Your comment"
+	if event.TargetType == entities.ModerationTargetPost {
+		content = fmt.Sprintf("Your post %q", event.Title)
+	}
+	action := "removed"
+	if event.Action == entities.ModerationActionEdit {
+		action = "edited"
+	}
+	return fmt.Sprintf("%s was %s by a moderator. Reason: %s", content, action, event.Reason)
+}
+
+// ListNotifications returns the user's notifications, newest first
+func (uc *NotificationUseCase) ListNotifications(userID string, unreadOnly bool) ([]*entities.Notification, error) {
+	notifications, err := uc.Repo.FindByUserID(userID, unreadOnly)
+	if err != nil {
+		uc.Logger.Error("Failed to fetch notifications", "error", err, "userID", userID)
+		return nil, errors.New("failed to fetch notifications")
+	}
+	return notifications, nil
+}
+
+// MarkRead marks one of the user's notifications as read
+func (uc *NotificationUseCase) MarkRead(userID, id string) error {
+	notification, err := uc.Repo.FindByID(id)
+	if err != nil {
+		uc.Logger.Error("Failed to find notification", "error", err, "id", id)
+		return errors.New("failed to update notification")
+	}
+	// Don't reveal notifications of other users
+	if notification == nil || notification.UserID != userID {
+		return errors.New("notification not found")
+	}
+
+	notification.MarkRead(time.Now())
+	if err := uc.save(notification); err != nil {
+		return errors.New("failed to update notification")
+	}
+	return nil
+}
+
+func (uc *NotificationUseCase) save(notification *entities.Notification) error {
+	if err := uc.Repo.Save(notification); err != nil {
+		uc.Logger.Error("Failed to save notification", "error", err, "userID", notification.UserID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// authorizationCodeDuration is how long a client has to exchange a code
+const authorizationCodeDuration = 5 * time.Minute
+
+// AccessTokenVerifier checks a signed access token and returns what it asserts
+type AccessTokenVerifier interface {
+	VerifyAccessToken(token string) (*entities.AccessToken, error)
+}
+
+// OAuthServerUseCase makes the blog an OAuth2 authorization server for
+// third-party apps. Only the authorization code grant is supported, and every
+// client must use PKCE with S256.
+type OAuthServerUseCase struct {
+	Clients     interfaces.OAuthClientRepository
+	Codes       interfaces.OAuthCodeRepository
+	UserRepo    interfaces.UserRepository
+	Tokens      TokenGenerator
+	Verifier    AccessTokenVerifier
+	Revocations interfaces.TokenRevocationRepository // nil: tokens are valid until they expire
+	Audit       *AuditLog
+	Logger      Logger
+}
+
+func NewOAuthServerUseCase(clients interfaces.OAuthClientRepository, codes interfaces.OAuthCodeRepository, userRepo interfaces.UserRepository, tokens TokenGenerator, verifier AccessTokenVerifier, revocations interfaces.TokenRevocationRepository, audit *AuditLog, logger Logger) *OAuthServerUseCase {
+	return &OAuthServerUseCase{
+		Clients:     clients,
+		Codes:       codes,
+		UserRepo:    userRepo,
+		Tokens:      tokens,
+		Verifier:    verifier,
+		Revocations: revocations,
+		Audit:       audit,
+		Logger:      logger,
+	}
+}
+
+// RegisterClient registers a third-party app. Confidential clients get a
+// secret, returned once; only its hash is stored.
+func (uc *OAuthServerUseCase) RegisterClient(actor interfaces.Actor, name string, redirectURIs []string, scopes []entities.Scope, confidential bool) (*entities.OAuthClient, string, error) {
+	var secret, secretHash string
+	if confidential {
+		var err error
+		if secret, err = newOpaqueToken(); err != nil {
+			uc.Logger.Error("Failed to generate client secret", "error", err)
+			return nil, "", errors.New("failed to register client")
+		}
+		secretHash = hashToken(secret)
+	}
+
+	client, err := entities.NewOAuthClient(uuid.New().String(), name, redirectURIs, scopes, secretHash, actor.UserID, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if err := uc.Clients.Save(client); err != nil {
+		uc.Logger.Error("Failed to save client", "error", err, "name", client.Name)
+		return nil, "", errors.New("failed to register client")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditOAuthClientCreate, actor.UserID, entities.AuditTargetOAuthClient, client.ID),
+		actor.Client, nil, client)
+	return client, secret, nil
+}
+
+// ListClients returns the registered clients, oldest first
+func (uc *OAuthServerUseCase) ListClients() ([]*entities.OAuthClient, error) {
+	clients, err := uc.Clients.FindAll()
+	if err != nil {
+		uc.Logger.Error("Failed to list clients", "error", err)
+		return nil, errors.New("failed to retrieve clients")
+	}
+	if clients == nil {
+		clients = []*entities.OAuthClient{}
+	}
+	return clients, nil
+}
+
+// DeleteClient removes a client. Its codes can no longer be exchanged, and
+// its tokens are refused (see ClientExists) and reported inactive.
+func (uc *OAuthServerUseCase) DeleteClient(actor interfaces.Actor, id string) error {
+	client, err := uc.findClient(id)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.New("client not found")
+	}
+
+	deleted, err := uc.Clients.Delete(id)
+	if err != nil {
+		uc.Logger.Error("Failed to delete client", "error", err, "clientID", id)
+		return errors.New("failed to delete client")
+	}
+	if !deleted {
+		return errors.New("client not found")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditOAuthClientDelete, actor.UserID, entities.AuditTargetOAuthClient, id),
+		actor.Client, client, nil)
+	return nil
+}
+
+// Authorize checks a client's request and returns what the consent screen
+// should ask the signed-in user
+func (uc *OAuthServerUseCase) Authorize(request interfaces.AuthorizationRequest) (*interfaces.ConsentPrompt, error) {
+	client, scopes, err := uc.checkAuthorization(request)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.ConsentPrompt{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		Scopes:      scopes,
+		RedirectURI: request.RedirectURI,
+	}, nil
+}
+
+// Consent records the user's answer to a client's request and returns where
+// to send the browser: back to the client with a code, or with access_denied
+func (uc *OAuthServerUseCase) Consent(userID string, request interfaces.AuthorizationRequest, approved bool, info interfaces.ClientInfo) (string, error) {
+	client, scopes, err := uc.checkAuthorization(request)
+	if err != nil {
+		return "", err
+	}
+	if !approved {
+		return request.RedirectWith(url.Values{"error": {"access_denied"}}), nil
+	}
+
+	code, err := newOpaqueToken()
+	if err != nil {
+		uc.Logger.Error("Failed to generate authorization code", "error", err)
+		return "", errors.New("failed to issue authorization code")
+	}
+	now := time.Now()
+	authorization := &entities.OAuthAuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: request.CodeChallenge,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(authorizationCodeDuration),
+	}
+	if err := uc.Codes.Save(authorization); err != nil {
+		uc.Logger.Error("Failed to save authorization code", "error", err, "clientID", client.ID, "userID", userID)
+		return "", errors.New("failed to issue authorization code")
+	}
+
+	uc.Audit.Record(entities.NewAuditEvent(entities.AuditOAuthConsent, userID, entities.AuditTargetOAuthClient, client.ID),
+		info, nil, map[string]interface{}{"scopes": scopes})
+	return request.RedirectWith(url.Values{"code": {code}}), nil
+}
+
+// checkAuthorization validates an authorization request, returning the client
+// and the scopes asked for. Errors about the client or redirect URI mustn't
+// be reported by redirecting, as the redirect URI can't be trusted.
+func (uc *OAuthServerUseCase) checkAuthorization(request interfaces.AuthorizationRequest) (*entities.OAuthClient, []entities.Scope, error) {
+	client, err := uc.findClient(request.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client == nil {
+		return nil, nil, errors.New("unknown client")
+	}
+	if !client.HasRedirectURI(request.RedirectURI) {
+		return nil, nil, errors.New("redirect URI is not registered for this client")
+	}
+
+	if request.ResponseType != "code" {
+		return nil, nil, errors.New("response type must be code")
+	}
+	// A SHA-256 challenge is 43 characters of unpadded base64url
+	if request.CodeChallengeMethod != "S256" || len(request.CodeChallenge) != 43 {
+		return nil, nil, errors.New("a PKCE code challenge using S256 is required")
+	}
+
+	scopes := client.Scopes
+	if request.Scope != "" {
+		if scopes, err = entities.ParseScopes(request.Scope); err != nil {
+			return nil, nil, err
+		}
+		if !client.AllowsScopes(scopes) {
+			return nil, nil, errors.New("scope is not allowed for this client")
+		}
+	}
+	return client, scopes, nil
+}
+
+// Token exchanges an authorization code for an access token limited to the
+// scopes the user consented to
+func (uc *OAuthServerUseCase) Token(request interfaces.TokenRequest) (*interfaces.TokenResponse, error) {
+	if request.GrantType != "authorization_code" {
+		return nil, errors.New("grant type must be authorization_code")
+	}
+	client, err := uc.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	codeHash := hashToken(request.Code)
+	code, err := uc.Codes.FindByHash(codeHash)
+	if err != nil {
+		uc.Logger.Error("Failed to find authorization code", "error", err)
+		return nil, errors.New("failed to exchange authorization code")
+	}
+	if code != nil && code.UsedAt != nil {
+		uc.revokeReplayedCode(code)
+		return nil, errors.New("invalid or expired authorization code")
+	}
+	if code == nil || code.IsExpired(time.Now()) || code.ClientID != client.ID {
+		return nil, errors.New("invalid or expired authorization code")
+	}
+
+	user, err := uc.UserRepo.FindByID(code.UserID)
+	if err != nil {
+		uc.Logger.Error("Failed to find user", "error", err, "userID", code.UserID)
+		return nil, errors.New("failed to exchange authorization code")
+	}
+	if user == nil {
+		return nil, errors.New("invalid or expired authorization code")
+	}
+
+	// Third-party tokens carry no role, so they never get staff permissions
+	accessToken := &entities.AccessToken{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		ClientID: client.ID,
+		Scopes:   code.Scopes,
+	}
+	if uc.Revocations != nil {
+		generation, err := uc.Revocations.GetUserGeneration(user.ID)
+		if err != nil {
+			uc.Logger.Error("Failed to read token generation", "error", err, "userID", user.ID)
+			return nil, errors.New("failed to exchange authorization code")
+		}
+		accessToken.Generation = generation
+	}
+	token, err := uc.Tokens.GenerateToken(accessToken)
+	if err != nil {
+		uc.Logger.Error("Failed to generate token", "error", err)
+		return nil, errors.New("failed to exchange authorization code")
+	}
+
+	// The token is signed first so that its ID is stored with the code in
+	// the same write that uses the code up. It is only handed out below.
+	consumed, err := uc.Codes.Consume(codeHash, accessToken.ID, accessToken.ExpiresAt, time.Now())
+	if err != nil {
+		uc.Logger.Error("Failed to consume authorization code", "error", err)
+		return nil, errors.New("failed to exchange authorization code")
+	}
+	if !consumed {
+		// Another request exchanged the code in the meantime
+		if used, err := uc.Codes.FindByHash(codeHash); err == nil && used != nil {
+			uc.revokeReplayedCode(used)
+		}
+		return nil, errors.New("invalid or expired authorization code")
+	}
+
+	if code.RedirectURI != request.RedirectURI {
+		return nil, errors.New("redirect URI does not match the authorization request")
+	}
+	if !verifyCodeChallenge(request.CodeVerifier, code.CodeChallenge) {
+		return nil, errors.New("code verifier does not match the code challenge")
+	}
+
+	return &interfaces.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(accessToken.ExpiresAt).Round(time.Second).Seconds()),
+		Scope:       entities.FormatScopes(code.Scopes),
+	}, nil
+}
+
+// revokeReplayedCode revokes the token issued for a code that is presented
+// again: the code has leaked, so whoever holds the token may not be the
+// client (RFC 6749 section 4.1.2). The grant issues no refresh tokens, so the
+// access token is all there is to revoke.
+func (uc *OAuthServerUseCase) revokeReplayedCode(code *entities.OAuthAuthorizationCode) {
+	if uc.Revocations == nil || code.TokenID == "" {
+		return
+	}
+	if err := uc.Revocations.RevokeToken(code.TokenID, code.TokenExpiresAt); err != nil {
+		uc.Logger.Error("Failed to revoke the token of a replayed authorization code", "error", err, "clientID", code.ClientID, "userID", code.UserID)
+		return
+	}
+	uc.Logger.Error("Authorization code replayed, revoked the token issued for it", "clientID", code.ClientID, "userID", code.UserID)
+}
+
+// Introspect tells a confidential client whether a token issued to a
+// third-party app is still active, and what it allows
+func (uc *OAuthServerUseCase) Introspect(clientID, clientSecret, token string) (*interfaces.TokenIntrospection, error) {
+	client, err := uc.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsConfidential() {
+		return nil, errors.New("client authentication failed")
+	}
+
+	inactive := &interfaces.TokenIntrospection{Active: false}
+	accessToken, err := uc.Verifier.VerifyAccessToken(token)
+	if err != nil || accessToken.ClientID == "" {
+		return inactive, nil
+	}
+	issuedTo, err := uc.findClient(accessToken.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if issuedTo == nil {
+		return inactive, nil
+	}
+
+	if uc.Revocations != nil {
+		revoked, err := uc.Revocations.IsRevoked(accessToken.ID)
+		if err != nil {
+			uc.Logger.Error("Failed to check token revocation", "error", err)
+			return nil, errors.New("failed to introspect token")
+		}
+		generation, err := uc.Revocations.GetUserGeneration(accessToken.UserID)
+		if err != nil {
+			uc.Logger.Error("Failed to read token generation", "error", err, "userID", accessToken.UserID)
+			return nil, errors.New("failed to introspect token")
+		}
+		if revoked || accessToken.Generation < generation {
+			return inactive, nil
+		}
+	}
+
+	return &interfaces.TokenIntrospection{
+		Active:    true,
+		Scope:     entities.FormatScopes(accessToken.Scopes),
+		ClientID:  accessToken.ClientID,
+		Username:  accessToken.Username,
+		TokenType: "Bearer",
+		ExpiresAt: accessToken.ExpiresAt.Unix(),
+		Subject:   accessToken.UserID,
+	}, nil
+}
+
+// ClientExists reports whether a client is still registered, so that the
+// tokens of deleted clients can be refused
+func (uc *OAuthServerUseCase) ClientExists(id string) (bool, error) {
+	client, err := uc.findClient(id)
+	return client != nil, err
+}
+
+// authenticateClient finds a client, checking the secret of confidential
+// clients
+func (uc *OAuthServerUseCase) authenticateClient(clientID, secret string) (*entities.OAuthClient, error) {
+	client, err := uc.findClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("client authentication failed")
+	}
+	if client.IsConfidential() && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, errors.New("client authentication failed")
+	}
+	return client, nil
+}
+
+func (uc *OAuthServerUseCase) findClient(id string) (*entities.OAuthClient, error) {
+	if id == "" {
+		return nil, nil
+	}
+	client, err := uc.Clients.FindByID(id)
+	if err != nil {
+		uc.Logger.Error("Failed to find client", "error", err, "clientID", id)
+		return nil, errors.New("failed to find client")
+	}
+	return client, nil
+}
+
+// verifyCodeChallenge checks a PKCE verifier against its S256 challenge (RFC
+// 7636 section 4.6)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+)
+
+// Policy decides what a user may do. Use cases ask it for permissions instead
+// of checking roles, so roles can be redefined without touching them.
+//
+// A nil Policy, or one without a user repository, grants every user the
+// permissions of the built-in user role. Users whose role requires a second
+// factor they haven't set up also only get the user role's permissions.
+type Policy struct {
+	Users  interfaces.UserRepository
+	Roles  interfaces.RoleRepository // nil allows built-in roles only
+	MFA    interfaces.MFARepository  // nil requires no second factor
+	Logger Logger
+	// UnverifiedRestrictions lists what users may not do until they verify
+	// their email address
+	UnverifiedRestrictions []entities.Restriction
+}
+
+func NewPolicy(users interfaces.UserRepository, roles interfaces.RoleRepository, mfa interfaces.MFARepository, logger Logger) *Policy {
+	return &Policy{
+		Users:  users,
+		Roles:  roles,
+		MFA:    mfa,
+		Logger: logger,
+	}
+}
+
+// FindRole resolves a built-in or custom role, returning nil when it doesn't
+// exist
+func (p *Policy) FindRole(name entities.UserRole) (*entities.Role, error) {
+	if role := entities.BuiltInRole(name); role != nil {
+		return role, nil
+	}
+	if p == nil || p.Roles == nil {
+		return nil, nil
+	}
+
+	role, err := p.Roles.FindByName(name)
+	if err != nil {
+		p.Logger.Error("Failed to find role", "error", err, "role", name)
+		return nil, errors.New("failed to verify permissions")
+	}
+	return role, nil
+}
+
+// RoleHasPermission reports whether a role grants a permission. Unknown roles,
+// such as a custom role deleted after it was assigned, grant nothing.
+func (p *Policy) RoleHasPermission(name entities.UserRole, permission entities.Permission) (bool, error) {
+	role, err := p.FindRole(name)
+	if err != nil || role == nil {
+		return false, err
+	}
+	return role.HasPermission(permission), nil
+}
+
+// UserHasPermission reports whether the user's role grants a permission
+func (p *Policy) UserHasPermission(userID string, permission entities.Permission) (bool, error) {
+	if p == nil || p.Users == nil {
+		return p.RoleHasPermission(entities.RoleUser, permission)
+	}
+
+	user, err := p.Users.FindByID(userID)
+	if err != nil {
+		p.Logger.Error("Failed to find user", "error", err, "userID", userID)
+		return false, errors.New("failed to verify permissions")
+	}
+	if user == nil {
+		return false, nil
+	}
+	role, err := p.EffectiveRole(user)
+	if err != nil {
+		return false, err
+	}
+	return p.RoleHasPermission(role, permission)
+}
+
+// EffectiveRole returns the role the user acts with: their own role, or the
+// user role while their role requires a second factor they haven't set up
+func (p *Policy) EffectiveRole(user *entities.User) (entities.UserRole, error) {
+	if p == nil {
+		return user.Role, nil
+	}
+	role, err := effectiveRole(p.MFA, user)
+	if err != nil {
+		p.Logger.Error("Failed to check two-factor requirement", "error", err, "userID", user.ID)
+		return "", errors.New("failed to verify permissions")
+	}
+	return role, nil
+}
+
+// CanModify reports whether the user may change a resource: owners always
+// may, anyone else needs the permission. A personal API token also needs the
+// permission's moderation scope, so that a staff member's token doesn't carry
+// their role unless they asked for it. Third-party apps' tokens never carry
+// the role.
+func (p *Policy) CanModify(userID, ownerID string, permission entities.Permission, client interfaces.ClientInfo) (bool, error) {
+	if userID != "" && userID == ownerID {
+		return true, nil
+	}
+	if client.OAuthClientID != "" {
+		return false, nil
+	}
+	if client.APITokenID != "" && !tokenAllows(client.Scopes, entities.ModerationScope(permission)) {
+		return false, nil
+	}
+	return p.UserHasPermission(userID, permission)
+}
+
+func tokenAllows(scopes []entities.Scope, scope entities.Scope) bool {
+	if scope == "" {
+		return false
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireVerifiedEmail returns an error when the restriction applies to
+// users who haven't verified their email address and the user hasn't
+func (p *Policy) RequireVerifiedEmail(userID string, restriction entities.Restriction) error {
+	if p == nil || p.Users == nil || !p.restricts(restriction) {
+		return nil
+	}
+
+	user, err := p.Users.FindByID(userID)
+	if err != nil {
+		p.Logger.Error("Failed to find user", "error", err, "userID", userID)
+		return errors.New("failed to verify permissions")
+	}
+	if user != nil && !user.IsEmailVerified() {
+		return errors.New("email address is not verified")
+	}
+	return nil
+}
+
+func (p *Policy) restricts(restriction entities.Restriction) bool {
+	for _, r := range p.UnverifiedRestrictions {
+		if r == restriction {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"errors"
+	"gocleanarchitecture/entities"
+	"gocleanarchitecture/interfaces"
+	"time"
+)
+
+// UserTokenRevoker invalidates every access and refresh token and every
+// session of a user. It is used on logout-all, password change and role change.
+type UserTokenRevoker interface {
+	RevokeUserTokens(userID string) error
+}
+
+type userTokenRevoker struct {
+	revocations   interfaces.TokenRevocationRepository
+	refreshTokens interfaces.RefreshTokenRepository
+	sessions      interfaces.SessionRepository
+	logger        Logger
+}
+
+// NewUserTokenRevoker creates a revoker; any repository may be nil when the
+// corresponding feature is disabled
+func NewUserTokenRevoker(revocations interfaces.TokenRevocationRepository, refreshTokens interfaces.RefreshTokenRepository, sessions interfaces.SessionRepository, logger Logger) UserTokenRevoker {
+	return &userTokenRevoker{
+		revocations:   revocations,
+		refreshTokens: refreshTokens,
+		sessions:      sessions,
+		logger:        logger,
+	}
+}
+
+func (r *userTokenRevoker) RevokeUserTokens(userID string) error {
+	var errs []error
+	if r.revocations != nil {
+		if _, err := r.revocations.BumpUserGeneration(userID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.refreshTokens != nil {
+		if err := r.refreshTokens.RevokeAllForUser(userID, time.Now()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.sessions != nil {
+		if err := r.sessions.RevokeAllForUser(userID, time.Now()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Logout revokes the access token used for the request, ends its session and,
+// when given, revokes the refresh token family it belongs to
+func (u *AuthUseCase) Logout(userID, sessionID, tokenID string, tokenExpiresAt time.Time, refreshToken string, client interfaces.ClientInfo) error {
+	if u.Revocations == nil {
+		return errors.New("token revocation is not enabled")
+	}
+
+	if tokenID != "" {
+		if err := u.Revocations.RevokeToken(tokenID, tokenExpiresAt); err != nil {
+			u.Logger.Error("Failed to revoke access token", "error", err, "userID", userID)
+			return errors.New("failed to log out")
+		}
+	}
+
+	if refreshToken != "" && u.RefreshTokens != nil {
+		stored, err := u.RefreshTokens.FindByHash(hashToken(refreshToken))
+		if err != nil {
+			u.Logger.Error("Failed to find refresh token", "error", err, "userID", userID)
+			return errors.New("failed to log out")
+		}
+		// Ignore tokens that belong to someone else
+		if stored != nil && stored.UserID == userID {
+			if err := u.RefreshTokens.RevokeFamily(stored.FamilyID, time.Now()); err != nil {
+				u.Logger.Error("Failed to revoke refresh token", "error", err, "userID", userID)
+				return errors.New("failed to log out")
+			}
+		}
+	}
+
+	session, err := u.findSession(sessionID)
+	if err != nil {
+		return errors.New("failed to log out")
+	}
+	if session != nil && session.UserID == userID {
+		if err := u.endSession(session, time.Now()); err != nil {
+			u.Logger.Error("Failed to end session", "error", err, "userID", userID)
+			return errors.New("failed to log out")
+		}
+	}
+
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditLogout, userID, entities.AuditTargetUser, userID), client, nil, nil)
+	return nil
+}
+
+// LogoutAll invalidates every token the user holds, on every device
+func (u *AuthUseCase) LogoutAll(userID string, client interfaces.ClientInfo) error {
+	if u.Revocations == nil {
+		return errors.New("token revocation is not enabled")
+	}
+
+	if err := u.TokenRevoker.RevokeUserTokens(userID); err != nil {
+		u.Logger.Error("Failed to revoke user tokens", "error", err, "userID", userID)
+		return errors.New("failed to log out")
+	}
+	u.Audit.Record(entities.NewAuditEvent(entities.AuditLogoutAll, userID, entities.AuditTargetUser, userID), client, nil, nil)
+	return nil
+}
+
+// ExpiringStore is a repository whose entries expire
+type ExpiringStore interface {
+	DeleteExpired(before time.Time) error
+}
+
+// PruneExpiredTokens removes expired entries (revoked tokens, refresh tokens,
+// sessions) from the given stores; nil stores are skipped. It is run
+// periodically.
+func PruneExpiredTokens(now time.Time, stores ...ExpiringStore) error {
+	var errs []error
+	for _, store := range stores {
+		if store == nil {
+			continue
+		}
+		if err := store.DeleteExpired(now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
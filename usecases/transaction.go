@@ -0,0 +1,37 @@
+package usecases
+
+import "gocleanarchitecture/interfaces"
+
+// Tx groups the repositories and event publisher that share a single storage
+// transaction. Events published through Tx.Events are committed together with
+// the entity changes, so they survive a crash right after the write.
+type Tx struct {
+	BlogPosts interfaces.BlogPostRepository
+	Comments  interfaces.CommentRepository
+	Users     interfaces.UserRepository
+	Events    EventPublisher
+}
+
+// Transactor runs fn atomically. If fn returns an error, nothing it wrote is
+// committed.
+type Transactor interface {
+	WithinTransaction(fn func(tx *Tx) error) error
+}
+
+// directTransactor is used when the storage backend has no transactions: the
+// repositories are used as-is and events go straight to the publisher.
+type directTransactor struct {
+	tx *Tx
+}
+
+func (d directTransactor) WithinTransaction(fn func(tx *Tx) error) error {
+	return fn(d.tx)
+}
+
+func transactorOrDirect(t Transactor, tx *Tx, logger Logger) Transactor {
+	if t == nil {
+		tx.Events = bestEffortPublisher{publisher: tx.Events, logger: logger}
+		return directTransactor{tx: tx}
+	}
+	return t
+}